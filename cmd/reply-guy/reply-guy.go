@@ -1,16 +1,26 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/marcusprice/twitter-clone/internal/api"
 	"github.com/marcusprice/twitter-clone/internal/dtypes"
 	"github.com/marcusprice/twitter-clone/internal/logger"
+	"github.com/marcusprice/twitter-clone/internal/persona"
 	"github.com/marcusprice/twitter-clone/internal/replyqueue"
 	"github.com/marcusprice/twitter-clone/internal/util"
+	_ "github.com/mattn/go-sqlite3"
 )
 
 // TODO: need more work here to handle various failure situations
@@ -23,20 +33,71 @@ func ReplyGuyHandler(replyQueue *replyqueue.ReplyQueue) http.HandlerFunc {
 			return
 		}
 
-		replyQueue.Enqueue(requestBody)
+		// the route decides who replies
+		requestBody.Persona = strings.TrimPrefix(r.PathValue("persona"), "@")
+		err = replyQueue.Enqueue(requestBody)
+		if err != nil {
+			var unknownPersonaError replyqueue.UnknownPersonaError
+			if errors.As(err, &unknownPersonaError) {
+				http.Error(w, api.NotFound, http.StatusNotFound)
+			} else {
+				http.Error(w, api.InternalServerError, http.StatusInternalServerError)
+			}
+
+			return
+		}
+
 		w.WriteHeader(http.StatusAccepted)
 	}
 }
 
+func MetricsHandler(replyQueue *replyqueue.ReplyQueue) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		metrics, err := replyQueue.Metrics()
+		if err != nil {
+			http.Error(w, api.InternalServerError, http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(metrics)
+	}
+}
+
+// how long running jobs get to finish on shutdown before they're put back in
+// the queue
+const DEFAULT_SHUTDOWN_TIMEOUT = 30 * time.Second
+
 func main() {
 	util.LoadEnvVariables()
 
-	replyQueue := replyqueue.NewReplyQueue()
-	replyQueue.StartWorker()
+	dbPath := os.Getenv("REPLY_GUY_DB_PATH")
+	if dbPath == "" {
+		panic("REPLY_GUY_DB_PATH required")
+	}
+
+	conn, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		panic(err)
+	}
+	// the handler and the workers share the queue, a single connection keeps
+	// sqlite from returning busy errors
+	conn.SetMaxOpenConns(1)
+
+	personas, err := persona.FromEnv()
+	if err != nil {
+		log.Fatal("could not load personas:", err)
+	}
+
+	replyQueue, err := replyqueue.NewReplyQueue(conn, replyqueue.ConfigFromEnv(), personas)
+	if err != nil {
+		log.Fatal("could not start reply queue:", err)
+	}
+	replyQueue.StartWorkers()
 
 	mux := http.NewServeMux()
 	mux.Handle(
-		"/api/v1/@dalecooper/request-reply",
+		"/api/v1/{persona}/request-reply",
 		api.Logger(
 			api.VerifyPostMethod(
 				ReplyGuyHandler(replyQueue),
@@ -44,8 +105,51 @@ func main() {
 		),
 	)
 
+	mux.Handle(
+		"/api/v1/metrics",
+		api.Logger(
+			api.VerifyGetMethod(
+				MetricsHandler(replyQueue),
+			),
+		),
+	)
+
+	shutdownTimeout, err := time.ParseDuration(os.Getenv("REPLY_GUY_SHUTDOWN_TIMEOUT"))
+	if err != nil || shutdownTimeout <= 0 {
+		shutdownTimeout = DEFAULT_SHUTDOWN_TIMEOUT
+	}
+
 	host := os.Getenv("REPLY_GUY_HOST")
 	port := os.Getenv("REPLY_GUY_PORT")
-	logger.LogInfo(fmt.Sprintf("REPLY GUY LISTENING AT %s:%s", host, port))
-	http.ListenAndServe(fmt.Sprintf("%s:%s", host, port), mux)
+	server := &http.Server{Addr: fmt.Sprintf("%s:%s", host, port), Handler: mux}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		logger.LogInfo(fmt.Sprintf("REPLY GUY LISTENING AT %s:%s", host, port))
+		err := server.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+
+	<-ctx.Done()
+	logger.LogInfo("REPLY GUY SHUTTING DOWN")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	// stop taking requests first so nothing is queued after the workers stop
+	err = server.Shutdown(shutdownCtx)
+	if err != nil {
+		logger.LogError("error shutting down server: " + err.Error())
+	}
+
+	err = replyQueue.Stop(shutdownCtx)
+	if err != nil {
+		logger.LogWarn("shutdown timed out, unfinished jobs were put back in the queue")
+	}
+
+	conn.Close()
 }
@@ -50,7 +50,7 @@ func main() {
 	if err != nil {
 		log.Fatal("could not enable foreign keys:", err)
 	}
-	handler := api.RegisterHandlers(conn)
+	handler := api.Start(conn)
 
 	logger.LogInfo(fmt.Sprintf("CORE APP LISTENING AT %s:%s", host, port))
 	log.Fatal(
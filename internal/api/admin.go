@@ -0,0 +1,279 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/marcusprice/twitter-clone/internal/controller"
+	"github.com/marcusprice/twitter-clone/internal/dtypes"
+	"github.com/marcusprice/twitter-clone/internal/model"
+)
+
+// resolution actions accepted by ResolveReport, keyed by the request's action
+var reportResolutions = map[string]model.ReportStatus{
+	"dismiss":           model.DISMISSED_REPORT,
+	"hide":              model.CONTENT_HIDDEN_REPORT,
+	"deactivate_author": model.AUTHOR_DEACTIVATED_REPORT,
+}
+
+type AdminAPI struct {
+	db *sql.DB
+}
+
+func (adminAPI *AdminAPI) GetUsers(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	limit, offset, err := parseLimitAndOffset(values.Get("limit"), values.Get("offset"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	admin, ok := adminAPI.loadAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	users, usersRemaining, err := admin.RecentUsers(limit, offset)
+	if err != nil {
+		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(generateAdminUserListPayload(users, usersRemaining))
+}
+
+func (adminAPI *AdminAPI) DeactivateUser(w http.ResponseWriter, r *http.Request) {
+	adminAPI.moderateUser(w, r, func(admin *controller.Admin, username string) error {
+		return admin.DeactivateUser(username)
+	})
+}
+
+func (adminAPI *AdminAPI) ReactivateUser(w http.ResponseWriter, r *http.Request) {
+	adminAPI.moderateUser(w, r, func(admin *controller.Admin, username string) error {
+		return admin.ReactivateUser(username)
+	})
+}
+
+func (adminAPI *AdminAPI) ChangeRole(w http.ResponseWriter, r *http.Request) {
+	var roleInput dtypes.RoleInput
+	err := json.NewDecoder(r.Body).Decode(&roleInput)
+	if err != nil {
+		http.Error(w, BadRequest, http.StatusBadRequest)
+		return
+	}
+
+	adminAPI.moderateUser(w, r, func(admin *controller.Admin, username string) error {
+		return admin.ChangeRole(username, roleInput.Role)
+	})
+}
+
+func (adminAPI *AdminAPI) moderateUser(
+	w http.ResponseWriter,
+	r *http.Request,
+	action func(*controller.Admin, string) error,
+) {
+	admin, ok := adminAPI.loadAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	err := action(admin, r.PathValue("username"))
+	if err != nil {
+		switch {
+		case errors.Is(err, model.UserNotFoundError{}):
+			http.Error(w, NotFound, http.StatusNotFound)
+		case errors.Is(err, controller.UnauthorizedActionError{}):
+			http.Error(w, Forbidden, http.StatusForbidden)
+		case errors.Is(err, controller.InvalidRoleError{}):
+			http.Error(w, BadRequest, http.StatusBadRequest)
+		default:
+			http.Error(w, InternalServerError, http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (adminAPI *AdminAPI) DeletePost(w http.ResponseWriter, r *http.Request) {
+	postID, err := strconv.Atoi(r.PathValue("postID"))
+	if err != nil {
+		http.Error(w, BadRequest, http.StatusBadRequest)
+		return
+	}
+
+	admin, ok := adminAPI.loadAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	images, err := admin.DeletePost(postID)
+	if err != nil {
+		var postNotFoundError model.PostNotFoundError
+		if errors.As(err, &postNotFoundError) {
+			http.Error(w, NotFound, http.StatusNotFound)
+		} else {
+			http.Error(w, InternalServerError, http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	deleteUploadedImages(images)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (adminAPI *AdminAPI) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	commentID, err := strconv.Atoi(r.PathValue("commentID"))
+	if err != nil {
+		http.Error(w, BadRequest, http.StatusBadRequest)
+		return
+	}
+
+	admin, ok := adminAPI.loadAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	images, err := admin.DeleteComment(commentID)
+	if err != nil {
+		var commentNotFoundError model.CommentNotFoundError
+		if errors.As(err, &commentNotFoundError) {
+			http.Error(w, NotFound, http.StatusNotFound)
+		} else {
+			http.Error(w, InternalServerError, http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	deleteUploadedImages(images)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetReports serves the moderation queue, open reports unless another
+// status is asked for
+func (adminAPI *AdminAPI) GetReports(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	limit, offset, err := parseLimitAndOffset(values.Get("limit"), values.Get("offset"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	status := model.OPEN_REPORT
+	if values.Get("status") != "" {
+		status = model.ReportStatus(values.Get("status"))
+		switch status {
+		case model.OPEN_REPORT, model.DISMISSED_REPORT, model.CONTENT_HIDDEN_REPORT, model.AUTHOR_DEACTIVATED_REPORT:
+		default:
+			http.Error(w, BadRequest, http.StatusBadRequest)
+			return
+		}
+	}
+
+	admin, ok := adminAPI.loadAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	reports, reportsRemaining, err := admin.Reports(status, limit, offset)
+	if err != nil {
+		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(generateReportListPayload(reports, reportsRemaining))
+}
+
+func (adminAPI *AdminAPI) ResolveReport(w http.ResponseWriter, r *http.Request) {
+	reportID, err := strconv.Atoi(r.PathValue("reportID"))
+	if err != nil {
+		http.Error(w, BadRequest, http.StatusBadRequest)
+		return
+	}
+
+	var resolveInput dtypes.ResolveReportInput
+	err = json.NewDecoder(r.Body).Decode(&resolveInput)
+	if err != nil {
+		http.Error(w, BadRequest, http.StatusBadRequest)
+		return
+	}
+
+	status, ok := reportResolutions[resolveInput.Action]
+	if !ok {
+		http.Error(w, BadRequest, http.StatusBadRequest)
+		return
+	}
+
+	admin, ok := adminAPI.loadAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	err = admin.ResolveReport(reportID, status)
+	if err != nil {
+		switch {
+		case errors.Is(err, model.ReportNotFoundError{}):
+			http.Error(w, NotFound, http.StatusNotFound)
+		case errors.Is(err, controller.ReportResolvedError{}):
+			http.Error(w, Conflict, http.StatusConflict)
+		case errors.Is(err, controller.UnauthorizedActionError{}):
+			http.Error(w, Forbidden, http.StatusForbidden)
+		default:
+			http.Error(w, InternalServerError, http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (adminAPI *AdminAPI) GetAuditLog(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	limit, offset, err := parseLimitAndOffset(values.Get("limit"), values.Get("offset"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	admin, ok := adminAPI.loadAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	entries, entriesRemaining, err := admin.AuditLog(limit, offset)
+	if err != nil {
+		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(generateAuditLogPayload(entries, entriesRemaining))
+}
+
+// loadAdmin writes the error response itself, callers should return when ok
+// is false
+func (adminAPI *AdminAPI) loadAdmin(w http.ResponseWriter, r *http.Request) (admin *controller.Admin, ok bool) {
+	adminID, ok := r.Context().Value("userID").(int)
+	if !ok {
+		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		return nil, false
+	}
+
+	return controller.NewAdminController(adminAPI.db, adminID), true
+}
+
+func NewAdminAPI(db *sql.DB) *AdminAPI {
+	return &AdminAPI{db}
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marcusprice/twitter-clone/internal/permissions"
+	"github.com/marcusprice/twitter-clone/internal/testutil"
+)
+
+func TestAdminRequiresAdminRole(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, _ time.Time) {
+		tu := testutil.NewTestUtil(t)
+		handler := RegisterHandlers(db)
+		userToken := loginAndToken(loadUserControllerByID(db, 1))
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/users?limit=10&offset=0", nil)
+		req.Header.Set("Authorization", "Bearer "+userToken)
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		tu.AssertEqual(http.StatusForbidden, res.Code)
+
+		req = httptest.NewRequest(http.MethodPost, "/api/v1/admin/user/audrey/deactivate", nil)
+		req.Header.Set("Authorization", "Bearer "+userToken)
+		res = httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		tu.AssertEqual(http.StatusForbidden, res.Code)
+
+		req = httptest.NewRequest(http.MethodGet, "/api/v1/admin/audit-log?limit=10&offset=0", nil)
+		res = httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		tu.AssertEqual(http.StatusUnauthorized, res.Code)
+	})
+}
+
+func TestAdminModerateUsers(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, _ time.Time) {
+		tu := testutil.NewTestUtil(t)
+		handler := RegisterHandlers(db)
+		adminToken := loginAndToken(loadUserControllerByID(db, 2))
+		audreyToken := loginAndToken(loadUserControllerByID(db, 4))
+
+		res := testutil.ServeRequest(handler, http.MethodPost, "/api/v1/admin/user/audrey/deactivate", adminToken, nil)
+		tu.AssertEqual(http.StatusNoContent, res.Code)
+		res = testutil.ServeRequest(handler, http.MethodGet, "/api/v1/user", audreyToken, nil)
+		tu.AssertEqual(http.StatusUnauthorized, res.Code)
+
+		// deactivation signed audrey out, she has to log in again
+		res = testutil.ServeRequest(handler, http.MethodPost, "/api/v1/admin/user/audrey/reactivate", adminToken, nil)
+		tu.AssertEqual(http.StatusNoContent, res.Code)
+		res = testutil.ServeRequest(handler, http.MethodGet, "/api/v1/user", audreyToken, nil)
+		tu.AssertEqual(http.StatusUnauthorized, res.Code)
+		audreyToken = loginAndToken(loadUserControllerByID(db, 4))
+		res = testutil.ServeRequest(handler, http.MethodGet, "/api/v1/user", audreyToken, nil)
+		tu.AssertEqual(http.StatusOK, res.Code)
+
+		res = testutil.ServeRequest(handler, http.MethodPut, "/api/v1/admin/user/audrey/role", adminToken,
+			strings.NewReader(fmt.Sprintf(`{"role": %d}`, permissions.ADMIN_ROLE)))
+		tu.AssertEqual(http.StatusNoContent, res.Code)
+		res = testutil.ServeRequest(handler, http.MethodGet, "/api/v1/admin/users?limit=10&offset=0", audreyToken, nil)
+		tu.AssertEqual(http.StatusOK, res.Code)
+
+		res = testutil.ServeRequest(handler, http.MethodPut, "/api/v1/admin/user/audrey/role", adminToken,
+			strings.NewReader(fmt.Sprintf(`{"role": %d}`, permissions.SYSTEM_ROLE)))
+		tu.AssertEqual(http.StatusBadRequest, res.Code)
+
+		// admins can't moderate themselves or system accounts
+		res = testutil.ServeRequest(handler, http.MethodPost, "/api/v1/admin/user/wallphace/deactivate", adminToken, nil)
+		tu.AssertEqual(http.StatusForbidden, res.Code)
+		res = testutil.ServeRequest(handler, http.MethodPost, "/api/v1/admin/user/dalecooper/deactivate", adminToken, nil)
+		tu.AssertEqual(http.StatusForbidden, res.Code)
+		res = testutil.ServeRequest(handler, http.MethodPost, "/api/v1/admin/user/nobody/deactivate", adminToken, nil)
+		tu.AssertEqual(http.StatusNotFound, res.Code)
+
+		res = testutil.ServeRequest(handler, http.MethodGet, "/api/v1/admin/users?limit=3&offset=0", adminToken, nil)
+		tu.AssertEqual(http.StatusOK, res.Code)
+		var users AdminUserListPayload
+		json.NewDecoder(res.Body).Decode(&users)
+		tu.AssertEqual(3, len(users.Users))
+		tu.AssertTrue(users.HasMore)
+		tu.AssertEqual(4, users.UsersRemaining)
+
+		res = testutil.ServeRequest(handler, http.MethodGet, "/api/v1/admin/audit-log?limit=10&offset=0", adminToken, nil)
+		tu.AssertEqual(http.StatusOK, res.Code)
+		var auditLog AuditLogPayload
+		json.NewDecoder(res.Body).Decode(&auditLog)
+		tu.AssertEqual(3, len(auditLog.Entries))
+		tu.AssertEqual("change_role", auditLog.Entries[0].Action)
+		tu.AssertEqual("wallphace", auditLog.Entries[0].Actor.Username)
+		tu.AssertEqual(4, auditLog.Entries[0].TargetID)
+		tu.AssertEqual("reactivate_user", auditLog.Entries[1].Action)
+		tu.AssertEqual("deactivate_user", auditLog.Entries[2].Action)
+	})
+}
+
+func TestAdminDeleteContent(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, _ time.Time) {
+		tu := testutil.NewTestUtil(t)
+		handler := RegisterHandlers(db)
+		adminToken := loginAndToken(loadUserControllerByID(db, 2))
+		post := createTestPost(1, db)
+		commentPost := createTestPost(1, db)
+
+		var commentID int
+		db.QueryRow(
+			"INSERT INTO Comment (post_id, user_id, depth, content, image) VALUES ($1, 4, 0, 'hi', '') RETURNING id;",
+			commentPost.ID,
+		).Scan(&commentID)
+
+		res := testutil.ServeRequest(handler, http.MethodDelete, fmt.Sprintf("/api/v1/admin/post/%d", post.ID), adminToken, nil)
+		tu.AssertEqual(http.StatusNoContent, res.Code)
+		res = testutil.ServeRequest(handler, http.MethodDelete, fmt.Sprintf("/api/v1/admin/post/%d", post.ID), adminToken, nil)
+		tu.AssertEqual(http.StatusNotFound, res.Code)
+
+		// hidden comments can still be deleted by admins
+		db.Exec("UPDATE Comment SET is_hidden = 1 WHERE id = $1;", commentID)
+		res = testutil.ServeRequest(handler, http.MethodDelete, fmt.Sprintf("/api/v1/admin/comment/%d", commentID), adminToken, nil)
+		tu.AssertEqual(http.StatusNoContent, res.Code)
+		res = testutil.ServeRequest(handler, http.MethodDelete, "/api/v1/admin/comment/42069", adminToken, nil)
+		tu.AssertEqual(http.StatusNotFound, res.Code)
+
+		var count int
+		db.QueryRow(
+			"SELECT COUNT(*) FROM AuditLog WHERE action IN('delete_post', 'delete_comment') AND actor_id = 2;",
+		).Scan(&count)
+		tu.AssertEqual(2, count)
+	})
+}
@@ -6,13 +6,31 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
 	"github.com/marcusprice/twitter-clone/internal/controller"
+	"github.com/marcusprice/twitter-clone/internal/permissions"
 	"github.com/marcusprice/twitter-clone/internal/util"
 )
 
+// RegisterHandlers returns the app's routes, Start also runs the background
+// jobs that go with them
 func RegisterHandlers(db *sql.DB) http.Handler {
+	handler, _ := registerHandlers(db)
+	return handler
+}
+
+// Start returns the app's routes after starting its background jobs, it's
+// meant to be called once by the server
+func Start(db *sql.DB) http.Handler {
+	handler, commentAPI := registerHandlers(db)
+	go commentAPI.CleanupGenerating()
+	return handler
+}
+
+func registerHandlers(db *sql.DB) (http.Handler, *CommentAPI) {
 	if db == nil {
 		panic("db conn cannot be nil")
 	}
@@ -24,6 +42,14 @@ func RegisterHandlers(db *sql.DB) http.Handler {
 	postAPI := NewPostAPI(post)
 	commentAPI := NewCommentAPI(comment)
 	timelineAPI := NewTimelineAPI(db)
+	notificationAPI := NewNotificationAPI(db)
+	profileAPI := NewProfileAPI(db)
+	hashtagAPI := NewHashtagAPI(db)
+	searchAPI := NewSearchAPI(db)
+	mentionAPI := NewMentionAPI(db)
+	suggestionAPI := NewSuggestionAPI(db)
+	messageAPI := NewMessageAPI(db)
+	adminAPI := NewAdminAPI(db)
 
 	mux := http.NewServeMux()
 
@@ -37,10 +63,23 @@ func RegisterHandlers(db *sql.DB) http.Handler {
 
 	mux.Handle(
 		"/api/v1/user",
-		VerifyGetMethod(
+		AllowMethods(
+			[]string{http.MethodGet, http.MethodPatch},
 			ValidateUser(
 				user,
-				http.HandlerFunc(userAPI.Get))),
+				MethodHandlers(map[string]http.HandlerFunc{
+					http.MethodGet:   userAPI.Get,
+					http.MethodPatch: userAPI.Update,
+				}))),
+	)
+
+	mux.Handle(
+		"/api/v1/user/avatar",
+		AllowMethods(
+			[]string{http.MethodPut},
+			ValidateUser(
+				user,
+				http.HandlerFunc(userAPI.UploadAvatar))),
 	)
 
 	mux.Handle(
@@ -63,6 +102,53 @@ func RegisterHandlers(db *sql.DB) http.Handler {
 			http.HandlerFunc(userAPI.Authenticate)),
 	)
 
+	mux.Handle(
+		"/api/v1/user/activate",
+		VerifyPostMethod(
+			http.HandlerFunc(userAPI.Activate)),
+	)
+
+	mux.Handle(
+		"/api/v1/user/activate/resend",
+		VerifyPostMethod(
+			http.HandlerFunc(userAPI.ResendActivation)),
+	)
+
+	mux.Handle(
+		"/api/v1/user/refresh",
+		VerifyPostMethod(
+			http.HandlerFunc(userAPI.Refresh)),
+	)
+
+	mux.Handle(
+		"/api/v1/user/logout",
+		VerifyPostMethod(
+			ValidateUser(
+				user,
+				http.HandlerFunc(userAPI.Logout))),
+	)
+
+	mux.Handle(
+		"/api/v1/user/password",
+		AllowMethods(
+			[]string{http.MethodPut},
+			ValidateUser(
+				user,
+				http.HandlerFunc(userAPI.ChangePassword))),
+	)
+
+	mux.Handle(
+		"/api/v1/user/password/forgot",
+		VerifyPostMethod(
+			http.HandlerFunc(userAPI.ForgotPassword)),
+	)
+
+	mux.Handle(
+		"/api/v1/user/password/reset",
+		VerifyPostMethod(
+			http.HandlerFunc(userAPI.ResetPassword)),
+	)
+
 	mux.Handle(
 		"/api/v1/user/bookmarks",
 		VerifyGetMethod(
@@ -71,6 +157,56 @@ func RegisterHandlers(db *sql.DB) http.Handler {
 				http.HandlerFunc(userAPI.GetBookmarks))),
 	)
 
+	mux.Handle(
+		"/api/v1/user/mentions",
+		VerifyGetMethod(
+			ValidateUser(
+				user,
+				http.HandlerFunc(mentionAPI.GetMentions))),
+	)
+
+	mux.Handle(
+		"/api/v1/user/suggestions",
+		VerifyGetMethod(
+			ValidateUser(
+				user,
+				http.HandlerFunc(suggestionAPI.GetSuggestions))),
+	)
+
+	mux.Handle(
+		"/api/v1/messages",
+		VerifyGetMethod(
+			ValidateUser(
+				user,
+				http.HandlerFunc(messageAPI.GetConversations))),
+	)
+
+	mux.Handle(
+		"/api/v1/messages/{target}",
+		AllowMethods(
+			[]string{http.MethodGet, http.MethodPost},
+			ValidateUser(
+				user,
+				http.HandlerFunc(messageAPI.Messages))),
+	)
+
+	mux.Handle(
+		"/api/v1/user/follow-requests",
+		VerifyGetMethod(
+			ValidateUser(
+				user,
+				http.HandlerFunc(userAPI.GetFollowRequests))),
+	)
+
+	mux.Handle(
+		"/api/v1/user/follow-requests/{username}",
+		AllowMethods(
+			[]string{http.MethodPut, http.MethodDelete},
+			ValidateUser(
+				user,
+				http.HandlerFunc(userAPI.FollowRequest))),
+	)
+
 	mux.Handle(
 		"/api/v1/user/follow/{username}",
 		AllowMethods(
@@ -81,11 +217,50 @@ func RegisterHandlers(db *sql.DB) http.Handler {
 	)
 
 	mux.Handle(
-		"/api/v1/post/{postID}",
+		"/api/v1/user/block/{username}",
+		AllowMethods(
+			[]string{http.MethodPut, http.MethodDelete},
+			ValidateUser(
+				user,
+				http.HandlerFunc(userAPI.Block))),
+	)
+
+	mux.Handle(
+		"/api/v1/user/mute/{username}",
+		AllowMethods(
+			[]string{http.MethodPut, http.MethodDelete},
+			ValidateUser(
+				user,
+				http.HandlerFunc(userAPI.Mute))),
+	)
+
+	mux.Handle(
+		"/api/v1/user/{username}",
 		VerifyGetMethod(
 			ValidateUser(
 				user,
-				http.HandlerFunc(postAPI.Get))),
+				http.HandlerFunc(profileAPI.Get))),
+	)
+
+	mux.Handle(
+		"/api/v1/user/{username}/{list}",
+		VerifyGetMethod(
+			ValidateUser(
+				user,
+				http.HandlerFunc(profileAPI.GetList))),
+	)
+
+	mux.Handle(
+		"/api/v1/post/{postID}",
+		AllowMethods(
+			[]string{http.MethodGet, http.MethodPatch, http.MethodDelete},
+			ValidateUser(
+				user,
+				MethodHandlers(map[string]http.HandlerFunc{
+					http.MethodGet:    postAPI.Get,
+					http.MethodPatch:  postAPI.Edit,
+					http.MethodDelete: postAPI.Delete,
+				}))),
 	)
 
 	mux.Handle(
@@ -123,6 +298,14 @@ func RegisterHandlers(db *sql.DB) http.Handler {
 				http.HandlerFunc(postAPI.Bookmark))),
 	)
 
+	mux.Handle(
+		"/api/v1/post/{id}/report",
+		VerifyPostMethod(
+			ValidateUser(
+				user,
+				http.HandlerFunc(postAPI.Report))),
+	)
+
 	mux.Handle(
 		"/api/v1/comment/create",
 		VerifyPostMethod(
@@ -131,6 +314,199 @@ func RegisterHandlers(db *sql.DB) http.Handler {
 				http.HandlerFunc(commentAPI.Create))),
 	)
 
+	mux.Handle(
+		"/api/v1/comment/{id}",
+		AllowMethods(
+			[]string{http.MethodPatch, http.MethodDelete},
+			ValidateUser(
+				user,
+				MethodHandlers(map[string]http.HandlerFunc{
+					http.MethodPatch:  commentAPI.Edit,
+					http.MethodDelete: commentAPI.Delete,
+				}))),
+	)
+
+	mux.Handle(
+		"/api/v1/comment/{id}/stream",
+		AllowMethods(
+			[]string{http.MethodGet, http.MethodPatch},
+			ValidateUser(
+				user,
+				MethodHandlers(map[string]http.HandlerFunc{
+					http.MethodGet:   commentAPI.Stream,
+					http.MethodPatch: commentAPI.UpdateGeneration,
+				}))),
+	)
+
+	mux.Handle(
+		"/api/v1/comment/{id}/like",
+		AllowMethods(
+			[]string{http.MethodPut, http.MethodDelete},
+			ValidateUser(
+				user,
+				http.HandlerFunc(commentAPI.Like))),
+	)
+
+	mux.Handle(
+		"/api/v1/comment/{id}/retweet",
+		AllowMethods(
+			[]string{http.MethodPut, http.MethodDelete},
+			ValidateUser(
+				user,
+				http.HandlerFunc(commentAPI.Retweet))),
+	)
+
+	mux.Handle(
+		"/api/v1/comment/{id}/bookmark",
+		AllowMethods(
+			[]string{http.MethodPut, http.MethodDelete},
+			ValidateUser(
+				user,
+				http.HandlerFunc(commentAPI.Bookmark))),
+	)
+
+	mux.Handle(
+		"/api/v1/comment/{id}/report",
+		VerifyPostMethod(
+			ValidateUser(
+				user,
+				http.HandlerFunc(commentAPI.Report))),
+	)
+
+	mux.Handle(
+		"/api/v1/notifications",
+		VerifyGetMethod(
+			ValidateUser(
+				user,
+				http.HandlerFunc(notificationAPI.Get))),
+	)
+
+	mux.Handle(
+		"/api/v1/notifications/read",
+		AllowMethods(
+			[]string{http.MethodPut},
+			ValidateUser(
+				user,
+				http.HandlerFunc(notificationAPI.MarkRead))),
+	)
+
+	mux.Handle(
+		"/api/v1/hashtag/{tag}",
+		VerifyGetMethod(
+			ValidateUser(
+				user,
+				http.HandlerFunc(hashtagAPI.GetFeed))),
+	)
+
+	mux.Handle(
+		"/api/v1/hashtags/trending",
+		VerifyGetMethod(
+			ValidateUser(
+				user,
+				http.HandlerFunc(hashtagAPI.GetTrending))),
+	)
+
+	mux.Handle(
+		"/api/v1/search",
+		VerifyGetMethod(
+			ValidateUser(
+				user,
+				http.HandlerFunc(searchAPI.Search))),
+	)
+
+	mux.Handle(
+		"/api/v1/admin/users",
+		VerifyGetMethod(
+			ValidateUser(
+				user,
+				RequireRole(
+					permissions.ADMIN_ROLE,
+					http.HandlerFunc(adminAPI.GetUsers)))),
+	)
+
+	mux.Handle(
+		"/api/v1/admin/user/{username}/deactivate",
+		VerifyPostMethod(
+			ValidateUser(
+				user,
+				RequireRole(
+					permissions.ADMIN_ROLE,
+					http.HandlerFunc(adminAPI.DeactivateUser)))),
+	)
+
+	mux.Handle(
+		"/api/v1/admin/user/{username}/reactivate",
+		VerifyPostMethod(
+			ValidateUser(
+				user,
+				RequireRole(
+					permissions.ADMIN_ROLE,
+					http.HandlerFunc(adminAPI.ReactivateUser)))),
+	)
+
+	mux.Handle(
+		"/api/v1/admin/user/{username}/role",
+		AllowMethods(
+			[]string{http.MethodPut},
+			ValidateUser(
+				user,
+				RequireRole(
+					permissions.ADMIN_ROLE,
+					http.HandlerFunc(adminAPI.ChangeRole)))),
+	)
+
+	mux.Handle(
+		"/api/v1/admin/post/{postID}",
+		AllowMethods(
+			[]string{http.MethodDelete},
+			ValidateUser(
+				user,
+				RequireRole(
+					permissions.ADMIN_ROLE,
+					http.HandlerFunc(adminAPI.DeletePost)))),
+	)
+
+	mux.Handle(
+		"/api/v1/admin/comment/{commentID}",
+		AllowMethods(
+			[]string{http.MethodDelete},
+			ValidateUser(
+				user,
+				RequireRole(
+					permissions.ADMIN_ROLE,
+					http.HandlerFunc(adminAPI.DeleteComment)))),
+	)
+
+	mux.Handle(
+		"/api/v1/admin/reports",
+		VerifyGetMethod(
+			ValidateUser(
+				user,
+				RequireRole(
+					permissions.ADMIN_ROLE,
+					http.HandlerFunc(adminAPI.GetReports)))),
+	)
+
+	mux.Handle(
+		"/api/v1/admin/reports/{reportID}/resolve",
+		VerifyPostMethod(
+			ValidateUser(
+				user,
+				RequireRole(
+					permissions.ADMIN_ROLE,
+					http.HandlerFunc(adminAPI.ResolveReport)))),
+	)
+
+	mux.Handle(
+		"/api/v1/admin/audit-log",
+		VerifyGetMethod(
+			ValidateUser(
+				user,
+				RequireRole(
+					permissions.ADMIN_ROLE,
+					http.HandlerFunc(adminAPI.GetAuditLog)))),
+	)
+
 	projectRoot, err := util.ProjectRoot()
 	uploadFileServer := http.FileServer(http.Dir(projectRoot + "/upload"))
 
@@ -156,12 +532,20 @@ func RegisterHandlers(db *sql.DB) http.Handler {
 			http.FileServer(http.Dir(".")))
 	}
 
-	return mux
+	return mux, commentAPI
 }
 
-func GenerateJWT(userID int) (string, error) {
+// GenerateJWT signs a short lived access token for userID, tokenVersion must
+// match the user's current version (controller.User.TokenVersion) for
+// ValidateUser to accept it. The jti lets a single token be revoked on logout.
+func GenerateJWT(userID, tokenVersion int) (string, error) {
+	now := time.Now()
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"sub": userID,
+		"ver": tokenVersion,
+		"jti": uuid.NewString(),
+		"iat": now.Unix(),
+		"exp": now.Add(getAccessTokenTTL()).Unix(),
 	})
 
 	secretKey := os.Getenv("JWT_KEY")
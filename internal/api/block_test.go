@@ -0,0 +1,125 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/marcusprice/twitter-clone/internal/controller"
+	"github.com/marcusprice/twitter-clone/internal/dtypes"
+	"github.com/marcusprice/twitter-clone/internal/testutil"
+	"github.com/marcusprice/twitter-clone/internal/util"
+)
+
+func TestBlockUser(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, _ time.Time) {
+		tu := testutil.NewTestUtil(t)
+		handler := RegisterHandlers(db)
+		blockerToken := loginAndToken(loadUserControllerByID(db, 7))
+		blockedToken := loginAndToken(loadUserControllerByID(db, 1))
+		blockerPost := createTestPost(7, db)
+
+		var blockerCommentID int
+		db.QueryRow(
+			"INSERT INTO Comment (post_id, user_id, depth, content, image) VALUES ($1, 7, 0, 'hi', '') RETURNING id;",
+			blockerPost.ID,
+		).Scan(&blockerCommentID)
+
+		res := testutil.ServeRequest(handler, http.MethodPut, "/api/v1/user/block/estecat", blockerToken, nil)
+		tu.AssertEqual(http.StatusNoContent, res.Code)
+		res = testutil.ServeRequest(handler, http.MethodPut, "/api/v1/user/block/endlesshappiness", blockerToken, nil)
+		tu.AssertEqual(http.StatusBadRequest, res.Code)
+		res = testutil.ServeRequest(handler, http.MethodPut, "/api/v1/user/block/nobody", blockerToken, nil)
+		tu.AssertEqual(http.StatusNotFound, res.Code)
+		res = testutil.ServeRequest(handler, http.MethodGet, "/api/v1/user/block/estecat", blockerToken, nil)
+		tu.AssertEqual(http.StatusMethodNotAllowed, res.Code)
+
+		// neither side can follow the other while the block stands
+		res = testutil.ServeRequest(handler, http.MethodPut, "/api/v1/user/follow/endlesshappiness", blockedToken, nil)
+		tu.AssertEqual(http.StatusForbidden, res.Code)
+		res = testutil.ServeRequest(handler, http.MethodPut, "/api/v1/user/follow/estecat", blockerToken, nil)
+		tu.AssertEqual(http.StatusForbidden, res.Code)
+
+		res = testutil.ServeRequest(handler, http.MethodPut, fmt.Sprintf("/api/v1/post/%d/like", blockerPost.ID), blockedToken, nil)
+		tu.AssertEqual(http.StatusForbidden, res.Code)
+		for _, action := range []string{"like", "retweet", "bookmark"} {
+			res = testutil.ServeRequest(handler, http.MethodPut, fmt.Sprintf("/api/v1/comment/%d/%s", blockerCommentID, action), blockedToken, nil)
+			tu.AssertEqual(http.StatusForbidden, res.Code)
+		}
+
+		for _, parentCommentID := range []string{"", fmt.Sprint(blockerCommentID)} {
+			formValues := map[string]string{
+				"content":         "let me in",
+				"postID":          fmt.Sprint(blockerPost.ID),
+				"parentCommentID": parentCommentID,
+			}
+			requestBody, contentType, _ := util.GenerateMultipartForm(formValues)
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/comment/create", requestBody)
+			req.Header.Set("Authorization", "Bearer "+blockedToken)
+			req.Header.Set("Content-Type", contentType)
+			res = httptest.NewRecorder()
+			handler.ServeHTTP(res, req)
+			tu.AssertEqual(http.StatusForbidden, res.Code)
+		}
+
+		controller.NewPostController(db).New(dtypes.PostInput{UserID: 1, Content: "hey @endlesshappiness"})
+		var mentions int
+		db.QueryRow(
+			"SELECT COUNT(*) FROM Notification WHERE type = 'mention' AND receiver_id = 7;",
+		).Scan(&mentions)
+		tu.AssertEqual(0, mentions)
+
+		res = testutil.ServeRequest(handler, http.MethodDelete, "/api/v1/user/block/estecat", blockerToken, nil)
+		tu.AssertEqual(http.StatusNoContent, res.Code)
+		res = testutil.ServeRequest(handler, http.MethodPut, "/api/v1/user/follow/endlesshappiness", blockedToken, nil)
+		tu.AssertEqual(http.StatusNoContent, res.Code)
+		res = testutil.ServeRequest(handler, http.MethodPut, fmt.Sprintf("/api/v1/post/%d/like", blockerPost.ID), blockedToken, nil)
+		tu.AssertEqual(http.StatusNoContent, res.Code)
+	})
+}
+
+func TestMuteUser(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, _ time.Time) {
+		tu := testutil.NewTestUtil(t)
+		handler := RegisterHandlers(db)
+		token := loginAndToken(loadUserControllerByID(db, 7))
+		post := createTestPost(1, db)
+		db.Exec(
+			"INSERT INTO Comment (post_id, user_id, depth, content, image) VALUES ($1, 4, 0, 'hi', ''), ($1, 5, 0, 'hey', '');",
+			post.ID,
+		)
+
+		res := testutil.ServeRequest(handler, http.MethodPut, "/api/v1/user/mute/audrey", token, nil)
+		tu.AssertEqual(http.StatusNoContent, res.Code)
+		res = testutil.ServeRequest(handler, http.MethodPut, "/api/v1/user/mute/nobody", token, nil)
+		tu.AssertEqual(http.StatusNotFound, res.Code)
+
+		for _, view := range []string{"FOLLOWING", "FOR_YOU"} {
+			res = testutil.ServeRequest(handler, http.MethodGet, "/api/v1/timeline?limit=40&offset=0&view="+view, token, nil)
+			tu.AssertEqual(http.StatusOK, res.Code)
+			var timeline TimelinePayload
+			json.NewDecoder(res.Body).Decode(&timeline)
+			tu.AssertTrue(len(timeline.Posts) > 0)
+			for _, timelinePost := range timeline.Posts {
+				tu.AssertTrue(timelinePost.Author.Username != "audrey")
+			}
+		}
+
+		res = testutil.ServeRequest(handler, http.MethodGet, fmt.Sprintf("/api/v1/post/%d", post.ID), token, nil)
+		tu.AssertEqual(http.StatusOK, res.Code)
+		var postAndComments PostAndCommentsPayload
+		json.NewDecoder(res.Body).Decode(&postAndComments)
+		tu.AssertEqual(1, len(postAndComments.Comments))
+		tu.AssertEqual("bobbybriggs", postAndComments.Comments[0].Author.Username)
+
+		res = testutil.ServeRequest(handler, http.MethodDelete, "/api/v1/user/mute/audrey", token, nil)
+		tu.AssertEqual(http.StatusNoContent, res.Code)
+		res = testutil.ServeRequest(handler, http.MethodGet, fmt.Sprintf("/api/v1/post/%d", post.ID), token, nil)
+		json.NewDecoder(res.Body).Decode(&postAndComments)
+		tu.AssertEqual(2, len(postAndComments.Comments))
+	})
+}
@@ -3,11 +3,17 @@ package api
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/marcusprice/twitter-clone/internal/controller"
+	"github.com/marcusprice/twitter-clone/internal/dbutils"
 	"github.com/marcusprice/twitter-clone/internal/dtypes"
+	"github.com/marcusprice/twitter-clone/internal/logger"
+	"github.com/marcusprice/twitter-clone/internal/model"
+	"github.com/marcusprice/twitter-clone/internal/permissions"
 )
 
 type CommentAPI struct {
@@ -39,6 +45,8 @@ func (commentAPI *CommentAPI) Create(w http.ResponseWriter, r *http.Request) {
 	postIDFormValue := r.FormValue("postID")
 	parentCommentIDFormValue := r.FormValue("parentCommentID")
 	content = r.FormValue("content")
+	// reply-guy placeholders, the content is streamed in after
+	generating := r.FormValue("generating") == "true"
 
 	postID, err := strconv.Atoi(postIDFormValue)
 	if err != nil {
@@ -63,7 +71,7 @@ func (commentAPI *CommentAPI) Create(w http.ResponseWriter, r *http.Request) {
 
 	if err == http.ErrMissingFile {
 		// no file upload, content required
-		if content == "" {
+		if content == "" && !generating {
 			http.Error(w, BadRequest, http.StatusBadRequest)
 			return
 		}
@@ -90,12 +98,17 @@ func (commentAPI *CommentAPI) Create(w http.ResponseWriter, r *http.Request) {
 		ParentCommentID: parentCommentID,
 		Content:         content,
 		Image:           filename,
+		Generating:      generating,
 	}
 
 	comment, err := commentAPI.comment.New(commentInput)
 	if err != nil {
 		if errors.Is(err, controller.DepthLimitError{}) {
 			http.Error(w, BadRequest, http.StatusBadRequest)
+		} else if errors.Is(err, controller.BlockedError{}) ||
+			errors.Is(err, controller.UnauthorizedActionError{}) ||
+			errors.Is(err, controller.PrivateAccountError{}) {
+			http.Error(w, Forbidden, http.StatusForbidden)
 		} else {
 			http.Error(w, InternalServerError, http.StatusInternalServerError)
 		}
@@ -108,6 +121,384 @@ func (commentAPI *CommentAPI) Create(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(payload)
 }
 
+func (commentAPI *CommentAPI) Like(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(int)
+	if !ok {
+		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	commentID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, BadRequest, http.StatusBadRequest)
+		return
+	}
+
+	if r.Method == http.MethodPut {
+		err = commentAPI.comment.Like(commentID, userID)
+	} else {
+		err = commentAPI.comment.Unlike(commentID, userID)
+	}
+
+	if err != nil {
+		var commentNotFoundError model.CommentNotFoundError
+		var postNotFoundError model.PostNotFoundError
+		switch {
+		case errors.As(err, &commentNotFoundError), errors.As(err, &postNotFoundError):
+			http.Error(w, NotFound, http.StatusNotFound)
+		case errors.Is(err, controller.BlockedError{}), errors.Is(err, controller.PrivateAccountError{}):
+			http.Error(w, Forbidden, http.StatusForbidden)
+		default:
+			http.Error(w, InternalServerError, http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (commentAPI *CommentAPI) Retweet(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(int)
+	if !ok {
+		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	commentID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, BadRequest, http.StatusBadRequest)
+		return
+	}
+
+	if r.Method == http.MethodPut {
+		err = commentAPI.comment.Retweet(commentID, userID)
+	} else {
+		err = commentAPI.comment.UnRetweet(commentID, userID)
+	}
+
+	if err != nil {
+		var commentNotFoundError model.CommentNotFoundError
+		var postNotFoundError model.PostNotFoundError
+		switch {
+		case errors.As(err, &commentNotFoundError), errors.As(err, &postNotFoundError):
+			http.Error(w, NotFound, http.StatusNotFound)
+		case errors.Is(err, controller.BlockedError{}), errors.Is(err, controller.PrivateAccountError{}):
+			http.Error(w, Forbidden, http.StatusForbidden)
+		default:
+			http.Error(w, InternalServerError, http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (commentAPI *CommentAPI) Bookmark(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(int)
+	if !ok {
+		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	commentID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, BadRequest, http.StatusBadRequest)
+		return
+	}
+
+	if r.Method == http.MethodPut {
+		err = commentAPI.comment.Bookmark(commentID, userID)
+	} else {
+		err = commentAPI.comment.UnBookmark(commentID, userID)
+	}
+
+	if err != nil {
+		var commentNotFoundError model.CommentNotFoundError
+		var postNotFoundError model.PostNotFoundError
+		switch {
+		case errors.As(err, &commentNotFoundError), errors.As(err, &postNotFoundError):
+			http.Error(w, NotFound, http.StatusNotFound)
+		case errors.Is(err, controller.BlockedError{}), errors.Is(err, controller.PrivateAccountError{}):
+			http.Error(w, Forbidden, http.StatusForbidden)
+		default:
+			http.Error(w, InternalServerError, http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (commentAPI *CommentAPI) Edit(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(int)
+	if !ok {
+		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	commentID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, BadRequest, http.StatusBadRequest)
+		return
+	}
+
+	var editInput dtypes.EditInput
+	err = json.NewDecoder(r.Body).Decode(&editInput)
+	if err != nil {
+		http.Error(w, BadRequest, http.StatusBadRequest)
+		return
+	}
+
+	comment, err := commentAPI.comment.Edit(commentID, userID, editInput.Content)
+	if err != nil {
+		var commentNotFoundError model.CommentNotFoundError
+		switch {
+		case errors.As(err, &commentNotFoundError):
+			http.Error(w, NotFound, http.StatusNotFound)
+		case errors.Is(err, controller.UnauthorizedActionError{}):
+			http.Error(w, Forbidden, http.StatusForbidden)
+		case dbutils.IsConstraintError(err):
+			http.Error(w, BadRequest, http.StatusBadRequest)
+		default:
+			http.Error(w, InternalServerError, http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	payload := generateCommentPayload(comment)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(payload)
+}
+
+// UpdateGeneration replaces the content of a generating comment, used by
+// reply-guy to stream a reply in as the model writes it
+func (commentAPI *CommentAPI) UpdateGeneration(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(int)
+	if !ok {
+		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	commentID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, BadRequest, http.StatusBadRequest)
+		return
+	}
+
+	var generationInput dtypes.GenerationInput
+	err = json.NewDecoder(r.Body).Decode(&generationInput)
+	if err != nil {
+		http.Error(w, BadRequest, http.StatusBadRequest)
+		return
+	}
+
+	comment, err := commentAPI.comment.UpdateGeneration(
+		commentID, userID, generationInput.Content, generationInput.Done)
+	if err != nil {
+		var commentNotFoundError model.CommentNotFoundError
+		switch {
+		case errors.As(err, &commentNotFoundError):
+			http.Error(w, NotFound, http.StatusNotFound)
+		case errors.Is(err, controller.UnauthorizedActionError{}):
+			http.Error(w, Forbidden, http.StatusForbidden)
+		case errors.Is(err, controller.GenerationFinishedError{}):
+			http.Error(w, Conflict, http.StatusConflict)
+		case dbutils.IsConstraintError(err):
+			// finishing with no content
+			http.Error(w, BadRequest, http.StatusBadRequest)
+		default:
+			http.Error(w, InternalServerError, http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	payload := generateCommentPayload(comment)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(payload)
+}
+
+// Stream sends the comment and then each update to it as server-sent events
+// until it's done generating, comments that aren't generating get one event
+func (commentAPI *CommentAPI) Stream(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(int)
+	if !ok {
+		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	commentID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, BadRequest, http.StatusBadRequest)
+		return
+	}
+
+	comment, updates, unsubscribe, err := commentAPI.comment.Subscribe(commentID, userID)
+	if err != nil {
+		var commentNotFoundError model.CommentNotFoundError
+		var postNotFoundError model.PostNotFoundError
+		switch {
+		case errors.As(err, &commentNotFoundError), errors.As(err, &postNotFoundError):
+			http.Error(w, NotFound, http.StatusNotFound)
+		case errors.Is(err, controller.PrivateAccountError{}):
+			http.Error(w, Forbidden, http.StatusForbidden)
+		default:
+			http.Error(w, InternalServerError, http.StatusInternalServerError)
+		}
+
+		return
+	}
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	responseController := http.NewResponseController(w)
+	err = writeCommentEvent(w, responseController, comment)
+	if err != nil || !comment.Generating {
+		return
+	}
+
+	keepAlive := time.NewTicker(STREAM_KEEP_ALIVE_INTERVAL)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			err = responseController.Flush()
+		case update, ok := <-updates:
+			// closed without a final update when the comment is deleted
+			if !ok {
+				return
+			}
+
+			err = writeCommentEvent(w, responseController, update)
+		}
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+func writeCommentEvent(w http.ResponseWriter, responseController *http.ResponseController, comment *controller.Comment) error {
+	// updates are shared between subscribers and the payload rewrites the
+	// image paths, so work on a copy
+	commentCopy := *comment
+	payload, err := json.Marshal(generateCommentPayload(&commentCopy))
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "event: comment\ndata: %s\n\n", payload)
+	if err != nil {
+		return err
+	}
+
+	return responseController.Flush()
+}
+
+func (commentAPI *CommentAPI) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(int)
+	if !ok {
+		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	role, ok := r.Context().Value("userRole").(permissions.Role)
+	if !ok {
+		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	commentID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, BadRequest, http.StatusBadRequest)
+		return
+	}
+
+	images, err := commentAPI.comment.Delete(commentID, userID, role)
+	if err != nil {
+		var commentNotFoundError model.CommentNotFoundError
+		switch {
+		case errors.As(err, &commentNotFoundError):
+			http.Error(w, NotFound, http.StatusNotFound)
+		case errors.Is(err, controller.UnauthorizedActionError{}):
+			http.Error(w, Forbidden, http.StatusForbidden)
+		default:
+			http.Error(w, InternalServerError, http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	deleteUploadedImages(images)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (commentAPI *CommentAPI) Report(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(int)
+	if !ok {
+		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	commentID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, BadRequest, http.StatusBadRequest)
+		return
+	}
+
+	var reportInput dtypes.ReportInput
+	err = json.NewDecoder(r.Body).Decode(&reportInput)
+	if err != nil || !validReportInput(reportInput) {
+		http.Error(w, BadRequest, http.StatusBadRequest)
+		return
+	}
+
+	err = commentAPI.comment.Report(
+		commentID, userID, model.ReportReason(reportInput.Reason), reportInput.Details)
+	if err != nil {
+		var commentNotFoundError model.CommentNotFoundError
+		switch {
+		case errors.As(err, &commentNotFoundError):
+			http.Error(w, NotFound, http.StatusNotFound)
+		case dbutils.IsUniqueConstraintError(err):
+			http.Error(w, Conflict, http.StatusConflict)
+		default:
+			http.Error(w, InternalServerError, http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CleanupGenerating deletes the generating comments reply-guy abandoned now
+// and then every GENERATION_CLEANUP_INTERVAL, it doesn't return
+func (commentAPI *CommentAPI) CleanupGenerating() {
+	for {
+		images, err := commentAPI.comment.CleanupGenerating()
+		deleteUploadedImages(images)
+		if err != nil {
+			logger.LogError("CommentAPI.CleanupGenerating() error: " + err.Error())
+		}
+
+		time.Sleep(GENERATION_CLEANUP_INTERVAL)
+	}
+}
+
 func NewCommentAPI(comment *controller.Comment) *CommentAPI {
 	return &CommentAPI{comment: comment}
 }
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/marcusprice/twitter-clone/internal/controller"
+	"github.com/marcusprice/twitter-clone/internal/dtypes"
+	"github.com/marcusprice/twitter-clone/internal/testutil"
+)
+
+func TestCommentActions(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, timestamp time.Time) {
+		tu := testutil.NewTestUtil(t)
+		handler := RegisterHandlers(db)
+		commentController := controller.NewCommentController(db)
+		user1 := loadUserControllerByID(db, 1)
+		user2 := loadUserControllerByID(db, 2)
+		user2Token := loginAndToken(user2)
+
+		comment, err := commentController.New(dtypes.CommentInput{
+			PostID:  1,
+			UserID:  user1.ID(),
+			Content: "meow",
+		})
+		tu.AssertErrorNil(err)
+
+		request := func(method, action string, commentID int) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(
+				method,
+				fmt.Sprintf("/api/v1/comment/%d/%s", commentID, action),
+				nil,
+			)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", user2Token))
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, req)
+			return res
+		}
+
+		res := request(http.MethodPut, "like", comment.ID)
+		tu.AssertEqual(http.StatusNoContent, res.Code)
+		res = request(http.MethodPut, "retweet", comment.ID)
+		tu.AssertEqual(http.StatusNoContent, res.Code)
+		res = request(http.MethodPut, "bookmark", comment.ID)
+		tu.AssertEqual(http.StatusNoContent, res.Code)
+
+		comment, err = commentController.ByID(comment.ID)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(1, comment.LikeCount)
+		tu.AssertEqual(1, comment.RetweetCount)
+		tu.AssertEqual(1, comment.BookmarkCount)
+
+		res = request(http.MethodDelete, "like", comment.ID)
+		tu.AssertEqual(http.StatusNoContent, res.Code)
+		res = request(http.MethodDelete, "retweet", comment.ID)
+		tu.AssertEqual(http.StatusNoContent, res.Code)
+		res = request(http.MethodDelete, "bookmark", comment.ID)
+		tu.AssertEqual(http.StatusNoContent, res.Code)
+
+		comment, err = commentController.ByID(comment.ID)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(0, comment.LikeCount)
+		tu.AssertEqual(0, comment.RetweetCount)
+		tu.AssertEqual(0, comment.BookmarkCount)
+
+		for _, action := range []string{"like", "retweet", "bookmark"} {
+			res = request(http.MethodPut, action, 42069)
+			tu.AssertEqual(http.StatusNotFound, res.Code)
+		}
+
+		res = request(http.MethodGet, "like", comment.ID)
+		tu.AssertEqual(http.StatusMethodNotAllowed, res.Code)
+
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/comment/meow/like", nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", user2Token))
+		res = httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		tu.AssertEqual(http.StatusBadRequest, res.Code)
+	})
+}
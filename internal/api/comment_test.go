@@ -1,9 +1,11 @@
 package api
 
 import (
+	"bufio"
 	"bytes"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -13,6 +15,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/marcusprice/twitter-clone/internal/controller"
 	"github.com/marcusprice/twitter-clone/internal/dtypes"
 	"github.com/marcusprice/twitter-clone/internal/testhelpers"
 	"github.com/marcusprice/twitter-clone/internal/testutil"
@@ -28,7 +31,7 @@ func TestCreateComment(t *testing.T) {
 
 		testUser := createTestUser(db)
 		testUser.Login()
-		token, _ := GenerateJWT(testUser.ID())
+		token, _ := GenerateJWT(testUser.ID(), testUser.TokenVersion)
 
 		formValues := make(map[string]string)
 		formValues["content"] = "Cats are awesome"
@@ -103,7 +106,7 @@ func TestCreateCommentReply(t *testing.T) {
 
 		testUser := createTestUser(db)
 		testUser.Login()
-		token, _ := GenerateJWT(testUser.ID())
+		token, _ := GenerateJWT(testUser.ID(), testUser.TokenVersion)
 
 		formValues := make(map[string]string)
 		formValues["content"] = "Cats are awesome"
@@ -178,7 +181,7 @@ func TestCreateCommentImageOnly(t *testing.T) {
 
 		testUser := createTestUser(db)
 		testUser.Login()
-		token, _ := GenerateJWT(testUser.ID())
+		token, _ := GenerateJWT(testUser.ID(), testUser.TokenVersion)
 
 		var b bytes.Buffer
 		writer := multipart.NewWriter(&b)
@@ -232,7 +235,7 @@ func TestCreateCommentContentAndImage(t *testing.T) {
 		handler := RegisterHandlers(db)
 		testUser := createTestUser(db)
 		testUser.Login()
-		token, _ := GenerateJWT(testUser.ID())
+		token, _ := GenerateJWT(testUser.ID(), testUser.TokenVersion)
 
 		var b bytes.Buffer
 		imgData := generateLargeString(5)
@@ -282,7 +285,7 @@ func TestCreateCommentUploadSizeTooLarge(t *testing.T) {
 		tu := testutil.NewTestUtil(t)
 		testUser := createTestUser(db)
 		testUser.Login()
-		token, _ := GenerateJWT(testUser.ID())
+		token, _ := GenerateJWT(testUser.ID(), testUser.TokenVersion)
 		tu.CreateTestUploadsDir()
 		defer tu.CleanTestUploads()
 
@@ -337,7 +340,7 @@ func TestCreateCommentNoContentOrImage(t *testing.T) {
 		handler := RegisterHandlers(db)
 		testUser := createTestUser(db)
 		testUser.Login()
-		token, _ := GenerateJWT(testUser.ID())
+		token, _ := GenerateJWT(testUser.ID(), testUser.TokenVersion)
 
 		var b bytes.Buffer
 		writer := multipart.NewWriter(&b)
@@ -391,7 +394,7 @@ func TestCreateCommentInvalidFileType(t *testing.T) {
 		handler := RegisterHandlers(db)
 		testUser := createTestUser(db)
 		testUser.Login()
-		token, _ := GenerateJWT(testUser.ID())
+		token, _ := GenerateJWT(testUser.ID(), testUser.TokenVersion)
 
 		var b bytes.Buffer
 		writer := multipart.NewWriter(&b)
@@ -507,6 +510,152 @@ func TestCreateCommentWrongMethod(t *testing.T) {
 	})
 }
 
+func TestEditAndDeleteComment(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, _ time.Time) {
+		tu := testutil.NewTestUtil(t)
+		handler := RegisterHandlers(db)
+		author := loadUserControllerByID(db, 1)
+		admin := loadUserControllerByID(db, 2)
+		user := loadUserControllerByID(db, 4)
+		authorToken := loginAndToken(author)
+		adminToken := loginAndToken(admin)
+		userToken := loginAndToken(user)
+		comment := controller.NewCommentController(db)
+
+		newComment, err := comment.New(dtypes.CommentInput{
+			PostID:  1,
+			UserID:  author.ID(),
+			Content: "meow",
+		})
+		tu.AssertErrorNil(err)
+
+		request := func(method, token, body string, commentID int) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(
+				method,
+				fmt.Sprintf("/api/v1/comment/%d", commentID),
+				bytes.NewBufferString(body),
+			)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, req)
+			return res
+		}
+
+		res := request(http.MethodPatch, authorToken, `{"content": "meow meow"}`, newComment.ID)
+		var commentPayload CommentPayload
+		json.Unmarshal(res.Body.Bytes(), &commentPayload)
+		tu.AssertEqual(http.StatusOK, res.Code)
+		tu.AssertEqual("meow meow", commentPayload.Content)
+
+		res = request(http.MethodPatch, userToken, `{"content": "woof"}`, newComment.ID)
+		tu.AssertEqual(http.StatusForbidden, res.Code)
+
+		res = request(http.MethodPatch, authorToken, `{"content": "woof"}`, 42069)
+		tu.AssertEqual(http.StatusNotFound, res.Code)
+
+		res = request(http.MethodDelete, userToken, "", newComment.ID)
+		tu.AssertEqual(http.StatusForbidden, res.Code)
+
+		res = request(http.MethodDelete, adminToken, "", newComment.ID)
+		tu.AssertEqual(http.StatusNoContent, res.Code)
+
+		res = request(http.MethodDelete, authorToken, "", newComment.ID)
+		tu.AssertEqual(http.StatusNotFound, res.Code)
+
+		res = request(http.MethodGet, authorToken, "", newComment.ID)
+		tu.AssertEqual(http.StatusMethodNotAllowed, res.Code)
+	})
+}
+
+func TestCommentStream(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, _ time.Time) {
+		tu := testutil.NewTestUtil(t)
+		server := httptest.NewServer(RegisterHandlers(db))
+		defer server.Close()
+		replyGuyToken := loginAndToken(loadUserControllerByID(db, 3))
+		userToken := loginAndToken(loadUserControllerByID(db, 1))
+
+		request := func(method, path, token string, body io.Reader, contentType string) *http.Response {
+			req := testutil.NewRequest(method, server.URL+path, token, body)
+			req.Header.Set("Content-Type", contentType)
+			res, err := http.DefaultClient.Do(req)
+			tu.AssertErrorNil(err)
+			return res
+		}
+
+		createPlaceholder := func(token string) *http.Response {
+			requestBody, contentType, _ := util.GenerateMultipartForm(map[string]string{
+				"postID":     "1",
+				"generating": "true",
+			})
+			return request(http.MethodPost, "/api/v1/comment/create", token, requestBody, contentType)
+		}
+
+		res := createPlaceholder(userToken)
+		res.Body.Close()
+		tu.AssertEqual(http.StatusForbidden, res.StatusCode)
+
+		res = createPlaceholder(replyGuyToken)
+		var placeholder CommentPayload
+		json.NewDecoder(res.Body).Decode(&placeholder)
+		res.Body.Close()
+		tu.AssertEqual(http.StatusOK, res.StatusCode)
+		tu.AssertTrue(placeholder.Generating)
+		tu.AssertEqual("", placeholder.Content)
+
+		streamPath := fmt.Sprintf("/api/v1/comment/%d/stream", placeholder.ID)
+		update := func(token, body string) int {
+			res := request(http.MethodPatch, streamPath, token, strings.NewReader(body), "application/json")
+			res.Body.Close()
+			return res.StatusCode
+		}
+
+		stream := request(http.MethodGet, streamPath, userToken, nil, "")
+		defer stream.Body.Close()
+		tu.AssertEqual(http.StatusOK, stream.StatusCode)
+		tu.AssertEqual("text/event-stream", stream.Header.Get("Content-Type"))
+		events := bufio.NewReader(stream.Body)
+		nextEvent := func() CommentPayload {
+			var event CommentPayload
+			for {
+				line, err := events.ReadString('\n')
+				tu.AssertErrorNil(err)
+				if data, ok := strings.CutPrefix(line, "data: "); ok {
+					json.Unmarshal([]byte(data), &event)
+				}
+
+				if line == "\n" {
+					return event
+				}
+			}
+		}
+
+		event := nextEvent()
+		tu.AssertTrue(event.Generating)
+
+		tu.AssertEqual(http.StatusForbidden, update(userToken, `{"content": "hijacked"}`))
+		tu.AssertEqual(http.StatusOK, update(replyGuyToken, `{"content": "the owls"}`))
+		event = nextEvent()
+		tu.AssertEqual("the owls", event.Content)
+		tu.AssertTrue(event.Generating)
+
+		tu.AssertEqual(http.StatusOK, update(replyGuyToken, `{"content": "the owls are not what they seem", "done": true}`))
+		event = nextEvent()
+		tu.AssertEqual("the owls are not what they seem", event.Content)
+		tu.AssertFalse(event.Generating)
+
+		// the stream ends with the comment
+		_, err := events.ReadString('\n')
+		tu.AssertTrue(errors.Is(err, io.EOF))
+
+		tu.AssertEqual(http.StatusConflict, update(replyGuyToken, `{"content": "edited"}`))
+
+		res = request(http.MethodGet, "/api/v1/comment/42069/stream", userToken, nil, "")
+		res.Body.Close()
+		tu.AssertEqual(http.StatusNotFound, res.StatusCode)
+	})
+}
+
 func createLargeImgMultipartFormBodyWithPostID(mbOver float64, postID int) (*bytes.Buffer, string) {
 	var b bytes.Buffer
 	writer := multipart.NewWriter(&b)
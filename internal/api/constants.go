@@ -1,11 +1,23 @@
 package api
 
-import "net/http"
+import (
+	"net/http"
+	"time"
+)
 
 const UPLOADS_PREFIX = "/uploads/"
+const DEFAULT_ACCESS_TOKEN_TTL = 15 * time.Minute
+const REFRESH_TOKEN_HEADER = "X-Refresh-Token"
+const MAX_REPORT_DETAILS_LENGTH = 500
+const MAX_SEARCH_QUERY_LENGTH = 100
+const MAX_MESSAGE_LENGTH = 1000
+const MAX_IMAGE_ALT_LENGTH = 1000
+const STREAM_KEEP_ALIVE_INTERVAL = 15 * time.Second
+const GENERATION_CLEANUP_INTERVAL = time.Minute
 
 var BadRequest = http.StatusText(http.StatusBadRequest)
 var Conflict = http.StatusText(http.StatusConflict)
+var Forbidden = http.StatusText(http.StatusForbidden)
 var InternalServerError = http.StatusText(http.StatusInternalServerError)
 var MethodNotAllowed = http.StatusText(http.StatusMethodNotAllowed)
 var NotFound = http.StatusText(http.StatusNotFound)
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marcusprice/twitter-clone/internal/testutil"
+	"github.com/marcusprice/twitter-clone/internal/util"
+)
+
+func TestFollowRequests(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, _ time.Time) {
+		tu := testutil.NewTestUtil(t)
+		handler := RegisterHandlers(db)
+		privateToken := loginAndToken(loadUserControllerByID(db, 2))
+		viewerToken := loginAndToken(loadUserControllerByID(db, 1))
+
+		// dalecooper retweets wallphace's first post while it's still public
+		res := testutil.ServeRequest(handler, http.MethodPut, "/api/v1/post/2/retweet", loginAndToken(loadUserControllerByID(db, 3)), nil)
+		tu.AssertEqual(http.StatusNoContent, res.Code)
+
+		var commentID int
+		db.QueryRow(
+			"INSERT INTO Comment (post_id, user_id, depth, content, image) VALUES (2, 3, 0, 'hi', '') RETURNING id;",
+		).Scan(&commentID)
+
+		res = testutil.ServeRequest(handler, http.MethodPatch, "/api/v1/user", privateToken, strings.NewReader(`{"isPrivate": true}`))
+		tu.AssertEqual(http.StatusOK, res.Code)
+		var user UserPayload
+		json.NewDecoder(res.Body).Decode(&user)
+		tu.AssertTrue(user.IsPrivate)
+
+		// wallphace's first post in the seed data
+		res = testutil.ServeRequest(handler, http.MethodGet, "/api/v1/post/2", viewerToken, nil)
+		tu.AssertEqual(http.StatusForbidden, res.Code)
+		res = testutil.ServeRequest(handler, http.MethodGet, "/api/v1/user/wallphace/posts?limit=5&offset=0", viewerToken, nil)
+		tu.AssertEqual(http.StatusForbidden, res.Code)
+		res = testutil.ServeRequest(handler, http.MethodGet, "/api/v1/post/2", privateToken, nil)
+		tu.AssertEqual(http.StatusOK, res.Code)
+
+		// non-followers can't act on the posts or list the account's follows
+		for _, action := range []string{"like", "retweet", "bookmark"} {
+			res = testutil.ServeRequest(handler, http.MethodPut, "/api/v1/post/2/"+action, viewerToken, nil)
+			tu.AssertEqual(http.StatusForbidden, res.Code)
+			res = testutil.ServeRequest(handler, http.MethodPut, fmt.Sprintf("/api/v1/comment/%d/%s", commentID, action), viewerToken, nil)
+			tu.AssertEqual(http.StatusForbidden, res.Code)
+		}
+		for _, list := range []string{"followers", "following"} {
+			res = testutil.ServeRequest(handler, http.MethodGet, "/api/v1/user/wallphace/"+list+"?limit=5&offset=0", viewerToken, nil)
+			tu.AssertEqual(http.StatusForbidden, res.Code)
+		}
+		requestBody, contentType, _ := util.GenerateMultipartForm(map[string]string{"content": "hi", "postID": "2"})
+		req := testutil.NewRequest(http.MethodPost, "/api/v1/comment/create", viewerToken, requestBody)
+		req.Header.Set("Content-Type", contentType)
+		res = httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		tu.AssertEqual(http.StatusForbidden, res.Code)
+
+		// or see them retweeted by a public account
+		res = testutil.ServeRequest(handler, http.MethodGet, "/api/v1/user/dalecooper/posts?limit=20&offset=0", viewerToken, nil)
+		tu.AssertEqual(http.StatusOK, res.Code)
+		var timeline TimelinePayload
+		json.NewDecoder(res.Body).Decode(&timeline)
+		for _, post := range timeline.Posts {
+			tu.AssertFalse(post.IsRetweet && post.ID == 2)
+		}
+		res = testutil.ServeRequest(handler, http.MethodGet, "/api/v1/user/dalecooper/posts?limit=20&offset=0", privateToken, nil)
+		timeline = TimelinePayload{}
+		json.NewDecoder(res.Body).Decode(&timeline)
+		retweeted := false
+		for _, post := range timeline.Posts {
+			retweeted = retweeted || (post.IsRetweet && post.ID == 2)
+		}
+		tu.AssertTrue(retweeted)
+
+		res = testutil.ServeRequest(handler, http.MethodPut, "/api/v1/user/follow/wallphace", viewerToken, nil)
+		tu.AssertEqual(http.StatusAccepted, res.Code)
+
+		res = testutil.ServeRequest(handler, http.MethodGet, "/api/v1/user/wallphace", viewerToken, nil)
+		var profile AuthorPayload
+		json.NewDecoder(res.Body).Decode(&profile)
+		tu.AssertTrue(profile.IsPrivate)
+		tu.AssertTrue(profile.ViewerRequested)
+		tu.AssertFalse(profile.ViewerFollowing)
+
+		res = testutil.ServeRequest(handler, http.MethodGet, "/api/v1/user/follow-requests?limit=5&offset=0", privateToken, nil)
+		tu.AssertEqual(http.StatusOK, res.Code)
+		var requests UserListPayload
+		json.NewDecoder(res.Body).Decode(&requests)
+		tu.AssertEqual(1, len(requests.Users))
+		tu.AssertEqual("estecat", requests.Users[0].Username)
+		tu.AssertFalse(requests.HasMore)
+
+		res = testutil.ServeRequest(handler, http.MethodPut, "/api/v1/user/follow-requests/dalecooper", privateToken, nil)
+		tu.AssertEqual(http.StatusNotFound, res.Code)
+		res = testutil.ServeRequest(handler, http.MethodPut, "/api/v1/user/follow-requests/estecat", privateToken, nil)
+		tu.AssertEqual(http.StatusNoContent, res.Code)
+
+		res = testutil.ServeRequest(handler, http.MethodGet, "/api/v1/post/2", viewerToken, nil)
+		tu.AssertEqual(http.StatusOK, res.Code)
+
+		res = testutil.ServeRequest(handler, http.MethodPut, "/api/v1/user/follow/wallphace", loginAndToken(loadUserControllerByID(db, 3)), nil)
+		tu.AssertEqual(http.StatusAccepted, res.Code)
+		res = testutil.ServeRequest(handler, http.MethodDelete, "/api/v1/user/follow-requests/dalecooper", privateToken, nil)
+		tu.AssertEqual(http.StatusNoContent, res.Code)
+		res = testutil.ServeRequest(handler, http.MethodDelete, "/api/v1/user/follow-requests/dalecooper", privateToken, nil)
+		tu.AssertEqual(http.StatusNotFound, res.Code)
+
+		res = testutil.ServeRequest(handler, http.MethodPut, "/api/v1/user/follow/dalecooper", viewerToken, nil)
+		tu.AssertEqual(http.StatusNoContent, res.Code)
+	})
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/marcusprice/twitter-clone/internal/controller"
+	"github.com/marcusprice/twitter-clone/internal/util"
+)
+
+const DEFAULT_TRENDING_WINDOW_HOURS = 24
+const MAX_TRENDING_WINDOW_HOURS = 24 * 7
+const DEFAULT_TRENDING_LIMIT = 10
+const MAX_TRENDING_LIMIT = 25
+
+type HashtagAPI struct {
+	hashtag *controller.Hashtag
+}
+
+func (hashtagAPI *HashtagAPI) GetFeed(w http.ResponseWriter, r *http.Request) {
+	viewerID, ok := r.Context().Value("userID").(int)
+	if !ok {
+		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	values := r.URL.Query()
+	limit, offset, err := parseLimitAndOffset(values.Get("limit"), values.Get("offset"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// the tag has to parse as exactly itself, so #cats#dogs or #2024 is rejected
+	tag := strings.ToLower(strings.TrimPrefix(r.PathValue("tag"), "#"))
+	tags := util.ParseHashtags("#" + tag)
+	if len(tags) != 1 || tags[0] != tag {
+		http.Error(w, BadRequest, http.StatusBadRequest)
+		return
+	}
+
+	posts, postsRemaining, err := hashtagAPI.hashtag.Feed(tag, viewerID, limit, offset)
+	if err != nil {
+		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	timelinePosts := []TimelinePostPayload{}
+	for _, post := range posts {
+		timelinePosts = append(timelinePosts, generateTimelinePostPayload(post))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(TimelinePayload{
+		Posts:          timelinePosts,
+		HasMore:        postsRemaining > 0,
+		PostsRemaining: &postsRemaining,
+	})
+}
+
+func (hashtagAPI *HashtagAPI) GetTrending(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	windowHours, ok := parseOptionalInt(values.Get("hours"), DEFAULT_TRENDING_WINDOW_HOURS, MAX_TRENDING_WINDOW_HOURS)
+	if !ok {
+		http.Error(w, BadRequest, http.StatusBadRequest)
+		return
+	}
+
+	limit, ok := parseOptionalInt(values.Get("limit"), DEFAULT_TRENDING_LIMIT, MAX_TRENDING_LIMIT)
+	if !ok {
+		http.Error(w, BadRequest, http.StatusBadRequest)
+		return
+	}
+
+	hashtags, err := hashtagAPI.hashtag.Trending(windowHours, limit)
+	if err != nil {
+		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(generateTrendingPayload(hashtags, windowHours))
+}
+
+// parseOptionalInt returns fallback for an empty param, ok is false unless
+// the param is between 1 and max
+func parseOptionalInt(param string, fallback, max int) (value int, ok bool) {
+	if param == "" {
+		return fallback, true
+	}
+
+	value, err := strconv.Atoi(param)
+	if err != nil || value < 1 || value > max {
+		return 0, false
+	}
+
+	return value, true
+}
+
+func NewHashtagAPI(db *sql.DB) *HashtagAPI {
+	return &HashtagAPI{
+		hashtag: controller.NewHashtagController(db),
+	}
+}
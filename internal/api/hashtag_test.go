@@ -0,0 +1,93 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/marcusprice/twitter-clone/internal/controller"
+	"github.com/marcusprice/twitter-clone/internal/dtypes"
+	"github.com/marcusprice/twitter-clone/internal/testutil"
+)
+
+func TestHashtagFeed(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, _ time.Time) {
+		tu := testutil.NewTestUtil(t)
+		handler := RegisterHandlers(db)
+		token := loginAndToken(loadUserControllerByID(db, 7))
+		for _, content := range []string{"#Esteban knocked it over", "more #esteban", "#synths"} {
+			controller.NewPostController(db).New(dtypes.PostInput{UserID: 1, Content: content})
+		}
+
+		request := func(path string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, req)
+			return res
+		}
+
+		res := request("/api/v1/hashtag/ESTEBAN?limit=1&offset=0")
+		tu.AssertEqual(http.StatusOK, res.Code)
+		var feed TimelinePayload
+		json.NewDecoder(res.Body).Decode(&feed)
+		tu.AssertEqual(1, len(feed.Posts))
+		tu.AssertTrue(feed.HasMore)
+		tu.AssertEqual(1, *feed.PostsRemaining)
+		tu.AssertEqual("post", feed.Posts[0].Type)
+		tu.AssertTrue(feed.Posts[0].Content != "#synths")
+
+		res = request("/api/v1/hashtag/nobody?limit=10&offset=0")
+		tu.AssertEqual(http.StatusOK, res.Code)
+		json.NewDecoder(res.Body).Decode(&feed)
+		tu.AssertEqual(0, len(feed.Posts))
+
+		res = request("/api/v1/hashtag/2024?limit=10&offset=0")
+		tu.AssertEqual(http.StatusBadRequest, res.Code)
+		res = request("/api/v1/hashtag/cats&dogs?limit=10&offset=0")
+		tu.AssertEqual(http.StatusBadRequest, res.Code)
+		res = request("/api/v1/hashtag/esteban")
+		tu.AssertEqual(http.StatusBadRequest, res.Code)
+	})
+}
+
+func TestHashtagTrending(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, _ time.Time) {
+		tu := testutil.NewTestUtil(t)
+		handler := RegisterHandlers(db)
+		token := loginAndToken(loadUserControllerByID(db, 7))
+		for _, content := range []string{"#esteban", "#esteban #synths", "#twinpeaks #esteban"} {
+			controller.NewPostController(db).New(dtypes.PostInput{UserID: 1, Content: content})
+		}
+
+		request := func(path string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, req)
+			return res
+		}
+
+		res := request("/api/v1/hashtags/trending")
+		tu.AssertEqual(http.StatusOK, res.Code)
+		var trending TrendingPayload
+		json.NewDecoder(res.Body).Decode(&trending)
+		tu.AssertEqual(DEFAULT_TRENDING_WINDOW_HOURS, trending.WindowHours)
+		tu.AssertEqual(3, len(trending.Hashtags))
+		tu.AssertEqual(HashtagPayload{Tag: "esteban", Uses: 3}, trending.Hashtags[0])
+
+		res = request("/api/v1/hashtags/trending?hours=1&limit=1")
+		tu.AssertEqual(http.StatusOK, res.Code)
+		json.NewDecoder(res.Body).Decode(&trending)
+		tu.AssertEqual(1, trending.WindowHours)
+		tu.AssertEqual(1, len(trending.Hashtags))
+
+		res = request("/api/v1/hashtags/trending?hours=1000")
+		tu.AssertEqual(http.StatusBadRequest, res.Code)
+		res = request("/api/v1/hashtags/trending?limit=0")
+		tu.AssertEqual(http.StatusBadRequest, res.Code)
+	})
+}
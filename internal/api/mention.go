@@ -0,0 +1,55 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+
+	"github.com/marcusprice/twitter-clone/internal/controller"
+)
+
+type MentionAPI struct {
+	mention *controller.Mention
+}
+
+// GetMentions lists the posts and comments mentioning the requesting user,
+// newest first
+func (mentionAPI *MentionAPI) GetMentions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(int)
+	if !ok {
+		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	values := r.URL.Query()
+	limit, offset, err := parseLimitAndOffset(values.Get("limit"), values.Get("offset"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	posts, postsRemaining, err := mentionAPI.mention.Feed(userID, limit, offset)
+	if err != nil {
+		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	timelinePosts := []TimelinePostPayload{}
+	for _, post := range posts {
+		timelinePosts = append(timelinePosts, generateTimelinePostPayload(post))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(TimelinePayload{
+		Posts:          timelinePosts,
+		HasMore:        postsRemaining > 0,
+		PostsRemaining: &postsRemaining,
+	})
+}
+
+func NewMentionAPI(db *sql.DB) *MentionAPI {
+	return &MentionAPI{
+		mention: controller.NewMentionController(db),
+	}
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/marcusprice/twitter-clone/internal/dtypes"
+	"github.com/marcusprice/twitter-clone/internal/model"
+	"github.com/marcusprice/twitter-clone/internal/testutil"
+)
+
+func TestMentions(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, _ time.Time) {
+		tu := testutil.NewTestUtil(t)
+		handler := RegisterHandlers(db)
+		token := loginAndToken(loadUserControllerByID(db, 7))
+		postID, err := model.NewPostModel(db).New(dtypes.PostInput{
+			UserID: 1, Content: "café time @endlesshappiness, @nobody and @audrey",
+		})
+		tu.AssertErrorNil(err)
+		model.NewCommentModel(db).NewPostComment(dtypes.CommentInput{
+			PostID: postID, UserID: 4, Content: "@estecat @endlesshappiness on my way",
+		})
+
+		request := func(path string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, req)
+			return res
+		}
+
+		res := request(fmt.Sprintf("/api/v1/post/%d", postID))
+		tu.AssertEqual(http.StatusOK, res.Code)
+		var post PostAndCommentsPayload
+		json.NewDecoder(res.Body).Decode(&post)
+		// offsets count code points, so é is one, and @nobody isn't a user
+		tu.AssertEqual(2, len(post.Mentions))
+		tu.AssertEqual(MentionPayload{Username: "endlesshappiness", Start: 10, End: 27}, post.Mentions[0])
+		tu.AssertEqual(MentionPayload{Username: "audrey", Start: 41, End: 48}, post.Mentions[1])
+		tu.AssertEqual(1, len(post.Comments))
+		tu.AssertEqual(2, len(post.Comments[0].Mentions))
+		tu.AssertEqual(MentionPayload{Username: "estecat", Start: 0, End: 8}, post.Comments[0].Mentions[0])
+
+		res = request("/api/v1/user/mentions?limit=1&offset=0")
+		tu.AssertEqual(http.StatusOK, res.Code)
+		var feed TimelinePayload
+		json.NewDecoder(res.Body).Decode(&feed)
+		tu.AssertEqual(1, len(feed.Posts))
+		tu.AssertTrue(feed.HasMore)
+		tu.AssertEqual(1, *feed.PostsRemaining)
+
+		res = request("/api/v1/user/mentions")
+		tu.AssertEqual(http.StatusBadRequest, res.Code)
+	})
+}
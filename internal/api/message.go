@@ -0,0 +1,151 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/marcusprice/twitter-clone/internal/controller"
+	"github.com/marcusprice/twitter-clone/internal/dbutils"
+	"github.com/marcusprice/twitter-clone/internal/dtypes"
+	"github.com/marcusprice/twitter-clone/internal/model"
+)
+
+type MessageAPI struct {
+	message *controller.Message
+}
+
+// GetConversations lists the requesting user's conversations, most recent
+// message first
+func (messageAPI *MessageAPI) GetConversations(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(int)
+	if !ok {
+		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	values := r.URL.Query()
+	limit, offset, err := parseLimitAndOffset(values.Get("limit"), values.Get("offset"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	conversations, conversationsRemaining, err := messageAPI.message.Conversations(userID, limit, offset)
+	if err != nil {
+		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(generateConversationListPayload(conversations, conversationsRemaining))
+}
+
+// Messages serves /messages/{target}, GET reads the conversation with the id
+// target and POST messages the user named target. One route is used for both
+// since the two patterns would conflict in the mux.
+func (messageAPI *MessageAPI) Messages(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		messageAPI.Send(w, r)
+	} else {
+		messageAPI.GetMessages(w, r)
+	}
+}
+
+func (messageAPI *MessageAPI) Send(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(int)
+	if !ok {
+		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	var messageInput dtypes.MessageInput
+	err := json.NewDecoder(r.Body).Decode(&messageInput)
+	if err != nil || !validMessageContent(messageInput.Content) {
+		http.Error(w, BadRequest, http.StatusBadRequest)
+		return
+	}
+
+	message, err := messageAPI.message.Send(userID, r.PathValue("target"), messageInput.Content)
+	if err != nil {
+		switch {
+		case errors.Is(err, model.UserNotFoundError{}):
+			http.Error(w, NotFound, http.StatusNotFound)
+		case errors.Is(err, controller.BlockedError{}),
+			errors.Is(err, controller.MessagingRestrictedError{}):
+			http.Error(w, Forbidden, http.StatusForbidden)
+		case errors.Is(err, controller.UnauthorizedActionError{}),
+			dbutils.IsConstraintError(err):
+			http.Error(w, BadRequest, http.StatusBadRequest)
+		default:
+			http.Error(w, InternalServerError, http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(generateMessagePayload(message))
+}
+
+func (messageAPI *MessageAPI) GetMessages(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(int)
+	if !ok {
+		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	conversationID, err := strconv.Atoi(r.PathValue("target"))
+	if err != nil {
+		http.Error(w, BadRequest, http.StatusBadRequest)
+		return
+	}
+
+	values := r.URL.Query()
+	limit, err := parseLimit(values.Get("limit"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	cursor, err := decodeCursor(values.Get("cursor"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	messages, next, err := messageAPI.message.GetMessages(userID, conversationID, limit, cursor)
+	if err != nil {
+		switch {
+		case errors.Is(err, model.ConversationNotFoundError{}):
+			http.Error(w, NotFound, http.StatusNotFound)
+		case errors.Is(err, controller.BlockedError{}):
+			http.Error(w, Forbidden, http.StatusForbidden)
+		default:
+			http.Error(w, InternalServerError, http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(generateMessageListPayload(messages, next))
+}
+
+func validMessageContent(content string) bool {
+	return strings.TrimSpace(content) != "" &&
+		utf8.RuneCountInString(content) <= MAX_MESSAGE_LENGTH
+}
+
+func NewMessageAPI(db *sql.DB) *MessageAPI {
+	return &MessageAPI{
+		message: controller.NewMessageController(db),
+	}
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marcusprice/twitter-clone/internal/model"
+	"github.com/marcusprice/twitter-clone/internal/testutil"
+)
+
+func TestMessages(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, _ time.Time) {
+		tu := testutil.NewTestUtil(t)
+		handler := RegisterHandlers(db)
+		senderToken := loginAndToken(loadUserControllerByID(db, 1))
+		recipientToken := loginAndToken(loadUserControllerByID(db, 3))
+
+		res := testutil.ServeRequest(handler, http.MethodPost, "/api/v1/messages/dalecooper", senderToken, strings.NewReader(`{"content": "meow"}`))
+		tu.AssertEqual(http.StatusCreated, res.Code)
+		var sent MessagePayload
+		json.NewDecoder(res.Body).Decode(&sent)
+		tu.AssertEqual("meow", sent.Content)
+		tu.AssertEqual("estecat", sent.Sender)
+		tu.AssertFalse(sent.IsRead)
+		testutil.ServeRequest(handler, http.MethodPost, "/api/v1/messages/dalecooper", senderToken, strings.NewReader(`{"content": "meow meow"}`))
+
+		res = testutil.ServeRequest(handler, http.MethodGet, "/api/v1/messages?limit=5&offset=0", recipientToken, nil)
+		tu.AssertEqual(http.StatusOK, res.Code)
+		var conversations ConversationListPayload
+		json.NewDecoder(res.Body).Decode(&conversations)
+		tu.AssertEqual(1, len(conversations.Conversations))
+		conversation := conversations.Conversations[0]
+		tu.AssertEqual(sent.ConversationID, conversation.ID)
+		tu.AssertEqual("estecat", conversation.User.Username)
+		tu.AssertEqual(getUploadPath("este-profile.jpg"), conversation.User.Avatar)
+		tu.AssertEqual("meow meow", conversation.LastMessage.Content)
+		tu.AssertEqual(2, conversation.UnreadCount)
+
+		conversationPath := fmt.Sprintf("/api/v1/messages/%d", sent.ConversationID)
+		res = testutil.ServeRequest(handler, http.MethodGet, conversationPath+"?limit=1", recipientToken, nil)
+		tu.AssertEqual(http.StatusOK, res.Code)
+		var page MessageListPayload
+		json.NewDecoder(res.Body).Decode(&page)
+		tu.AssertEqual(1, len(page.Messages))
+		tu.AssertTrue(page.HasMore)
+
+		res = testutil.ServeRequest(handler, http.MethodGet, conversationPath+"?limit=1&cursor="+page.NextCursor, recipientToken, nil)
+		json.NewDecoder(res.Body).Decode(&page)
+		tu.AssertEqual(1, len(page.Messages))
+		tu.AssertEqual(sent.ID, page.Messages[0].ID)
+		tu.AssertFalse(page.HasMore)
+
+		// reading the conversation marks it read for the recipient only
+		res = testutil.ServeRequest(handler, http.MethodGet, conversationPath+"?limit=5", senderToken, nil)
+		json.NewDecoder(res.Body).Decode(&page)
+		tu.AssertEqual(2, len(page.Messages))
+		tu.AssertTrue(page.Messages[0].IsRead)
+		res = testutil.ServeRequest(handler, http.MethodGet, "/api/v1/messages?limit=5&offset=0", recipientToken, nil)
+		json.NewDecoder(res.Body).Decode(&conversations)
+		tu.AssertEqual(0, conversations.Conversations[0].UnreadCount)
+
+		res = testutil.ServeRequest(handler, http.MethodGet, conversationPath+"?limit=5", loginAndToken(loadUserControllerByID(db, 4)), nil)
+		tu.AssertEqual(http.StatusNotFound, res.Code)
+		res = testutil.ServeRequest(handler, http.MethodGet, "/api/v1/messages/dalecooper?limit=5", senderToken, nil)
+		tu.AssertEqual(http.StatusBadRequest, res.Code)
+		res = testutil.ServeRequest(handler, http.MethodPost, "/api/v1/messages/dalecooper", senderToken, strings.NewReader(`{"content": " "}`))
+		tu.AssertEqual(http.StatusBadRequest, res.Code)
+		res = testutil.ServeRequest(handler, http.MethodPost, "/api/v1/messages/estecat", senderToken, strings.NewReader(`{"content": "hi me"}`))
+		tu.AssertEqual(http.StatusBadRequest, res.Code)
+		res = testutil.ServeRequest(handler, http.MethodPost, "/api/v1/messages/nobody", senderToken, strings.NewReader(`{"content": "hello?"}`))
+		tu.AssertEqual(http.StatusNotFound, res.Code)
+		res = testutil.ServeRequest(handler, http.MethodDelete, conversationPath, senderToken, nil)
+		tu.AssertEqual(http.StatusMethodNotAllowed, res.Code)
+
+		userModel := model.NewUserModel(db)
+		userModel.Block(3, 1)
+		res = testutil.ServeRequest(handler, http.MethodPost, "/api/v1/messages/dalecooper", senderToken, strings.NewReader(`{"content": "hello?"}`))
+		tu.AssertEqual(http.StatusForbidden, res.Code)
+		res = testutil.ServeRequest(handler, http.MethodGet, conversationPath+"?limit=5", senderToken, nil)
+		tu.AssertEqual(http.StatusForbidden, res.Code)
+		userModel.UnBlock(3, 1)
+
+		res = testutil.ServeRequest(handler, http.MethodPatch, "/api/v1/user", recipientToken, strings.NewReader(`{"dmMutualsOnly": true}`))
+		tu.AssertEqual(http.StatusOK, res.Code)
+		res = testutil.ServeRequest(handler, http.MethodPost, "/api/v1/messages/dalecooper", senderToken, strings.NewReader(`{"content": "hello?"}`))
+		tu.AssertEqual(http.StatusForbidden, res.Code)
+
+		userModel.Follow(1, 3)
+		userModel.Follow(3, 1)
+		res = testutil.ServeRequest(handler, http.MethodPost, "/api/v1/messages/dalecooper", senderToken, strings.NewReader(`{"content": "hello?"}`))
+		tu.AssertEqual(http.StatusCreated, res.Code)
+	})
+}
@@ -24,9 +24,9 @@ func WithCORS(next http.Handler) http.Handler {
 
 		w.Header().Set("Access-Control-Allow-Origin", fmt.Sprintf("http://%s:%s", tweetRotHost, tweetRotPort))
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+		w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, "+REFRESH_TOKEN_HEADER)
 		w.Header().Set("Access-Control-Allow-Credentials", "true")
-		w.Header().Set("Access-Control-Expose-Headers", "Authorization")
+		w.Header().Set("Access-Control-Expose-Headers", "Authorization, "+REFRESH_TOKEN_HEADER)
 		w.Header().Set("Access-Control-Max-Age", "600")
 
 		if r.Method == http.MethodOptions {
@@ -75,9 +75,36 @@ func ValidateUser(user *controller.User, next http.Handler) http.Handler {
 			return
 		}
 
+		// jwt only checks exp when it's present, tokens without one never expire
+		exp, hasExp := claims["exp"].(float64)
+		jti, hasJTI := claims["jti"].(string)
+		if !hasExp || !hasJTI || jti == "" {
+			logger.LogWarn("token missing exp or jti claim")
+			http.Error(w, Unauthorized, http.StatusUnauthorized)
+			return
+		}
+
+		revoked, err := user.TokenRevoked(jti)
+		if err != nil {
+			http.Error(w, InternalServerError, http.StatusInternalServerError)
+			return
+		}
+
+		if revoked {
+			http.Error(w, Unauthorized, http.StatusUnauthorized)
+			return
+		}
+
+		// tokens issued before the ver claim existed are version 0
+		version, _ := claims["ver"].(float64)
+
+		// user is shared by every request, load into a copy
 		userID := int(sub)
-		err = user.ByID(userID)
-		if err != nil || (!user.IsActive && user.Role != permissions.SYSTEM_ROLE) {
+		requestUser := user.Fresh()
+		err = requestUser.ByID(userID)
+		if err != nil ||
+			(!requestUser.IsActive && requestUser.Role != permissions.SYSTEM_ROLE) ||
+			int(version) != requestUser.TokenVersion {
 			if err != nil && !errors.Is(err, model.UserNotFoundError{}) {
 				http.Error(w, InternalServerError, http.StatusInternalServerError)
 			} else {
@@ -96,11 +123,38 @@ func ValidateUser(user *controller.User, next http.Handler) http.Handler {
 		)
 		ctx := context.WithValue(
 			r.Context(), "userID", userID)
+		ctx = context.WithValue(ctx, "userRole", requestUser.Role)
+		ctx = context.WithValue(ctx, "tokenVersion", requestUser.TokenVersion)
+		ctx = context.WithValue(ctx, "tokenID", jti)
+		ctx = context.WithValue(ctx, "tokenExpiresAt", time.Unix(int64(exp), 0))
 
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// RequireRole must sit inside ValidateUser, which sets the userRole it reads
+func RequireRole(role permissions.Role, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userRole, ok := r.Context().Value("userRole").(permissions.Role)
+		if !ok {
+			http.Error(w, InternalServerError, http.StatusInternalServerError)
+			return
+		}
+
+		if userRole != role {
+			logger.LogWarn(fmt.Sprintf(
+				"role check failed * userID: %v * requestID %v",
+				r.Context().Value("userID"),
+				r.Context().Value("requestID"),
+			))
+			http.Error(w, Forbidden, http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
 func VerifyPostMethod(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
@@ -134,6 +188,19 @@ func AllowMethods(methods []string, next http.Handler) http.Handler {
 	})
 }
 
+// MethodHandlers dispatches to the handler registered for the request method
+func MethodHandlers(handlers map[string]http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler, ok := handlers[r.Method]
+		if !ok {
+			http.Error(w, MethodNotAllowed, http.StatusMethodNotAllowed)
+			return
+		}
+
+		handler(w, r)
+	})
+}
+
 var requestCounter uint64
 
 func Logger(next http.Handler) http.Handler {
@@ -176,3 +243,9 @@ func (rw *responseWriterWrapper) WriteHeader(code int) {
 	rw.statusCode = code
 	rw.ResponseWriter.WriteHeader(code)
 }
+
+// Unwrap lets http.ResponseController reach the underlying writer, streaming
+// responses need it to flush
+func (rw *responseWriterWrapper) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
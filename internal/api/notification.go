@@ -0,0 +1,97 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/marcusprice/twitter-clone/internal/controller"
+	"github.com/marcusprice/twitter-clone/internal/dtypes"
+)
+
+type NotificationAPI struct {
+	notification *controller.Notification
+}
+
+func (notificationAPI *NotificationAPI) Get(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(int)
+	if !ok {
+		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	values := r.URL.Query()
+	limitParam := values.Get("limit")
+	offsetParam := values.Get("offset")
+	unreadParam := values.Get("unread")
+
+	limit, offset, err := parseLimitAndOffset(limitParam, offsetParam)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	unreadOnly := false
+	if unreadParam != "" {
+		unreadOnly, err = strconv.ParseBool(unreadParam)
+		if err != nil {
+			http.Error(w, BadRequest, http.StatusBadRequest)
+			return
+		}
+	}
+
+	notification := notificationAPI.notification
+	notifications, notificationsRemaining, err := notification.GetNotifications(
+		userID, limit, offset, unreadOnly)
+
+	if err != nil {
+		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	unreadCount, err := notification.UnreadCount(userID)
+	if err != nil {
+		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	payload := generateNotificationResponsePayload(
+		notifications, notificationsRemaining, unreadCount)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(payload)
+}
+
+func (notificationAPI *NotificationAPI) MarkRead(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(int)
+	if !ok {
+		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	// an empty body marks every notification as read
+	var readInput dtypes.NotificationReadInput
+	err := json.NewDecoder(r.Body).Decode(&readInput)
+	if err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, BadRequest, http.StatusBadRequest)
+		return
+	}
+
+	err = notificationAPI.notification.MarkRead(userID, readInput.NotificationIDs)
+	if err != nil {
+		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func NewNotificationAPI(db *sql.DB) *NotificationAPI {
+	return &NotificationAPI{
+		notification: controller.NewNotificationController(db),
+	}
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/marcusprice/twitter-clone/internal/testutil"
+)
+
+func TestNotificationsGet(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, _ time.Time) {
+		tu := testutil.NewTestUtil(t)
+		handler := RegisterHandlers(db)
+		user1 := loadUserControllerByID(db, 1)
+		user2 := loadUserControllerByID(db, 2)
+		user3 := loadUserControllerByID(db, 3)
+		user2Token := loginAndToken(user2)
+		user1.Follow(user2.Username)
+		user3.Follow(user2.Username)
+
+		req := httptest.NewRequest(
+			http.MethodGet, "/api/v1/notifications?limit=1&offset=0", nil)
+		req.Header.Set("Authorization", "Bearer "+user2Token)
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+
+		var payload NotificationResponsePayload
+		json.NewDecoder(res.Body).Decode(&payload)
+		tu.AssertEqual(http.StatusOK, res.Code)
+		tu.AssertEqual(1, len(payload.Notifications))
+		tu.AssertEqual("follow", payload.Notifications[0].Type)
+		tu.AssertEqual(user3.Username, payload.Notifications[0].Initiator.Username)
+		tu.AssertFalse(payload.Notifications[0].IsRead)
+		tu.AssertTrue(payload.HasMore)
+		tu.AssertEqual(1, payload.NotificationsRemaining)
+		tu.AssertEqual(2, payload.UnreadCount)
+
+		req = httptest.NewRequest(
+			http.MethodGet, "/api/v1/notifications?limit=10&offset=0&unread=nope", nil)
+		req.Header.Set("Authorization", "Bearer "+user2Token)
+		res = httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		tu.AssertEqual(http.StatusBadRequest, res.Code)
+
+		req = httptest.NewRequest(
+			http.MethodPost, "/api/v1/notifications?limit=10&offset=0", nil)
+		req.Header.Set("Authorization", "Bearer "+user2Token)
+		res = httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		tu.AssertEqual(http.StatusMethodNotAllowed, res.Code)
+
+		req = httptest.NewRequest(
+			http.MethodGet, "/api/v1/notifications?limit=10&offset=0", nil)
+		res = httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		tu.AssertEqual(http.StatusUnauthorized, res.Code)
+	})
+}
+
+func TestNotificationsMarkRead(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, _ time.Time) {
+		tu := testutil.NewTestUtil(t)
+		handler := RegisterHandlers(db)
+		user1 := loadUserControllerByID(db, 1)
+		user2 := loadUserControllerByID(db, 2)
+		user3 := loadUserControllerByID(db, 3)
+		user2Token := loginAndToken(user2)
+		user1.Follow(user2.Username)
+		user3.Follow(user2.Username)
+
+		getUnread := func() NotificationResponsePayload {
+			req := httptest.NewRequest(
+				http.MethodGet,
+				"/api/v1/notifications?limit=10&offset=0&unread=true",
+				nil,
+			)
+			req.Header.Set("Authorization", "Bearer "+user2Token)
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, req)
+
+			var payload NotificationResponsePayload
+			json.NewDecoder(res.Body).Decode(&payload)
+			return payload
+		}
+
+		payload := getUnread()
+		tu.AssertEqual(2, len(payload.Notifications))
+
+		body := fmt.Sprintf(`{"notificationIDs": [%d]}`, payload.Notifications[0].ID)
+		req := httptest.NewRequest(
+			http.MethodPut, "/api/v1/notifications/read", bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "Bearer "+user2Token)
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		tu.AssertEqual(http.StatusNoContent, res.Code)
+
+		payload = getUnread()
+		tu.AssertEqual(1, len(payload.Notifications))
+		tu.AssertEqual(1, payload.UnreadCount)
+
+		req = httptest.NewRequest(http.MethodPut, "/api/v1/notifications/read", nil)
+		req.Header.Set("Authorization", "Bearer "+user2Token)
+		res = httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		tu.AssertEqual(http.StatusNoContent, res.Code)
+
+		payload = getUnread()
+		tu.AssertEqual(0, len(payload.Notifications))
+		tu.AssertEqual(0, payload.UnreadCount)
+
+		req = httptest.NewRequest(
+			http.MethodPut, "/api/v1/notifications/read", bytes.NewBufferString("{bad json"))
+		req.Header.Set("Authorization", "Bearer "+user2Token)
+		res = httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		tu.AssertEqual(http.StatusBadRequest, res.Code)
+	})
+}
@@ -5,22 +5,29 @@ import (
 
 	"github.com/marcusprice/twitter-clone/internal/controller"
 	"github.com/marcusprice/twitter-clone/internal/dtypes"
+	"github.com/marcusprice/twitter-clone/internal/permissions"
 	"github.com/marcusprice/twitter-clone/internal/util"
 )
 
 type TimelinePayload struct {
-	Posts          []TimelinePostPayload `json:"posts"`
-	HasMore        bool                  `json:"hasMore"`
-	PostsRemaining int                   `json:"postsRemaining"`
+	Posts   []TimelinePostPayload `json:"posts"`
+	HasMore bool                  `json:"hasMore"`
+	// PostsRemaining is only set by offset paginated feeds
+	PostsRemaining *int `json:"postsRemaining,omitempty"`
+	// NextCursor is only set by cursor paginated feeds that have another page
+	NextCursor string `json:"nextCursor,omitempty"`
 }
 
 type UserPayload struct {
-	Email       string `json:"email"`
-	Username    string `json:"username"`
-	FirstName   string `json:"firstName"`
-	LastName    string `json:"lastName"`
-	DisplayName string `json:"displayName"`
-	Avatar      string `json:"avatar"`
+	Email         string `json:"email"`
+	Username      string `json:"username"`
+	FirstName     string `json:"firstName"`
+	LastName      string `json:"lastName"`
+	DisplayName   string `json:"displayName"`
+	Bio           string `json:"bio"`
+	Avatar        string `json:"avatar"`
+	IsPrivate     bool   `json:"isPrivate"`
+	DMMutualsOnly bool   `json:"dmMutualsOnly"`
 }
 
 type AuthorPayload struct {
@@ -32,6 +39,9 @@ type AuthorPayload struct {
 	FollowingCount  int              `json:"followingCount"`
 	ViewerFollowing bool             `json:"viewerFollowing"`
 	MutalFollowers  []*AuthorPayload `json:"mutualFollowers"`
+	// only set on profiles
+	IsPrivate       bool `json:"isPrivate,omitempty"`
+	ViewerRequested bool `json:"viewerRequested,omitempty"`
 }
 
 func generateAuthorPayload(author dtypes.Author) AuthorPayload {
@@ -43,38 +53,106 @@ func generateAuthorPayload(author dtypes.Author) AuthorPayload {
 		FollowerCount:   author.FollowerCount,
 		FollowingCount:  author.FollowingCount,
 		ViewerFollowing: author.ViewerFollowing,
+		IsPrivate:       author.IsPrivate,
+		ViewerRequested: author.ViewerRequested,
 	}
 
 	if authorPayload.Avatar != "" {
 		authorPayload.Avatar = getUploadPath(authorPayload.Avatar)
 	}
 
+	for _, mutual := range author.MutalFollowers {
+		mutualPayload := generateAuthorPayload(*mutual)
+		authorPayload.MutalFollowers = append(authorPayload.MutalFollowers, &mutualPayload)
+	}
+
 	return authorPayload
 }
 
+type UserListPayload struct {
+	Users          []AuthorPayload `json:"users"`
+	HasMore        bool            `json:"hasMore"`
+	UsersRemaining int             `json:"usersRemaining"`
+}
+
+func generateUserListPayload(users []dtypes.Author, usersRemaining int) UserListPayload {
+	userPayloads := []AuthorPayload{}
+	for _, user := range users {
+		userPayloads = append(userPayloads, generateAuthorPayload(user))
+	}
+
+	return UserListPayload{
+		Users:          userPayloads,
+		HasMore:        usersRemaining > 0,
+		UsersRemaining: usersRemaining,
+	}
+}
+
+// SuggestionPayload is a suggested account, reason is one of mutual, liked or
+// popular
+type SuggestionPayload struct {
+	AuthorPayload
+	Reason string `json:"reason"`
+}
+
+type SuggestionListPayload struct {
+	Users []SuggestionPayload `json:"users"`
+}
+
+func generateSuggestionListPayload(suggestions []dtypes.UserSuggestion) SuggestionListPayload {
+	suggestionPayloads := []SuggestionPayload{}
+	for _, suggestion := range suggestions {
+		suggestionPayloads = append(suggestionPayloads, SuggestionPayload{
+			AuthorPayload: generateAuthorPayload(suggestion.User),
+			Reason:        controller.SuggestionReason(suggestion),
+		})
+	}
+
+	return SuggestionListPayload{Users: suggestionPayloads}
+}
+
+// MentionPayload locates an @mention within content, start and end are
+// unicode code point offsets covering the @ and the username, end exclusive
+type MentionPayload struct {
+	Username string `json:"username"`
+	Start    int    `json:"start"`
+	End      int    `json:"end"`
+}
+
+func generateMentionPayloads(mentions []dtypes.MentionEntity) []MentionPayload {
+	mentionPayloads := []MentionPayload{}
+	for _, mention := range mentions {
+		mentionPayloads = append(mentionPayloads, MentionPayload(mention))
+	}
+
+	return mentionPayloads
+}
+
 type RetweeterPayload struct {
 	Username    string `json:"username"`
 	DisplayName string `json:"displayName"`
 }
 
 type PostPayload struct {
-	ID                   int           `json:"postID"`
-	Content              string        `json:"content"`
-	CommentCount         int           `json:"commentCount"`
-	LikeCount            int           `json:"likeCount"`
-	RetweetCount         int           `json:"retweetCount"`
-	BookmarkCount        int           `json:"bookmarkCount"`
-	Impressions          int           `json:"impressions"`
-	Image                string        `json:"image"`
-	CreatedAt            time.Time     `json:"createdAt"`
-	UpdatedAt            time.Time     `json:"updatedAt"`
-	Author               AuthorPayload `json:"author"`
-	IsRetweet            bool          `json:"isRetweet"`
-	RetweeterUsername    string        `json:"retweeterUsername"`
-	RetweeterDisplayName string        `json:"retweeterDisplayName"`
-	Liked                bool          `json:"liked"`
-	Retweeted            bool          `json:"retweeted"`
-	Bookmarked           bool          `json:"bookmarked"`
+	ID                   int              `json:"postID"`
+	Content              string           `json:"content"`
+	CommentCount         int              `json:"commentCount"`
+	LikeCount            int              `json:"likeCount"`
+	RetweetCount         int              `json:"retweetCount"`
+	BookmarkCount        int              `json:"bookmarkCount"`
+	Impressions          int              `json:"impressions"`
+	Image                string           `json:"image"`
+	ImageAlt             string           `json:"imageAlt"`
+	CreatedAt            time.Time        `json:"createdAt"`
+	UpdatedAt            time.Time        `json:"updatedAt"`
+	Author               AuthorPayload    `json:"author"`
+	IsRetweet            bool             `json:"isRetweet"`
+	RetweeterUsername    string           `json:"retweeterUsername"`
+	RetweeterDisplayName string           `json:"retweeterDisplayName"`
+	Liked                bool             `json:"liked"`
+	Retweeted            bool             `json:"retweeted"`
+	Bookmarked           bool             `json:"bookmarked"`
+	Mentions             []MentionPayload `json:"mentions"`
 }
 
 func generatePostPayload(post *controller.Post) PostPayload {
@@ -101,6 +179,7 @@ func generatePostPayload(post *controller.Post) PostPayload {
 		BookmarkCount:        post.BookmarkCount,
 		Impressions:          post.Impressions,
 		Image:                post.Image,
+		ImageAlt:             post.ImageAlt,
 		CreatedAt:            post.CreatedAt,
 		UpdatedAt:            post.UpdatedAt,
 		Author:               author,
@@ -110,6 +189,7 @@ func generatePostPayload(post *controller.Post) PostPayload {
 		Liked:                post.Liked,
 		Retweeted:            post.Retweeted,
 		Bookmarked:           post.Bookmarked,
+		Mentions:             generateMentionPayloads(post.Mentions),
 	}
 }
 
@@ -134,8 +214,35 @@ type TimelinePostPayload struct {
 	ParentCommentID             int       `json:"parentCommentID"`
 	ParentCommentAuthorUsername string    `json:"parentCommentAuthorUsername"`
 
-	Author    AuthorPayload    `json:"author"`
-	Retweeter RetweeterPayload `json:"retweeter"`
+	Author     AuthorPayload      `json:"author"`
+	Retweeter  RetweeterPayload   `json:"retweeter"`
+	QuotedPost *QuotedPostPayload `json:"quotedPost,omitempty"`
+}
+
+type QuotedPostPayload struct {
+	ID        int           `json:"id"`
+	Content   string        `json:"content"`
+	Image     string        `json:"image"`
+	CreatedAt time.Time     `json:"createdAt"`
+	UpdatedAt time.Time     `json:"updatedAt"`
+	Author    AuthorPayload `json:"author"`
+}
+
+func generateQuotedPostPayload(quotedPost dtypes.QuotedPost) *QuotedPostPayload {
+	payload := &QuotedPostPayload{
+		ID:        quotedPost.ID,
+		Content:   quotedPost.Content,
+		Image:     quotedPost.Image,
+		CreatedAt: util.ParseTime(quotedPost.CreatedAt),
+		UpdatedAt: util.ParseTime(quotedPost.UpdatedAt),
+		Author:    generateAuthorPayload(quotedPost.Author),
+	}
+
+	if payload.Image != "" {
+		payload.Image = getUploadPath(payload.Image)
+	}
+
+	return payload
 }
 
 func generateTimelinePostPayload(timelinePostData dtypes.TimelinePostData) TimelinePostPayload {
@@ -181,6 +288,10 @@ func generateTimelinePostPayload(timelinePostData dtypes.TimelinePostData) Timel
 		payload.Image = getUploadPath(payload.Image)
 	}
 
+	if timelinePostData.QuotedPost.ID != 0 {
+		payload.QuotedPost = generateQuotedPostPayload(timelinePostData.QuotedPost)
+	}
+
 	return payload
 }
 
@@ -200,12 +311,12 @@ type BookmarkPayload struct {
 }
 
 type BookmarkResponsePayload struct {
-	Bookmarks          []BookmarkPayload `json:"bookmarks"`
-	HasMore            bool              `json:"hasMore"`
-	BookmarksRemaining int               `json:"bookmarksRemaining"`
+	Bookmarks  []BookmarkPayload `json:"bookmarks"`
+	HasMore    bool              `json:"hasMore"`
+	NextCursor string            `json:"nextCursor,omitempty"`
 }
 
-func generateBookmarkPayload(bookmarkData []dtypes.BookmarkData, bookmarksRemaining int) BookmarkResponsePayload {
+func generateBookmarkPayload(bookmarkData []dtypes.BookmarkData, next *dtypes.Cursor) BookmarkResponsePayload {
 	var bookmarks []BookmarkPayload
 	for _, bookmark := range bookmarkData {
 		authorPayload := AuthorPayload{
@@ -235,28 +346,30 @@ func generateBookmarkPayload(bookmarkData []dtypes.BookmarkData, bookmarksRemain
 	}
 
 	return BookmarkResponsePayload{
-		Bookmarks:          bookmarks,
-		HasMore:            bookmarksRemaining > 0,
-		BookmarksRemaining: bookmarksRemaining,
+		Bookmarks:  bookmarks,
+		HasMore:    next != nil,
+		NextCursor: encodeCursor(next),
 	}
 }
 
 type CommentPayload struct {
-	ID                   int           `json:"commentID"`
-	PostID               int           `json:"postID"`
-	ParentCommentID      int           `json:"parentCommentID"`
-	Content              string        `json:"content"`
-	LikeCount            int           `json:"likeCount"`
-	RetweetCount         int           `json:"retweetCount"`
-	BookmarkCount        int           `json:"bookmarkCount"`
-	Impressions          int           `json:"impressions"`
-	Image                string        `json:"image"`
-	CreatedAt            time.Time     `json:"createdAt"`
-	UpdatedAt            time.Time     `json:"updatedAt"`
-	Author               AuthorPayload `json:"author"`
-	IsRetweet            bool          `json:"isRetweet"`
-	RetweeterUsername    string        `json:"retweeterUsername"`
-	RetweeterDisplayName string        `json:"retweeterDisplayName"`
+	ID                   int              `json:"commentID"`
+	PostID               int              `json:"postID"`
+	ParentCommentID      int              `json:"parentCommentID"`
+	Content              string           `json:"content"`
+	LikeCount            int              `json:"likeCount"`
+	RetweetCount         int              `json:"retweetCount"`
+	BookmarkCount        int              `json:"bookmarkCount"`
+	Impressions          int              `json:"impressions"`
+	Image                string           `json:"image"`
+	Generating           bool             `json:"generating"`
+	CreatedAt            time.Time        `json:"createdAt"`
+	UpdatedAt            time.Time        `json:"updatedAt"`
+	Author               AuthorPayload    `json:"author"`
+	IsRetweet            bool             `json:"isRetweet"`
+	RetweeterUsername    string           `json:"retweeterUsername"`
+	RetweeterDisplayName string           `json:"retweeterDisplayName"`
+	Mentions             []MentionPayload `json:"mentions"`
 }
 
 func generateCommentPayload(comment *controller.Comment) *CommentPayload {
@@ -283,12 +396,14 @@ func generateCommentPayload(comment *controller.Comment) *CommentPayload {
 		BookmarkCount:        comment.BookmarkCount,
 		Impressions:          comment.Impressions,
 		Image:                comment.Image,
+		Generating:           comment.Generating,
 		CreatedAt:            comment.CreatedAt,
 		UpdatedAt:            comment.UpdatedAt,
 		IsRetweet:            comment.IsRetweet,
 		RetweeterUsername:    comment.RetweeterUsername,
 		RetweeterDisplayName: comment.RetweeterDisplayName,
 		Author:               author,
+		Mentions:             generateMentionPayloads(comment.Mentions),
 	}
 }
 
@@ -302,9 +417,11 @@ type CommentFromPostPayload struct {
 	BookmarkCount   int                       `json:"bookmarkCount"`
 	Impressions     int                       `json:"impressions"`
 	Image           string                    `json:"image"`
+	Generating      bool                      `json:"generating"`
 	CreatedAt       time.Time                 `json:"createdAt"`
 	UpdatedAt       time.Time                 `json:"updatedAt"`
 	Author          AuthorPayload             `json:"author"`
+	Mentions        []MentionPayload          `json:"mentions"`
 	Replies         []*CommentFromPostPayload `json:"replies"`
 }
 
@@ -317,10 +434,12 @@ type PostAndCommentsPayload struct {
 	BookmarkCount int                       `json:"bookmarkCount"`
 	Impressions   int                       `json:"impressions"`
 	Image         string                    `json:"image"`
+	ImageAlt      string                    `json:"imageAlt"`
 	CreatedAt     time.Time                 `json:"createdAt"`
 	UpdatedAt     time.Time                 `json:"updatedAt"`
 	Author        AuthorPayload             `json:"author"`
 	Liked         bool                      `json:"liked"`
+	Mentions      []MentionPayload          `json:"mentions"`
 	Comments      []*CommentFromPostPayload `json:"comments"`
 }
 
@@ -358,9 +477,11 @@ func generatePostAndCommentsPayload(post *controller.Post) PostAndCommentsPayloa
 			replyPayload.BookmarkCount = reply.BookmarkCount
 			replyPayload.Impressions = reply.Impressions
 			replyPayload.Image = reply.Image
+			replyPayload.Generating = reply.Generating
 			replyPayload.CreatedAt = reply.CreatedAt
 			replyPayload.UpdatedAt = reply.UpdatedAt
 			replyPayload.Author = authorPayload
+			replyPayload.Mentions = generateMentionPayloads(reply.Mentions)
 			repliesPayload = append(repliesPayload, replyPayload)
 		}
 
@@ -382,9 +503,11 @@ func generatePostAndCommentsPayload(post *controller.Post) PostAndCommentsPayloa
 		commentPayload.BookmarkCount = comment.BookmarkCount
 		commentPayload.Impressions = comment.Impressions
 		commentPayload.Image = comment.Image
+		commentPayload.Generating = comment.Generating
 		commentPayload.CreatedAt = comment.CreatedAt
 		commentPayload.UpdatedAt = comment.UpdatedAt
 		commentPayload.Author = authorPayload
+		commentPayload.Mentions = generateMentionPayloads(comment.Mentions)
 		commentPayload.Replies = repliesPayload
 
 		postAndCommentsPayload.Comments = append(
@@ -418,10 +541,389 @@ func generatePostAndCommentsPayload(post *controller.Post) PostAndCommentsPayloa
 	postAndCommentsPayload.BookmarkCount = post.BookmarkCount
 	postAndCommentsPayload.Impressions = post.Impressions
 	postAndCommentsPayload.Image = post.Image
+	postAndCommentsPayload.ImageAlt = post.ImageAlt
 	postAndCommentsPayload.CreatedAt = post.CreatedAt
 	postAndCommentsPayload.UpdatedAt = post.UpdatedAt
 	postAndCommentsPayload.Author = authorPayload
 	postAndCommentsPayload.Liked = post.Liked
+	postAndCommentsPayload.Mentions = generateMentionPayloads(post.Mentions)
 
 	return postAndCommentsPayload
 }
+
+type NotificationPayload struct {
+	ID        int           `json:"id"`
+	Type      string        `json:"type"`
+	IsRead    bool          `json:"isRead"`
+	PostID    int           `json:"postID"`
+	CommentID int           `json:"commentID"`
+	Content   string        `json:"content"`
+	Initiator AuthorPayload `json:"initiator"`
+	CreatedAt time.Time     `json:"createdAt"`
+	UpdatedAt time.Time     `json:"updatedAt"`
+}
+
+type NotificationResponsePayload struct {
+	Notifications          []NotificationPayload `json:"notifications"`
+	HasMore                bool                  `json:"hasMore"`
+	NotificationsRemaining int                   `json:"notificationsRemaining"`
+	UnreadCount            int                   `json:"unreadCount"`
+}
+
+func generateNotificationResponsePayload(
+	notificationData []dtypes.NotificationData,
+	notificationsRemaining int,
+	unreadCount int,
+) NotificationResponsePayload {
+	notifications := []NotificationPayload{}
+	for _, notification := range notificationData {
+		initiatorPayload := AuthorPayload{
+			Username:    notification.Initiator.Username,
+			DisplayName: notification.Initiator.DisplayName,
+			Avatar:      notification.Initiator.Avatar,
+		}
+
+		if initiatorPayload.Avatar != "" {
+			initiatorPayload.Avatar = getUploadPath(initiatorPayload.Avatar)
+		}
+
+		notifications = append(notifications, NotificationPayload{
+			ID:        notification.ID,
+			Type:      notification.Type,
+			IsRead:    notification.IsRead == 1,
+			PostID:    notification.PostID,
+			CommentID: notification.CommentID,
+			Content:   notification.Content,
+			Initiator: initiatorPayload,
+			CreatedAt: util.ParseTime(notification.CreatedAt),
+			UpdatedAt: util.ParseTime(notification.UpdatedAt),
+		})
+	}
+
+	return NotificationResponsePayload{
+		Notifications:          notifications,
+		HasMore:                notificationsRemaining > 0,
+		NotificationsRemaining: notificationsRemaining,
+		UnreadCount:            unreadCount,
+	}
+}
+
+type MessagePayload struct {
+	ID             int       `json:"id"`
+	ConversationID int       `json:"conversationID"`
+	Sender         string    `json:"sender"`
+	Content        string    `json:"content"`
+	IsRead         bool      `json:"isRead"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+func generateMessagePayload(message dtypes.MessageData) MessagePayload {
+	return MessagePayload{
+		ID:             message.ID,
+		ConversationID: message.ConversationID,
+		Sender:         message.Sender,
+		Content:        message.Content,
+		IsRead:         message.ReadAt != "",
+		CreatedAt:      util.ParseTime(message.CreatedAt),
+	}
+}
+
+type MessageListPayload struct {
+	Messages []MessagePayload `json:"messages"`
+	HasMore  bool             `json:"hasMore"`
+	// NextCursor is only set when there is another page
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+func generateMessageListPayload(messages []dtypes.MessageData, next *dtypes.Cursor) MessageListPayload {
+	messagePayloads := []MessagePayload{}
+	for _, message := range messages {
+		messagePayloads = append(messagePayloads, generateMessagePayload(message))
+	}
+
+	return MessageListPayload{
+		Messages:   messagePayloads,
+		HasMore:    next != nil,
+		NextCursor: encodeCursor(next),
+	}
+}
+
+type ConversationPayload struct {
+	ID          int            `json:"id"`
+	User        AuthorPayload  `json:"user"`
+	LastMessage MessagePayload `json:"lastMessage"`
+	UnreadCount int            `json:"unreadCount"`
+}
+
+type ConversationListPayload struct {
+	Conversations          []ConversationPayload `json:"conversations"`
+	HasMore                bool                  `json:"hasMore"`
+	ConversationsRemaining int                   `json:"conversationsRemaining"`
+}
+
+func generateConversationListPayload(conversations []dtypes.ConversationData, conversationsRemaining int) ConversationListPayload {
+	conversationPayloads := []ConversationPayload{}
+	for _, conversation := range conversations {
+		conversationPayloads = append(conversationPayloads, ConversationPayload{
+			ID:          conversation.ID,
+			User:        generateAuthorPayload(conversation.User),
+			LastMessage: generateMessagePayload(conversation.LastMessage),
+			UnreadCount: conversation.UnreadCount,
+		})
+	}
+
+	return ConversationListPayload{
+		Conversations:          conversationPayloads,
+		HasMore:                conversationsRemaining > 0,
+		ConversationsRemaining: conversationsRemaining,
+	}
+}
+
+type AdminUserPayload struct {
+	ID          int              `json:"id"`
+	Email       string           `json:"email"`
+	Username    string           `json:"username"`
+	DisplayName string           `json:"displayName"`
+	Avatar      string           `json:"avatar"`
+	IsActive    bool             `json:"isActive"`
+	Role        permissions.Role `json:"role"`
+	LastLogin   *time.Time       `json:"lastLogin"`
+	CreatedAt   time.Time        `json:"createdAt"`
+}
+
+type AdminUserListPayload struct {
+	Users          []AdminUserPayload `json:"users"`
+	HasMore        bool               `json:"hasMore"`
+	UsersRemaining int                `json:"usersRemaining"`
+}
+
+func generateAdminUserListPayload(users []dtypes.UserData, usersRemaining int) AdminUserListPayload {
+	userPayloads := []AdminUserPayload{}
+	for _, user := range users {
+		userPayload := AdminUserPayload{
+			ID:          user.ID,
+			Email:       user.Email,
+			Username:    user.Username,
+			DisplayName: user.DisplayName,
+			Avatar:      user.Avatar,
+			IsActive:    user.IsActive == 1,
+			Role:        user.Role,
+			CreatedAt:   util.ParseTime(user.CreatedAt),
+		}
+
+		if userPayload.Avatar != "" {
+			userPayload.Avatar = getUploadPath(userPayload.Avatar)
+		}
+
+		if user.LastLogin != "" {
+			lastLogin := util.ParseTime(user.LastLogin)
+			userPayload.LastLogin = &lastLogin
+		}
+
+		userPayloads = append(userPayloads, userPayload)
+	}
+
+	return AdminUserListPayload{
+		Users:          userPayloads,
+		HasMore:        usersRemaining > 0,
+		UsersRemaining: usersRemaining,
+	}
+}
+
+type AuditLogEntryPayload struct {
+	ID         int           `json:"id"`
+	Action     string        `json:"action"`
+	TargetType string        `json:"targetType"`
+	TargetID   int           `json:"targetID"`
+	Details    string        `json:"details"`
+	Actor      AuthorPayload `json:"actor"`
+	CreatedAt  time.Time     `json:"createdAt"`
+}
+
+type AuditLogPayload struct {
+	Entries          []AuditLogEntryPayload `json:"entries"`
+	HasMore          bool                   `json:"hasMore"`
+	EntriesRemaining int                    `json:"entriesRemaining"`
+}
+
+func generateAuditLogPayload(entries []dtypes.AuditLogData, entriesRemaining int) AuditLogPayload {
+	entryPayloads := []AuditLogEntryPayload{}
+	for _, entry := range entries {
+		actorPayload := AuthorPayload{
+			Username:    entry.Actor.Username,
+			DisplayName: entry.Actor.DisplayName,
+			Avatar:      entry.Actor.Avatar,
+		}
+
+		if actorPayload.Avatar != "" {
+			actorPayload.Avatar = getUploadPath(actorPayload.Avatar)
+		}
+
+		entryPayloads = append(entryPayloads, AuditLogEntryPayload{
+			ID:         entry.ID,
+			Action:     entry.Action,
+			TargetType: entry.TargetType,
+			TargetID:   entry.TargetID,
+			Details:    entry.Details,
+			Actor:      actorPayload,
+			CreatedAt:  util.ParseTime(entry.CreatedAt),
+		})
+	}
+
+	return AuditLogPayload{
+		Entries:          entryPayloads,
+		HasMore:          entriesRemaining > 0,
+		EntriesRemaining: entriesRemaining,
+	}
+}
+
+type ReportPayload struct {
+	ID         int           `json:"id"`
+	Reason     string        `json:"reason"`
+	Details    string        `json:"details"`
+	Status     string        `json:"status"`
+	PostID     int           `json:"postID"`
+	CommentID  int           `json:"commentID"`
+	Content    string        `json:"content"`
+	Image      string        `json:"image"`
+	Reporter   AuthorPayload `json:"reporter"`
+	Author     AuthorPayload `json:"author"`
+	CreatedAt  time.Time     `json:"createdAt"`
+	ResolvedAt *time.Time    `json:"resolvedAt"`
+}
+
+type ReportListPayload struct {
+	Reports          []ReportPayload `json:"reports"`
+	HasMore          bool            `json:"hasMore"`
+	ReportsRemaining int             `json:"reportsRemaining"`
+}
+
+func generateReportListPayload(reports []dtypes.ReportData, reportsRemaining int) ReportListPayload {
+	reportPayloads := []ReportPayload{}
+	for _, report := range reports {
+		reportPayload := ReportPayload{
+			ID:        report.ID,
+			Reason:    report.Reason,
+			Details:   report.Details,
+			Status:    report.Status,
+			PostID:    report.PostID,
+			CommentID: report.CommentID,
+			Content:   report.Content,
+			Image:     report.Image,
+			Reporter: AuthorPayload{
+				Username:    report.Reporter.Username,
+				DisplayName: report.Reporter.DisplayName,
+				Avatar:      report.Reporter.Avatar,
+			},
+			Author: AuthorPayload{
+				Username:    report.Author.Username,
+				DisplayName: report.Author.DisplayName,
+				Avatar:      report.Author.Avatar,
+			},
+			CreatedAt: util.ParseTime(report.CreatedAt),
+		}
+
+		if reportPayload.Image != "" {
+			reportPayload.Image = getUploadPath(reportPayload.Image)
+		}
+
+		if reportPayload.Reporter.Avatar != "" {
+			reportPayload.Reporter.Avatar = getUploadPath(reportPayload.Reporter.Avatar)
+		}
+
+		if reportPayload.Author.Avatar != "" {
+			reportPayload.Author.Avatar = getUploadPath(reportPayload.Author.Avatar)
+		}
+
+		if report.ResolvedAt != "" {
+			resolvedAt := util.ParseTime(report.ResolvedAt)
+			reportPayload.ResolvedAt = &resolvedAt
+		}
+
+		reportPayloads = append(reportPayloads, reportPayload)
+	}
+
+	return ReportListPayload{
+		Reports:          reportPayloads,
+		HasMore:          reportsRemaining > 0,
+		ReportsRemaining: reportsRemaining,
+	}
+}
+
+type HashtagPayload struct {
+	Tag  string `json:"tag"`
+	Uses int    `json:"uses"`
+}
+
+type TrendingPayload struct {
+	Hashtags    []HashtagPayload `json:"hashtags"`
+	WindowHours int              `json:"windowHours"`
+}
+
+func generateTrendingPayload(hashtags []dtypes.HashtagData, windowHours int) TrendingPayload {
+	hashtagPayloads := []HashtagPayload{}
+	for _, hashtag := range hashtags {
+		hashtagPayloads = append(hashtagPayloads, HashtagPayload(hashtag))
+	}
+
+	return TrendingPayload{
+		Hashtags:    hashtagPayloads,
+		WindowHours: windowHours,
+	}
+}
+
+type SearchPostPayload struct {
+	TimelinePostPayload
+	Snippet string `json:"snippet"`
+}
+
+// SearchPostsPayload is used for both post and comment results, comments are
+// told apart by their type
+type SearchPostsPayload struct {
+	Posts            []SearchPostPayload `json:"posts"`
+	HasMore          bool                `json:"hasMore"`
+	ResultsRemaining int                 `json:"resultsRemaining"`
+}
+
+func generateSearchPostsPayload(results []dtypes.SearchPostData, resultsRemaining int) SearchPostsPayload {
+	postPayloads := []SearchPostPayload{}
+	for _, result := range results {
+		postPayloads = append(postPayloads, SearchPostPayload{
+			TimelinePostPayload: generateTimelinePostPayload(result.Post),
+			Snippet:             result.Snippet,
+		})
+	}
+
+	return SearchPostsPayload{
+		Posts:            postPayloads,
+		HasMore:          resultsRemaining > 0,
+		ResultsRemaining: resultsRemaining,
+	}
+}
+
+type SearchUserPayload struct {
+	AuthorPayload
+	Snippet string `json:"snippet"`
+}
+
+type SearchUsersPayload struct {
+	Users            []SearchUserPayload `json:"users"`
+	HasMore          bool                `json:"hasMore"`
+	ResultsRemaining int                 `json:"resultsRemaining"`
+}
+
+func generateSearchUsersPayload(results []dtypes.SearchUserData, resultsRemaining int) SearchUsersPayload {
+	userPayloads := []SearchUserPayload{}
+	for _, result := range results {
+		userPayloads = append(userPayloads, SearchUserPayload{
+			AuthorPayload: generateAuthorPayload(result.User),
+			Snippet:       result.Snippet,
+		})
+	}
+
+	return SearchUsersPayload{
+		Users:            userPayloads,
+		HasMore:          resultsRemaining > 0,
+		ResultsRemaining: resultsRemaining,
+	}
+}
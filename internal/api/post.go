@@ -5,11 +5,14 @@ import (
 	"errors"
 	"net/http"
 	"strconv"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/marcusprice/twitter-clone/internal/controller"
 	"github.com/marcusprice/twitter-clone/internal/dbutils"
 	"github.com/marcusprice/twitter-clone/internal/dtypes"
 	"github.com/marcusprice/twitter-clone/internal/model"
+	"github.com/marcusprice/twitter-clone/internal/permissions"
 )
 
 const MAX_POST_UPLOAD_BYTES int64 = 1024 * 1024 * 10 // 10 mb
@@ -34,8 +37,16 @@ func (postAPI PostAPI) Get(w http.ResponseWriter, r *http.Request) {
 
 	post, err := postAPI.post.GetPostAndComments(postID, userID)
 	if err != nil {
-		// TODO: figure out error handling
-		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		var postNotFoundError model.PostNotFoundError
+		if errors.As(err, &postNotFoundError) {
+			http.Error(w, NotFound, http.StatusNotFound)
+		} else if errors.Is(err, controller.PrivateAccountError{}) {
+			http.Error(w, Forbidden, http.StatusForbidden)
+		} else {
+			http.Error(w, InternalServerError, http.StatusInternalServerError)
+		}
+
+		return
 	}
 
 	w.WriteHeader(http.StatusOK)
@@ -51,6 +62,7 @@ func (postAPI PostAPI) Create(w http.ResponseWriter, r *http.Request) {
 
 	filename := ""
 	content := ""
+	imageAlt := ""
 
 	r.Body = http.MaxBytesReader(w, r.Body, MAX_POST_UPLOAD_BYTES)
 	err := r.ParseMultipartForm(getMaxUploadMemory())
@@ -82,6 +94,12 @@ func (postAPI PostAPI) Create(w http.ResponseWriter, r *http.Request) {
 		// user uploaded file, content optional
 		defer file.Close()
 
+		imageAlt = strings.TrimSpace(r.FormValue("imageAlt"))
+		if utf8.RuneCountInString(imageAlt) > MAX_IMAGE_ALT_LENGTH {
+			http.Error(w, BadRequest, http.StatusBadRequest)
+			return
+		}
+
 		filename, err = handleImageUpload(file, header)
 		if err != nil {
 			var invalidFileTypeError InvalidFileTypeError
@@ -96,9 +114,10 @@ func (postAPI PostAPI) Create(w http.ResponseWriter, r *http.Request) {
 	}
 
 	postInput := dtypes.PostInput{
-		UserID:  userID,
-		Content: content,
-		Image:   filename,
+		UserID:   userID,
+		Content:  content,
+		Image:    filename,
+		ImageAlt: imageAlt,
 	}
 
 	err = postAPI.post.New(postInput)
@@ -128,27 +147,25 @@ func (postAPI *PostAPI) Like(w http.ResponseWriter, r *http.Request) {
 	postID, err := strconv.Atoi(r.PathValue("id"))
 	if err != nil {
 		http.Error(w, BadRequest, http.StatusBadRequest)
+		return
+	}
+
+	if r.Method == http.MethodPut {
+		err = postAPI.post.Like(postID, userID)
+	} else {
+		err = postAPI.post.Unlike(postID, userID)
 	}
 
-	post := postAPI.post
-	err = post.ByID(postID)
 	if err != nil {
 		var postNotFoundError model.PostNotFoundError
 		if errors.As(err, &postNotFoundError) {
 			http.Error(w, NotFound, http.StatusNotFound)
+		} else if errors.Is(err, controller.BlockedError{}) || errors.Is(err, controller.PrivateAccountError{}) {
+			http.Error(w, Forbidden, http.StatusForbidden)
 		} else {
 			http.Error(w, InternalServerError, http.StatusInternalServerError)
 		}
-	}
-
-	if r.Method == http.MethodPut {
-		err = post.Like(userID)
-	} else {
-		err = post.Unlike(userID)
-	}
 
-	if err != nil {
-		http.Error(w, InternalServerError, http.StatusInternalServerError)
 		return
 	}
 
@@ -165,27 +182,105 @@ func (postAPI *PostAPI) Retweet(w http.ResponseWriter, r *http.Request) {
 	postID, err := strconv.Atoi(r.PathValue("id"))
 	if err != nil {
 		http.Error(w, BadRequest, http.StatusBadRequest)
+		return
+	}
+
+	isQuote := strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data")
+	if r.Method == http.MethodPut && isQuote {
+		postAPI.quoteRetweet(w, r, postID, userID)
+		return
 	}
 
-	post := postAPI.post
-	err = post.ByID(postID)
+	images := []string{}
+	if r.Method == http.MethodPut {
+		err = postAPI.post.Retweet(postID, userID)
+	} else {
+		images, err = postAPI.post.UnRetweet(postID, userID)
+	}
+
+	deleteUploadedImages(images)
 	if err != nil {
 		var postNotFoundError model.PostNotFoundError
 		if errors.As(err, &postNotFoundError) {
 			http.Error(w, NotFound, http.StatusNotFound)
+		} else if errors.Is(err, controller.PrivateAccountError{}) {
+			http.Error(w, Forbidden, http.StatusForbidden)
 		} else {
 			http.Error(w, InternalServerError, http.StatusInternalServerError)
 		}
+
+		return
 	}
 
-	if r.Method == http.MethodPut {
-		err = post.Retweet(userID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// quoteRetweet handles a retweet sent with a multipart body of commentary
+// and/or an image
+func (postAPI *PostAPI) quoteRetweet(w http.ResponseWriter, r *http.Request, postID, userID int) {
+	filename := ""
+	content := ""
+
+	r.Body = http.MaxBytesReader(w, r.Body, MAX_POST_UPLOAD_BYTES)
+	err := r.ParseMultipartForm(getMaxUploadMemory())
+	if err != nil {
+		if requestBodyTooLarge(err) {
+			http.Error(w, RequestEntityTooLarge, http.StatusRequestEntityTooLarge)
+		} else {
+			http.Error(w, BadRequest, http.StatusBadRequest)
+		}
+
+		return
+	}
+
+	content = r.FormValue("content")
+	file, header, err := r.FormFile("image")
+	if err != nil && err != http.ErrMissingFile {
+		http.Error(w, BadRequest, http.StatusBadRequest)
+		return
+	}
+
+	if err == http.ErrMissingFile {
+		// no file upload, content required
+		if content == "" {
+			http.Error(w, BadRequest, http.StatusBadRequest)
+			return
+		}
 	} else {
-		err = post.UnRetweet(userID)
+		// user uploaded file, content optional
+		defer file.Close()
+
+		filename, err = handleImageUpload(file, header)
+		if err != nil {
+			var invalidFileTypeError InvalidFileTypeError
+			if errors.As(err, &invalidFileTypeError) {
+				http.Error(w, UnsupportedMediaType, http.StatusUnsupportedMediaType)
+			} else {
+				http.Error(w, InternalServerError, http.StatusInternalServerError)
+			}
+
+			return
+		}
 	}
 
+	err = postAPI.post.QuoteRetweet(postID, userID, content, filename)
 	if err != nil {
-		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		if filename != "" {
+			deleteUploadedImages([]string{filename})
+		}
+
+		var postNotFoundError model.PostNotFoundError
+		if errors.As(err, &postNotFoundError) {
+			http.Error(w, NotFound, http.StatusNotFound)
+		} else if dbutils.IsUniqueConstraintError(err) {
+			// already retweeted or quoted by this user
+			http.Error(w, Conflict, http.StatusConflict)
+		} else if errors.Is(err, controller.PrivateAccountError{}) {
+			http.Error(w, Forbidden, http.StatusForbidden)
+		} else {
+			http.Error(w, InternalServerError, http.StatusInternalServerError)
+		}
+
 		return
 	}
 
@@ -202,30 +297,148 @@ func (postAPI *PostAPI) Bookmark(w http.ResponseWriter, r *http.Request) {
 	postID, err := strconv.Atoi(r.PathValue("id"))
 	if err != nil {
 		http.Error(w, BadRequest, http.StatusBadRequest)
+		return
+	}
+
+	if r.Method == http.MethodPut {
+		err = postAPI.post.Bookmark(postID, userID)
+	} else {
+		err = postAPI.post.UnBookmark(postID, userID)
 	}
 
-	post := postAPI.post
-	err = post.ByID(postID)
 	if err != nil {
 		var postNotFoundError model.PostNotFoundError
 		if errors.As(err, &postNotFoundError) {
 			http.Error(w, NotFound, http.StatusNotFound)
+		} else if errors.Is(err, controller.PrivateAccountError{}) {
+			http.Error(w, Forbidden, http.StatusForbidden)
 		} else {
 			http.Error(w, InternalServerError, http.StatusInternalServerError)
 		}
+
+		return
 	}
 
-	if r.Method == http.MethodPut {
-		err = post.Bookmark(userID)
-	} else {
-		err = post.UnBookmark(userID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (postAPI *PostAPI) Report(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(int)
+	if !ok {
+		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	postID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, BadRequest, http.StatusBadRequest)
+		return
+	}
+
+	var reportInput dtypes.ReportInput
+	err = json.NewDecoder(r.Body).Decode(&reportInput)
+	if err != nil || !validReportInput(reportInput) {
+		http.Error(w, BadRequest, http.StatusBadRequest)
+		return
+	}
+
+	err = postAPI.post.Report(
+		postID, userID, model.ReportReason(reportInput.Reason), reportInput.Details)
+	if err != nil {
+		var postNotFoundError model.PostNotFoundError
+		switch {
+		case errors.As(err, &postNotFoundError):
+			http.Error(w, NotFound, http.StatusNotFound)
+		case dbutils.IsUniqueConstraintError(err):
+			http.Error(w, Conflict, http.StatusConflict)
+		default:
+			http.Error(w, InternalServerError, http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (postAPI *PostAPI) Edit(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(int)
+	if !ok {
+		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		return
 	}
 
+	postID, err := strconv.Atoi(r.PathValue("postID"))
 	if err != nil {
+		http.Error(w, BadRequest, http.StatusBadRequest)
+		return
+	}
+
+	var editInput dtypes.EditInput
+	err = json.NewDecoder(r.Body).Decode(&editInput)
+	if err != nil {
+		http.Error(w, BadRequest, http.StatusBadRequest)
+		return
+	}
+
+	post, err := postAPI.post.Edit(postID, userID, editInput.Content)
+	if err != nil {
+		var postNotFoundError model.PostNotFoundError
+		switch {
+		case errors.As(err, &postNotFoundError):
+			http.Error(w, NotFound, http.StatusNotFound)
+		case errors.Is(err, controller.UnauthorizedActionError{}):
+			http.Error(w, Forbidden, http.StatusForbidden)
+		case dbutils.IsConstraintError(err):
+			http.Error(w, BadRequest, http.StatusBadRequest)
+		default:
+			http.Error(w, InternalServerError, http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	payload := generatePostPayload(post)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(payload)
+}
+
+func (postAPI *PostAPI) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(int)
+	if !ok {
 		http.Error(w, InternalServerError, http.StatusInternalServerError)
 		return
 	}
 
+	role, ok := r.Context().Value("userRole").(permissions.Role)
+	if !ok {
+		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	postID, err := strconv.Atoi(r.PathValue("postID"))
+	if err != nil {
+		http.Error(w, BadRequest, http.StatusBadRequest)
+		return
+	}
+
+	images, err := postAPI.post.Delete(postID, userID, role)
+	if err != nil {
+		var postNotFoundError model.PostNotFoundError
+		switch {
+		case errors.As(err, &postNotFoundError):
+			http.Error(w, NotFound, http.StatusNotFound)
+		case errors.Is(err, controller.UnauthorizedActionError{}):
+			http.Error(w, Forbidden, http.StatusForbidden)
+		default:
+			http.Error(w, InternalServerError, http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	deleteUploadedImages(images)
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -1,9 +1,12 @@
 package api
 
 import (
+	"bytes"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"log"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -20,7 +23,7 @@ func TestPostLikeSimple(t *testing.T) {
 		handler := RegisterHandlers(db)
 		user := createTestUser(db)
 		user.Login()
-		token, _ := GenerateJWT(user.ID())
+		token, _ := GenerateJWT(user.ID(), user.TokenVersion)
 		post := createTestPost(user.ID(), db)
 
 		req := httptest.NewRequest(
@@ -123,7 +126,7 @@ func TestLikePostMissingPost(t *testing.T) {
 		handler := RegisterHandlers(db)
 		user := createTestUser(db)
 		user.Login()
-		token, _ := GenerateJWT(user.ID())
+		token, _ := GenerateJWT(user.ID(), user.TokenVersion)
 
 		req := httptest.NewRequest(
 			http.MethodPut,
@@ -138,6 +141,24 @@ func TestLikePostMissingPost(t *testing.T) {
 	})
 }
 
+func TestLikePostBadID(t *testing.T) {
+	testutil.WithTestDB(t, func(db *sql.DB) {
+		tu := testutil.NewTestUtil(t)
+		handler := RegisterHandlers(db)
+		user := createTestUser(db)
+		user.Login()
+		token, _ := GenerateJWT(user.ID(), user.TokenVersion)
+
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/post/dags/like", nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		tu.AssertEqual(http.StatusBadRequest, res.Code)
+		tu.AssertEqual(BadRequest+"\n", res.Body.String())
+	})
+}
+
 func TestCreatePostLikeWrongMethod(t *testing.T) {
 	testutil.WithTestDB(t, func(db *sql.DB) {
 		tu := testutil.NewTestUtil(t)
@@ -214,7 +235,7 @@ func TestPostRetweetSimple(t *testing.T) {
 		handler := RegisterHandlers(db)
 		user := createTestUser(db)
 		user.Login()
-		token, _ := GenerateJWT(user.ID())
+		token, _ := GenerateJWT(user.ID(), user.TokenVersion)
 		post := createTestPost(user.ID(), db)
 
 		req := httptest.NewRequest(
@@ -311,13 +332,94 @@ func TestPostRetweetComprehensive(t *testing.T) {
 	})
 }
 
+func TestPostQuoteRetweet(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, timestamp time.Time) {
+		tu := testutil.NewTestUtil(t)
+		tu.CreateTestUploadsDir()
+		defer tu.CleanTestUploads()
+		handler := RegisterHandlers(db)
+		user := loadUserControllerByID(db, 4)
+		token := loginAndToken(user)
+		post := loadPostControllerByID(db, 1)
+
+		quote := func(fields map[string]string) *httptest.ResponseRecorder {
+			var b bytes.Buffer
+			writer := multipart.NewWriter(&b)
+			for key, value := range fields {
+				writer.WriteField(key, value)
+			}
+			writer.Close()
+
+			req := httptest.NewRequest(
+				http.MethodPut,
+				fmt.Sprintf("/api/v1/post/%d/retweet", post.ID),
+				&b,
+			)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+			req.Header.Set("Content-Type", writer.FormDataContentType())
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, req)
+			return res
+		}
+
+		res := quote(map[string]string{})
+		tu.AssertEqual(http.StatusBadRequest, res.Code)
+
+		res = quote(map[string]string{"content": "coffee > chocolate"})
+		post.Sync()
+		tu.AssertEqual(http.StatusNoContent, res.Code)
+		tu.AssertEqual(1, post.RetweetCount)
+
+		res = quote(map[string]string{"content": "coffee > chocolate"})
+		tu.AssertEqual(http.StatusConflict, res.Code)
+
+		req := httptest.NewRequest(
+			http.MethodGet, "/api/v1/timeline?limit=40&offset=0&view=FOR_YOU", nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		res = httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		tu.AssertEqual(http.StatusOK, res.Code)
+
+		var timelinePayload TimelinePayload
+		json.NewDecoder(res.Body).Decode(&timelinePayload)
+
+		var quotePayload TimelinePostPayload
+		for _, timelinePost := range timelinePayload.Posts {
+			if timelinePost.Type == "post-quote" {
+				quotePayload = timelinePost
+			} else {
+				tu.AssertNil(timelinePost.QuotedPost)
+			}
+		}
+
+		tu.AssertEqual("coffee > chocolate", quotePayload.Content)
+		tu.AssertEqual(user.Username, quotePayload.Author.Username)
+		tu.AssertFalse(quotePayload.IsRetweet)
+		tu.AssertNotNil(quotePayload.QuotedPost)
+		tu.AssertEqual(post.ID, quotePayload.QuotedPost.ID)
+		tu.AssertEqual(post.Content, quotePayload.QuotedPost.Content)
+		tu.AssertEqual(getUploadPath(post.Image), quotePayload.QuotedPost.Image)
+		tu.AssertEqual(post.Author.Username, quotePayload.QuotedPost.Author.Username)
+
+		// unretweeting removes the quote
+		req = httptest.NewRequest(
+			http.MethodDelete, fmt.Sprintf("/api/v1/post/%d/retweet", post.ID), nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		res = httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		post.Sync()
+		tu.AssertEqual(http.StatusNoContent, res.Code)
+		tu.AssertEqual(0, post.RetweetCount)
+	})
+}
+
 func TestRetweetPostMissingPost(t *testing.T) {
 	testutil.WithTestDB(t, func(db *sql.DB) {
 		tu := testutil.NewTestUtil(t)
 		handler := RegisterHandlers(db)
 		user := createTestUser(db)
 		user.Login()
-		token, _ := GenerateJWT(user.ID())
+		token, _ := GenerateJWT(user.ID(), user.TokenVersion)
 
 		req := httptest.NewRequest(
 			http.MethodPut,
@@ -408,7 +510,7 @@ func TestPostBookmarkSimple(t *testing.T) {
 		handler := RegisterHandlers(db)
 		user := createTestUser(db)
 		user.Login()
-		token, _ := GenerateJWT(user.ID())
+		token, _ := GenerateJWT(user.ID(), user.TokenVersion)
 		post := createTestPost(user.ID(), db)
 
 		req := httptest.NewRequest(
@@ -511,7 +613,7 @@ func TestBookmarkPostMissingPost(t *testing.T) {
 		handler := RegisterHandlers(db)
 		user := createTestUser(db)
 		user.Login()
-		token, _ := GenerateJWT(user.ID())
+		token, _ := GenerateJWT(user.ID(), user.TokenVersion)
 
 		req := httptest.NewRequest(
 			http.MethodPut,
@@ -526,6 +628,24 @@ func TestBookmarkPostMissingPost(t *testing.T) {
 	})
 }
 
+func TestBookmarkPostBadID(t *testing.T) {
+	testutil.WithTestDB(t, func(db *sql.DB) {
+		tu := testutil.NewTestUtil(t)
+		handler := RegisterHandlers(db)
+		user := createTestUser(db)
+		user.Login()
+		token, _ := GenerateJWT(user.ID(), user.TokenVersion)
+
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/post/dags/bookmark", nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		tu.AssertEqual(http.StatusBadRequest, res.Code)
+		tu.AssertEqual(BadRequest+"\n", res.Body.String())
+	})
+}
+
 func TestCreatePostBookmarkWrongMethod(t *testing.T) {
 	testutil.WithTestDB(t, func(db *sql.DB) {
 		tu := testutil.NewTestUtil(t)
@@ -639,7 +759,7 @@ func loadPostControllerByID(db *sql.DB, postID int) *controller.Post {
 
 func loginAndToken(user *controller.User) (token string) {
 	user.Login()
-	token, err := GenerateJWT(user.ID())
+	token, err := GenerateJWT(user.ID(), user.TokenVersion)
 	if err != nil {
 		log.Fatal(err)
 	}
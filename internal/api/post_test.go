@@ -4,11 +4,14 @@ import (
 	"bytes"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -16,6 +19,7 @@ import (
 	"github.com/golang-jwt/jwt"
 	"github.com/marcusprice/twitter-clone/internal/controller"
 	"github.com/marcusprice/twitter-clone/internal/dtypes"
+	"github.com/marcusprice/twitter-clone/internal/mailer"
 	"github.com/marcusprice/twitter-clone/internal/testutil"
 )
 
@@ -28,7 +32,7 @@ func TestCreatePostContentOnly(t *testing.T) {
 
 		testUser := createTestUser(db)
 		testUser.Login()
-		token, _ := GenerateJWT(testUser.ID())
+		token, _ := GenerateJWT(testUser.ID(), testUser.TokenVersion)
 
 		var b bytes.Buffer
 		writer := multipart.NewWriter(&b)
@@ -73,7 +77,7 @@ func TestCreatePostImageOnly(t *testing.T) {
 
 		testUser := createTestUser(db)
 		testUser.Login()
-		token, _ := GenerateJWT(testUser.ID())
+		token, _ := GenerateJWT(testUser.ID(), testUser.TokenVersion)
 
 		var b bytes.Buffer
 		writer := multipart.NewWriter(&b)
@@ -123,7 +127,7 @@ func TestCreatePostContentAndImage(t *testing.T) {
 		handler := RegisterHandlers(db)
 		testUser := createTestUser(db)
 		testUser.Login()
-		token, _ := GenerateJWT(testUser.ID())
+		token, _ := GenerateJWT(testUser.ID(), testUser.TokenVersion)
 
 		var b bytes.Buffer
 		imgData := generateLargeString(5)
@@ -174,7 +178,7 @@ func TestCreatePostInvalidFileType(t *testing.T) {
 		handler := RegisterHandlers(db)
 		testUser := createTestUser(db)
 		testUser.Login()
-		token, _ := GenerateJWT(testUser.ID())
+		token, _ := GenerateJWT(testUser.ID(), testUser.TokenVersion)
 
 		var b bytes.Buffer
 		writer := multipart.NewWriter(&b)
@@ -220,7 +224,7 @@ func TestCreatePostNoContentOrImage(t *testing.T) {
 		handler := RegisterHandlers(db)
 		testUser := createTestUser(db)
 		testUser.Login()
-		token, _ := GenerateJWT(testUser.ID())
+		token, _ := GenerateJWT(testUser.ID(), testUser.TokenVersion)
 
 		var b bytes.Buffer
 		writer := multipart.NewWriter(&b)
@@ -267,7 +271,7 @@ func TestCreatePostUploadSizeTooLarge(t *testing.T) {
 		tu := testutil.NewTestUtil(t)
 		testUser := createTestUser(db)
 		testUser.Login()
-		token, _ := GenerateJWT(testUser.ID())
+		token, _ := GenerateJWT(testUser.ID(), testUser.TokenVersion)
 		tu.CreateTestUploadsDir()
 		defer tu.CleanTestUploads()
 
@@ -387,6 +391,96 @@ func TestCreatePostWrongMethod(t *testing.T) {
 	})
 }
 
+func TestEditPost(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, _ time.Time) {
+		tu := testutil.NewTestUtil(t)
+		handler := RegisterHandlers(db)
+		author := loadUserControllerByID(db, 3)
+		admin := loadUserControllerByID(db, 2)
+		authorToken := loginAndToken(author)
+		adminToken := loginAndToken(admin)
+
+		edit := func(token, body string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(
+				http.MethodPatch, "/api/v1/post/1", bytes.NewBufferString(body))
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, req)
+			return res
+		}
+
+		res := edit(authorToken, `{"content": "Diane, correction: chocolate rabbits."}`)
+		var postPayload PostPayload
+		json.Unmarshal(res.Body.Bytes(), &postPayload)
+		tu.AssertEqual(http.StatusOK, res.Code)
+		tu.AssertEqual("Diane, correction: chocolate rabbits.", postPayload.Content)
+		tu.AssertTrue(postPayload.UpdatedAt.After(postPayload.CreatedAt))
+
+		// only the author can edit, admins included
+		res = edit(adminToken, `{"content": "hacked"}`)
+		tu.AssertEqual(http.StatusForbidden, res.Code)
+
+		res = edit(authorToken, `{bad json`)
+		tu.AssertEqual(http.StatusBadRequest, res.Code)
+
+		req := httptest.NewRequest(
+			http.MethodPatch, "/api/v1/post/42069", bytes.NewBufferString(`{"content": "hi"}`))
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authorToken))
+		res = httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		tu.AssertEqual(http.StatusNotFound, res.Code)
+
+		req = httptest.NewRequest(http.MethodPut, "/api/v1/post/1", nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", authorToken))
+		res = httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		tu.AssertEqual(http.StatusMethodNotAllowed, res.Code)
+	})
+}
+
+func TestDeletePost(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, _ time.Time) {
+		tu := testutil.NewTestUtil(t)
+		tu.CreateTestUploadsDir()
+		defer tu.CleanTestUploads()
+		handler := RegisterHandlers(db)
+		author := loadUserControllerByID(db, 3)
+		admin := loadUserControllerByID(db, 2)
+		user := loadUserControllerByID(db, 1)
+		authorToken := loginAndToken(author)
+		adminToken := loginAndToken(admin)
+		userToken := loginAndToken(user)
+
+		post := loadPostControllerByID(db, 1)
+		imagePath := filepath.Join(os.Getenv("TEST_IMAGE_STORAGE_PATH"), post.Image)
+		os.WriteFile(imagePath, []byte("bunnies"), 0755)
+
+		deletePost := func(token string, postID int) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(
+				http.MethodDelete, fmt.Sprintf("/api/v1/post/%d", postID), nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, req)
+			return res
+		}
+
+		res := deletePost(userToken, post.ID)
+		tu.AssertEqual(http.StatusForbidden, res.Code)
+
+		res = deletePost(authorToken, post.ID)
+		tu.AssertEqual(http.StatusNoContent, res.Code)
+		_, err := os.Stat(imagePath)
+		tu.AssertTrue(errors.Is(err, os.ErrNotExist))
+
+		res = deletePost(authorToken, post.ID)
+		tu.AssertEqual(http.StatusNotFound, res.Code)
+
+		// admins can delete anyone's post
+		res = deletePost(adminToken, 2)
+		tu.AssertEqual(http.StatusNoContent, res.Code)
+	})
+}
+
 func generateBadToken() string {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"sub": 42069,
@@ -442,9 +536,39 @@ func createTestUser(db *sql.DB) *controller.User {
 		DisplayName: "Bubba",
 	}
 	user.Set(nil, userInput)
-	err := user.Create("password")
+	createAndActivateUser(user, "password")
+	return user
+}
+
+type recordingMailer struct {
+	messages []mailer.Message
+}
+
+func (rm *recordingMailer) Send(msg mailer.Message) error {
+	rm.messages = append(rm.messages, msg)
+	return nil
+}
+
+// lastToken pulls the token off the end of the most recent message body
+func (rm *recordingMailer) lastToken() string {
+	if len(rm.messages) == 0 {
+		return ""
+	}
+
+	fields := strings.Fields(rm.messages[len(rm.messages)-1].Body)
+	return fields[len(fields)-1]
+}
+
+func createAndActivateUser(user *controller.User, password string) {
+	mail := &recordingMailer{}
+	user.SetMailer(mail)
+	err := user.Create(password)
+	if err != nil {
+		panic(err)
+	}
+
+	err = user.Activate(mail.lastToken())
 	if err != nil {
 		panic(err)
 	}
-	return user
 }
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/marcusprice/twitter-clone/internal/controller"
+	"github.com/marcusprice/twitter-clone/internal/dtypes"
+	"github.com/marcusprice/twitter-clone/internal/model"
+)
+
+type ProfileAPI struct {
+	db *sql.DB
+}
+
+func (profileAPI *ProfileAPI) Get(w http.ResponseWriter, r *http.Request) {
+	profile, ok := profileAPI.loadProfile(w, r)
+	if !ok {
+		return
+	}
+
+	author, err := profile.Get()
+	if err != nil {
+		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(generateAuthorPayload(author))
+}
+
+func (profileAPI *ProfileAPI) GetPosts(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	limit, offset, err := parseLimitAndOffset(values.Get("limit"), values.Get("offset"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	profile, ok := profileAPI.loadProfile(w, r)
+	if !ok {
+		return
+	}
+
+	posts, postsRemaining, err := profile.GetPosts(limit, offset)
+	if err != nil {
+		if errors.Is(err, controller.PrivateAccountError{}) {
+			http.Error(w, Forbidden, http.StatusForbidden)
+		} else {
+			http.Error(w, InternalServerError, http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	timelinePosts := []TimelinePostPayload{}
+	for _, post := range posts {
+		timelinePosts = append(timelinePosts, generateTimelinePostPayload(post))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(TimelinePayload{
+		Posts:          timelinePosts,
+		HasMore:        postsRemaining > 0,
+		PostsRemaining: &postsRemaining,
+	})
+}
+
+// GetList serves /user/{username}/{list}, a single wildcard route is used
+// because /user/{username}/posts would conflict with /user/by-post/{postID}
+// and /user/follow/{username} in the mux
+func (profileAPI *ProfileAPI) GetList(w http.ResponseWriter, r *http.Request) {
+	switch r.PathValue("list") {
+	case "posts":
+		profileAPI.GetPosts(w, r)
+	case "followers":
+		profileAPI.GetFollowers(w, r)
+	case "following":
+		profileAPI.GetFollowing(w, r)
+	default:
+		http.Error(w, NotFound, http.StatusNotFound)
+	}
+}
+
+func (profileAPI *ProfileAPI) GetFollowers(w http.ResponseWriter, r *http.Request) {
+	profileAPI.getFollowList(w, r, (*controller.Profile).GetFollowers)
+}
+
+func (profileAPI *ProfileAPI) GetFollowing(w http.ResponseWriter, r *http.Request) {
+	profileAPI.getFollowList(w, r, (*controller.Profile).GetFollowing)
+}
+
+func (profileAPI *ProfileAPI) getFollowList(
+	w http.ResponseWriter,
+	r *http.Request,
+	list func(*controller.Profile, int, int) ([]dtypes.Author, int, error),
+) {
+	values := r.URL.Query()
+	limit, offset, err := parseLimitAndOffset(values.Get("limit"), values.Get("offset"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	profile, ok := profileAPI.loadProfile(w, r)
+	if !ok {
+		return
+	}
+
+	users, usersRemaining, err := list(profile, limit, offset)
+	if err != nil {
+		if errors.Is(err, controller.PrivateAccountError{}) {
+			http.Error(w, Forbidden, http.StatusForbidden)
+		} else {
+			http.Error(w, InternalServerError, http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(generateUserListPayload(users, usersRemaining))
+}
+
+// loadProfile writes the error response itself, callers should return when
+// ok is false
+func (profileAPI *ProfileAPI) loadProfile(w http.ResponseWriter, r *http.Request) (profile *controller.Profile, ok bool) {
+	viewerID, ok := r.Context().Value("userID").(int)
+	if !ok {
+		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		return nil, false
+	}
+
+	profile = controller.NewProfileController(profileAPI.db)
+	err := profile.Set(r.PathValue("username"), viewerID)
+	if err != nil {
+		if errors.Is(err, model.UserNotFoundError{}) {
+			http.Error(w, NotFound, http.StatusNotFound)
+		} else {
+			http.Error(w, InternalServerError, http.StatusInternalServerError)
+		}
+		return nil, false
+	}
+
+	return profile, true
+}
+
+func NewProfileAPI(db *sql.DB) *ProfileAPI {
+	return &ProfileAPI{db}
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/marcusprice/twitter-clone/internal/testutil"
+)
+
+func TestProfileGet(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, _ time.Time) {
+		tu := testutil.NewTestUtil(t)
+		handler := RegisterHandlers(db)
+		user1 := loadUserControllerByID(db, 1)
+		user1Token := loginAndToken(user1)
+		user1.Follow("endlesshappiness")
+
+		request := func(path string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			req.Header.Set("Authorization", "Bearer "+user1Token)
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, req)
+			return res
+		}
+
+		res := request("/api/v1/user/wallphace")
+		tu.AssertEqual(http.StatusOK, res.Code)
+		var profile AuthorPayload
+		json.NewDecoder(res.Body).Decode(&profile)
+		tu.AssertEqual("wallphace", profile.Username)
+		tu.AssertEqual(1, profile.FollowerCount)
+		tu.AssertEqual(0, profile.FollowingCount)
+		tu.AssertFalse(profile.ViewerFollowing)
+		tu.AssertEqual(1, len(profile.MutalFollowers))
+		tu.AssertEqual("endlesshappiness", profile.MutalFollowers[0].Username)
+
+		res = request("/api/v1/user/endlesshappiness")
+		tu.AssertEqual(http.StatusOK, res.Code)
+		json.NewDecoder(res.Body).Decode(&profile)
+		tu.AssertEqual(6, profile.FollowingCount)
+		tu.AssertTrue(profile.ViewerFollowing)
+
+		res = request("/api/v1/user/nobody")
+		tu.AssertEqual(http.StatusNotFound, res.Code)
+
+		// static user routes still win over the profile wildcard
+		res = request("/api/v1/user/bookmarks?limit=10")
+		tu.AssertEqual(http.StatusOK, res.Code)
+	})
+}
+
+func TestProfileLists(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, _ time.Time) {
+		tu := testutil.NewTestUtil(t)
+		handler := RegisterHandlers(db)
+		user1 := loadUserControllerByID(db, 1)
+		user1Token := loginAndToken(user1)
+
+		request := func(path string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			req.Header.Set("Authorization", "Bearer "+user1Token)
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, req)
+			return res
+		}
+
+		res := request("/api/v1/user/wallphace/posts?limit=1&offset=0")
+		tu.AssertEqual(http.StatusOK, res.Code)
+		var posts TimelinePayload
+		json.NewDecoder(res.Body).Decode(&posts)
+		tu.AssertEqual(1, len(posts.Posts))
+		tu.AssertEqual("wallphace", posts.Posts[0].Author.Username)
+		var postCount int
+		db.QueryRow("SELECT COUNT(*) FROM Post WHERE user_id = 2").Scan(&postCount)
+		tu.AssertTrue(posts.HasMore)
+		tu.AssertEqual(postCount-1, *posts.PostsRemaining)
+
+		res = request("/api/v1/user/endlesshappiness/following?limit=4&offset=0")
+		tu.AssertEqual(http.StatusOK, res.Code)
+		var users UserListPayload
+		json.NewDecoder(res.Body).Decode(&users)
+		tu.AssertEqual(4, len(users.Users))
+		tu.AssertTrue(users.HasMore)
+		tu.AssertEqual(2, users.UsersRemaining)
+
+		res = request("/api/v1/user/estecat/followers?limit=10&offset=0")
+		tu.AssertEqual(http.StatusOK, res.Code)
+		json.NewDecoder(res.Body).Decode(&users)
+		tu.AssertEqual(1, len(users.Users))
+		tu.AssertEqual("endlesshappiness", users.Users[0].Username)
+		tu.AssertFalse(users.HasMore)
+
+		res = request("/api/v1/user/estecat/followers")
+		tu.AssertEqual(http.StatusBadRequest, res.Code)
+
+		res = request("/api/v1/user/estecat/likes?limit=10&offset=0")
+		tu.AssertEqual(http.StatusNotFound, res.Code)
+
+		res = request("/api/v1/user/nobody/posts?limit=10&offset=0")
+		tu.AssertEqual(http.StatusNotFound, res.Code)
+	})
+}
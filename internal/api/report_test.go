@@ -0,0 +1,151 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marcusprice/twitter-clone/internal/testutil"
+)
+
+func TestReportContent(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, _ time.Time) {
+		tu := testutil.NewTestUtil(t)
+		handler := RegisterHandlers(db)
+		userToken := loginAndToken(loadUserControllerByID(db, 1))
+		post := createTestPost(4, db)
+
+		var commentID int
+		db.QueryRow(
+			"INSERT INTO Comment (post_id, user_id, depth, content, image) VALUES ($1, 5, 0, 'hi', '') RETURNING id;",
+			post.ID,
+		).Scan(&commentID)
+
+		report := func(path, body string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+			req.Header.Set("Authorization", "Bearer "+userToken)
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, req)
+			return res
+		}
+
+		postPath := fmt.Sprintf("/api/v1/post/%d/report", post.ID)
+		commentPath := fmt.Sprintf("/api/v1/comment/%d/report", commentID)
+
+		res := report(postPath, `{"reason": "spam", "details": "selling cats"}`)
+		tu.AssertEqual(http.StatusNoContent, res.Code)
+		res = report(postPath, `{"reason": "spam"}`)
+		tu.AssertEqual(http.StatusConflict, res.Code)
+		res = report(commentPath, `{"reason": "boring"}`)
+		tu.AssertEqual(http.StatusBadRequest, res.Code)
+		res = report(commentPath, `{"reason": "harassment"}`)
+		tu.AssertEqual(http.StatusNoContent, res.Code)
+		res = report("/api/v1/post/42069/report", `{"reason": "spam"}`)
+		tu.AssertEqual(http.StatusNotFound, res.Code)
+		res = report("/api/v1/comment/42069/report", `{"reason": "spam"}`)
+		tu.AssertEqual(http.StatusNotFound, res.Code)
+
+		req := httptest.NewRequest(http.MethodPost, postPath, strings.NewReader(`{"reason": "spam"}`))
+		res = httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		tu.AssertEqual(http.StatusUnauthorized, res.Code)
+	})
+}
+
+func TestAdminResolveReports(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, _ time.Time) {
+		tu := testutil.NewTestUtil(t)
+		handler := RegisterHandlers(db)
+		adminToken := loginAndToken(loadUserControllerByID(db, 2))
+		userToken := loginAndToken(loadUserControllerByID(db, 1))
+		followerToken := loginAndToken(loadUserControllerByID(db, 7))
+		hiddenPost := createTestPost(4, db)
+		dismissedPost := createTestPost(4, db)
+		spamPost := createTestPost(6, db)
+
+		for _, postID := range []int{hiddenPost.ID, dismissedPost.ID, spamPost.ID} {
+			res := testutil.ServeRequest(handler, http.MethodPost, fmt.Sprintf("/api/v1/post/%d/report", postID), userToken,
+				strings.NewReader(`{"reason": "spam"}`))
+			tu.AssertEqual(http.StatusNoContent, res.Code)
+		}
+
+		res := testutil.ServeRequest(handler, http.MethodGet, "/api/v1/admin/reports?limit=10&offset=0", userToken, nil)
+		tu.AssertEqual(http.StatusForbidden, res.Code)
+		res = testutil.ServeRequest(handler, http.MethodGet, "/api/v1/admin/reports?limit=10&offset=0&status=whatever", adminToken, nil)
+		tu.AssertEqual(http.StatusBadRequest, res.Code)
+
+		res = testutil.ServeRequest(handler, http.MethodGet, "/api/v1/admin/reports?limit=2&offset=0", adminToken, nil)
+		tu.AssertEqual(http.StatusOK, res.Code)
+		var queue ReportListPayload
+		json.NewDecoder(res.Body).Decode(&queue)
+		tu.AssertEqual(2, len(queue.Reports))
+		tu.AssertTrue(queue.HasMore)
+		tu.AssertEqual(1, queue.ReportsRemaining)
+		tu.AssertEqual(hiddenPost.ID, queue.Reports[0].PostID)
+		tu.AssertEqual("estecat", queue.Reports[0].Reporter.Username)
+		tu.AssertEqual("audrey", queue.Reports[0].Author.Username)
+		tu.AssertNil(queue.Reports[0].ResolvedAt)
+
+		resolvePath := func(reportID int) string {
+			return fmt.Sprintf("/api/v1/admin/reports/%d/resolve", reportID)
+		}
+
+		res = testutil.ServeRequest(handler, http.MethodPost, resolvePath(queue.Reports[0].ID), adminToken,
+			strings.NewReader(`{"action": "ban"}`))
+		tu.AssertEqual(http.StatusBadRequest, res.Code)
+		res = testutil.ServeRequest(handler, http.MethodPost, resolvePath(42069), adminToken,
+			strings.NewReader(`{"action": "dismiss"}`))
+		tu.AssertEqual(http.StatusNotFound, res.Code)
+
+		res = testutil.ServeRequest(handler, http.MethodPost, resolvePath(queue.Reports[0].ID), adminToken,
+			strings.NewReader(`{"action": "hide"}`))
+		tu.AssertEqual(http.StatusNoContent, res.Code)
+		res = testutil.ServeRequest(handler, http.MethodPost, resolvePath(queue.Reports[0].ID), adminToken,
+			strings.NewReader(`{"action": "dismiss"}`))
+		tu.AssertEqual(http.StatusConflict, res.Code)
+		res = testutil.ServeRequest(handler, http.MethodPost, resolvePath(queue.Reports[1].ID), adminToken,
+			strings.NewReader(`{"action": "dismiss"}`))
+		tu.AssertEqual(http.StatusNoContent, res.Code)
+
+		res = testutil.ServeRequest(handler, http.MethodGet, fmt.Sprintf("/api/v1/post/%d", hiddenPost.ID), userToken, nil)
+		tu.AssertEqual(http.StatusNotFound, res.Code)
+		res = testutil.ServeRequest(handler, http.MethodGet, fmt.Sprintf("/api/v1/post/%d", dismissedPost.ID), userToken, nil)
+		tu.AssertEqual(http.StatusOK, res.Code)
+
+		res = testutil.ServeRequest(handler, http.MethodGet, "/api/v1/timeline?limit=40&offset=0&view=FOLLOWING", followerToken, nil)
+		tu.AssertEqual(http.StatusOK, res.Code)
+		var timeline TimelinePayload
+		json.NewDecoder(res.Body).Decode(&timeline)
+		for _, timelinePost := range timeline.Posts {
+			tu.AssertTrue(timelinePost.ID != hiddenPost.ID)
+		}
+
+		res = testutil.ServeRequest(handler, http.MethodGet, "/api/v1/admin/reports?limit=10&offset=0", adminToken, nil)
+		json.NewDecoder(res.Body).Decode(&queue)
+		tu.AssertEqual(1, len(queue.Reports))
+		tu.AssertEqual(spamPost.ID, queue.Reports[0].PostID)
+
+		donnaToken := loginAndToken(loadUserControllerByID(db, 6))
+		res = testutil.ServeRequest(handler, http.MethodPost, resolvePath(queue.Reports[0].ID), adminToken,
+			strings.NewReader(`{"action": "deactivate_author"}`))
+		tu.AssertEqual(http.StatusNoContent, res.Code)
+		res = testutil.ServeRequest(handler, http.MethodGet, "/api/v1/user", donnaToken, nil)
+		tu.AssertEqual(http.StatusUnauthorized, res.Code)
+
+		res = testutil.ServeRequest(handler, http.MethodGet, "/api/v1/admin/reports?limit=10&offset=0&status=content_hidden", adminToken, nil)
+		json.NewDecoder(res.Body).Decode(&queue)
+		tu.AssertEqual(1, len(queue.Reports))
+		tu.AssertNotNil(queue.Reports[0].ResolvedAt)
+
+		var count int
+		db.QueryRow(
+			"SELECT COUNT(*) FROM AuditLog WHERE action = 'resolve_report' AND actor_id = 2;",
+		).Scan(&count)
+		tu.AssertEqual(3, count)
+	})
+}
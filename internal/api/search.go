@@ -0,0 +1,75 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/marcusprice/twitter-clone/internal/controller"
+)
+
+type SearchAPI struct {
+	search *controller.Search
+}
+
+// Search takes q, limit and offset, and type which is one of posts (the
+// default), comments or users
+func (searchAPI *SearchAPI) Search(w http.ResponseWriter, r *http.Request) {
+	viewerID, ok := r.Context().Value("userID").(int)
+	if !ok {
+		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	values := r.URL.Query()
+	limit, offset, err := parseLimitAndOffset(values.Get("limit"), values.Get("offset"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	query := strings.TrimSpace(values.Get("q"))
+	if query == "" || utf8.RuneCountInString(query) > MAX_SEARCH_QUERY_LENGTH {
+		http.Error(w, BadRequest, http.StatusBadRequest)
+		return
+	}
+
+	switch values.Get("type") {
+	case "", "posts":
+		results, resultsRemaining, err := searchAPI.search.Posts(query, viewerID, limit, offset)
+		writeSearchResults(w, err, func() any {
+			return generateSearchPostsPayload(results, resultsRemaining)
+		})
+	case "comments":
+		results, resultsRemaining, err := searchAPI.search.Comments(query, viewerID, limit, offset)
+		writeSearchResults(w, err, func() any {
+			return generateSearchPostsPayload(results, resultsRemaining)
+		})
+	case "users":
+		results, resultsRemaining, err := searchAPI.search.Users(query, viewerID, limit, offset)
+		writeSearchResults(w, err, func() any {
+			return generateSearchUsersPayload(results, resultsRemaining)
+		})
+	default:
+		http.Error(w, BadRequest, http.StatusBadRequest)
+	}
+}
+
+func writeSearchResults(w http.ResponseWriter, err error, payload func() any) {
+	if err != nil {
+		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(payload())
+}
+
+func NewSearchAPI(db *sql.DB) *SearchAPI {
+	return &SearchAPI{
+		search: controller.NewSearchController(db),
+	}
+}
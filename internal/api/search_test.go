@@ -0,0 +1,70 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marcusprice/twitter-clone/internal/controller"
+	"github.com/marcusprice/twitter-clone/internal/dtypes"
+	"github.com/marcusprice/twitter-clone/internal/testutil"
+)
+
+func TestSearch(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, _ time.Time) {
+		tu := testutil.NewTestUtil(t)
+		handler := RegisterHandlers(db)
+		token := loginAndToken(loadUserControllerByID(db, 7))
+		post := controller.NewPostController(db)
+		for _, content := range []string{"the sycamores are calling", "twelve sycamores", "the <b>sycamores</b>"} {
+			post.New(dtypes.PostInput{UserID: 1, Content: content})
+		}
+
+		request := func(params url.Values) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/search?"+params.Encode(), nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, req)
+			return res
+		}
+
+		res := request(url.Values{"q": {"SYCAMORE"}, "limit": {"2"}, "offset": {"0"}})
+		tu.AssertEqual(http.StatusOK, res.Code)
+		var posts SearchPostsPayload
+		json.NewDecoder(res.Body).Decode(&posts)
+		tu.AssertEqual(2, len(posts.Posts))
+		tu.AssertTrue(posts.HasMore)
+		tu.AssertEqual(1, posts.ResultsRemaining)
+		tu.AssertEqual("post", posts.Posts[0].Type)
+		tu.AssertEqual("estecat", posts.Posts[0].Author.Username)
+		tu.AssertTrue(strings.Contains(posts.Posts[0].Snippet, "<mark>sycamores</mark>"))
+
+		res = request(url.Values{"q": {"sycamores"}, "type": {"comments"}, "limit": {"10"}, "offset": {"0"}})
+		tu.AssertEqual(http.StatusOK, res.Code)
+		json.NewDecoder(res.Body).Decode(&posts)
+		tu.AssertEqual(0, len(posts.Posts))
+
+		res = request(url.Values{"q": {"audrey"}, "type": {"users"}, "limit": {"10"}, "offset": {"0"}})
+		tu.AssertEqual(http.StatusOK, res.Code)
+		var users SearchUsersPayload
+		json.NewDecoder(res.Body).Decode(&users)
+		tu.AssertEqual(1, len(users.Users))
+		tu.AssertEqual("audrey", users.Users[0].Username)
+		tu.AssertTrue(users.Users[0].ViewerFollowing)
+		tu.AssertFalse(users.HasMore)
+
+		res = request(url.Values{"q": {"  "}, "limit": {"10"}, "offset": {"0"}})
+		tu.AssertEqual(http.StatusBadRequest, res.Code)
+		res = request(url.Values{"q": {strings.Repeat("a", MAX_SEARCH_QUERY_LENGTH+1)}, "limit": {"10"}, "offset": {"0"}})
+		tu.AssertEqual(http.StatusBadRequest, res.Code)
+		res = request(url.Values{"q": {"sycamores"}, "type": {"hashtags"}, "limit": {"10"}, "offset": {"0"}})
+		tu.AssertEqual(http.StatusBadRequest, res.Code)
+		res = request(url.Values{"q": {"sycamores"}})
+		tu.AssertEqual(http.StatusBadRequest, res.Code)
+	})
+}
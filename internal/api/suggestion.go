@@ -0,0 +1,44 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+
+	"github.com/marcusprice/twitter-clone/internal/controller"
+)
+
+type SuggestionAPI struct {
+	suggestion *controller.Suggestion
+}
+
+// GetSuggestions lists accounts the requesting user might want to follow
+func (suggestionAPI *SuggestionAPI) GetSuggestions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(int)
+	if !ok {
+		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	limit, err := parseLimit(r.URL.Query().Get("limit"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	suggestions, err := suggestionAPI.suggestion.ForUser(userID, limit)
+	if err != nil {
+		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(generateSuggestionListPayload(suggestions))
+}
+
+func NewSuggestionAPI(db *sql.DB) *SuggestionAPI {
+	return &SuggestionAPI{
+		suggestion: controller.NewSuggestionController(db),
+	}
+}
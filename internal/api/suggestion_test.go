@@ -0,0 +1,65 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/marcusprice/twitter-clone/internal/controller"
+	"github.com/marcusprice/twitter-clone/internal/model"
+	"github.com/marcusprice/twitter-clone/internal/testutil"
+)
+
+func TestSuggestions(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, _ time.Time) {
+		tu := testutil.NewTestUtil(t)
+		handler := RegisterHandlers(db)
+		token := loginAndToken(loadUserControllerByID(db, 1))
+
+		userModel := model.NewUserModel(db)
+		userModel.Follow(1, 2)
+		userModel.Follow(2, 3)
+		userModel.Follow(1, 6)
+		userModel.Follow(6, 3)
+		_, err := db.Exec(`
+			INSERT INTO PostLike (post_id, user_id)
+			SELECT id, 1 FROM Post WHERE user_id = 4 ORDER BY id LIMIT 1;
+		`)
+		tu.AssertErrorNil(err)
+
+		request := func(path string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, req)
+			return res
+		}
+
+		res := request("/api/v1/user/suggestions?limit=3")
+		tu.AssertEqual(http.StatusOK, res.Code)
+		var payload SuggestionListPayload
+		json.NewDecoder(res.Body).Decode(&payload)
+		tu.AssertEqual(3, len(payload.Users))
+
+		dale := payload.Users[0]
+		tu.AssertEqual("dalecooper", dale.Username)
+		tu.AssertEqual(controller.SUGGESTION_REASON_MUTUAL, dale.Reason)
+		tu.AssertFalse(dale.ViewerFollowing)
+		tu.AssertEqual(getUploadPath("cooper-profile.png"), dale.Avatar)
+		tu.AssertEqual(2, len(dale.MutalFollowers))
+		tu.AssertEqual("donnahayward", dale.MutalFollowers[0].Username)
+		tu.AssertEqual("wallphace", dale.MutalFollowers[1].Username)
+
+		tu.AssertEqual("audrey", payload.Users[1].Username)
+		tu.AssertEqual(controller.SUGGESTION_REASON_LIKED, payload.Users[1].Reason)
+		tu.AssertEqual(0, len(payload.Users[1].MutalFollowers))
+		tu.AssertEqual("bobbybriggs", payload.Users[2].Username)
+		tu.AssertEqual(controller.SUGGESTION_REASON_POPULAR, payload.Users[2].Reason)
+
+		res = request("/api/v1/user/suggestions")
+		tu.AssertEqual(http.StatusBadRequest, res.Code)
+	})
+}
@@ -28,10 +28,16 @@ func (timelineAPI *TimelineAPI) Get(w http.ResponseWriter, r *http.Request) {
 
 	values := r.URL.Query()
 	limitParam := values.Get("limit")
-	offsetParam := values.Get("offset")
+	cursorParam := values.Get("cursor")
 	viewParam := values.Get("view")
 
-	limit, offset, err := parseLimitAndOffset(limitParam, offsetParam)
+	limit, err := parseLimit(limitParam)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	cursor, err := decodeCursor(cursorParam)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -44,7 +50,7 @@ func (timelineAPI *TimelineAPI) Get(w http.ResponseWriter, r *http.Request) {
 
 	view := controller.TimelineView(viewParam)
 	timeline := timelineAPI.timeline.Set(userID, view)
-	posts, postsRemaining, err := timeline.GetPosts(limit, offset)
+	posts, next, err := timeline.GetPosts(limit, cursor)
 	if err != nil {
 		http.Error(w, InternalServerError, http.StatusInternalServerError)
 		return
@@ -57,9 +63,9 @@ func (timelineAPI *TimelineAPI) Get(w http.ResponseWriter, r *http.Request) {
 	}
 
 	timelinePayload := TimelinePayload{
-		Posts:          timelinePosts,
-		HasMore:        postsRemaining > 0,
-		PostsRemaining: postsRemaining,
+		Posts:      timelinePosts,
+		HasMore:    next != nil,
+		NextCursor: encodeCursor(next),
 	}
 
 	w.WriteHeader(http.StatusOK)
@@ -67,9 +73,9 @@ func (timelineAPI *TimelineAPI) Get(w http.ResponseWriter, r *http.Request) {
 }
 
 func parseLimitAndOffset(limitParam, offsetParam string) (limit, offset int, err error) {
-	limit, limitErr := strconv.Atoi(limitParam)
-	if limitParam == "" || limitErr != nil {
-		return -1, -1, errors.New("Bad limit value")
+	limit, err = parseLimit(limitParam)
+	if err != nil {
+		return -1, -1, err
 	}
 
 	offset, offsetErr := strconv.Atoi(offsetParam)
@@ -77,15 +83,24 @@ func parseLimitAndOffset(limitParam, offsetParam string) (limit, offset int, err
 		return -1, -1, errors.New("Bad offset value")
 	}
 
+	return limit, offset, nil
+}
+
+func parseLimit(limitParam string) (limit int, err error) {
+	limit, limitErr := strconv.Atoi(limitParam)
+	if limitParam == "" || limitErr != nil {
+		return -1, errors.New("Bad limit value")
+	}
+
 	if limit < MAX_LIMIT {
-		return -1, -1, fmt.Errorf("Too large of a limit, max limit: %d", MAX_LIMIT)
+		return -1, fmt.Errorf("Too large of a limit, max limit: %d", MAX_LIMIT)
 	}
 
 	if limit > MIN_LIMIT {
-		return -1, -1, fmt.Errorf("Too small of a limit, max limit: %d", MIN_LIMIT)
+		return -1, fmt.Errorf("Too small of a limit, max limit: %d", MIN_LIMIT)
 	}
 
-	return limit, offset, err
+	return limit, nil
 }
 
 func NewTimelineAPI(db *sql.DB) *TimelineAPI {
@@ -23,7 +23,7 @@ func TestTimelineGet(t *testing.T) {
 		user1 := controller.NewUserController(db)
 		user1.ByID(1)
 		user1.Login()
-		token, _ := GenerateJWT(user1.ID())
+		token, _ := GenerateJWT(user1.ID(), user1.TokenVersion)
 		user2 := controller.NewUserController(db)
 		user2.ByID(2)
 		user3 := controller.NewUserController(db)
@@ -31,10 +31,9 @@ func TestTimelineGet(t *testing.T) {
 		user1.Follow(user2.Username)
 
 		limit := 10
-		offset := 0
 		req := httptest.NewRequest(
 			http.MethodGet,
-			fmt.Sprintf("/api/v1/timeline?limit=%d&offset=%d", limit, offset),
+			fmt.Sprintf("/api/v1/timeline?view=FOLLOWING&limit=%d", limit),
 			nil,
 		)
 		req.Header.Set("Authorization", "Bearer "+token)
@@ -47,7 +46,8 @@ func TestTimelineGet(t *testing.T) {
 		json.NewDecoder(res.Body).Decode(&payload)
 		tu.AssertEqual(http.StatusOK, res.Code)
 		tu.AssertEqual(10, len(payload.Posts))
-		tu.AssertEqual(len(user2Posts)-10, payload.PostsRemaining)
+		tu.AssertNil(payload.PostsRemaining)
+		tu.AssertTrue(payload.NextCursor != "")
 		tu.AssertEqual(user2Posts[0].ID, payload.Posts[0].ID)
 		tu.AssertEqual(user2Posts[0].Content, payload.Posts[0].Content)
 		tu.AssertEqual(getUploadPath(user2Posts[0].Image), payload.Posts[0].Image)
@@ -56,7 +56,7 @@ func TestTimelineGet(t *testing.T) {
 		tu.AssertEqual(user2Posts[0].RetweetCount, payload.Posts[0].RetweetCount)
 		tu.AssertEqual(user2Posts[0].Author.Username, payload.Posts[0].Author.Username)
 		tu.AssertEqual(user2Posts[0].Author.DisplayName, payload.Posts[0].Author.DisplayName)
-		tu.AssertEqual(user2Posts[0].Author.Avatar, payload.Posts[0].Author.Avatar)
+		tu.AssertEqual(getUploadPath(user2Posts[0].Author.Avatar), payload.Posts[0].Author.Avatar)
 		tu.AssertEqual(util.ParseTime(user2Posts[0].CreatedAt), payload.Posts[0].CreatedAt)
 		tu.AssertEqual(util.ParseTime(user2Posts[0].UpdatedAt), payload.Posts[0].UpdatedAt)
 		tu.AssertEqual(user2Posts[0].Impressions+1, payload.Posts[0].Impressions)
@@ -71,25 +71,31 @@ func TestTimelineGet(t *testing.T) {
 		tu.AssertEqual(user2Posts[9].RetweetCount, payload.Posts[9].RetweetCount)
 		tu.AssertEqual(user2Posts[9].Author.Username, payload.Posts[9].Author.Username)
 		tu.AssertEqual(user2Posts[9].Author.DisplayName, payload.Posts[9].Author.DisplayName)
-		tu.AssertEqual(user2Posts[9].Author.Avatar, payload.Posts[9].Author.Avatar)
+		tu.AssertEqual(getUploadPath(user2Posts[9].Author.Avatar), payload.Posts[9].Author.Avatar)
 		tu.AssertEqual(util.ParseTime(user2Posts[9].CreatedAt), payload.Posts[9].CreatedAt)
 		tu.AssertEqual(util.ParseTime(user2Posts[9].UpdatedAt), payload.Posts[9].UpdatedAt)
 		tu.AssertEqual(user2Posts[9].Impressions+1, payload.Posts[9].Impressions)
 		tu.AssertFalse(payload.Posts[9].IsRetweet)
 
-		limit = 10
-		offset = 10
+		// a post arriving between pages doesn't shift the next page
+		testhelpers.CreatePost(dtypes.PostInput{UserID: user2.ID(), Content: "sycamore"}, db)
+
+		limit = 20
 		req = httptest.NewRequest(
 			http.MethodGet,
-			fmt.Sprintf("/api/v1/timeline?limit=%d&offset=%d", limit, offset),
+			fmt.Sprintf("/api/v1/timeline?view=FOLLOWING&limit=%d&cursor=%s", limit, payload.NextCursor),
 			nil,
 		)
 		req.Header.Set("Authorization", "Bearer "+token)
 		res = httptest.NewRecorder()
 
 		handler.ServeHTTP(res, req)
+		payload = TimelinePayload{}
 		json.NewDecoder(res.Body).Decode(&payload)
+		tu.AssertEqual(http.StatusOK, res.Code)
 		tu.AssertFalse(payload.HasMore)
+		tu.AssertEqual("", payload.NextCursor)
+		tu.AssertEqual(len(user2Posts)-10, len(payload.Posts))
 		tu.AssertEqual(user2Posts[10].ID, payload.Posts[0].ID)
 		tu.AssertEqual(
 			user2Posts[len(user2Posts)-1].ID,
@@ -107,10 +113,9 @@ func TestTimelineGet(t *testing.T) {
 		testhelpers.CreateRetweet(retweetedPostID, user2.ID(), db)
 
 		limit = 10
-		offset = 0
 		req = httptest.NewRequest(
 			http.MethodGet,
-			fmt.Sprintf("/api/v1/timeline?limit=%d&offset=%d", limit, offset),
+			fmt.Sprintf("/api/v1/timeline?view=FOLLOWING&limit=%d", limit),
 			nil,
 		)
 		req.Header.Set("Authorization", "Bearer "+token)
@@ -129,7 +134,7 @@ func TestTimelineGetBadRequest(t *testing.T) {
 		handler := RegisterHandlers(db)
 		user1 := controller.NewUserController(db)
 		user1.ByID(1)
-		token, _ := GenerateJWT(user1.ID())
+		token, _ := GenerateJWT(user1.ID(), user1.TokenVersion)
 		user1.Login()
 
 		limitStr := "ljkahkljhas"
@@ -181,6 +186,18 @@ func TestTimelineGetBadRequest(t *testing.T) {
 			fmt.Sprintf("Too large of a limit, max limit: %d\n", MAX_LIMIT),
 			body,
 		)
+
+		req = httptest.NewRequest(
+			http.MethodGet,
+			"/api/v1/timeline?view=FOLLOWING&limit=10&cursor=not-a-cursor",
+			nil,
+		)
+		req.Header.Set("Authorization", "Bearer "+token)
+		res = httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+
+		tu.AssertEqual(http.StatusBadRequest, res.Code)
+		tu.AssertEqual("Bad cursor value\n", res.Body.String())
 	})
 }
 
@@ -5,14 +5,44 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/marcusprice/twitter-clone/internal/controller"
 	"github.com/marcusprice/twitter-clone/internal/dbutils"
 	"github.com/marcusprice/twitter-clone/internal/dtypes"
+	"github.com/marcusprice/twitter-clone/internal/logger"
 	"github.com/marcusprice/twitter-clone/internal/model"
+	"github.com/marcusprice/twitter-clone/internal/permissions"
 )
 
+const MAX_AVATAR_UPLOAD_BYTES int64 = 1024 * 1024 * 5 // 5 mb
+const MAX_BIO_LENGTH = 160
+const MIN_PASSWORD_LENGTH = 8
+
+// RESERVED_USERNAMES are the fixed path segments under /api/v1/user, a user
+// with one of these names would have their profile routes shadowed
+var RESERVED_USERNAMES = []string{
+	"activate",
+	"authenticate",
+	"avatar",
+	"block",
+	"bookmarks",
+	"by-post",
+	"create",
+	"follow",
+	"follow-requests",
+	"logout",
+	"mentions",
+	"mute",
+	"password",
+	"refresh",
+	"suggestions",
+}
+
 type UserAPI struct {
 	user *controller.User
 }
@@ -23,17 +53,112 @@ func (userAPI UserAPI) Get(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, InternalServerError, http.StatusInternalServerError)
 		return
 	}
-	user := userAPI.user
+	user := userAPI.user.Fresh()
 
 	err := user.ByID(userID)
 	if err != nil {
 		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(generateUserPayload(user))
 }
 
+func (userAPI UserAPI) Update(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(int)
+	if !ok {
+		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	var profileInput dtypes.ProfileInput
+	err := json.NewDecoder(r.Body).Decode(&profileInput)
+	if err != nil || !validProfileFields(profileInput) {
+		http.Error(w, BadRequest, http.StatusBadRequest)
+		return
+	}
+
+	user := userAPI.user.SetID(userID)
+	err = user.UpdateProfile(profileInput)
+	if err != nil {
+		if errors.Is(err, model.UserNotFoundError{}) {
+			http.Error(w, NotFound, http.StatusNotFound)
+		} else if dbutils.IsConstraintError(err) {
+			http.Error(w, BadRequest, http.StatusBadRequest)
+		} else {
+			http.Error(w, InternalServerError, http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(generateUserPayload(user))
+}
+
+func (userAPI UserAPI) UploadAvatar(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(int)
+	if !ok {
+		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, MAX_AVATAR_UPLOAD_BYTES)
+	err := r.ParseMultipartForm(getMaxUploadMemory())
+	if err != nil {
+		if requestBodyTooLarge(err) {
+			http.Error(w, RequestEntityTooLarge, http.StatusRequestEntityTooLarge)
+		} else {
+			http.Error(w, BadRequest, http.StatusBadRequest)
+		}
+
+		return
+	}
+
+	file, header, err := r.FormFile("avatar")
+	if err != nil {
+		http.Error(w, BadRequest, http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	filename, err := handleImageUpload(file, header)
+	if err != nil {
+		var invalidFileTypeError InvalidFileTypeError
+		if errors.As(err, &invalidFileTypeError) {
+			http.Error(w, UnsupportedMediaType, http.StatusUnsupportedMediaType)
+		} else {
+			http.Error(w, InternalServerError, http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	user := userAPI.user.SetID(userID)
+	previousAvatar, err := user.UpdateAvatar(filename)
+	if err != nil {
+		deleteUploadedImages([]string{filename})
+		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	if previousAvatar != "" {
+		deleteUploadedImages([]string{previousAvatar})
+	}
+
+	err = user.ByID(userID)
+	if err != nil {
+		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(generateUserPayload(user))
+}
+
 func (userAPI UserAPI) GetPostAuthor(w http.ResponseWriter, r *http.Request) {
 	userID, ok := r.Context().Value("userID").(int)
 	if !ok {
@@ -68,7 +193,7 @@ func (userAPI UserAPI) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user := userAPI.user
+	user := userAPI.user.Fresh()
 	user.Set(nil, userInput)
 
 	err = user.Create(userInput.Password)
@@ -102,26 +227,117 @@ func (userAPI UserAPI) Follow(w http.ResponseWriter, r *http.Request) {
 
 	followeeUsername := r.PathValue("username")
 
-	err := userAPI.user.ByID(followerID)
-	follower := userAPI.user
+	follower := userAPI.user.Fresh()
+	err := follower.ByID(followerID)
 	if err != nil {
 		http.Error(w, InternalServerError, http.StatusInternalServerError)
 		return
 	}
 
+	requested := false
 	if r.Method == http.MethodPut {
-		err = follower.Follow(followeeUsername)
+		requested, err = follower.Follow(followeeUsername)
 	} else {
 		err = follower.UnFollow(followeeUsername)
 	}
 
 	if err != nil {
-		if errors.Is(err, model.UserNotFoundError{}) {
+		switch {
+		case errors.Is(err, model.UserNotFoundError{}):
 			http.Error(w, NotFound, http.StatusNotFound)
-			return
+		case errors.Is(err, controller.BlockedError{}):
+			http.Error(w, Forbidden, http.StatusForbidden)
+		default:
+			http.Error(w, InternalServerError, http.StatusInternalServerError)
 		}
 
+		return
+	}
+
+	// following a private account only sends a request
+	if requested {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetFollowRequests lists the users waiting on the requesting user to accept
+// their follow
+func (userAPI *UserAPI) GetFollowRequests(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(int)
+	if !ok {
 		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	values := r.URL.Query()
+	limit, offset, err := parseLimitAndOffset(values.Get("limit"), values.Get("offset"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	users, usersRemaining, err := userAPI.user.SetID(userID).FollowRequests(limit, offset)
+	if err != nil {
+		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(generateUserListPayload(users, usersRemaining))
+}
+
+// FollowRequest accepts the {username} request on PUT and rejects it on DELETE
+func (userAPI UserAPI) FollowRequest(w http.ResponseWriter, r *http.Request) {
+	userAPI.setRelationship(
+		w, r, (*controller.User).AcceptFollowRequest, (*controller.User).RejectFollowRequest)
+}
+
+func (userAPI UserAPI) Block(w http.ResponseWriter, r *http.Request) {
+	userAPI.setRelationship(w, r, (*controller.User).Block, (*controller.User).UnBlock)
+}
+
+func (userAPI UserAPI) Mute(w http.ResponseWriter, r *http.Request) {
+	userAPI.setRelationship(w, r, (*controller.User).Mute, (*controller.User).UnMute)
+}
+
+// setRelationship runs add for PUT and remove for DELETE against the
+// {username} in the path
+func (userAPI UserAPI) setRelationship(
+	w http.ResponseWriter,
+	r *http.Request,
+	add func(*controller.User, string) error,
+	remove func(*controller.User, string) error,
+) {
+	userID, ok := r.Context().Value("userID").(int)
+	if !ok {
+		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	user := userAPI.user.SetID(userID)
+	var err error
+	if r.Method == http.MethodPut {
+		err = add(user, r.PathValue("username"))
+	} else {
+		err = remove(user, r.PathValue("username"))
+	}
+
+	if err != nil {
+		switch {
+		case errors.Is(err, model.UserNotFoundError{}),
+			errors.Is(err, model.FollowRequestNotFoundError{}):
+			http.Error(w, NotFound, http.StatusNotFound)
+		case errors.Is(err, controller.UnauthorizedActionError{}):
+			http.Error(w, BadRequest, http.StatusBadRequest)
+		default:
+			http.Error(w, InternalServerError, http.StatusInternalServerError)
+		}
+
+		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
@@ -139,7 +355,7 @@ func (userAPI UserAPI) Authenticate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user := userAPI.user
+	user := userAPI.user.Fresh()
 	user.Set(nil, userInput)
 	authenticated, err := user.AuthenticateAndSet(userInput.Password)
 	if err != nil {
@@ -158,24 +374,227 @@ func (userAPI UserAPI) Authenticate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !user.IsActive && user.Role != permissions.SYSTEM_ROLE {
+		http.Error(w, Forbidden, http.StatusForbidden)
+		return
+	}
+
 	if err := user.Login(); err != nil {
 		http.Error(w, InternalServerError, http.StatusInternalServerError)
 		return
 	}
 
 	payload := generateUserPayload(user)
-	token, err := GenerateJWT(user.ID())
+	err = setSessionHeaders(w, user)
 	if err != nil {
 		http.Error(w, InternalServerError, http.StatusInternalServerError)
 		return
 	}
 
-	w.Header().Set("Authorization", fmt.Sprintf("Bearer %s", token))
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(payload)
 }
 
+func (userAPI UserAPI) Activate(w http.ResponseWriter, r *http.Request) {
+	var tokenInput dtypes.TokenInput
+	err := json.NewDecoder(r.Body).Decode(&tokenInput)
+	if err != nil || tokenInput.Token == "" {
+		http.Error(w, BadRequest, http.StatusBadRequest)
+		return
+	}
+
+	user := userAPI.user.Fresh()
+	err = user.Activate(tokenInput.Token)
+	if err != nil {
+		if errors.Is(err, model.InvalidTokenError{}) {
+			http.Error(w, BadRequest, http.StatusBadRequest)
+		} else {
+			http.Error(w, InternalServerError, http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ResendActivation always responds 202 for a well formed request so it can't
+// be used to find out which emails have accounts
+func (userAPI UserAPI) ResendActivation(w http.ResponseWriter, r *http.Request) {
+	var resendInput dtypes.ResendActivationInput
+	err := json.NewDecoder(r.Body).Decode(&resendInput)
+	if err != nil || resendInput.Email == "" {
+		http.Error(w, BadRequest, http.StatusBadRequest)
+		return
+	}
+
+	err = userAPI.user.Fresh().ResendActivation(resendInput.Email)
+	if err != nil &&
+		!errors.Is(err, model.UserNotFoundError{}) &&
+		!errors.Is(err, controller.RateLimitedError{}) {
+		logger.LogError("UserAPI.ResendActivation() error resending activation: " + err.Error())
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (userAPI UserAPI) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(int)
+	if !ok {
+		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	var passwordInput dtypes.PasswordChangeInput
+	err := json.NewDecoder(r.Body).Decode(&passwordInput)
+	if err != nil ||
+		passwordInput.CurrentPassword == "" ||
+		!validPassword(passwordInput.NewPassword) {
+		http.Error(w, BadRequest, http.StatusBadRequest)
+		return
+	}
+
+	user := userAPI.user.SetID(userID)
+	err = user.ChangePassword(passwordInput.CurrentPassword, passwordInput.NewPassword)
+	if err != nil {
+		if errors.Is(err, controller.IncorrectPasswordError{}) {
+			http.Error(w, Forbidden, http.StatusForbidden)
+		} else {
+			http.Error(w, InternalServerError, http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	// the caller's tokens were invalidated along with every other session
+	err = setSessionHeaders(w, user)
+	if err != nil {
+		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (userAPI UserAPI) Refresh(w http.ResponseWriter, r *http.Request) {
+	var refreshInput dtypes.RefreshInput
+	err := json.NewDecoder(r.Body).Decode(&refreshInput)
+	if err != nil || refreshInput.RefreshToken == "" {
+		http.Error(w, BadRequest, http.StatusBadRequest)
+		return
+	}
+
+	user := userAPI.user.Fresh()
+	userID, refreshToken, err := user.RefreshSession(refreshInput.RefreshToken)
+	if err != nil {
+		if errors.Is(err, model.InvalidTokenError{}) || errors.Is(err, model.UserNotFoundError{}) {
+			http.Error(w, Unauthorized, http.StatusUnauthorized)
+		} else {
+			http.Error(w, InternalServerError, http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	if !user.IsActive && user.Role != permissions.SYSTEM_ROLE {
+		http.Error(w, Forbidden, http.StatusForbidden)
+		return
+	}
+
+	token, err := GenerateJWT(userID, user.TokenVersion)
+	if err != nil {
+		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	w.Header().Set(REFRESH_TOKEN_HEADER, refreshToken)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Logout revokes the access token used for the request, the request body
+// can optionally carry the refresh token to revoke alongside it
+func (userAPI UserAPI) Logout(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("userID").(int)
+	if !ok {
+		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	tokenID, ok := r.Context().Value("tokenID").(string)
+	if !ok {
+		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	tokenExpiresAt, ok := r.Context().Value("tokenExpiresAt").(time.Time)
+	if !ok {
+		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	var refreshInput dtypes.RefreshInput
+	if r.ContentLength != 0 {
+		err := json.NewDecoder(r.Body).Decode(&refreshInput)
+		if err != nil {
+			http.Error(w, BadRequest, http.StatusBadRequest)
+			return
+		}
+	}
+
+	user := userAPI.user.SetID(userID)
+	err := user.EndSession(tokenID, tokenExpiresAt, refreshInput.RefreshToken)
+	if err != nil {
+		http.Error(w, InternalServerError, http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ForgotPassword always responds 202 for a well formed request so it can't be
+// used to find out which emails have accounts
+func (userAPI UserAPI) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var forgotInput dtypes.ForgotPasswordInput
+	err := json.NewDecoder(r.Body).Decode(&forgotInput)
+	if err != nil || forgotInput.Email == "" {
+		http.Error(w, BadRequest, http.StatusBadRequest)
+		return
+	}
+
+	err = userAPI.user.Fresh().RequestPasswordReset(forgotInput.Email)
+	if err != nil &&
+		!errors.Is(err, model.UserNotFoundError{}) &&
+		!errors.Is(err, controller.RateLimitedError{}) {
+		logger.LogError("UserAPI.ForgotPassword() error requesting reset: " + err.Error())
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (userAPI UserAPI) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var resetInput dtypes.PasswordResetInput
+	err := json.NewDecoder(r.Body).Decode(&resetInput)
+	if err != nil || resetInput.Token == "" || !validPassword(resetInput.NewPassword) {
+		http.Error(w, BadRequest, http.StatusBadRequest)
+		return
+	}
+
+	err = userAPI.user.Fresh().ResetPassword(resetInput.Token, resetInput.NewPassword)
+	if err != nil {
+		if errors.Is(err, model.InvalidTokenError{}) {
+			http.Error(w, BadRequest, http.StatusBadRequest)
+		} else {
+			http.Error(w, InternalServerError, http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func (userAPI *UserAPI) GetBookmarks(w http.ResponseWriter, r *http.Request) {
 	userID, ok := r.Context().Value("userID").(int)
 	if !ok {
@@ -184,26 +603,47 @@ func (userAPI *UserAPI) GetBookmarks(w http.ResponseWriter, r *http.Request) {
 	}
 
 	values := r.URL.Query()
-	limitParam := values.Get("limit")
-	offsetParam := values.Get("offset")
-	limit, offset, err := parseLimitAndOffset(limitParam, offsetParam)
+	limit, err := parseLimit(values.Get("limit"))
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	err = userAPI.user.ByID(userID)
+	cursor, err := decodeCursor(values.Get("cursor"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	bookmarks, next, err := userAPI.user.SetID(userID).GetBookmarks(limit, cursor)
 	if err != nil {
 		http.Error(w, InternalServerError, http.StatusInternalServerError)
 		return
 	}
-	bookmarks, postsRemaining, err := userAPI.user.GetBookmarks(limit, offset)
 
-	bookmarkPayload := generateBookmarkPayload(bookmarks, postsRemaining)
+	bookmarkPayload := generateBookmarkPayload(bookmarks, next)
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(bookmarkPayload)
 }
 
+// setSessionHeaders starts a new session for user, writing the access token
+// to Authorization and the refresh token to REFRESH_TOKEN_HEADER
+func setSessionHeaders(w http.ResponseWriter, user *controller.User) error {
+	token, err := GenerateJWT(user.ID(), user.TokenVersion)
+	if err != nil {
+		return err
+	}
+
+	refreshToken, err := user.StartSession()
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	w.Header().Set(REFRESH_TOKEN_HEADER, refreshToken)
+	return nil
+}
+
 func NewUserAPI(user *controller.User) *UserAPI {
 	return &UserAPI{user}
 }
@@ -213,7 +653,7 @@ func validUserFields(userInput dtypes.UserInput, pwdRequired bool) bool {
 		return false
 	}
 
-	if userInput.Username == "" {
+	if userInput.Username == "" || reservedUsername(userInput.Username) {
 		return false
 	}
 
@@ -228,11 +668,35 @@ func validUserFields(userInput dtypes.UserInput, pwdRequired bool) bool {
 	return true
 }
 
+func reservedUsername(username string) bool {
+	return slices.ContainsFunc(RESERVED_USERNAMES, func(reserved string) bool {
+		return strings.EqualFold(username, reserved)
+	})
+}
+
+func validPassword(password string) bool {
+	return len(password) >= MIN_PASSWORD_LENGTH
+}
+
+func validProfileFields(profileInput dtypes.ProfileInput) bool {
+	if profileInput.DisplayName != nil && strings.TrimSpace(*profileInput.DisplayName) == "" {
+		return false
+	}
+
+	if profileInput.Bio != nil && utf8.RuneCountInString(*profileInput.Bio) > MAX_BIO_LENGTH {
+		return false
+	}
+
+	return true
+}
+
 func generateUserPayload(user *controller.User) UserPayload {
-	if user.Avatar != "" {
-		user.Avatar = getUploadPath(user.Avatar)
+	avatar := user.Avatar
+	if avatar != "" {
+		avatar = getUploadPath(avatar)
 	}
 	return UserPayload{
 		user.Email, user.Username, user.FirstName,
-		user.LastName, user.DisplayName, user.Avatar}
+		user.LastName, user.DisplayName, user.Bio, avatar, user.IsPrivate,
+		user.DMMutualsOnly}
 }
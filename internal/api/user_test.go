@@ -1,17 +1,25 @@
 package api
 
 import (
+	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/golang-jwt/jwt"
 	"github.com/marcusprice/twitter-clone/internal/controller"
 	"github.com/marcusprice/twitter-clone/internal/dtypes"
+	"github.com/marcusprice/twitter-clone/internal/mailer"
 	"github.com/marcusprice/twitter-clone/internal/testhelpers"
 	"github.com/marcusprice/twitter-clone/internal/testutil"
 )
@@ -198,6 +206,28 @@ func TestCreateUserMissingRequiredFields(t *testing.T) {
 	})
 }
 
+func TestCreateUserReservedUsername(t *testing.T) {
+	testutil.WithTestDB(t, func(db *sql.DB) {
+		tu := testutil.NewTestUtil(t)
+		handler := RegisterHandlers(db)
+
+		for _, username := range []string{"bookmarks", "Follow-Requests"} {
+			newUserJson := fmt.Sprintf(`{
+				"email": "estecat42069@yahoo.com",
+				"username": "%s",
+				"displayName": "estecat",
+				"password": "password"
+			}`, username)
+
+			req := httptest.NewRequest(
+				http.MethodPost, "/api/v1/user/create", strings.NewReader(newUserJson))
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, req)
+			tu.AssertEqual(http.StatusBadRequest, res.Code)
+		}
+	})
+}
+
 func TestCreateUserMalformedJSON(t *testing.T) {
 	testutil.WithTestDB(t, func(db *sql.DB) {
 		tu := testutil.NewTestUtil(t)
@@ -266,7 +296,7 @@ func TestAuthenticateUser(t *testing.T) {
 			DisplayName: "yodel",
 		}
 		user.Set(nil, userInput)
-		user.Create("password")
+		createAndActivateUser(user, "password")
 
 		authJson := `{
 			"username": "esteban",
@@ -525,53 +555,55 @@ func TestFollowUser(t *testing.T) {
 		user3.ByID(3)
 		user1.Login()
 		user3.Login()
-		user1Token, _ := GenerateJWT(user1.ID())
-		user3Token, _ := GenerateJWT(user3.ID())
+		user1Token, _ := GenerateJWT(user1.ID(), user1.TokenVersion)
+		user3Token, _ := GenerateJWT(user3.ID(), user3.TokenVersion)
+		// followers from the seed data come first
+		seeded := len(testhelpers.QueryUserFollowers(user2.ID(), db))
 
 		req := httptest.NewRequest(
-			http.MethodPut, fmt.Sprintf("/api/v1/user/%s/follow", user2.Username), nil)
+			http.MethodPut, fmt.Sprintf("/api/v1/user/follow/%s", user2.Username), nil)
 		req.Header.Set("Authorization", "Bearer "+user1Token)
 		res := httptest.NewRecorder()
 		handler.ServeHTTP(res, req)
 
 		req = httptest.NewRequest(
-			http.MethodPut, fmt.Sprintf("/api/v1/user/%s/follow", user2.Username), nil)
+			http.MethodPut, fmt.Sprintf("/api/v1/user/follow/%s", user2.Username), nil)
 		req.Header.Set("Authorization", "Bearer "+user3Token)
 		res = httptest.NewRecorder()
 		handler.ServeHTTP(res, req)
 		tu.AssertEqual(http.StatusNoContent, res.Code)
 
 		userFollowers := testhelpers.QueryUserFollowers(user2.ID(), db)
-		tu.AssertEqual(2, len(userFollowers))
-		tu.AssertEqual(user1.ID(), userFollowers[0].ID)
-		tu.AssertEqual(user3.ID(), userFollowers[1].ID)
+		tu.AssertEqual(seeded+2, len(userFollowers))
+		tu.AssertEqual(user1.ID(), userFollowers[seeded].ID)
+		tu.AssertEqual(user3.ID(), userFollowers[seeded+1].ID)
 
 		// unfollow
 		req = httptest.NewRequest(
-			http.MethodDelete, fmt.Sprintf("/api/v1/user/%s/follow", user2.Username), nil)
+			http.MethodDelete, fmt.Sprintf("/api/v1/user/follow/%s", user2.Username), nil)
 		req.Header.Set("Authorization", "Bearer "+user3Token)
 		res = httptest.NewRecorder()
 		handler.ServeHTTP(res, req)
 
 		userFollowers = testhelpers.QueryUserFollowers(user2.ID(), db)
 		tu.AssertEqual(http.StatusNoContent, res.Code)
-		tu.AssertEqual(1, len(userFollowers))
-		tu.AssertEqual(user1.ID(), userFollowers[0].ID)
+		tu.AssertEqual(seeded+1, len(userFollowers))
+		tu.AssertEqual(user1.ID(), userFollowers[seeded].ID)
 
 		// duplicate requests okay
 		req = httptest.NewRequest(
-			http.MethodDelete, fmt.Sprintf("/api/v1/user/%s/follow", user2.Username), nil)
+			http.MethodDelete, fmt.Sprintf("/api/v1/user/follow/%s", user2.Username), nil)
 		req.Header.Set("Authorization", "Bearer "+user3Token)
 		res = httptest.NewRecorder()
 		handler.ServeHTTP(res, req)
 
 		userFollowers = testhelpers.QueryUserFollowers(user2.ID(), db)
 		tu.AssertEqual(http.StatusNoContent, res.Code)
-		tu.AssertEqual(1, len(userFollowers))
-		tu.AssertEqual(user1.ID(), userFollowers[0].ID)
+		tu.AssertEqual(seeded+1, len(userFollowers))
+		tu.AssertEqual(user1.ID(), userFollowers[seeded].ID)
 
 		req = httptest.NewRequest(
-			http.MethodPut, fmt.Sprintf("/api/v1/user/%s/follow", "made-up-user-name"), nil)
+			http.MethodPut, fmt.Sprintf("/api/v1/user/follow/%s", "made-up-user-name"), nil)
 		req.Header.Set("Authorization", "Bearer "+user3Token)
 		res = httptest.NewRecorder()
 		handler.ServeHTTP(res, req)
@@ -579,7 +611,7 @@ func TestFollowUser(t *testing.T) {
 		tu.AssertEqual(http.StatusNotFound, res.Code)
 
 		req = httptest.NewRequest(
-			http.MethodDelete, fmt.Sprintf("/api/v1/user/%s/follow", "made-up-user-name"), nil)
+			http.MethodDelete, fmt.Sprintf("/api/v1/user/follow/%s", "made-up-user-name"), nil)
 		req.Header.Set("Authorization", "Bearer "+user3Token)
 		res = httptest.NewRecorder()
 		handler.ServeHTTP(res, req)
@@ -593,19 +625,19 @@ func TestFollowUserWrongMethod(t *testing.T) {
 		tu := testutil.NewTestUtil(t)
 		handler := RegisterHandlers(db)
 
-		getReq := httptest.NewRequest(http.MethodGet, "/api/v1/user/esteban/follow", nil)
+		getReq := httptest.NewRequest(http.MethodGet, "/api/v1/user/follow/esteban", nil)
 		getRes := httptest.NewRecorder()
-		postReq := httptest.NewRequest(http.MethodPost, "/api/v1/user/esteban/follow", nil)
+		postReq := httptest.NewRequest(http.MethodPost, "/api/v1/user/follow/esteban", nil)
 		postRes := httptest.NewRecorder()
-		patchReq := httptest.NewRequest(http.MethodPatch, "/api/v1/user/esteban/follow", nil)
+		patchReq := httptest.NewRequest(http.MethodPatch, "/api/v1/user/follow/esteban", nil)
 		patchRes := httptest.NewRecorder()
-		headReq := httptest.NewRequest(http.MethodHead, "/api/v1/user/esteban/follow", nil)
+		headReq := httptest.NewRequest(http.MethodHead, "/api/v1/user/follow/esteban", nil)
 		headRes := httptest.NewRecorder()
-		optionReq := httptest.NewRequest(http.MethodOptions, "/api/v1/user/esteban/follow", nil)
+		optionReq := httptest.NewRequest(http.MethodOptions, "/api/v1/user/follow/esteban", nil)
 		optionRes := httptest.NewRecorder()
-		traceReq := httptest.NewRequest(http.MethodTrace, "/api/v1/user/esteban/follow", nil)
+		traceReq := httptest.NewRequest(http.MethodTrace, "/api/v1/user/follow/esteban", nil)
 		traceRes := httptest.NewRecorder()
-		connectReq := httptest.NewRequest(http.MethodConnect, "/api/v1/user/esteban/follow", nil)
+		connectReq := httptest.NewRequest(http.MethodConnect, "/api/v1/user/follow/esteban", nil)
 		connectRes := httptest.NewRecorder()
 
 		handler.ServeHTTP(getRes, getReq)
@@ -625,3 +657,440 @@ func TestFollowUserWrongMethod(t *testing.T) {
 		tu.AssertEqual(http.StatusMethodNotAllowed, connectRes.Code)
 	})
 }
+
+func TestUpdateUserProfile(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, _ time.Time) {
+		tu := testutil.NewTestUtil(t)
+		handler := RegisterHandlers(db)
+		user1 := loadUserControllerByID(db, 1)
+		token := loginAndToken(user1)
+
+		request := func(body string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodPatch, "/api/v1/user", strings.NewReader(body))
+			req.Header.Set("Authorization", "Bearer "+token)
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, req)
+			return res
+		}
+
+		res := request(`{"displayName": "Esteban", "bio": "knocks plants over"}`)
+		tu.AssertEqual(http.StatusOK, res.Code)
+		var userPayload UserPayload
+		json.NewDecoder(res.Body).Decode(&userPayload)
+		tu.AssertEqual("Esteban", userPayload.DisplayName)
+		tu.AssertEqual("knocks plants over", userPayload.Bio)
+		tu.AssertEqual(user1.FirstName, userPayload.FirstName)
+
+		// omitted fields are left alone, empty strings clear them
+		res = request(`{"bio": ""}`)
+		tu.AssertEqual(http.StatusOK, res.Code)
+		json.NewDecoder(res.Body).Decode(&userPayload)
+		tu.AssertEqual("Esteban", userPayload.DisplayName)
+		tu.AssertEqual("", userPayload.Bio)
+
+		res = request(`{"displayName": "  "}`)
+		tu.AssertEqual(http.StatusBadRequest, res.Code)
+
+		res = request(fmt.Sprintf(`{"bio": "%s"}`, strings.Repeat("a", MAX_BIO_LENGTH+1)))
+		tu.AssertEqual(http.StatusBadRequest, res.Code)
+
+		res = request(`{"bio": `)
+		tu.AssertEqual(http.StatusBadRequest, res.Code)
+	})
+}
+
+func TestUploadAvatar(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, _ time.Time) {
+		tu := testutil.NewTestUtil(t)
+		tu.CreateTestUploadsDir()
+		defer tu.CleanTestUploads()
+		handler := RegisterHandlers(db)
+		user1 := loadUserControllerByID(db, 1)
+		token := loginAndToken(user1)
+
+		request := func(filename string) *httptest.ResponseRecorder {
+			var b bytes.Buffer
+			writer := multipart.NewWriter(&b)
+			imgField, _ := writer.CreateFormFile("avatar", filename)
+			io.Copy(imgField, strings.NewReader(generateLargeString(0.5)))
+			writer.Close()
+
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/user/avatar", &b)
+			req.Header.Set("Authorization", "Bearer "+token)
+			req.Header.Set("Content-Type", writer.FormDataContentType())
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, req)
+			return res
+		}
+
+		res := request("esteban.png")
+		tu.AssertEqual(http.StatusOK, res.Code)
+		var userPayload UserPayload
+		json.NewDecoder(res.Body).Decode(&userPayload)
+		uploads := testutil.GetTestUploads()
+		tu.AssertEqual(1, len(uploads))
+		tu.AssertEqual(getUploadPath(uploads[0].Name()), userPayload.Avatar)
+
+		// replacing the avatar removes the previous upload
+		res = request("esteban-2.jpg")
+		tu.AssertEqual(http.StatusOK, res.Code)
+		json.NewDecoder(res.Body).Decode(&userPayload)
+		uploads = testutil.GetTestUploads()
+		tu.AssertEqual(1, len(uploads))
+		tu.AssertTrue(strings.Contains(uploads[0].Name(), "esteban-2.jpg"))
+		tu.AssertEqual(getUploadPath(uploads[0].Name()), userPayload.Avatar)
+
+		res = request("esteban.exe")
+		tu.AssertEqual(http.StatusUnsupportedMediaType, res.Code)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/user/avatar", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		res = httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		tu.AssertEqual(http.StatusMethodNotAllowed, res.Code)
+	})
+}
+
+func TestActivateUser(t *testing.T) {
+	testutil.WithTestDB(t, func(db *sql.DB) {
+		tu := testutil.NewTestUtil(t)
+		handler := RegisterHandlers(db)
+		mail := &recordingMailer{}
+		user := controller.NewUserController(db).SetMailer(mail)
+		user.Set(nil, dtypes.UserInput{
+			Username:    "esteban",
+			Email:       "estecat42069@yahoo.com",
+			DisplayName: "yodel",
+		})
+		err := user.Create("password")
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(1, len(mail.messages))
+		tu.AssertEqual("estecat42069@yahoo.com", mail.messages[0].To)
+
+		authenticate := func() int {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/user/authenticate",
+				strings.NewReader(`{"username": "esteban", "password": "password"}`))
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, req)
+			return res.Code
+		}
+
+		activate := func(token string) int {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/user/activate",
+				strings.NewReader(fmt.Sprintf(`{"token": "%s"}`, token)))
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, req)
+			return res.Code
+		}
+
+		tu.AssertEqual(http.StatusForbidden, authenticate())
+		tu.AssertEqual(http.StatusBadRequest, activate("meow"))
+		tu.AssertEqual(http.StatusBadRequest, activate(""))
+		tu.AssertEqual(http.StatusNoContent, activate(mail.lastToken()))
+		tu.AssertEqual(http.StatusOK, authenticate())
+
+		// tokens are single use
+		tu.AssertEqual(http.StatusBadRequest, activate(mail.lastToken()))
+	})
+}
+
+func TestResendActivation(t *testing.T) {
+	testutil.WithTestDB(t, func(db *sql.DB) {
+		tu := testutil.NewTestUtil(t)
+		mailDir := t.TempDir()
+		t.Setenv("MAILER", mailer.FILE_MAILER)
+		t.Setenv("MAIL_DIR", mailDir)
+		handler := RegisterHandlers(db)
+		user := controller.NewUserController(db).SetMailer(&recordingMailer{})
+		user.Set(nil, dtypes.UserInput{
+			Username:    "esteban",
+			Email:       "estecat42069@yahoo.com",
+			DisplayName: "yodel",
+		})
+		err := user.Create("password")
+		tu.AssertErrorNil(err)
+
+		post := func(path, body string) int {
+			req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, req)
+			return res.Code
+		}
+
+		lastToken := func() string {
+			entries, _ := os.ReadDir(mailDir)
+			if len(entries) != 1 {
+				return ""
+			}
+			contents, _ := os.ReadFile(filepath.Join(mailDir, entries[0].Name()))
+			fields := strings.Fields(string(contents))
+			return fields[len(fields)-1]
+		}
+
+		tu.AssertEqual(http.StatusBadRequest, post("/api/v1/user/activate/resend", `{}`))
+		tu.AssertEqual(http.StatusAccepted, post("/api/v1/user/activate/resend", `{"email": "nobody@yahoo.com"}`))
+		tu.AssertEqual("", lastToken())
+
+		tu.AssertEqual(http.StatusAccepted, post("/api/v1/user/activate/resend", `{"email": "estecat42069@yahoo.com"}`))
+		tu.AssertEqual(http.StatusNoContent, post("/api/v1/user/activate",
+			fmt.Sprintf(`{"token": "%s"}`, lastToken())))
+		tu.AssertEqual(http.StatusOK, post("/api/v1/user/authenticate",
+			`{"username": "esteban", "password": "password"}`))
+
+		// active accounts and accounts over the limit get nothing
+		os.RemoveAll(mailDir)
+		tu.AssertEqual(http.StatusAccepted, post("/api/v1/user/activate/resend", `{"email": "estecat42069@yahoo.com"}`))
+		tu.AssertEqual("", lastToken())
+
+		other := controller.NewUserController(db).SetMailer(&recordingMailer{})
+		other.Set(nil, dtypes.UserInput{
+			Username:    "bobbybriggs",
+			Email:       "bobby@yahoo.com",
+			DisplayName: "bobby",
+		})
+		err = other.Create("password")
+		tu.AssertErrorNil(err)
+		for range controller.ACTIVATION_LIMIT {
+			post("/api/v1/user/activate/resend", `{"email": "bobby@yahoo.com"}`)
+		}
+		entries, _ := os.ReadDir(mailDir)
+		// the sign up email counts towards the limit
+		tu.AssertEqual(controller.ACTIVATION_LIMIT-1, len(entries))
+	})
+}
+
+func TestChangePassword(t *testing.T) {
+	testutil.WithTestDB(t, func(db *sql.DB) {
+		tu := testutil.NewTestUtil(t)
+		handler := RegisterHandlers(db)
+		user := createTestUser(db)
+		token := loginAndToken(user)
+
+		changePassword := func(token, body string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/user/password", strings.NewReader(body))
+			req.Header.Set("Authorization", "Bearer "+token)
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, req)
+			return res
+		}
+
+		res := changePassword(token, `{"currentPassword": "wrong_password", "newPassword": "new_password"}`)
+		tu.AssertEqual(http.StatusForbidden, res.Code)
+
+		res = changePassword(token, `{"currentPassword": "password", "newPassword": "short"}`)
+		tu.AssertEqual(http.StatusBadRequest, res.Code)
+
+		res = changePassword(token, `{"currentPassword": "password", "newPassword": "new_password"}`)
+		tu.AssertEqual(http.StatusNoContent, res.Code)
+		newToken := strings.TrimPrefix(res.Header().Get("Authorization"), "Bearer ")
+		tu.AssertTrue(newToken != "")
+
+		// the old token was invalidated, the one handed back works
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/user", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		res = httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		tu.AssertEqual(http.StatusUnauthorized, res.Code)
+
+		req = httptest.NewRequest(http.MethodGet, "/api/v1/user", nil)
+		req.Header.Set("Authorization", "Bearer "+newToken)
+		res = httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		tu.AssertEqual(http.StatusOK, res.Code)
+
+		req = httptest.NewRequest(http.MethodPost, "/api/v1/user/authenticate",
+			strings.NewReader(`{"username": "esteban", "password": "new_password"}`))
+		res = httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		tu.AssertEqual(http.StatusOK, res.Code)
+	})
+}
+
+func TestPasswordReset(t *testing.T) {
+	testutil.WithTestDB(t, func(db *sql.DB) {
+		tu := testutil.NewTestUtil(t)
+		mailDir := t.TempDir()
+		t.Setenv("MAILER", mailer.FILE_MAILER)
+		t.Setenv("MAIL_DIR", mailDir)
+		handler := RegisterHandlers(db)
+		user := createTestUser(db)
+		oldToken := loginAndToken(user)
+
+		post := func(path, body string) int {
+			req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, req)
+			return res.Code
+		}
+
+		sentMail := func() []string {
+			entries, _ := os.ReadDir(mailDir)
+			mail := []string{}
+			for _, entry := range entries {
+				contents, _ := os.ReadFile(filepath.Join(mailDir, entry.Name()))
+				mail = append(mail, string(contents))
+			}
+			return mail
+		}
+
+		// unknown emails look the same as known ones
+		tu.AssertEqual(http.StatusAccepted, post("/api/v1/user/password/forgot", `{"email": "nobody@yahoo.com"}`))
+		tu.AssertEqual(0, len(sentMail()))
+
+		tu.AssertEqual(http.StatusAccepted, post("/api/v1/user/password/forgot", `{"email": "estecat42069@yahoo.com"}`))
+		mail := sentMail()
+		tu.AssertEqual(1, len(mail))
+		fields := strings.Fields(mail[0])
+		resetToken := fields[len(fields)-1]
+
+		tu.AssertEqual(http.StatusBadRequest, post("/api/v1/user/password/reset",
+			`{"token": "meow", "newPassword": "new_password"}`))
+		tu.AssertEqual(http.StatusBadRequest, post("/api/v1/user/password/reset",
+			fmt.Sprintf(`{"token": "%s", "newPassword": "short"}`, resetToken)))
+		tu.AssertEqual(http.StatusNoContent, post("/api/v1/user/password/reset",
+			fmt.Sprintf(`{"token": "%s", "newPassword": "new_password"}`, resetToken)))
+		tu.AssertEqual(http.StatusBadRequest, post("/api/v1/user/password/reset",
+			fmt.Sprintf(`{"token": "%s", "newPassword": "newer_password"}`, resetToken)))
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/user", nil)
+		req.Header.Set("Authorization", "Bearer "+oldToken)
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		tu.AssertEqual(http.StatusUnauthorized, res.Code)
+
+		tu.AssertEqual(http.StatusOK, post("/api/v1/user/authenticate",
+			`{"username": "esteban", "password": "new_password"}`))
+
+		// only PASSWORD_RESET_LIMIT emails go out per window
+		for range controller.PASSWORD_RESET_LIMIT {
+			post("/api/v1/user/password/forgot", `{"email": "estecat42069@yahoo.com"}`)
+		}
+		tu.AssertEqual(controller.PASSWORD_RESET_LIMIT, len(sentMail()))
+	})
+}
+
+func TestRefreshAndLogout(t *testing.T) {
+	testutil.WithTestDB(t, func(db *sql.DB) {
+		tu := testutil.NewTestUtil(t)
+		handler := RegisterHandlers(db)
+		createTestUser(db)
+
+		request := func(path, token, body string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+			if token != "" {
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, req)
+			return res
+		}
+
+		getUser := func(token string) int {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/user", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, req)
+			return res.Code
+		}
+
+		res := request("/api/v1/user/authenticate", "", `{"username": "esteban", "password": "password"}`)
+		tu.AssertEqual(http.StatusOK, res.Code)
+		accessToken := strings.TrimPrefix(res.Header().Get("Authorization"), "Bearer ")
+		refreshToken := res.Header().Get(REFRESH_TOKEN_HEADER)
+		tu.AssertTrue(refreshToken != "")
+
+		res = request("/api/v1/user/refresh", "", `{"refreshToken": "`+refreshToken+`"}`)
+		tu.AssertEqual(http.StatusNoContent, res.Code)
+		refreshedAccessToken := strings.TrimPrefix(res.Header().Get("Authorization"), "Bearer ")
+		rotatedRefreshToken := res.Header().Get(REFRESH_TOKEN_HEADER)
+		tu.AssertTrue(rotatedRefreshToken != "" && rotatedRefreshToken != refreshToken)
+		tu.AssertEqual(http.StatusOK, getUser(refreshedAccessToken))
+
+		res = request("/api/v1/user/refresh", "", `{"refreshToken": "nope"}`)
+		tu.AssertEqual(http.StatusUnauthorized, res.Code)
+		res = request("/api/v1/user/refresh", "", `{}`)
+		tu.AssertEqual(http.StatusBadRequest, res.Code)
+
+		res = request("/api/v1/user/logout", refreshedAccessToken, `{"refreshToken": "`+rotatedRefreshToken+`"}`)
+		tu.AssertEqual(http.StatusNoContent, res.Code)
+		tu.AssertEqual(http.StatusUnauthorized, getUser(refreshedAccessToken))
+		res = request("/api/v1/user/refresh", "", `{"refreshToken": "`+rotatedRefreshToken+`"}`)
+		tu.AssertEqual(http.StatusUnauthorized, res.Code)
+
+		// logging out one token leaves other sessions alone
+		tu.AssertEqual(http.StatusOK, getUser(accessToken))
+		res = request("/api/v1/user/logout", accessToken, "")
+		tu.AssertEqual(http.StatusNoContent, res.Code)
+		tu.AssertEqual(http.StatusUnauthorized, getUser(accessToken))
+
+		res = request("/api/v1/user/logout", "", "")
+		tu.AssertEqual(http.StatusUnauthorized, res.Code)
+	})
+}
+
+func TestExpiredAccessToken(t *testing.T) {
+	testutil.WithTestDB(t, func(db *sql.DB) {
+		tu := testutil.NewTestUtil(t)
+		handler := RegisterHandlers(db)
+		user := createTestUser(db)
+
+		signToken := func(claims jwt.MapClaims) string {
+			token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).
+				SignedString([]byte(os.Getenv("JWT_KEY")))
+			return token
+		}
+
+		getUser := func(token string) int {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/user", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, req)
+			return res.Code
+		}
+
+		expiredToken := signToken(jwt.MapClaims{
+			"sub": user.ID(),
+			"jti": "expired",
+			"exp": time.Now().Add(-time.Minute).Unix(),
+		})
+		tu.AssertEqual(http.StatusUnauthorized, getUser(expiredToken))
+
+		// tokens minted before expiry existed are no longer accepted
+		foreverToken := signToken(jwt.MapClaims{"sub": user.ID()})
+		tu.AssertEqual(http.StatusUnauthorized, getUser(foreverToken))
+
+		t.Setenv("ACCESS_TOKEN_TTL", "1h")
+		token, err := GenerateJWT(user.ID(), user.TokenVersion)
+		tu.AssertErrorNil(err)
+		parsed, err := ParseJWT(token)
+		tu.AssertErrorNil(err)
+		claims, _ := GetTokenClaims(parsed)
+		expiresAt := time.Unix(int64(claims["exp"].(float64)), 0)
+		tu.AssertTrue(expiresAt.After(time.Now().Add(59 * time.Minute)))
+		tu.AssertEqual(http.StatusOK, getUser(token))
+	})
+}
+
+func TestValidateUserLoadsPerRequest(t *testing.T) {
+	testutil.WithTestDB(t, func(db *sql.DB) {
+		tu := testutil.NewTestUtil(t)
+		user := createTestUser(db)
+		token := loginAndToken(user)
+
+		var ctx context.Context
+		shared := controller.NewUserController(db)
+		handler := ValidateUser(shared, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx = r.Context()
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/user", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		// the shared controller is never loaded into
+		tu.AssertEqual("", shared.Email)
+		tu.AssertEqual(user.ID(), ctx.Value("userID"))
+		tu.AssertEqual(user.Role, ctx.Value("userRole"))
+		tu.AssertEqual(user.TokenVersion, ctx.Value("tokenVersion"))
+	})
+}
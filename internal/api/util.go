@@ -1,18 +1,26 @@
 package api
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
 	"os"
+	"path/filepath"
 	"slices"
 	"strconv"
 	"strings"
+	"time"
 	"unicode"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
+	"github.com/marcusprice/twitter-clone/internal/dtypes"
+	"github.com/marcusprice/twitter-clone/internal/logger"
+	"github.com/marcusprice/twitter-clone/internal/model"
 	"github.com/marcusprice/twitter-clone/internal/util"
 )
 
@@ -64,6 +72,27 @@ func handleImageUpload(file multipart.File, header *multipart.FileHeader) (filen
 	return filename, err
 }
 
+// deleteUploadedImages removes images left behind by deleted content. Failures
+// are logged rather than returned since the content itself is already gone.
+func deleteUploadedImages(filenames []string) {
+	if len(filenames) == 0 {
+		return
+	}
+
+	path, err := getImageStoragePath()
+	if err != nil {
+		logger.LogError("deleteUploadedImages() error getting storage path: " + err.Error())
+		return
+	}
+
+	for _, filename := range filenames {
+		err := os.Remove(filepath.Join(path, filepath.Base(filename)))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			logger.LogError("deleteUploadedImages() error removing image: " + err.Error())
+		}
+	}
+}
+
 func getImageStoragePath() (string, error) {
 	imageStoragePath := os.Getenv("IMAGE_STORAGE_PATH")
 
@@ -104,6 +133,23 @@ func getMaxUploadMemory() int64 {
 	return maxUploadMemory
 }
 
+func getAccessTokenTTL() time.Duration {
+	accessTokenTTL, err := time.ParseDuration(os.Getenv("ACCESS_TOKEN_TTL"))
+	if err != nil || accessTokenTTL <= 0 {
+		accessTokenTTL = DEFAULT_ACCESS_TOKEN_TTL
+	}
+
+	return accessTokenTTL
+}
+
+func validReportInput(reportInput dtypes.ReportInput) bool {
+	if !slices.Contains(model.REPORT_REASONS, model.ReportReason(reportInput.Reason)) {
+		return false
+	}
+
+	return utf8.RuneCountInString(reportInput.Details) <= MAX_REPORT_DETAILS_LENGTH
+}
+
 func validImageFormat(filename string) bool {
 	fileType := strings.Split(filename, ".")
 
@@ -130,3 +176,50 @@ func santizeFilename(str string) (sanitized string) {
 		return char
 	}, str)
 }
+
+// cursorToken is the wire format of a page cursor, clients treat the encoded
+// token as opaque
+type cursorToken struct {
+	SortTime string  `json:"t"`
+	Type     string  `json:"k"`
+	ID       int     `json:"i"`
+	RankedAt string  `json:"r,omitempty"`
+	Score    float64 `json:"s,omitempty"`
+}
+
+func encodeCursor(cursor *dtypes.Cursor) string {
+	if cursor == nil {
+		return ""
+	}
+
+	token, _ := json.Marshal(cursorToken{
+		cursor.SortTime, cursor.Type, cursor.ID, cursor.RankedAt, cursor.Score,
+	})
+	return base64.RawURLEncoding.EncodeToString(token)
+}
+
+// decodeCursor parses a cursor query param, an empty param is the first page
+// and decodes to a nil cursor
+func decodeCursor(cursorParam string) (*dtypes.Cursor, error) {
+	if cursorParam == "" {
+		return nil, nil
+	}
+
+	var token cursorToken
+	raw, err := base64.RawURLEncoding.DecodeString(cursorParam)
+	if err == nil {
+		err = json.Unmarshal(raw, &token)
+	}
+
+	if err != nil || token.SortTime == "" || token.Type == "" {
+		return nil, errors.New("Bad cursor value")
+	}
+
+	return &dtypes.Cursor{
+		SortTime: token.SortTime,
+		Type:     token.Type,
+		ID:       token.ID,
+		RankedAt: token.RankedAt,
+		Score:    token.Score,
+	}, nil
+}
@@ -1,24 +1,51 @@
 package client
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 
+	"github.com/marcusprice/twitter-clone/internal/dtypes"
 	"github.com/marcusprice/twitter-clone/internal/logger"
 	"github.com/marcusprice/twitter-clone/internal/util"
 )
 
 const COMMENT_API_ENDPOINT = "/api/v1/comment/create"
 
+// formatted with the post id
+const POST_API_ENDPOINT = "/api/v1/post/%d"
+
+// formatted with the comment id
+const (
+	COMMENT_ID_API_ENDPOINT     = "/api/v1/comment/%d"
+	COMMENT_STREAM_API_ENDPOINT = "/api/v1/comment/%d/stream"
+)
+
+// StatusError is a response from the core service that wasn't a success
+type StatusError struct {
+	StatusCode int
+}
+
+func (e StatusError) Error() string {
+	return fmt.Sprintf("core service returned status %d", e.StatusCode)
+}
+
+// TokenSource returns a bearer token for the next request, access tokens
+// expire so long running clients mint a new one each time
+type TokenSource func() (string, error)
+
 type CoreClient struct {
-	host      string
-	port      string
-	client    *http.Client
-	authToken string
+	host        string
+	port        string
+	client      *http.Client
+	tokenSource TokenSource
 }
 
-func (cc *CoreClient) PostComment(postID, parentCommentID int, content string) (*http.Response, error) {
+func (cc *CoreClient) PostComment(ctx context.Context, postID, parentCommentID int, content string) (*http.Response, error) {
 	fields := make(map[string]string)
 	fields["content"] = content
 	fields["postID"] = fmt.Sprintf("%d", postID)
@@ -29,7 +56,8 @@ func (cc *CoreClient) PostComment(postID, parentCommentID int, content string) (
 		logger.LogError("CoreClient.PostComment() error generating multipart form: " + err.Error())
 		return &http.Response{}, err
 	}
-	request, err := http.NewRequest(
+	request, err := http.NewRequestWithContext(
+		ctx,
 		http.MethodPost,
 		fmt.Sprintf("http://%s:%s%s", cc.host, cc.port, COMMENT_API_ENDPOINT),
 		requestBody)
@@ -39,7 +67,13 @@ func (cc *CoreClient) PostComment(postID, parentCommentID int, content string) (
 		return &http.Response{}, err
 	}
 
-	request.Header.Set("Authorization", "Bearer "+cc.authToken)
+	authToken, err := cc.tokenSource()
+	if err != nil {
+		logger.LogError("CoreClient.PostComment() error getting auth token: " + err.Error())
+		return &http.Response{}, err
+	}
+
+	request.Header.Set("Authorization", "Bearer "+authToken)
 	request.Header.Set("Content-Type", contentType)
 
 	apiResponse, err := cc.client.Do(request)
@@ -50,16 +84,162 @@ func (cc *CoreClient) PostComment(postID, parentCommentID int, content string) (
 	return apiResponse, nil
 }
 
-func NewCoreClient(jwtToken string) *CoreClient {
+// GetThread returns the post with its comments and their replies, as the
+// token's user sees it
+func (cc *CoreClient) GetThread(ctx context.Context, postID int) (dtypes.ReplyGuyThread, error) {
+	request, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodGet,
+		fmt.Sprintf("http://%s:%s"+POST_API_ENDPOINT, cc.host, cc.port, postID),
+		nil)
+
+	if err != nil {
+		logger.LogError("CoreClient.GetThread() error creating new request: " + err.Error())
+		return dtypes.ReplyGuyThread{}, err
+	}
+
+	authToken, err := cc.tokenSource()
+	if err != nil {
+		logger.LogError("CoreClient.GetThread() error getting auth token: " + err.Error())
+		return dtypes.ReplyGuyThread{}, err
+	}
+	request.Header.Set("Authorization", "Bearer "+authToken)
+
+	resp, err := cc.client.Do(request)
+	if err != nil {
+		return dtypes.ReplyGuyThread{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return dtypes.ReplyGuyThread{}, StatusError{resp.StatusCode}
+	}
+
+	var thread dtypes.ReplyGuyThread
+	err = json.NewDecoder(resp.Body).Decode(&thread)
+	if err != nil {
+		logger.LogError("CoreClient.GetThread() error decoding response: " + err.Error())
+		return dtypes.ReplyGuyThread{}, err
+	}
+
+	return thread, nil
+}
+
+// CreateGeneratingComment posts an empty placeholder comment for a streamed
+// reply and returns its id
+func (cc *CoreClient) CreateGeneratingComment(ctx context.Context, postID, parentCommentID int) (int, error) {
+	fields := make(map[string]string)
+	fields["generating"] = "true"
+	fields["postID"] = fmt.Sprintf("%d", postID)
+	fields["parentCommentID"] = fmt.Sprintf("%d", parentCommentID)
+
+	requestBody, contentType, err := util.GenerateMultipartForm(fields)
+	if err != nil {
+		logger.LogError("CoreClient.CreateGeneratingComment() error generating multipart form: " + err.Error())
+		return 0, err
+	}
+
+	resp, err := cc.do(ctx, http.MethodPost, COMMENT_API_ENDPOINT, requestBody, contentType)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, StatusError{resp.StatusCode}
+	}
+
+	var comment struct {
+		ID int `json:"commentID"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&comment)
+	if err != nil {
+		logger.LogError("CoreClient.CreateGeneratingComment() error decoding response: " + err.Error())
+		return 0, err
+	}
+
+	return comment.ID, nil
+}
+
+// UpdateGeneratingComment replaces the placeholder's content with what's been
+// generated so far, done finishes the comment
+func (cc *CoreClient) UpdateGeneratingComment(ctx context.Context, commentID int, content string, done bool) error {
+	payload, err := json.Marshal(dtypes.GenerationInput{Content: content, Done: done})
+	if err != nil {
+		return err
+	}
+
+	resp, err := cc.do(
+		ctx,
+		http.MethodPatch,
+		fmt.Sprintf(COMMENT_STREAM_API_ENDPOINT, commentID),
+		bytes.NewReader(payload),
+		"application/json")
+
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return StatusError{resp.StatusCode}
+	}
+
+	return nil
+}
+
+func (cc *CoreClient) DeleteComment(ctx context.Context, commentID int) error {
+	resp, err := cc.do(ctx, http.MethodDelete, fmt.Sprintf(COMMENT_ID_API_ENDPOINT, commentID), nil, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		return StatusError{resp.StatusCode}
+	}
+
+	return nil
+}
+
+// do sends an authenticated request to the core service, the caller closes
+// the response body
+func (cc *CoreClient) do(ctx context.Context, method, path string, body io.Reader, contentType string) (*http.Response, error) {
+	request, err := http.NewRequestWithContext(
+		ctx,
+		method,
+		fmt.Sprintf("http://%s:%s%s", cc.host, cc.port, path),
+		body)
+
+	if err != nil {
+		logger.LogError("CoreClient.do() error creating new request: " + err.Error())
+		return nil, err
+	}
+
+	authToken, err := cc.tokenSource()
+	if err != nil {
+		logger.LogError("CoreClient.do() error getting auth token: " + err.Error())
+		return nil, err
+	}
+
+	request.Header.Set("Authorization", "Bearer "+authToken)
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+
+	return cc.client.Do(request)
+}
+
+func NewCoreClient(tokenSource TokenSource) *CoreClient {
 	host := os.Getenv("HOST")
 	port := os.Getenv("PORT")
 
 	client := &http.Client{}
 	cc := &CoreClient{
-		host:      host,
-		port:      port,
-		client:    client,
-		authToken: jwtToken,
+		host:        host,
+		port:        port,
+		client:      client,
+		tokenSource: tokenSource,
 	}
 
 	return cc
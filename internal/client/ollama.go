@@ -2,16 +2,20 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
+	"strings"
 
 	"github.com/marcusprice/twitter-clone/internal/dtypes"
 	"github.com/marcusprice/twitter-clone/internal/logger"
 )
 
-const GENERATE_ENDPOINT = "/api/generate"
+const CHAT_ENDPOINT = "/api/chat"
 
 type OllamaClient struct {
 	host   string
@@ -19,66 +23,132 @@ type OllamaClient struct {
 	client *http.Client
 }
 
-func (oc OllamaClient) Prompt(job dtypes.ReplyGuyRequest) (dtypes.ModelResponse, error) {
-	ollamaRequestPayload := dtypes.OllamaRequest{
-		Stream: false,
-		Model:  job.Model,
-		Prompt: formatPrompt(job),
+// Chat asks model for the next message in the conversation
+func (oc OllamaClient) Chat(ctx context.Context, model string, messages []dtypes.OllamaMessage) (dtypes.ModelResponse, error) {
+	ollamaRequestPayload := dtypes.OllamaChatRequest{
+		Stream:   false,
+		Model:    model,
+		Messages: messages,
 	}
 
 	payload, err := json.Marshal(ollamaRequestPayload)
 	if err != nil {
-		logger.LogError("OllamaClient.Prompt() error marshalling payload: " + err.Error())
+		logger.LogError("OllamaClient.Chat() error marshalling payload: " + err.Error())
 		return dtypes.ModelResponse{}, err
 	}
 
-	resp, err := http.Post(
-		fmt.Sprintf("http://%s:%s%s", oc.host, oc.port, GENERATE_ENDPOINT),
-		"application/json",
+	request, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		fmt.Sprintf("http://%s:%s%s", oc.host, oc.port, CHAT_ENDPOINT),
 		bytes.NewReader(payload))
 
+	if err != nil {
+		logger.LogError("OllamaClient.Chat() error creating new request: " + err.Error())
+		return dtypes.ModelResponse{}, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	resp, err := oc.client.Do(request)
 	if err != nil {
 		return dtypes.ModelResponse{}, err
 	}
 
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return dtypes.ModelResponse{}, fmt.Errorf("ollama returned status %d", resp.StatusCode)
+	}
+
 	var modelResponse dtypes.ModelResponse
-	json.NewDecoder(resp.Body).Decode(&modelResponse)
+	err = json.NewDecoder(resp.Body).Decode(&modelResponse)
+	if err != nil {
+		logger.LogError("OllamaClient.Chat() error decoding response: " + err.Error())
+		return dtypes.ModelResponse{}, err
+	}
 
 	return modelResponse, nil
 }
 
-func formatPrompt(request dtypes.ReplyGuyRequest) string {
-	prompt := "***************************************************\n\n"
-	prompt += request.Comment.Content + "\n\n"
-	prompt += fmt.Sprintf("posted by: @%s", request.Comment.Author.Username) + "\n\n"
-	prompt += "***************************************************\n\n"
-	prompt += "The user's prompt has ended, the following is additional context for the LLM: \n\n"
-	prompt += fmt.Sprintf(
-		"The top level post was posted by user @%s and the content read:\n\n%s",
-		request.ParentPost.Author.Username,
-		request.ParentPost.Content)
-
-	if request.Comment.Author.Username == request.ParentComment.Author.Username {
-		prompt += fmt.Sprintf(
-			"\n\n(the top level post was posted by the same user @%s)",
-			request.ParentPost.Author.Username,
-		)
+// ChatStream is Chat with ollama's streaming response, onContent is called
+// with the message generated so far after each chunk. An error from onContent
+// stops the generation. The returned response has the whole message.
+func (oc OllamaClient) ChatStream(
+	ctx context.Context,
+	model string,
+	messages []dtypes.OllamaMessage,
+	onContent func(content string) error,
+) (dtypes.ModelResponse, error) {
+	ollamaRequestPayload := dtypes.OllamaChatRequest{
+		Stream:   true,
+		Model:    model,
+		Messages: messages,
+	}
+
+	payload, err := json.Marshal(ollamaRequestPayload)
+	if err != nil {
+		logger.LogError("OllamaClient.ChatStream() error marshalling payload: " + err.Error())
+		return dtypes.ModelResponse{}, err
+	}
+
+	request, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		fmt.Sprintf("http://%s:%s%s", oc.host, oc.port, CHAT_ENDPOINT),
+		bytes.NewReader(payload))
+
+	if err != nil {
+		logger.LogError("OllamaClient.ChatStream() error creating new request: " + err.Error())
+		return dtypes.ModelResponse{}, err
 	}
+	request.Header.Set("Content-Type", "application/json")
 
-	if request.ParentComment.ID != 0 {
-		prompt += "\n\n"
-		prompt += "This user is replying to another comment, the content of"
-		prompt += "the top level comment in the thread is:"
-		prompt += request.ParentComment.Content
+	resp, err := oc.client.Do(request)
+	if err != nil {
+		return dtypes.ModelResponse{}, err
 	}
 
-	prompt += "\n\n"
-	prompt += "Feel free to reply/acknowledge the other users (include their "
-	prompt += "username with the @ symbol) if it warrants it."
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return dtypes.ModelResponse{}, fmt.Errorf("ollama returned status %d", resp.StatusCode)
+	}
 
-	return prompt
+	// newline delimited json, one chunk of the message per line and the
+	// last one has done set along with the stats
+	var content strings.Builder
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var chunk dtypes.ModelResponse
+		err = decoder.Decode(&chunk)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+
+			logger.LogError("OllamaClient.ChatStream() error decoding response: " + err.Error())
+			return dtypes.ModelResponse{}, err
+		}
+
+		if chunk.Error != "" {
+			return dtypes.ModelResponse{}, fmt.Errorf("ollama stream failed: %s", chunk.Error)
+		}
+
+		content.WriteString(chunk.Message.Content)
+		if chunk.Done {
+			chunk.Message.Content = content.String()
+			return chunk, nil
+		}
+
+		if chunk.Message.Content == "" {
+			continue
+		}
+
+		err = onContent(content.String())
+		if err != nil {
+			return dtypes.ModelResponse{}, err
+		}
+	}
 }
 
 func NewOllamaClient() *OllamaClient {
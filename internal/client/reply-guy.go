@@ -9,10 +9,12 @@ import (
 
 	"github.com/marcusprice/twitter-clone/internal/dtypes"
 	"github.com/marcusprice/twitter-clone/internal/logger"
+	"github.com/marcusprice/twitter-clone/internal/persona"
 	"github.com/marcusprice/twitter-clone/internal/util"
 )
 
-const DALE_COOPER_ENDPOINT = "/api/v1/@dalecooper/request-reply"
+// formatted with the persona's username
+const REQUEST_REPLY_ENDPOINT = "/api/v1/@%s/request-reply"
 
 type ReplyGuyRequester interface {
 	RunAsync() bool
@@ -23,9 +25,10 @@ type ReplyGuyRequester interface {
 // TODO: retries
 
 type ReplyGuyClient struct {
-	host   string
-	port   string
-	client *http.Client
+	host     string
+	port     string
+	client   *http.Client
+	personas *persona.Registry
 }
 
 func (rg *ReplyGuyClient) RunAsync() bool {
@@ -33,7 +36,12 @@ func (rg *ReplyGuyClient) RunAsync() bool {
 }
 
 func (rg *ReplyGuyClient) GetReplyGuys() []string {
-	return []string{"@dalecooper"}
+	replyGuys := []string{}
+	for _, persona := range rg.personas.Personas() {
+		replyGuys = append(replyGuys, "@"+persona.Username)
+	}
+
+	return replyGuys
 }
 
 func (rg *ReplyGuyClient) RequestReply(request dtypes.ReplyGuyRequest) {
@@ -46,7 +54,7 @@ func (rg *ReplyGuyClient) RequestReply(request dtypes.ReplyGuyRequest) {
 	}
 
 	resp, err := http.Post(
-		rg.address()+DALE_COOPER_ENDPOINT,
+		rg.address()+fmt.Sprintf(REQUEST_REPLY_ENDPOINT, request.Persona),
 		"application/json",
 		bytes.NewReader(json),
 	)
@@ -69,12 +77,23 @@ func NewReplyGuyClient() *ReplyGuyClient {
 	host := os.Getenv("REPLY_GUY_HOST")
 	port := os.Getenv("REPLY_GUY_PORT")
 
+	personas, err := persona.FromEnv()
+	if err != nil {
+		logger.LogError("NewReplyGuyClient() error loading personas: " + err.Error())
+		if util.InDevContext() {
+			panic(err)
+		}
+
+		personas = persona.DefaultRegistry()
+	}
+
 	client := &http.Client{}
 
 	replyGuyClient := &ReplyGuyClient{
-		host:   host,
-		port:   port,
-		client: client,
+		host:     host,
+		port:     port,
+		client:   client,
+		personas: personas,
 	}
 
 	return replyGuyClient
@@ -0,0 +1,213 @@
+package controller
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/marcusprice/twitter-clone/internal/dtypes"
+	"github.com/marcusprice/twitter-clone/internal/logger"
+	"github.com/marcusprice/twitter-clone/internal/model"
+	"github.com/marcusprice/twitter-clone/internal/permissions"
+)
+
+type ReportResolvedError struct{}
+
+func (r ReportResolvedError) Error() string {
+	return "Report has already been resolved"
+}
+
+type InvalidRoleError struct{}
+
+func (i InvalidRoleError) Error() string {
+	return "Role must be a user or admin role"
+}
+
+// Admin performs moderation actions on behalf of adminID, every action that
+// changes something is written to the audit log in the same transaction.
+// Callers are expected to have checked the admin's role already
+// (api.RequireRole).
+type Admin struct {
+	adminID      int
+	userModel    *model.UserModel
+	postModel    *model.PostModel
+	commentModel *model.CommentModel
+	auditModel   *model.AuditModel
+	reportModel  *model.ReportModel
+}
+
+// DeactivateUser also signs the user out of every session
+func (a *Admin) DeactivateUser(username string) error {
+	return a.setUserActive(username, false, model.DEACTIVATE_USER_ACTION)
+}
+
+func (a *Admin) ReactivateUser(username string) error {
+	return a.setUserActive(username, true, model.REACTIVATE_USER_ACTION)
+}
+
+func (a *Admin) setUserActive(username string, active bool, action model.AuditAction) error {
+	userData, err := a.moderatableUser(username)
+	if err != nil {
+		return err
+	}
+
+	return a.userModel.SetActive(
+		userData.ID, active, a.auditEntry(action, model.USER_AUDIT_TARGET, userData.ID, ""))
+}
+
+// ChangeRole only moves users between the user and admin roles, system
+// accounts are managed outside the API
+func (a *Admin) ChangeRole(username string, role permissions.Role) error {
+	if role != permissions.USER_ROLE && role != permissions.ADMIN_ROLE {
+		return InvalidRoleError{}
+	}
+
+	userData, err := a.moderatableUser(username)
+	if err != nil {
+		return err
+	}
+
+	if userData.Role == role {
+		return nil
+	}
+
+	return a.userModel.SetRole(userData.ID, role, a.auditEntry(
+		model.CHANGE_ROLE_ACTION, model.USER_AUDIT_TARGET, userData.ID,
+		fmt.Sprintf("role %d -> %d", userData.Role, role)))
+}
+
+// moderatableUser loads username, admins can't moderate themselves (so they
+// can't lock themselves out) or system accounts
+func (a *Admin) moderatableUser(username string) (dtypes.UserData, error) {
+	userData, err := a.userModel.GetByIdentifier("", username)
+	if err != nil {
+		return dtypes.UserData{}, err
+	}
+
+	if userData.ID == a.adminID || userData.Role == permissions.SYSTEM_ROLE {
+		logger.LogWarn(fmt.Sprintf("Admin: user %d attempted to moderate user %d", a.adminID, userData.ID))
+		return dtypes.UserData{}, UnauthorizedActionError{}
+	}
+
+	return userData, nil
+}
+
+// DeletePost returns the deleted post's images for the caller to clean up
+func (a *Admin) DeletePost(postID int) (images []string, err error) {
+	postData, err := a.postModel.GetByIDIncludingHidden(postID)
+	if err != nil {
+		return []string{}, err
+	}
+
+	return a.postModel.Delete(postID, a.auditEntry(
+		model.DELETE_POST_ACTION, model.POST_AUDIT_TARGET, postID,
+		fmt.Sprintf("author %d", postData.UserID)))
+}
+
+// DeleteComment returns the deleted comment's images for the caller to clean up
+func (a *Admin) DeleteComment(commentID int) (images []string, err error) {
+	commentData, err := a.commentModel.GetByIDIncludingHidden(commentID)
+	if err != nil {
+		return []string{}, err
+	}
+
+	return a.commentModel.Delete(commentID, a.auditEntry(
+		model.DELETE_COMMENT_ACTION, model.COMMENT_AUDIT_TARGET, commentID,
+		fmt.Sprintf("author %d", commentData.UserID)))
+}
+
+func (a *Admin) Reports(status model.ReportStatus, limit, offset int) (reports []dtypes.ReportData, reportsRemaining int, err error) {
+	reports, err = a.reportModel.GetByStatus(status, limit, offset)
+	if err != nil {
+		return []dtypes.ReportData{}, -1, err
+	}
+
+	totalReports, err := a.reportModel.GetCount(status)
+	if err != nil {
+		return []dtypes.ReportData{}, -1, err
+	}
+
+	return reports, totalReports - (limit + offset), nil
+}
+
+// ResolveReport closes reportID along with every other open report against
+// the same content. Hiding and deactivating both hide the content, the
+// latter also deactivates its author.
+func (a *Admin) ResolveReport(reportID int, status model.ReportStatus) error {
+	report, err := a.reportModel.GetByID(reportID)
+	if err != nil {
+		return err
+	}
+
+	if report.Status != string(model.OPEN_REPORT) {
+		return ReportResolvedError{}
+	}
+
+	audit := []model.AuditEntry{}
+	if status == model.AUTHOR_DEACTIVATED_REPORT {
+		_, err = a.moderatableUser(report.Author.Username)
+		if err != nil {
+			return err
+		}
+
+		audit = append(audit, a.auditEntry(
+			model.DEACTIVATE_USER_ACTION, model.USER_AUDIT_TARGET, report.AuthorID, ""))
+	}
+
+	audit = append(audit, a.auditEntry(
+		model.RESOLVE_REPORT_ACTION, model.REPORT_AUDIT_TARGET, reportID, string(status)))
+
+	return a.reportModel.Resolve(report, a.adminID, status, audit...)
+}
+
+func (a *Admin) auditEntry(action model.AuditAction, targetType model.AuditTarget, targetID int, details string) model.AuditEntry {
+	return model.AuditEntry{
+		ActorID:    a.adminID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Details:    details,
+	}
+}
+
+func (a *Admin) RecentUsers(limit, offset int) (users []dtypes.UserData, usersRemaining int, err error) {
+	users, err = a.userModel.GetRecent(limit, offset)
+	if err != nil {
+		return []dtypes.UserData{}, -1, err
+	}
+
+	totalUsers, err := a.userModel.Count()
+	if err != nil {
+		return []dtypes.UserData{}, -1, err
+	}
+
+	return users, totalUsers - (limit + offset), nil
+}
+
+func (a *Admin) AuditLog(limit, offset int) (entries []dtypes.AuditLogData, entriesRemaining int, err error) {
+	entries, err = a.auditModel.GetRecent(limit, offset)
+	if err != nil {
+		return []dtypes.AuditLogData{}, -1, err
+	}
+
+	totalEntries, err := a.auditModel.GetCount()
+	if err != nil {
+		return []dtypes.AuditLogData{}, -1, err
+	}
+
+	return entries, totalEntries - (limit + offset), nil
+}
+
+func NewAdminController(db *sql.DB, adminID int) *Admin {
+	if db == nil {
+		panic("db conn cannot be nil")
+	}
+
+	return &Admin{
+		adminID:      adminID,
+		userModel:    model.NewUserModel(db),
+		postModel:    model.NewPostModel(db),
+		commentModel: model.NewCommentModel(db),
+		auditModel:   model.NewAuditModel(db),
+		reportModel:  model.NewReportModel(db),
+	}
+}
@@ -2,6 +2,9 @@ package controller
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
 	"strings"
 	"time"
 
@@ -9,6 +12,7 @@ import (
 	"github.com/marcusprice/twitter-clone/internal/dtypes"
 	"github.com/marcusprice/twitter-clone/internal/logger"
 	"github.com/marcusprice/twitter-clone/internal/model"
+	"github.com/marcusprice/twitter-clone/internal/permissions"
 	"github.com/marcusprice/twitter-clone/internal/util"
 )
 
@@ -18,12 +22,26 @@ func (d DepthLimitError) Error() string {
 	return "Reply depth exceeds limit"
 }
 
+type GenerationFinishedError struct{}
+
+func (g GenerationFinishedError) Error() string {
+	return "Comment is no longer generating"
+}
+
 const DEPTH_LIMIT = 1
 
+// a generating comment without an update this long was abandoned by
+// reply-guy, a sqlite datetime() modifier
+const GENERATION_TIMEOUT = "-5 minutes"
+
 type Comment struct {
 	model                *model.CommentModel
+	commentAction        *model.CommentAction
+	report               *model.ReportModel
+	user                 *model.UserModel
 	post                 *Post
 	replyGuy             client.ReplyGuyRequester
+	streams              *CommentStreams
 	ID                   int
 	PostID               int
 	UserID               int
@@ -35,12 +53,14 @@ type Comment struct {
 	BookmarkCount        int
 	Impressions          int
 	Image                string
+	Generating           bool
 	CreatedAt            time.Time
 	UpdatedAt            time.Time
 	Author               dtypes.Author
 	IsRetweet            bool
 	RetweeterUsername    string
 	RetweeterDisplayName string
+	Mentions             []dtypes.MentionEntity
 	Replies              []*Comment
 }
 
@@ -55,12 +75,14 @@ func (comment *Comment) setFromModel(commentData dtypes.CommentData) {
 	comment.BookmarkCount = commentData.BookmarkCount
 	comment.Impressions = commentData.Impressions
 	comment.Image = commentData.Image
+	comment.Generating = commentData.Generating
 	comment.CreatedAt = util.ParseTime(commentData.CreatedAt)
 	comment.UpdatedAt = util.ParseTime(commentData.UpdatedAt)
 	comment.Author.Username = commentData.Author.Username
 	comment.Author.DisplayName = commentData.Author.DisplayName
 	comment.Author.Avatar = commentData.Author.Avatar
 	comment.Depth = commentData.Depth
+	comment.Mentions = resolvedMentions(commentData.Content, commentData.MentionedUsernames)
 }
 
 func (comment *Comment) ByID(commentID int) (*Comment, error) {
@@ -73,8 +95,9 @@ func (comment *Comment) ByID(commentID int) (*Comment, error) {
 	return queriedComment, nil
 }
 
-func (comment *Comment) GetPostComments(postID int) ([]*Comment, error) {
-	commentData, err := comment.model.GetByPostID(postID)
+// GetPostComments builds the comment tree for postID as seen by viewerID
+func (comment *Comment) GetPostComments(postID, viewerID int) ([]*Comment, error) {
+	commentData, err := comment.model.GetByPostID(postID, viewerID)
 	if err != nil {
 		return []*Comment{}, err
 	}
@@ -109,11 +132,39 @@ func (comment *Comment) GetPostComments(postID int) ([]*Comment, error) {
 	return topLevelComments, nil
 }
 
+// New fails with BlockedError when the author of the post or comment being
+// replied to has blocked the commenter, and PrivateAccountError when the
+// commenter can't see the post. Only reply-guy accounts can create
+// generating comments.
 func (comment *Comment) New(commentInput dtypes.CommentInput) (*Comment, error) {
+	if commentInput.Generating {
+		err := comment.checkReplyGuy(commentInput.UserID)
+		if err != nil {
+			return &Comment{}, err
+		}
+	}
+
 	var commentID int
-	var err error
 	if commentInput.ParentCommentID == 0 {
+		postData, err := comment.post.model.GetByID(commentInput.PostID)
+		if err != nil {
+			return &Comment{}, err
+		}
+
+		err = checkBlocked(comment.user, postData.UserID, commentInput.UserID)
+		if err != nil {
+			return &Comment{}, err
+		}
+
+		err = checkCanView(comment.user, postData.UserID, commentInput.UserID)
+		if err != nil {
+			return &Comment{}, err
+		}
+
 		commentID, err = comment.model.NewPostComment(commentInput)
+		if err != nil {
+			return &Comment{}, err
+		}
 	} else {
 		parentComment, err := comment.model.GetByID(commentInput.ParentCommentID)
 		if err != nil {
@@ -125,23 +176,139 @@ func (comment *Comment) New(commentInput dtypes.CommentInput) (*Comment, error)
 			return &Comment{}, DepthLimitError{}
 		}
 
+		err = checkBlocked(comment.user, parentComment.UserID, commentInput.UserID)
+		if err != nil {
+			return &Comment{}, err
+		}
+
+		postData, err := comment.post.model.GetByID(parentComment.PostID)
+		if err != nil {
+			return &Comment{}, err
+		}
+
+		err = checkCanView(comment.user, postData.UserID, commentInput.UserID)
+		if err != nil {
+			return &Comment{}, err
+		}
+
 		commentID, err = comment.model.NewCommentReply(commentInput)
+		if err != nil {
+			return &Comment{}, err
+		}
 	}
 
+	commentData, err := comment.model.GetByID(commentID)
 	if err != nil {
 		return &Comment{}, err
 	}
 
+	newComment := &Comment{}
+	newComment.setFromModel(commentData)
+
+	// generating comments request replies once they're done
+	if !newComment.Generating {
+		comment.requestReplies(newComment)
+	}
+
+	return newComment, nil
+}
+
+// UpdateGeneration replaces the content of one of userID's generating
+// comments and publishes it to subscribers, done finishes the comment.
+// Finished comments fail with GenerationFinishedError.
+func (comment *Comment) UpdateGeneration(commentID, userID int, content string, done bool) (*Comment, error) {
 	commentData, err := comment.model.GetByID(commentID)
 	if err != nil {
 		return &Comment{}, err
 	}
 
-	newComment := &Comment{}
-	newComment.setFromModel(commentData)
+	if commentData.UserID != userID {
+		logger.LogWarn(fmt.Sprintf("Comment.UpdateGeneration(): user %d attempted to update comment %d", userID, commentID))
+		return &Comment{}, UnauthorizedActionError{}
+	}
+
+	if !commentData.Generating {
+		return &Comment{}, GenerationFinishedError{}
+	}
+
+	err = comment.model.UpdateGeneration(commentID, userID, content, done)
+	if err != nil {
+		var commentNotFoundError model.CommentNotFoundError
+		if errors.As(err, &commentNotFoundError) {
+			// finished by another request since the lookup
+			return &Comment{}, GenerationFinishedError{}
+		}
+
+		return &Comment{}, err
+	}
+
+	updatedComment, err := comment.ByID(commentID)
+	if err != nil {
+		return &Comment{}, err
+	}
+
+	// subscribers get their own copy, callers are free to change theirs
+	published := *updatedComment
+	comment.streams.publish(&published)
+	if done {
+		comment.requestReplies(updatedComment)
+	}
+
+	return updatedComment, nil
+}
+
+// Subscribe returns commentID as viewerID sees it now along with its updates
+// until it's done, comments that aren't generating come with a closed
+// channel. Fails with PrivateAccountError like GetPostAndComments.
+func (comment *Comment) Subscribe(commentID, viewerID int) (current *Comment, updates <-chan *Comment, unsubscribe func(), err error) {
+	// subscribe before reading so no update falls in between
+	updates, unsubscribe = comment.streams.Subscribe(commentID)
+
+	current, err = comment.ByID(commentID)
+	if err != nil {
+		unsubscribe()
+		return &Comment{}, nil, nil, err
+	}
 
+	postData, err := comment.post.model.GetByID(current.PostID)
+	if err == nil {
+		err = checkCanView(comment.user, postData.UserID, viewerID)
+	}
+
+	if err != nil {
+		unsubscribe()
+		return &Comment{}, nil, nil, err
+	}
+
+	if !current.Generating {
+		unsubscribe()
+	}
+
+	return current, updates, unsubscribe, nil
+}
+
+func (comment *Comment) checkReplyGuy(userID int) error {
+	userData, err := comment.user.GetByID(userID)
+	if err != nil {
+		return err
+	}
+
+	isReplyGuy := slices.ContainsFunc(comment.replyGuy.GetReplyGuys(), func(guy string) bool {
+		return strings.EqualFold(guy, "@"+userData.Username)
+	})
+
+	if !isReplyGuy {
+		logger.LogWarn(fmt.Sprintf("Comment.New(): user %d attempted to create a generating comment", userID))
+		return UnauthorizedActionError{}
+	}
+
+	return nil
+}
+
+func (comment *Comment) requestReplies(newComment *Comment) {
+	mentions := util.ParseMentions(newComment.Content)
 	for _, guy := range comment.replyGuy.GetReplyGuys() {
-		if strings.Contains(newComment.Content, guy) {
+		if slices.Contains(mentions, strings.TrimPrefix(guy, "@")) {
 			err := comment.handleReplyGuyRequest(guy, newComment)
 
 			if err != nil {
@@ -149,8 +316,169 @@ func (comment *Comment) New(commentInput dtypes.CommentInput) (*Comment, error)
 			}
 		}
 	}
+}
 
-	return newComment, nil
+// Edit updates the content of one of userID's comments
+func (comment *Comment) Edit(commentID, userID int, content string) (*Comment, error) {
+	commentData, err := comment.model.GetByID(commentID)
+	if err != nil {
+		return &Comment{}, err
+	}
+
+	if commentData.UserID != userID {
+		logger.LogWarn(fmt.Sprintf("Comment.Edit(): user %d attempted to edit comment %d", userID, commentID))
+		return &Comment{}, UnauthorizedActionError{}
+	}
+
+	err = comment.model.UpdateContent(commentID, content)
+	if err != nil {
+		return &Comment{}, err
+	}
+
+	return comment.ByID(commentID)
+}
+
+// Delete removes a comment and its replies if userID is the comment's author
+// or an admin, returning the filenames of images orphaned by the delete
+func (comment *Comment) Delete(commentID, userID int, role permissions.Role) (images []string, err error) {
+	getByID := comment.model.GetByID
+	if role == permissions.ADMIN_ROLE {
+		getByID = comment.model.GetByIDIncludingHidden
+	}
+
+	commentData, err := getByID(commentID)
+	if err != nil {
+		return []string{}, err
+	}
+
+	if commentData.UserID != userID && role != permissions.ADMIN_ROLE {
+		logger.LogWarn(fmt.Sprintf("Comment.Delete(): user %d attempted to delete comment %d", userID, commentID))
+		return []string{}, UnauthorizedActionError{}
+	}
+
+	images, err = comment.model.Delete(commentID)
+	if err == nil && commentData.Generating {
+		comment.streams.end(commentID)
+	}
+
+	return images, err
+}
+
+// CleanupGenerating deletes generating comments that stopped getting updates,
+// left behind when reply-guy crashes mid-stream or can't reach the core
+// service to delete them itself. Returns the filenames of images on their
+// deleted replies.
+func (comment *Comment) CleanupGenerating() (images []string, err error) {
+	commentIDs, err := comment.model.GetStaleGenerating(GENERATION_TIMEOUT)
+	if err != nil {
+		return []string{}, err
+	}
+
+	images = []string{}
+	for _, commentID := range commentIDs {
+		deletedImages, err := comment.model.Delete(commentID)
+		if err != nil {
+			var commentNotFoundError model.CommentNotFoundError
+			if errors.As(err, &commentNotFoundError) {
+				// deleted since, or along with a stale parent
+				continue
+			}
+
+			return images, err
+		}
+
+		logger.LogWarn(fmt.Sprintf("Comment.CleanupGenerating(): deleted abandoned comment %d", commentID))
+		comment.streams.end(commentID)
+		images = append(images, deletedImages...)
+	}
+
+	return images, nil
+}
+
+// Report flags commentID for the admin moderation queue
+func (comment *Comment) Report(commentID, reporterID int, reason model.ReportReason, details string) error {
+	_, err := comment.model.GetByID(commentID)
+	if err != nil {
+		return err
+	}
+
+	return comment.report.New(reporterID, 0, commentID, reason, details)
+}
+
+// checkCanAct gates actions on commentID the way Comment.New gates replies,
+// failing with BlockedError when the comment's author has blocked actorID and
+// PrivateAccountError when actorID can't see the comment's post
+func (comment *Comment) checkCanAct(commentID, actorID int) error {
+	commentData, err := comment.model.GetByID(commentID)
+	if err != nil {
+		return err
+	}
+
+	err = checkBlocked(comment.user, commentData.UserID, actorID)
+	if err != nil {
+		return err
+	}
+
+	postData, err := comment.post.model.GetByID(commentData.PostID)
+	if err != nil {
+		return err
+	}
+
+	return checkCanView(comment.user, postData.UserID, actorID)
+}
+
+func (comment *Comment) Like(commentID, likerUserID int) error {
+	err := comment.checkCanAct(commentID, likerUserID)
+	if err != nil {
+		return err
+	}
+
+	return comment.commentAction.Like(commentID, likerUserID)
+}
+
+func (comment *Comment) Unlike(commentID, likerUserID int) error {
+	_, err := comment.model.GetByID(commentID)
+	if err != nil {
+		return err
+	}
+
+	return comment.commentAction.Unlike(commentID, likerUserID)
+}
+
+func (comment *Comment) Retweet(commentID, retweeterID int) error {
+	err := comment.checkCanAct(commentID, retweeterID)
+	if err != nil {
+		return err
+	}
+
+	return comment.commentAction.Retweet(commentID, retweeterID)
+}
+
+func (comment *Comment) UnRetweet(commentID, retweeterID int) error {
+	_, err := comment.model.GetByID(commentID)
+	if err != nil {
+		return err
+	}
+
+	return comment.commentAction.UnRetweet(commentID, retweeterID)
+}
+
+func (comment *Comment) Bookmark(commentID, bookmarkerID int) error {
+	err := comment.checkCanAct(commentID, bookmarkerID)
+	if err != nil {
+		return err
+	}
+
+	return comment.commentAction.Bookmark(commentID, bookmarkerID)
+}
+
+func (comment *Comment) UnBookmark(commentID, bookmarkerID int) error {
+	_, err := comment.model.GetByID(commentID)
+	if err != nil {
+		return err
+	}
+
+	return comment.commentAction.UnBookmark(commentID, bookmarkerID)
 }
 
 func (comment *Comment) handleReplyGuyRequest(guy string, newComment *Comment) error {
@@ -201,11 +529,19 @@ func (comment *Comment) handleReplyGuyRequest(guy string, newComment *Comment) e
 		}
 	}
 
+	persona := strings.TrimPrefix(guy, "@")
+	personaData, err := comment.user.GetByIdentifier("", persona)
+	if err != nil {
+		logger.LogError("Comment.New() error querying persona @" + persona + ": " + err.Error())
+		return err
+	}
+
 	replyGuyRequest := dtypes.ReplyGuyRequest{
-		Model:         strings.TrimPrefix(guy, "@"),
-		Comment:       replyGuyComment,
-		ParentPost:    replyGuyParentPost,
-		ParentComment: replyGuyParentComment,
+		Persona:             persona,
+		Comment:             replyGuyComment,
+		ParentPost:          replyGuyParentPost,
+		ParentComment:       replyGuyParentComment,
+		PersonaTokenVersion: personaData.TokenVersion,
 	}
 
 	if comment.replyGuy.RunAsync() {
@@ -222,6 +558,17 @@ func NewCommentController(db *sql.DB) *Comment {
 	postModel := model.NewPostModel(db)
 	postController := &Post{model: postModel}
 
+	commentAction := model.NewCommentActionModel(db)
+	reportModel := model.NewReportModel(db)
+	userModel := model.NewUserModel(db)
 	model := model.NewCommentModel(db)
-	return &Comment{model: model, post: postController, replyGuy: replyGuy}
+	return &Comment{
+		model:         model,
+		commentAction: commentAction,
+		report:        reportModel,
+		user:          userModel,
+		post:          postController,
+		replyGuy:      replyGuy,
+		streams:       NewCommentStreams(),
+	}
 }
@@ -8,6 +8,7 @@ import (
 
 	"github.com/marcusprice/twitter-clone/internal/dtypes"
 	"github.com/marcusprice/twitter-clone/internal/model"
+	"github.com/marcusprice/twitter-clone/internal/permissions"
 	"github.com/marcusprice/twitter-clone/internal/testhelpers"
 	"github.com/marcusprice/twitter-clone/internal/testutil"
 	"github.com/marcusprice/twitter-clone/internal/util"
@@ -40,8 +41,14 @@ func TestCommentByID(t *testing.T) {
 func TestCommentNew(t *testing.T) {
 	testutil.WithTestData(t, func(db *sql.DB, _ time.Time) {
 		tu := testutil.NewTestUtil(t)
+		userModel := model.NewUserModel(db)
 		model := model.NewCommentModel(db)
-		Comment := &Comment{model: model, replyGuy: &testhelpers.MockReplyGuyClient{}}
+		Comment := &Comment{
+			model:    model,
+			user:     userModel,
+			post:     NewPostController(db),
+			replyGuy: &testhelpers.MockReplyGuyClient{},
+		}
 		commentInput := dtypes.CommentInput{
 			PostID:  1,
 			UserID:  1,
@@ -77,8 +84,14 @@ func TestCommentNew(t *testing.T) {
 func TestCommentNewReply(t *testing.T) {
 	testutil.WithTestData(t, func(db *sql.DB, _ time.Time) {
 		tu := testutil.NewTestUtil(t)
+		userModel := model.NewUserModel(db)
 		model := model.NewCommentModel(db)
-		Comment := &Comment{model: model, replyGuy: &testhelpers.MockReplyGuyClient{}}
+		Comment := &Comment{
+			model:    model,
+			user:     userModel,
+			post:     NewPostController(db),
+			replyGuy: &testhelpers.MockReplyGuyClient{},
+		}
 		commentInput := dtypes.CommentInput{
 			PostID:  1,
 			UserID:  1,
@@ -134,11 +147,13 @@ func TestCommentNewReply(t *testing.T) {
 func TestNewCommentWithReplyGuyRequest(t *testing.T) {
 	testutil.WithTestData(t, func(db *sql.DB, _ time.Time) {
 		tu := testutil.NewTestUtil(t)
+		userModel := model.NewUserModel(db)
 		model := model.NewCommentModel(db)
 		postController := NewPostController(db)
 		replyGuyMockClient := &testhelpers.MockReplyGuyClient{}
 		Comment := &Comment{
 			model:    model,
+			user:     userModel,
 			replyGuy: replyGuyMockClient,
 			post:     postController,
 		}
@@ -155,7 +170,7 @@ func TestNewCommentWithReplyGuyRequest(t *testing.T) {
 		newComment, err := Comment.New(commentInput)
 		calledWith := replyGuyMockClient.CalledWith
 		tu.AssertErrorNil(err)
-		tu.AssertEqual("dalecooper", calledWith.Model)
+		tu.AssertEqual("dalecooper", calledWith.Persona)
 
 		tu.AssertEqual(op.ID, calledWith.ParentPost.ID)
 		tu.AssertEqual(op.Content, calledWith.ParentPost.Content)
@@ -180,7 +195,7 @@ func TestNewCommentWithReplyGuyRequest(t *testing.T) {
 		commentReply, err := Comment.New(commentInput)
 		calledWith = replyGuyMockClient.CalledWith
 		tu.AssertErrorNil(err)
-		tu.AssertEqual("dalecooper", calledWith.Model)
+		tu.AssertEqual("dalecooper", calledWith.Persona)
 
 		tu.AssertEqual(op.ID, calledWith.ParentPost.ID)
 		tu.AssertEqual(op.Content, calledWith.ParentPost.Content)
@@ -197,3 +212,125 @@ func TestNewCommentWithReplyGuyRequest(t *testing.T) {
 		tu.AssertEqual(newComment.Content, calledWith.ParentComment.Content)
 	})
 }
+
+func TestNewCommentReplyGuyNeedsExactMention(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, _ time.Time) {
+		tu := testutil.NewTestUtil(t)
+		replyGuyMockClient := &testhelpers.MockReplyGuyClient{}
+		Comment := &Comment{
+			model:    model.NewCommentModel(db),
+			user:     model.NewUserModel(db),
+			replyGuy: replyGuyMockClient,
+			post:     NewPostController(db),
+		}
+
+		for _, content := range []string{
+			"@dalecooperfan is the best account",
+			"email dalecooper@fbi.gov",
+			"dalecooper without the @",
+		} {
+			_, err := Comment.New(dtypes.CommentInput{UserID: 6, PostID: 41, Content: content})
+			tu.AssertErrorNil(err)
+			tu.AssertEqual("", replyGuyMockClient.CalledWith.Persona)
+		}
+
+		// reply-guy signs its requests with the persona's current version
+		db.Exec("UPDATE User SET token_version = 2 WHERE user_name = 'dalecooper';")
+		newComment, err := Comment.New(dtypes.CommentInput{UserID: 6, PostID: 41, Content: "(@dalecooper)"})
+		tu.AssertErrorNil(err)
+		tu.AssertEqual("dalecooper", replyGuyMockClient.CalledWith.Persona)
+		tu.AssertEqual(2, replyGuyMockClient.CalledWith.PersonaTokenVersion)
+		tu.AssertEqual(1, len(newComment.Mentions))
+		tu.AssertEqual(dtypes.MentionEntity{Username: "dalecooper", Start: 1, End: 12}, newComment.Mentions[0])
+	})
+}
+
+func TestCommentGeneration(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, _ time.Time) {
+		tu := testutil.NewTestUtil(t)
+		replyGuyMockClient := &testhelpers.MockReplyGuyClient{}
+		Comment := &Comment{
+			model:    model.NewCommentModel(db),
+			user:     model.NewUserModel(db),
+			post:     NewPostController(db),
+			replyGuy: replyGuyMockClient,
+			streams:  NewCommentStreams(),
+		}
+
+		// only reply-guy accounts stream comments in
+		_, err := Comment.New(dtypes.CommentInput{PostID: 1, UserID: 1, Generating: true})
+		tu.AssertTrue(errors.Is(err, UnauthorizedActionError{}))
+
+		placeholder, err := Comment.New(dtypes.CommentInput{PostID: 1, UserID: 3, Generating: true})
+		tu.AssertErrorNil(err)
+		tu.AssertTrue(placeholder.Generating)
+
+		current, updates, unsubscribe, err := Comment.Subscribe(placeholder.ID, 1)
+		tu.AssertErrorNil(err)
+		defer unsubscribe()
+		tu.AssertTrue(current.Generating)
+
+		_, err = Comment.UpdateGeneration(placeholder.ID, 1, "hijacked", false)
+		tu.AssertTrue(errors.Is(err, UnauthorizedActionError{}))
+
+		_, err = Comment.UpdateGeneration(placeholder.ID, 3, "the owls", false)
+		tu.AssertErrorNil(err)
+		update := <-updates
+		tu.AssertEqual("the owls", update.Content)
+		tu.AssertTrue(update.Generating)
+
+		// reply-guy requests wait for the finished content
+		tu.AssertEqual("", replyGuyMockClient.CalledWith.Persona)
+		_, err = Comment.UpdateGeneration(placeholder.ID, 3, "the owls are not what they seem @dalecooper", true)
+		tu.AssertErrorNil(err)
+		update = <-updates
+		tu.AssertEqual("the owls are not what they seem @dalecooper", update.Content)
+		tu.AssertFalse(update.Generating)
+		_, open := <-updates
+		tu.AssertFalse(open)
+		tu.AssertEqual("dalecooper", replyGuyMockClient.CalledWith.Persona)
+
+		_, err = Comment.UpdateGeneration(placeholder.ID, 3, "edited", false)
+		tu.AssertTrue(errors.Is(err, GenerationFinishedError{}))
+
+		current, updates, _, err = Comment.Subscribe(placeholder.ID, 1)
+		tu.AssertErrorNil(err)
+		tu.AssertFalse(current.Generating)
+		_, open = <-updates
+		tu.AssertFalse(open)
+
+		// deleting a generating comment ends its streams
+		placeholder, err = Comment.New(dtypes.CommentInput{PostID: 1, UserID: 3, Generating: true})
+		tu.AssertErrorNil(err)
+		_, updates, _, err = Comment.Subscribe(placeholder.ID, 1)
+		tu.AssertErrorNil(err)
+		_, err = Comment.Delete(placeholder.ID, 3, permissions.SYSTEM_ROLE)
+		tu.AssertErrorNil(err)
+		_, open = <-updates
+		tu.AssertFalse(open)
+
+		// comments reply-guy stopped updating are cleaned up, along with
+		// their streams
+		// inserted with an old updated_at, the timestamp trigger resets it on
+		// updates
+		var staleID int
+		err = db.QueryRow(`
+			INSERT INTO Comment (user_id, post_id, content, image, generating, depth, updated_at)
+			VALUES (3, 1, '', '', 1, 0, datetime(current_timestamp, '-1 hours'))
+			RETURNING id;`).Scan(&staleID)
+		tu.AssertErrorNil(err)
+		fresh, err := Comment.New(dtypes.CommentInput{PostID: 1, UserID: 3, Generating: true})
+		tu.AssertErrorNil(err)
+		_, updates, _, err = Comment.Subscribe(staleID, 1)
+		tu.AssertErrorNil(err)
+
+		_, err = Comment.CleanupGenerating()
+		tu.AssertErrorNil(err)
+		_, open = <-updates
+		tu.AssertFalse(open)
+		_, err = Comment.ByID(staleID)
+		tu.AssertTrue(errors.As(err, &model.CommentNotFoundError{}))
+		_, err = Comment.ByID(fresh.ID)
+		tu.AssertErrorNil(err)
+	})
+}
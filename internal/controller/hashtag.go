@@ -0,0 +1,43 @@
+package controller
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/marcusprice/twitter-clone/internal/dtypes"
+	"github.com/marcusprice/twitter-clone/internal/model"
+)
+
+type Hashtag struct {
+	model *model.HashtagModel
+}
+
+// Feed lists posts and comments tagged with tag as seen by viewerID, tag is
+// matched case insensitively with or without its leading #
+func (h *Hashtag) Feed(tag string, viewerID, limit, offset int) (posts []dtypes.TimelinePostData, postsRemaining int, err error) {
+	tag = strings.ToLower(strings.TrimPrefix(tag, "#"))
+
+	posts, err = h.model.GetFeed(tag, viewerID, limit, offset)
+	if err != nil {
+		return []dtypes.TimelinePostData{}, -1, err
+	}
+
+	totalPosts, err := h.model.GetFeedCount(tag, viewerID)
+	if err != nil {
+		return []dtypes.TimelinePostData{}, -1, err
+	}
+
+	return posts, totalPosts - (limit + offset), nil
+}
+
+// Trending returns the most used tags over the last windowHours hours
+func (h *Hashtag) Trending(windowHours, limit int) ([]dtypes.HashtagData, error) {
+	return h.model.GetTrending(fmt.Sprintf("-%d hours", windowHours), limit)
+}
+
+func NewHashtagController(db *sql.DB) *Hashtag {
+	return &Hashtag{
+		model: model.NewHashtagModel(db),
+	}
+}
@@ -0,0 +1,48 @@
+package controller
+
+import (
+	"database/sql"
+	"slices"
+
+	"github.com/marcusprice/twitter-clone/internal/dtypes"
+	"github.com/marcusprice/twitter-clone/internal/model"
+	"github.com/marcusprice/twitter-clone/internal/util"
+)
+
+type Mention struct {
+	model *model.MentionModel
+}
+
+// Feed lists the posts and comments that mention userID
+func (m *Mention) Feed(userID, limit, offset int) (posts []dtypes.TimelinePostData, postsRemaining int, err error) {
+	posts, err = m.model.GetFeed(userID, limit, offset)
+	if err != nil {
+		return []dtypes.TimelinePostData{}, -1, err
+	}
+
+	totalPosts, err := m.model.GetFeedCount(userID)
+	if err != nil {
+		return []dtypes.TimelinePostData{}, -1, err
+	}
+
+	return posts, totalPosts - (limit + offset), nil
+}
+
+// resolvedMentions returns the mentions in content that belong to a user,
+// mentionedUsernames being the ones the Mention table resolved
+func resolvedMentions(content string, mentionedUsernames []string) []dtypes.MentionEntity {
+	mentions := []dtypes.MentionEntity{}
+	for _, mention := range util.ParseMentionEntities(content) {
+		if slices.Contains(mentionedUsernames, mention.Username) {
+			mentions = append(mentions, mention)
+		}
+	}
+
+	return mentions
+}
+
+func NewMentionController(db *sql.DB) *Mention {
+	return &Mention{
+		model: model.NewMentionModel(db),
+	}
+}
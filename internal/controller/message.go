@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"database/sql"
+
+	"github.com/marcusprice/twitter-clone/internal/dtypes"
+	"github.com/marcusprice/twitter-clone/internal/model"
+)
+
+type MessagingRestrictedError struct{}
+
+func (m MessagingRestrictedError) Error() string {
+	return "User only accepts messages from mutual followers"
+}
+
+type Message struct {
+	model     *model.MessageModel
+	userModel *model.UserModel
+}
+
+// Send messages recipientUsername, failing with BlockedError when either user
+// has blocked the other and MessagingRestrictedError when the recipient only
+// accepts messages from mutual followers
+func (m *Message) Send(senderID int, recipientUsername, content string) (dtypes.MessageData, error) {
+	recipient, err := m.userModel.GetByIdentifier("", recipientUsername)
+	if err != nil {
+		return dtypes.MessageData{}, err
+	}
+
+	if recipient.ID == senderID {
+		return dtypes.MessageData{}, UnauthorizedActionError{}
+	}
+
+	err = checkBlockedEitherWay(m.userModel, senderID, recipient.ID)
+	if err != nil {
+		return dtypes.MessageData{}, err
+	}
+
+	if recipient.DMMutualsOnly == 1 {
+		mutual, err := m.userModel.IsMutualFollow(senderID, recipient.ID)
+		if err != nil {
+			return dtypes.MessageData{}, err
+		}
+
+		if !mutual {
+			return dtypes.MessageData{}, MessagingRestrictedError{}
+		}
+	}
+
+	return m.model.Send(senderID, recipient.ID, content)
+}
+
+func (m *Message) Conversations(userID, limit, offset int) (conversations []dtypes.ConversationData, conversationsRemaining int, err error) {
+	conversations, err = m.model.GetConversations(userID, limit, offset)
+	if err != nil {
+		return []dtypes.ConversationData{}, -1, err
+	}
+
+	totalConversations, err := m.model.GetConversationCount(userID)
+	if err != nil {
+		return []dtypes.ConversationData{}, -1, err
+	}
+
+	return conversations, totalConversations - (limit + offset), nil
+}
+
+// GetMessages returns a page of conversationID newest first and marks what
+// userID received as read. model.ConversationNotFoundError when userID isn't
+// part of the conversation, BlockedError when either user blocked the other.
+func (m *Message) GetMessages(userID, conversationID, limit int, cursor *dtypes.Cursor) (messages []dtypes.MessageData, next *dtypes.Cursor, err error) {
+	otherUserID, err := m.model.GetOtherUserID(conversationID, userID)
+	if err != nil {
+		return []dtypes.MessageData{}, nil, err
+	}
+
+	err = checkBlockedEitherWay(m.userModel, userID, otherUserID)
+	if err != nil {
+		return []dtypes.MessageData{}, nil, err
+	}
+
+	messages, next, err = m.model.GetMessages(conversationID, limit, cursor)
+	if err != nil {
+		return []dtypes.MessageData{}, nil, err
+	}
+
+	m.model.MarkRead(conversationID, userID) // okay to silently fail
+	return messages, next, nil
+}
+
+func checkBlockedEitherWay(userModel *model.UserModel, userID, otherUserID int) error {
+	err := checkBlocked(userModel, userID, otherUserID)
+	if err != nil {
+		return err
+	}
+
+	return checkBlocked(userModel, otherUserID, userID)
+}
+
+func NewMessageController(db *sql.DB) *Message {
+	return &Message{
+		model:     model.NewMessageModel(db),
+		userModel: model.NewUserModel(db),
+	}
+}
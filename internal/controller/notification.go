@@ -0,0 +1,52 @@
+package controller
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/marcusprice/twitter-clone/internal/dtypes"
+	"github.com/marcusprice/twitter-clone/internal/model"
+)
+
+type Notification struct {
+	model *model.NotificationModel
+}
+
+func (n *Notification) GetNotifications(userID, limit, offset int, unreadOnly bool) (notifications []dtypes.NotificationData, notificationsRemaining int, err error) {
+	if userID == 0 {
+		return []dtypes.NotificationData{}, -1, errors.New("userID required to fetch notifications")
+	}
+
+	notifications, err = n.model.GetByReceiverID(userID, limit, offset, unreadOnly)
+	if err != nil {
+		return []dtypes.NotificationData{}, -1, err
+	}
+
+	totalNotifications, err := n.model.GetCount(userID, unreadOnly)
+	if err != nil {
+		return []dtypes.NotificationData{}, -1, err
+	}
+
+	return notifications, totalNotifications - (limit + offset), nil
+}
+
+func (n *Notification) UnreadCount(userID int) (int, error) {
+	return n.model.GetCount(userID, true)
+}
+
+// MarkRead marks the user's notifications as read, all of them when
+// notificationIDs is empty
+func (n *Notification) MarkRead(userID int, notificationIDs []int) error {
+	if userID == 0 {
+		return errors.New("userID required to mark notifications read")
+	}
+
+	_, err := n.model.MarkRead(userID, notificationIDs)
+	return err
+}
+
+func NewNotificationController(db *sql.DB) *Notification {
+	return &Notification{
+		model: model.NewNotificationModel(db),
+	}
+}
@@ -4,18 +4,26 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
-	"log"
 	"time"
 
 	"github.com/marcusprice/twitter-clone/internal/dtypes"
 	"github.com/marcusprice/twitter-clone/internal/logger"
 	"github.com/marcusprice/twitter-clone/internal/model"
+	"github.com/marcusprice/twitter-clone/internal/permissions"
 	"github.com/marcusprice/twitter-clone/internal/util"
 )
 
+type UnauthorizedActionError struct{}
+
+func (u UnauthorizedActionError) Error() string {
+	return "User not permitted to perform this action"
+}
+
 type Post struct {
 	model         *model.PostModel
 	postAction    *model.PostAction
+	report        *model.ReportModel
+	user          *model.UserModel
 	comment       *Comment
 	ID            int
 	UserID        int
@@ -26,12 +34,14 @@ type Post struct {
 	BookmarkCount int
 	Impressions   int
 	Image         string
+	ImageAlt      string
 	Liked         bool
 	Retweeted     bool
 	Bookmarked    bool
 	CreatedAt     time.Time
 	UpdatedAt     time.Time
 	Author        dtypes.Author
+	Mentions      []dtypes.MentionEntity
 	Comments      []*Comment
 	dtypes.Retweeter
 }
@@ -46,6 +56,7 @@ func (p *Post) setFromModel(postData dtypes.PostData) {
 	p.BookmarkCount = postData.BookmarkCount
 	p.Impressions = postData.Impressions
 	p.Image = postData.Image
+	p.ImageAlt = postData.ImageAlt
 	p.CreatedAt = util.ParseTime(postData.CreatedAt)
 	p.UpdatedAt = util.ParseTime(postData.UpdatedAt)
 	p.Author.Username = postData.Author.Username
@@ -56,6 +67,7 @@ func (p *Post) setFromModel(postData dtypes.PostData) {
 	p.Liked = postData.Liked == 1
 	p.Retweeted = postData.Retweeted == 1
 	p.Bookmarked = postData.Bookmarked == 1
+	p.Mentions = resolvedMentions(postData.Content, postData.MentionedUsernames)
 }
 
 func (post *Post) New(postInput dtypes.PostInput) error {
@@ -74,17 +86,25 @@ func (post *Post) New(postInput dtypes.PostInput) error {
 	return nil
 }
 
+// GetPostAndComments returns model.PostNotFoundError for missing and hidden
+// posts and PrivateAccountError when userID can't see the author's posts,
+// hidden comments are left out of the tree
 func (post *Post) GetPostAndComments(postID, userID int) (*Post, error) {
 	postData, err := post.model.GetByIDUserContext(userID, postID)
 	if err != nil {
 		logger.LogError("Post.GetPostAndComments() error querying posts:" + err.Error())
-		return &Post{}, nil
+		return &Post{}, err
 	}
 
-	postComments, err := post.comment.GetPostComments(postData.ID)
+	err = checkCanView(post.user, postData.UserID, userID)
+	if err != nil {
+		return &Post{}, err
+	}
+
+	postComments, err := post.comment.GetPostComments(postData.ID, userID)
 	if err != nil {
 		logger.LogError("Post.GetPostAndComments() error querying comments:" + err.Error())
-		return &Post{}, nil
+		return &Post{}, err
 	}
 
 	ret := &Post{}
@@ -106,142 +126,160 @@ func (post *Post) ByID(postID int) error {
 	return nil
 }
 
-func (post *Post) Like(likerUserID int) error {
-	if post.ID == 0 {
-		err := fmt.Errorf("Post.Like(): missing required postID in post controller")
-		if util.InDevContext() {
-			log.Panicf("Like failed: %v", err)
-		}
-
+// Like fails with BlockedError when the post's author has blocked
+// likerUserID, and PrivateAccountError when likerUserID can't see the post
+func (post *Post) Like(postID, likerUserID int) error {
+	postData, err := post.model.GetByID(postID)
+	if err != nil {
 		return err
 	}
 
-	err := post.postAction.Like(post.ID, likerUserID)
+	err = checkBlocked(post.user, postData.UserID, likerUserID)
 	if err != nil {
 		return err
 	}
 
-	err = post.Sync()
+	err = checkCanView(post.user, postData.UserID, likerUserID)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return post.postAction.Like(postID, likerUserID)
 }
 
-func (post *Post) Unlike(likerUserID int) error {
-	if post.ID == 0 {
-		err := fmt.Errorf("Post.Unlike(): missing required postID in post controller")
-		if util.InDevContext() {
-			log.Panicf("Unlike failed: %v", err)
-		}
-
+func (post *Post) Unlike(postID, likerUserID int) error {
+	_, err := post.model.GetByID(postID)
+	if err != nil {
 		return err
 	}
 
-	err := post.postAction.Unlike(post.ID, likerUserID)
+	return post.postAction.Unlike(postID, likerUserID)
+}
+
+// Retweet fails with PrivateAccountError when retweeterID can't see the post
+func (post *Post) Retweet(postID, retweeterID int) error {
+	postData, err := post.model.GetByID(postID)
 	if err != nil {
 		return err
 	}
 
-	err = post.Sync()
+	err = checkCanView(post.user, postData.UserID, retweeterID)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return post.postAction.Retweet(postID, retweeterID)
 }
 
-func (post *Post) Retweet(retweeterID int) error {
-	if post.ID == 0 {
-		err := fmt.Errorf("Post.Retweet(): missing required postID in post controller")
-		if util.InDevContext() {
-			log.Panicf("Like failed: %v", err)
-		}
-
+// QuoteRetweet quotes postID with quoterID's commentary and/or image
+func (post *Post) QuoteRetweet(postID, quoterID int, content, image string) error {
+	postData, err := post.model.GetByID(postID)
+	if err != nil {
 		return err
 	}
 
-	err := post.postAction.Retweet(post.ID, retweeterID)
+	err = checkCanView(post.user, postData.UserID, quoterID)
 	if err != nil {
 		return err
 	}
 
-	err = post.Sync()
+	return post.postAction.QuoteRetweet(postID, quoterID, content, image)
+}
+
+// UnRetweet removes retweeterID's retweet, returning the filename of the
+// quote's image if it had one
+func (post *Post) UnRetweet(postID, retweeterID int) (images []string, err error) {
+	_, err = post.model.GetByID(postID)
 	if err != nil {
-		return err
+		return []string{}, err
 	}
 
-	return nil
+	return post.postAction.UnRetweet(postID, retweeterID)
 }
 
-func (post *Post) UnRetweet(retweeterID int) error {
-	if post.ID == 0 {
-		err := fmt.Errorf("Post.UnRetweet(): missing required postID in post controller")
-		if util.InDevContext() {
-			log.Panicf("Unlike failed: %v", err)
-		}
-
+// Bookmark fails with PrivateAccountError when bookmarkerID can't see the
+// post
+func (post *Post) Bookmark(postID, bookmarkerID int) error {
+	postData, err := post.model.GetByID(postID)
+	if err != nil {
 		return err
 	}
 
-	err := post.postAction.UnRetweet(post.ID, retweeterID)
+	err = checkCanView(post.user, postData.UserID, bookmarkerID)
 	if err != nil {
 		return err
 	}
 
-	err = post.Sync()
+	return post.postAction.Bookmark(postID, bookmarkerID)
+}
+
+func (post *Post) UnBookmark(postID, bookmarkerID int) error {
+	_, err := post.model.GetByID(postID)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return post.postAction.UnBookmark(postID, bookmarkerID)
 }
 
-func (post *Post) Bookmark(bookmarkerID int) error {
-	if post.ID == 0 {
-		err := fmt.Errorf("Post.Bookmark(): missing required postID in post controller")
-		if util.InDevContext() {
-			log.Panicf("Like failed: %v", err)
-		}
+// Edit updates the content of one of userID's posts and returns the edited
+// post
+func (post *Post) Edit(postID, userID int, content string) (*Post, error) {
+	postData, err := post.model.GetByID(postID)
+	if err != nil {
+		return &Post{}, err
+	}
 
-		return err
+	if postData.UserID != userID {
+		logger.LogWarn(fmt.Sprintf("Post.Edit(): user %d attempted to edit post %d", userID, postID))
+		return &Post{}, UnauthorizedActionError{}
 	}
 
-	err := post.postAction.Bookmark(post.ID, bookmarkerID)
+	err = post.model.UpdateContent(postID, content)
 	if err != nil {
-		return err
+		return &Post{}, err
 	}
 
-	err = post.Sync()
+	postData, err = post.model.GetByID(postID)
 	if err != nil {
-		return err
+		return &Post{}, err
 	}
 
-	return nil
-}
+	edited := &Post{}
+	edited.setFromModel(postData)
 
-func (post *Post) UnBookmark(bookmarkerID int) error {
-	if post.ID == 0 {
-		err := fmt.Errorf("Post.UnBookmark(): missing required postID in post controller")
-		if util.InDevContext() {
-			log.Panicf("Unlike failed: %v", err)
-		}
+	return edited, nil
+}
 
-		return err
+// Delete removes a post if userID is its author or an admin, returning the
+// filenames of images orphaned by the delete
+func (post *Post) Delete(postID, userID int, role permissions.Role) (images []string, err error) {
+	getByID := post.model.GetByID
+	if role == permissions.ADMIN_ROLE {
+		getByID = post.model.GetByIDIncludingHidden
 	}
 
-	err := post.postAction.UnBookmark(post.ID, bookmarkerID)
+	postData, err := getByID(postID)
 	if err != nil {
-		return err
+		return []string{}, err
+	}
+
+	if postData.UserID != userID && role != permissions.ADMIN_ROLE {
+		logger.LogWarn(fmt.Sprintf("Post.Delete(): user %d attempted to delete post %d", userID, postID))
+		return []string{}, UnauthorizedActionError{}
 	}
 
-	err = post.Sync()
+	return post.model.Delete(postID)
+}
+
+// Report flags postID for the admin moderation queue
+func (post *Post) Report(postID, reporterID int, reason model.ReportReason, details string) error {
+	_, err := post.model.GetByID(postID)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return post.report.New(reporterID, postID, 0, reason, details)
 }
 
 func (post *Post) AddImpression() error {
@@ -272,6 +310,8 @@ func NewPostController(db *sql.DB) *Post {
 	return &Post{
 		model:      model.NewPostModel(db),
 		postAction: model.NewPostActionModel(db),
+		report:     model.NewReportModel(db),
+		user:       model.NewUserModel(db),
 		comment:    commentController,
 	}
 }
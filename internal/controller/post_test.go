@@ -157,38 +157,37 @@ func TestPostLike(t *testing.T) {
 		user3 := NewUserController(db)
 		user4 := NewUserController(db)
 		post := NewPostController(db)
+		likeCount := func() int {
+			postData, _ := post.model.GetByID(1)
+			return postData.LikeCount
+		}
 		user1.ByID(1)
 		user2.ByID(2)
 		user3.ByID(3)
 		user4.ByID(4)
 
-		err := post.Like(user1.ID())
-		tu.AssertErrorNotNil(err)
-		tu.AssertEqual(
-			"Post.Like(): missing required postID in post controller",
-			err.Error(),
-		)
-
-		post.ByID(1)
+		err := post.Like(0, user1.ID())
+		var postNotFoundErr model.PostNotFoundError
+		tu.AssertTrue(errors.As(err, &postNotFoundErr))
 
-		err = post.Like(user1.ID())
+		err = post.Like(1, user1.ID())
 		tu.AssertErrorNil(err)
-		tu.AssertEqual(1, post.LikeCount)
+		tu.AssertEqual(1, likeCount())
 
-		err = post.Like(user2.ID())
+		err = post.Like(1, user2.ID())
 		tu.AssertErrorNil(err)
-		tu.AssertEqual(2, post.LikeCount)
+		tu.AssertEqual(2, likeCount())
 
-		err = post.Like(user3.ID())
+		err = post.Like(1, user3.ID())
 		tu.AssertErrorNil(err)
-		err = post.Like(user4.ID())
+		err = post.Like(1, user4.ID())
 		tu.AssertErrorNil(err)
-		tu.AssertEqual(4, post.LikeCount)
+		tu.AssertEqual(4, likeCount())
 
 		// user4 likes again, no error expected and count stays the same
-		err = post.Like(user4.ID())
+		err = post.Like(1, user4.ID())
 		tu.AssertErrorNil(err)
-		tu.AssertEqual(4, post.LikeCount)
+		tu.AssertEqual(4, likeCount())
 	})
 }
 
@@ -200,47 +199,47 @@ func TestPostUnlike(t *testing.T) {
 		user3 := NewUserController(db)
 		user4 := NewUserController(db)
 		post := NewPostController(db)
+		likeCount := func() int {
+			postData, _ := post.model.GetByID(1)
+			return postData.LikeCount
+		}
 		user1.ByID(1)
 		user2.ByID(2)
 		user3.ByID(3)
 		user4.ByID(4)
 
-		err := post.Unlike(user1.ID())
-		tu.AssertErrorNotNil(err)
-		tu.AssertEqual(
-			"Post.Unlike(): missing required postID in post controller",
-			err.Error(),
-		)
+		err := post.Unlike(0, user1.ID())
+		var postNotFoundErr model.PostNotFoundError
+		tu.AssertTrue(errors.As(err, &postNotFoundErr))
 
-		post.ByID(1)
-		post.Like(user1.ID())
-		post.Like(user2.ID())
-		post.Like(user3.ID())
-		post.Like(user4.ID())
+		post.Like(1, user1.ID())
+		post.Like(1, user2.ID())
+		post.Like(1, user3.ID())
+		post.Like(1, user4.ID())
 
-		err = post.Unlike(user4.ID())
+		err = post.Unlike(1, user4.ID())
 		tu.AssertErrorNil(err)
-		tu.AssertEqual(3, post.LikeCount)
+		tu.AssertEqual(3, likeCount())
 
-		err = post.Unlike(user4.ID())
+		err = post.Unlike(1, user4.ID())
 		tu.AssertErrorNil(err)
-		tu.AssertEqual(3, post.LikeCount)
+		tu.AssertEqual(3, likeCount())
 
-		err = post.Unlike(user3.ID())
+		err = post.Unlike(1, user3.ID())
 		tu.AssertErrorNil(err)
-		tu.AssertEqual(2, post.LikeCount)
+		tu.AssertEqual(2, likeCount())
 
-		err = post.Unlike(user2.ID())
+		err = post.Unlike(1, user2.ID())
 		tu.AssertErrorNil(err)
-		tu.AssertEqual(1, post.LikeCount)
+		tu.AssertEqual(1, likeCount())
 
-		err = post.Unlike(user1.ID())
+		err = post.Unlike(1, user1.ID())
 		tu.AssertErrorNil(err)
-		tu.AssertEqual(0, post.LikeCount)
+		tu.AssertEqual(0, likeCount())
 
-		err = post.Unlike(user1.ID())
+		err = post.Unlike(1, user1.ID())
 		tu.AssertErrorNil(err)
-		tu.AssertEqual(0, post.LikeCount)
+		tu.AssertEqual(0, likeCount())
 	})
 }
 
@@ -252,39 +251,38 @@ func TestPostRetweet(t *testing.T) {
 		user3 := NewUserController(db)
 		user4 := NewUserController(db)
 		post := NewPostController(db)
+		retweetCount := func() int {
+			postData, _ := post.model.GetByID(1)
+			return postData.RetweetCount
+		}
 		user1.ByID(1)
 		user2.ByID(2)
 		user3.ByID(3)
 		user4.ByID(4)
 
-		err := post.Retweet(user1.ID())
-		tu.AssertErrorNotNil(err)
-		tu.AssertEqual(
-			"Post.Retweet(): missing required postID in post controller",
-			err.Error(),
-		)
-
-		post.ByID(1)
+		err := post.Retweet(0, user1.ID())
+		var postNotFoundErr model.PostNotFoundError
+		tu.AssertTrue(errors.As(err, &postNotFoundErr))
 
-		err = post.Retweet(user1.ID())
+		err = post.Retweet(1, user1.ID())
 		tu.AssertErrorNil(err)
-		tu.AssertEqual(1, post.RetweetCount)
+		tu.AssertEqual(1, retweetCount())
 
-		err = post.Retweet(user2.ID())
+		err = post.Retweet(1, user2.ID())
 		tu.AssertErrorNil(err)
-		tu.AssertEqual(2, post.RetweetCount)
+		tu.AssertEqual(2, retweetCount())
 
-		err = post.Retweet(user3.ID())
+		err = post.Retweet(1, user3.ID())
 		tu.AssertErrorNil(err)
 
-		err = post.Retweet(user4.ID())
+		err = post.Retweet(1, user4.ID())
 		tu.AssertErrorNil(err)
-		tu.AssertEqual(4, post.RetweetCount)
+		tu.AssertEqual(4, retweetCount())
 
 		// user4 retweets again, no error expected and count stays the same
-		err = post.Retweet(user4.ID())
+		err = post.Retweet(1, user4.ID())
 		tu.AssertErrorNil(err)
-		tu.AssertEqual(4, post.RetweetCount)
+		tu.AssertEqual(4, retweetCount())
 	})
 }
 
@@ -296,47 +294,47 @@ func TestPostUnRetweet(t *testing.T) {
 		user3 := NewUserController(db)
 		user4 := NewUserController(db)
 		post := NewPostController(db)
+		retweetCount := func() int {
+			postData, _ := post.model.GetByID(1)
+			return postData.RetweetCount
+		}
 		user1.ByID(1)
 		user2.ByID(2)
 		user3.ByID(3)
 		user4.ByID(4)
 
-		err := post.UnRetweet(user1.ID())
-		tu.AssertErrorNotNil(err)
-		tu.AssertEqual(
-			"Post.UnRetweet(): missing required postID in post controller",
-			err.Error(),
-		)
+		_, err := post.UnRetweet(0, user1.ID())
+		var postNotFoundErr model.PostNotFoundError
+		tu.AssertTrue(errors.As(err, &postNotFoundErr))
 
-		post.ByID(1)
-		post.Retweet(user1.ID())
-		post.Retweet(user2.ID())
-		post.Retweet(user3.ID())
-		post.Retweet(user4.ID())
+		post.Retweet(1, user1.ID())
+		post.Retweet(1, user2.ID())
+		post.Retweet(1, user3.ID())
+		post.Retweet(1, user4.ID())
 
-		err = post.UnRetweet(user4.ID())
+		_, err = post.UnRetweet(1, user4.ID())
 		tu.AssertErrorNil(err)
-		tu.AssertEqual(3, post.RetweetCount)
+		tu.AssertEqual(3, retweetCount())
 
-		err = post.UnRetweet(user4.ID())
+		_, err = post.UnRetweet(1, user4.ID())
 		tu.AssertErrorNil(err)
-		tu.AssertEqual(3, post.RetweetCount)
+		tu.AssertEqual(3, retweetCount())
 
-		err = post.UnRetweet(user3.ID())
+		_, err = post.UnRetweet(1, user3.ID())
 		tu.AssertErrorNil(err)
-		tu.AssertEqual(2, post.RetweetCount)
+		tu.AssertEqual(2, retweetCount())
 
-		err = post.UnRetweet(user2.ID())
+		_, err = post.UnRetweet(1, user2.ID())
 		tu.AssertErrorNil(err)
-		tu.AssertEqual(1, post.RetweetCount)
+		tu.AssertEqual(1, retweetCount())
 
-		err = post.UnRetweet(user1.ID())
+		_, err = post.UnRetweet(1, user1.ID())
 		tu.AssertErrorNil(err)
-		tu.AssertEqual(0, post.RetweetCount)
+		tu.AssertEqual(0, retweetCount())
 
-		err = post.UnRetweet(user1.ID())
+		_, err = post.UnRetweet(1, user1.ID())
 		tu.AssertErrorNil(err)
-		tu.AssertEqual(0, post.RetweetCount)
+		tu.AssertEqual(0, retweetCount())
 	})
 }
 
@@ -348,39 +346,38 @@ func TestPostBookmark(t *testing.T) {
 		user3 := NewUserController(db)
 		user4 := NewUserController(db)
 		post := NewPostController(db)
+		bookmarkCount := func() int {
+			postData, _ := post.model.GetByID(1)
+			return postData.BookmarkCount
+		}
 		user1.ByID(1)
 		user2.ByID(2)
 		user3.ByID(3)
 		user4.ByID(4)
 
-		err := post.Bookmark(user1.ID())
-		tu.AssertErrorNotNil(err)
-		tu.AssertEqual(
-			"Post.Bookmark(): missing required postID in post controller",
-			err.Error(),
-		)
-
-		post.ByID(1)
+		err := post.Bookmark(0, user1.ID())
+		var postNotFoundErr model.PostNotFoundError
+		tu.AssertTrue(errors.As(err, &postNotFoundErr))
 
-		err = post.Bookmark(user1.ID())
+		err = post.Bookmark(1, user1.ID())
 		tu.AssertErrorNil(err)
-		tu.AssertEqual(1, post.BookmarkCount)
+		tu.AssertEqual(1, bookmarkCount())
 
-		err = post.Bookmark(user2.ID())
+		err = post.Bookmark(1, user2.ID())
 		tu.AssertErrorNil(err)
-		tu.AssertEqual(2, post.BookmarkCount)
+		tu.AssertEqual(2, bookmarkCount())
 
-		err = post.Bookmark(user3.ID())
+		err = post.Bookmark(1, user3.ID())
 		tu.AssertErrorNil(err)
 
-		err = post.Bookmark(user4.ID())
+		err = post.Bookmark(1, user4.ID())
 		tu.AssertErrorNil(err)
-		tu.AssertEqual(4, post.BookmarkCount)
+		tu.AssertEqual(4, bookmarkCount())
 
 		// user4 retweets again, no error expected and count stays the same
-		err = post.Bookmark(user4.ID())
+		err = post.Bookmark(1, user4.ID())
 		tu.AssertErrorNil(err)
-		tu.AssertEqual(4, post.BookmarkCount)
+		tu.AssertEqual(4, bookmarkCount())
 	})
 }
 
@@ -392,47 +389,68 @@ func TestPostUnBookmark(t *testing.T) {
 		user3 := NewUserController(db)
 		user4 := NewUserController(db)
 		post := NewPostController(db)
+		bookmarkCount := func() int {
+			postData, _ := post.model.GetByID(1)
+			return postData.BookmarkCount
+		}
 		user1.ByID(1)
 		user2.ByID(2)
 		user3.ByID(3)
 		user4.ByID(4)
 
-		err := post.UnBookmark(user1.ID())
-		tu.AssertErrorNotNil(err)
-		tu.AssertEqual(
-			"Post.UnBookmark(): missing required postID in post controller",
-			err.Error(),
-		)
+		err := post.UnBookmark(0, user1.ID())
+		var postNotFoundErr model.PostNotFoundError
+		tu.AssertTrue(errors.As(err, &postNotFoundErr))
 
-		post.ByID(1)
-		post.Bookmark(user1.ID())
-		post.Bookmark(user2.ID())
-		post.Bookmark(user3.ID())
-		post.Bookmark(user4.ID())
+		post.Bookmark(1, user1.ID())
+		post.Bookmark(1, user2.ID())
+		post.Bookmark(1, user3.ID())
+		post.Bookmark(1, user4.ID())
 
-		err = post.UnBookmark(user4.ID())
+		err = post.UnBookmark(1, user4.ID())
 		tu.AssertErrorNil(err)
-		tu.AssertEqual(3, post.BookmarkCount)
+		tu.AssertEqual(3, bookmarkCount())
 
-		err = post.UnBookmark(user4.ID())
+		err = post.UnBookmark(1, user4.ID())
 		tu.AssertErrorNil(err)
-		tu.AssertEqual(3, post.BookmarkCount)
+		tu.AssertEqual(3, bookmarkCount())
 
-		err = post.UnBookmark(user3.ID())
+		err = post.UnBookmark(1, user3.ID())
 		tu.AssertErrorNil(err)
-		tu.AssertEqual(2, post.BookmarkCount)
+		tu.AssertEqual(2, bookmarkCount())
 
-		err = post.UnBookmark(user2.ID())
+		err = post.UnBookmark(1, user2.ID())
 		tu.AssertErrorNil(err)
-		tu.AssertEqual(1, post.BookmarkCount)
+		tu.AssertEqual(1, bookmarkCount())
 
-		err = post.UnBookmark(user1.ID())
+		err = post.UnBookmark(1, user1.ID())
 		tu.AssertErrorNil(err)
-		tu.AssertEqual(0, post.BookmarkCount)
+		tu.AssertEqual(0, bookmarkCount())
 
-		err = post.UnBookmark(user1.ID())
+		err = post.UnBookmark(1, user1.ID())
 		tu.AssertErrorNil(err)
-		tu.AssertEqual(0, post.BookmarkCount)
+		tu.AssertEqual(0, bookmarkCount())
+	})
+}
+
+func TestPostEdit(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, timestamp time.Time) {
+		tu := testutil.NewTestUtil(t)
+		post := NewPostController(db)
+
+		edited, err := post.Edit(1, 3, "Diane, correction: chocolate rabbits.")
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(1, edited.ID)
+		tu.AssertEqual("Diane, correction: chocolate rabbits.", edited.Content)
+		// the shared controller isn't left holding the edited post
+		tu.AssertEqual(0, post.ID)
+
+		_, err = post.Edit(1, 2, "hacked")
+		tu.AssertTrue(errors.Is(err, UnauthorizedActionError{}))
+
+		_, err = post.Edit(42069, 3, "hi")
+		var postNotFoundErr model.PostNotFoundError
+		tu.AssertTrue(errors.As(err, &postNotFoundErr))
 	})
 }
 
@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/marcusprice/twitter-clone/internal/dtypes"
+	"github.com/marcusprice/twitter-clone/internal/model"
+)
+
+const MUTUAL_FOLLOWERS_LIMIT = 3
+
+type Profile struct {
+	userID    int
+	viewerID  int
+	userModel *model.UserModel
+	postModel *model.PostModel
+}
+
+// Set loads the profile owner by username, viewerID is used for the
+// viewer relative fields (ViewerFollowing, MutalFollowers)
+func (p *Profile) Set(username string, viewerID int) error {
+	userData, err := p.userModel.GetByIdentifier("", username)
+	if err != nil {
+		return err
+	}
+
+	p.userID = userData.ID
+	p.viewerID = viewerID
+	return nil
+}
+
+func (p *Profile) Get() (dtypes.Author, error) {
+	if p.userID == 0 {
+		return dtypes.Author{}, errors.New("profile user required")
+	}
+
+	profile, err := p.userModel.GetProfile(p.userID, p.viewerID)
+	if err != nil {
+		return dtypes.Author{}, err
+	}
+
+	if p.userID != p.viewerID {
+		mutuals, err := p.userModel.GetMutualFollowers(p.userID, p.viewerID, MUTUAL_FOLLOWERS_LIMIT)
+		if err != nil {
+			return dtypes.Author{}, err
+		}
+		profile.MutalFollowers = mutuals
+	}
+
+	return profile, nil
+}
+
+// GetPosts fails with PrivateAccountError when the viewer can't see the
+// profile user's posts
+func (p *Profile) GetPosts(limit, offset int) (posts []dtypes.TimelinePostData, postsRemaining int, err error) {
+	if p.userID == 0 {
+		return []dtypes.TimelinePostData{}, -1, errors.New("profile user required")
+	}
+
+	err = checkCanView(p.userModel, p.userID, p.viewerID)
+	if err != nil {
+		return []dtypes.TimelinePostData{}, -1, err
+	}
+
+	posts, err = p.postModel.GetByUserID(p.viewerID, p.userID, limit, offset)
+	if err != nil {
+		return []dtypes.TimelinePostData{}, -1, err
+	}
+
+	totalPosts, err := p.postModel.UserPostCount(p.userID)
+	if err != nil {
+		return []dtypes.TimelinePostData{}, -1, err
+	}
+
+	return posts, totalPosts - (limit + offset), nil
+}
+
+// GetFollowers and GetFollowing fail with PrivateAccountError like GetPosts
+func (p *Profile) GetFollowers(limit, offset int) (users []dtypes.Author, usersRemaining int, err error) {
+	err = checkCanView(p.userModel, p.userID, p.viewerID)
+	if err != nil {
+		return []dtypes.Author{}, -1, err
+	}
+
+	profile, err := p.userModel.GetProfile(p.userID, p.viewerID)
+	if err != nil {
+		return []dtypes.Author{}, -1, err
+	}
+
+	users, err = p.userModel.GetFollowers(p.userID, p.viewerID, limit, offset)
+	if err != nil {
+		return []dtypes.Author{}, -1, err
+	}
+
+	return users, profile.FollowerCount - (limit + offset), nil
+}
+
+func (p *Profile) GetFollowing(limit, offset int) (users []dtypes.Author, usersRemaining int, err error) {
+	err = checkCanView(p.userModel, p.userID, p.viewerID)
+	if err != nil {
+		return []dtypes.Author{}, -1, err
+	}
+
+	profile, err := p.userModel.GetProfile(p.userID, p.viewerID)
+	if err != nil {
+		return []dtypes.Author{}, -1, err
+	}
+
+	users, err = p.userModel.GetFollowing(p.userID, p.viewerID, limit, offset)
+	if err != nil {
+		return []dtypes.Author{}, -1, err
+	}
+
+	return users, profile.FollowingCount - (limit + offset), nil
+}
+
+func NewProfileController(db *sql.DB) *Profile {
+	if db == nil {
+		panic("db conn cannot be nil")
+	}
+
+	return &Profile{
+		userModel: model.NewUserModel(db),
+		postModel: model.NewPostModel(db),
+	}
+}
@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"database/sql"
+
+	"github.com/marcusprice/twitter-clone/internal/dtypes"
+	"github.com/marcusprice/twitter-clone/internal/model"
+)
+
+type Search struct {
+	model *model.SearchModel
+}
+
+func (s *Search) Posts(query string, viewerID, limit, offset int) (results []dtypes.SearchPostData, resultsRemaining int, err error) {
+	return s.timelineRows(s.model.Posts, s.model.PostsCount, query, viewerID, limit, offset)
+}
+
+func (s *Search) Comments(query string, viewerID, limit, offset int) (results []dtypes.SearchPostData, resultsRemaining int, err error) {
+	return s.timelineRows(s.model.Comments, s.model.CommentsCount, query, viewerID, limit, offset)
+}
+
+func (s *Search) timelineRows(
+	search func(string, int, int, int) ([]dtypes.SearchPostData, error),
+	count func(string, int) (int, error),
+	query string,
+	viewerID, limit, offset int,
+) (results []dtypes.SearchPostData, resultsRemaining int, err error) {
+	results, err = search(query, viewerID, limit, offset)
+	if err != nil {
+		return []dtypes.SearchPostData{}, -1, err
+	}
+
+	totalResults, err := count(query, viewerID)
+	if err != nil {
+		return []dtypes.SearchPostData{}, -1, err
+	}
+
+	return results, totalResults - (limit + offset), nil
+}
+
+func (s *Search) Users(query string, viewerID, limit, offset int) (results []dtypes.SearchUserData, resultsRemaining int, err error) {
+	results, err = s.model.Users(query, viewerID, limit, offset)
+	if err != nil {
+		return []dtypes.SearchUserData{}, -1, err
+	}
+
+	totalResults, err := s.model.UsersCount(query, viewerID)
+	if err != nil {
+		return []dtypes.SearchUserData{}, -1, err
+	}
+
+	return results, totalResults - (limit + offset), nil
+}
+
+func NewSearchController(db *sql.DB) *Search {
+	return &Search{
+		model: model.NewSearchModel(db),
+	}
+}
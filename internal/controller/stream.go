@@ -0,0 +1,90 @@
+package controller
+
+import "sync"
+
+// CommentStreams fans out updates to comments reply-guy is still generating.
+// Updates carry the whole comment, so a subscriber that falls behind only
+// gets the latest one.
+type CommentStreams struct {
+	lock        sync.Mutex
+	subscribers map[int]map[chan *Comment]struct{}
+}
+
+// Subscribe returns a channel of updates to commentID, closed once the
+// comment is done or unsubscribe is called
+func (cs *CommentStreams) Subscribe(commentID int) (updates <-chan *Comment, unsubscribe func()) {
+	cs.lock.Lock()
+	defer cs.lock.Unlock()
+
+	channel := make(chan *Comment, 1)
+	if cs.subscribers[commentID] == nil {
+		cs.subscribers[commentID] = make(map[chan *Comment]struct{})
+	}
+	cs.subscribers[commentID][channel] = struct{}{}
+
+	return channel, func() {
+		cs.lock.Lock()
+		defer cs.lock.Unlock()
+
+		if _, ok := cs.subscribers[commentID][channel]; ok {
+			cs.remove(commentID, channel)
+		}
+	}
+}
+
+// publish sends comment to its subscribers, closing their channels after the
+// last update
+func (cs *CommentStreams) publish(comment *Comment) {
+	// controllers built without streams, like in tests
+	if cs == nil {
+		return
+	}
+
+	cs.lock.Lock()
+	defer cs.lock.Unlock()
+
+	for channel := range cs.subscribers[comment.ID] {
+		select {
+		case channel <- comment:
+		default:
+			// drop the stale update unless the subscriber just took it, only
+			// publish sends so there's room after either way
+			select {
+			case <-channel:
+			default:
+			}
+			channel <- comment
+		}
+
+		if !comment.Generating {
+			cs.remove(comment.ID, channel)
+		}
+	}
+}
+
+// end closes commentID's subscriptions without a final update, for comments
+// deleted while generating
+func (cs *CommentStreams) end(commentID int) {
+	if cs == nil {
+		return
+	}
+
+	cs.lock.Lock()
+	defer cs.lock.Unlock()
+
+	for channel := range cs.subscribers[commentID] {
+		cs.remove(commentID, channel)
+	}
+}
+
+func (cs *CommentStreams) remove(commentID int, channel chan *Comment) {
+	close(channel)
+	delete(cs.subscribers[commentID], channel)
+	if len(cs.subscribers[commentID]) == 0 {
+		delete(cs.subscribers, commentID)
+	}
+}
+
+func NewCommentStreams() *CommentStreams {
+	return &CommentStreams{subscribers: make(map[int]map[chan *Comment]struct{})}
+}
@@ -0,0 +1,62 @@
+package controller
+
+import (
+	"database/sql"
+
+	"github.com/marcusprice/twitter-clone/internal/dtypes"
+	"github.com/marcusprice/twitter-clone/internal/model"
+)
+
+const (
+	SUGGESTION_REASON_MUTUAL  = "mutual"
+	SUGGESTION_REASON_LIKED   = "liked"
+	SUGGESTION_REASON_POPULAR = "popular"
+)
+
+type Suggestion struct {
+	userModel *model.UserModel
+}
+
+// ForUser lists accounts userID might want to follow, each with up to
+// MUTUAL_FOLLOWERS_LIMIT of the accounts they share with userID
+func (s *Suggestion) ForUser(userID, limit int) ([]dtypes.UserSuggestion, error) {
+	suggestions, err := s.userModel.GetSuggestions(userID, limit)
+	if err != nil {
+		return []dtypes.UserSuggestion{}, err
+	}
+
+	for index := range suggestions {
+		suggestion := &suggestions[index]
+		if suggestion.MutualCount == 0 {
+			continue
+		}
+
+		mutuals, err := s.userModel.GetMutualFollowers(suggestion.UserID, userID, MUTUAL_FOLLOWERS_LIMIT)
+		if err != nil {
+			return []dtypes.UserSuggestion{}, err
+		}
+		suggestion.User.MutalFollowers = mutuals
+	}
+
+	return suggestions, nil
+}
+
+// SuggestionReason is why an account was suggested, shared connections win
+// over likes
+func SuggestionReason(suggestion dtypes.UserSuggestion) string {
+	if suggestion.MutualCount > 0 {
+		return SUGGESTION_REASON_MUTUAL
+	}
+
+	if suggestion.LikedCount > 0 {
+		return SUGGESTION_REASON_LIKED
+	}
+
+	return SUGGESTION_REASON_POPULAR
+}
+
+func NewSuggestionController(db *sql.DB) *Suggestion {
+	return &Suggestion{
+		userModel: model.NewUserModel(db),
+	}
+}
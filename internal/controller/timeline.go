@@ -3,9 +3,14 @@ package controller
 import (
 	"database/sql"
 	"errors"
+	"fmt"
+	"time"
 
+	"github.com/marcusprice/twitter-clone/internal/constants"
 	"github.com/marcusprice/twitter-clone/internal/dtypes"
 	"github.com/marcusprice/twitter-clone/internal/model"
+	"github.com/marcusprice/twitter-clone/internal/ranking"
+	"github.com/marcusprice/twitter-clone/internal/util"
 )
 
 type TimelineView string
@@ -21,6 +26,8 @@ type Timeline struct {
 	userModel *model.UserModel
 	postModel *model.PostModel
 	posts     []*Post
+	weights   ranking.Weights
+	now       func() time.Time
 }
 
 func (t *Timeline) Set(userID int, view TimelineView) *Timeline {
@@ -29,36 +36,27 @@ func (t *Timeline) Set(userID int, view TimelineView) *Timeline {
 	return t
 }
 
-func (t *Timeline) GetPosts(limit, offset int) (posts []dtypes.TimelinePostData, postsRemaining int, err error) {
+// GetPosts returns the page of the timeline after cursor, a nil cursor fetches
+// the first page and a nil next cursor means there are no more pages. FOLLOWING
+// is chronological, FOR_YOU is ranked.
+func (t *Timeline) GetPosts(limit int, cursor *dtypes.Cursor) (posts []dtypes.TimelinePostData, next *dtypes.Cursor, err error) {
 	if t.userID == 0 {
-		return []dtypes.TimelinePostData{}, -1, errors.New("userID required to fetch posts")
+		return []dtypes.TimelinePostData{}, nil, errors.New("userID required to fetch posts")
 	}
 
 	var postRows []dtypes.TimelinePostData
 	var postIDs []int
-	var totalPosts int
 	if t.view == FOLLOWING {
-		postRows, postIDs, err = t.postModel.QueryUserFollowingTimeline(t.userID, limit, offset)
-		if err != nil {
-			return []dtypes.TimelinePostData{}, -1, err
-		}
-
-		totalPosts, err = t.postModel.UserFollowingTimelineCount(t.userID)
-		if err != nil {
-			return []dtypes.TimelinePostData{}, -1, err
-		}
+		postRows, postIDs, next, err = t.postModel.QueryUserFollowingTimeline(t.userID, limit, cursor)
 	} else {
-		postRows, postIDs, err = t.postModel.GetAllIncludingRetweets(t.userID, limit, offset)
-		if err != nil {
-			return []dtypes.TimelinePostData{}, -1, err
-		}
-
-		totalPosts, err = t.postModel.AllIncludingRetweetCount()
-		if err != nil {
-			return []dtypes.TimelinePostData{}, -1, err
-		}
+		postRows, postIDs, next, err = t.rankedPosts(limit, cursor)
+	}
+	if err != nil {
+		return []dtypes.TimelinePostData{}, nil, err
 	}
 
+	t.postModel.AddViews(t.userID, postIDs) // okay to silently fail
+
 	rowsAffected := 0
 	// TODO: this is a performance bottleneck
 	// TODO: addimpressionbulk for comment retweets
@@ -66,24 +64,107 @@ func (t *Timeline) GetPosts(limit, offset int) (posts []dtypes.TimelinePostData,
 		rowsAffected, _ = t.postModel.AddImpressionBulk(postIDs) // okay to silently fail
 	}
 
-	if err != nil {
-		return []dtypes.TimelinePostData{}, -1, err
-	}
-
 	for _, row := range postRows {
-		if rowsAffected == len(postIDs) && row.Type != "comment-reply" {
+		if rowsAffected == len(postIDs) && row.Type != "comment-reply" && row.Type != "post-quote" {
 			row.Impressions += 1
 		}
 		posts = append(posts, row)
-		postIDs = append(postIDs, row.ID)
 	}
 
-	return posts, totalPosts - (limit + offset), nil
+	return posts, next, nil
+}
+
+// rankedPosts ranks the For You candidates and returns the page after cursor.
+// The cursor pins the ranking time, so later pages rank the same candidates
+// the same way and newer posts wait for the next first page.
+func (t *Timeline) rankedPosts(limit int, cursor *dtypes.Cursor) (postRows []dtypes.TimelinePostData, postIDs []int, next *dtypes.Cursor, err error) {
+	if limit <= 0 {
+		return []dtypes.TimelinePostData{}, []int{}, nil, errors.New("Positive limit value required")
+	}
+
+	rankedAt := t.now().UTC().Format(constants.TIME_LAYOUT)
+	var after *ranking.Ranked
+	if cursor != nil && cursor.RankedAt != "" {
+		rankedAt = cursor.RankedAt
+		after = &ranking.Ranked{
+			Candidate: ranking.Candidate{
+				Type:     cursor.Type,
+				ID:       cursor.ID,
+				SortTime: util.ParseTime(cursor.SortTime),
+			},
+			Score: cursor.Score,
+		}
+	}
+
+	rows, err := t.postModel.GetForYouCandidates(t.userID, rankedAt, t.weights.CandidatePool)
+	if err != nil {
+		return []dtypes.TimelinePostData{}, []int{}, nil, err
+	}
+
+	candidates := make([]ranking.Candidate, len(rows))
+	for index, row := range rows {
+		candidates[index] = rankingCandidate(row)
+	}
+
+	ranked := ranking.Rank(candidates, t.weights, util.ParseTime(rankedAt))
+	page, more := ranking.Page(ranked, after, limit)
+	for _, row := range page {
+		post := rows[row.Index].Post
+		postRows = append(postRows, post)
+
+		switch post.Type {
+		case "comment-retweet":
+			// comment impressions aren't tracked by AddImpressionBulk
+		case "post-quote":
+			postIDs = append(postIDs, post.QuotedPost.ID)
+		default:
+			postIDs = append(postIDs, post.ID)
+		}
+	}
+
+	if more {
+		last := page[len(page)-1]
+		next = &dtypes.Cursor{
+			SortTime: rows[last.Index].Post.SortTime,
+			Type:     last.Type,
+			ID:       last.ID,
+			RankedAt: rankedAt,
+			Score:    last.Score,
+		}
+	}
+
+	return postRows, postIDs, next, nil
+}
+
+func rankingCandidate(row dtypes.ForYouCandidate) ranking.Candidate {
+	contentKey := ""
+	switch row.Post.Type {
+	case "post", "post-retweet":
+		contentKey = fmt.Sprintf("post:%d", row.Post.ID)
+	case "comment-retweet":
+		contentKey = fmt.Sprintf("comment:%d", row.Post.ID)
+	}
+
+	return ranking.Candidate{
+		Type:          row.Post.Type,
+		ID:            row.SortID,
+		ContentKey:    contentKey,
+		SortTime:      util.ParseTime(row.Post.SortTime),
+		LikeCount:     row.Post.LikeCount,
+		RetweetCount:  row.Post.RetweetCount,
+		CommentCount:  row.Post.CommentCount,
+		Impressions:   row.Post.Impressions,
+		FollowsAuthor: row.FollowsAuthor,
+		AuthorLikes:   row.AuthorLikes,
+		Seen:          row.Seen,
+	}
 }
 
 func NewTimelineController(db *sql.DB) *Timeline {
 	return &Timeline{
 		userModel: model.NewUserModel(db),
 		postModel: model.NewPostModel(db),
+		weights:   ranking.WeightsFromEnv(),
+		now:       time.Now,
 	}
 }
@@ -2,22 +2,17 @@ package controller
 
 import (
 	"database/sql"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/marcusprice/twitter-clone/internal/constants"
+	"github.com/marcusprice/twitter-clone/internal/dtypes"
+	"github.com/marcusprice/twitter-clone/internal/ranking"
 	"github.com/marcusprice/twitter-clone/internal/testhelpers"
 	"github.com/marcusprice/twitter-clone/internal/testutil"
 )
 
-func TestTimelineSetID(t *testing.T) {
-	tu := testutil.NewTestUtil(t)
-	timeline := &Timeline{}
-	timeline2 := timeline.SetID(42069)
-	tu.AssertEqual(timeline.userID, timeline2.userID)
-	tu.AssertEqual(timeline.userID, timeline2.userID)
-}
-
 func TestTimelineGetPosts(t *testing.T) {
 	testutil.WithTestData(t, func(db *sql.DB, _ time.Time) {
 		tu := testutil.NewTestUtil(t)
@@ -28,20 +23,22 @@ func TestTimelineGetPosts(t *testing.T) {
 		user2.ByID(2)
 		user1.Follow(user2.Username)
 
-		posts, postsRemaining, err := timeline.GetPosts(10, 0)
+		posts, next, err := timeline.GetPosts(10, nil)
 		tu.AssertErrorNotNil(err)
 		tu.AssertEqual(0, len(posts))
-		tu.AssertEqual(-1, postsRemaining)
+		tu.AssertNil(next)
 
-		timeline.userID = 42069
-		posts, postsRemaining, err = timeline.GetPosts(10, 0)
+		timeline.Set(42069, FOLLOWING)
+		posts, next, err = timeline.GetPosts(10, nil)
 		tu.AssertErrorNil(err)
 		tu.AssertEqual(0, len(posts))
-		tu.AssertEqual(0, postsRemaining)
+		tu.AssertNil(next)
 
 		user2Posts := testhelpers.QueryUserPosts(user2.ID(), db)
-		timeline.SetID(user1.ID())
-		posts, postsRemaining, err = timeline.GetPosts(10, 0)
+		timeline.Set(user1.ID(), FOLLOWING)
+		posts, next, err = timeline.GetPosts(10, nil)
+		tu.AssertErrorNil(err)
+		tu.AssertNotNil(next)
 		tu.AssertTrue(len(posts) <= 10)
 		tu.AssertEqual(user2Posts[0].ID, posts[0].ID)
 		tu.AssertEqual(user2Posts[0].Content, posts[0].Content)
@@ -53,8 +50,8 @@ func TestTimelineGetPosts(t *testing.T) {
 		tu.AssertEqual(user2Posts[0].Author.DisplayName, posts[0].Author.DisplayName)
 		tu.AssertEqual(user2Posts[0].Author.Username, posts[0].Author.Username)
 		tu.AssertEqual(user2Posts[0].Author.Avatar, posts[0].Author.Avatar)
-		tu.AssertEqual(user2Posts[0].CreatedAt, posts[0].CreatedAt.Format(constants.TIME_LAYOUT))
-		tu.AssertEqual(user2Posts[0].UpdatedAt, posts[0].UpdatedAt.Format(constants.TIME_LAYOUT))
+		tu.AssertEqual(user2Posts[0].CreatedAt, posts[0].CreatedAt)
+		tu.AssertEqual(user2Posts[0].UpdatedAt, posts[0].UpdatedAt)
 
 		tu.AssertEqual(user2Posts[9].ID, posts[9].ID)
 		tu.AssertEqual(user2Posts[9].Content, posts[9].Content)
@@ -66,11 +63,13 @@ func TestTimelineGetPosts(t *testing.T) {
 		tu.AssertEqual(user2Posts[9].Author.DisplayName, posts[9].Author.DisplayName)
 		tu.AssertEqual(user2Posts[9].Author.Username, posts[9].Author.Username)
 		tu.AssertEqual(user2Posts[9].Author.Avatar, posts[9].Author.Avatar)
-		tu.AssertEqual(user2Posts[9].CreatedAt, posts[9].CreatedAt.Format(constants.TIME_LAYOUT))
-		tu.AssertEqual(user2Posts[9].UpdatedAt, posts[9].UpdatedAt.Format(constants.TIME_LAYOUT))
+		tu.AssertEqual(user2Posts[9].CreatedAt, posts[9].CreatedAt)
+		tu.AssertEqual(user2Posts[9].UpdatedAt, posts[9].UpdatedAt)
 
-		posts, postsRemaining, err = timeline.GetPosts(10, 10)
-		tu.AssertTrue(len(posts) <= 10)
+		posts, next, err = timeline.GetPosts(10, next)
+		tu.AssertErrorNil(err)
+		tu.AssertNil(next)
+		tu.AssertEqual(len(user2Posts)-10, len(posts))
 		tu.AssertEqual(user2Posts[10].ID, posts[0].ID)
 		tu.AssertEqual(user2Posts[10].Content, posts[0].Content)
 		tu.AssertEqual(user2Posts[10].Image, posts[0].Image)
@@ -81,20 +80,69 @@ func TestTimelineGetPosts(t *testing.T) {
 		tu.AssertEqual(user2Posts[10].Author.DisplayName, posts[0].Author.DisplayName)
 		tu.AssertEqual(user2Posts[10].Author.Username, posts[0].Author.Username)
 		tu.AssertEqual(user2Posts[10].Author.Avatar, posts[0].Author.Avatar)
-		tu.AssertEqual(user2Posts[10].CreatedAt, posts[0].CreatedAt.Format(constants.TIME_LAYOUT))
-		tu.AssertEqual(user2Posts[10].UpdatedAt, posts[0].UpdatedAt.Format(constants.TIME_LAYOUT))
-
-		tu.AssertEqual(user2Posts[19].ID, posts[9].ID)
-		tu.AssertEqual(user2Posts[19].Content, posts[9].Content)
-		tu.AssertEqual(user2Posts[19].Image, posts[9].Image)
-		tu.AssertEqual(user2Posts[19].Impressions+1, posts[9].Impressions)
-		tu.AssertEqual(user2Posts[19].BookmarkCount, posts[9].BookmarkCount)
-		tu.AssertEqual(user2Posts[19].RetweetCount, posts[9].RetweetCount)
-		tu.AssertEqual(user2Posts[19].LikeCount, posts[9].LikeCount)
-		tu.AssertEqual(user2Posts[19].Author.DisplayName, posts[9].Author.DisplayName)
-		tu.AssertEqual(user2Posts[19].Author.Username, posts[9].Author.Username)
-		tu.AssertEqual(user2Posts[19].Author.Avatar, posts[9].Author.Avatar)
-		tu.AssertEqual(user2Posts[19].CreatedAt, posts[9].CreatedAt.Format(constants.TIME_LAYOUT))
-		tu.AssertEqual(user2Posts[19].UpdatedAt, posts[9].UpdatedAt.Format(constants.TIME_LAYOUT))
+		tu.AssertEqual(user2Posts[10].CreatedAt, posts[0].CreatedAt)
+		tu.AssertEqual(user2Posts[10].UpdatedAt, posts[0].UpdatedAt)
+
+		lastPost := user2Posts[len(user2Posts)-1]
+		tu.AssertEqual(lastPost.ID, posts[len(posts)-1].ID)
+		tu.AssertEqual(lastPost.Content, posts[len(posts)-1].Content)
+		tu.AssertEqual(lastPost.Image, posts[len(posts)-1].Image)
+		tu.AssertEqual(lastPost.Impressions+1, posts[len(posts)-1].Impressions)
+		tu.AssertEqual(lastPost.BookmarkCount, posts[len(posts)-1].BookmarkCount)
+		tu.AssertEqual(lastPost.RetweetCount, posts[len(posts)-1].RetweetCount)
+		tu.AssertEqual(lastPost.LikeCount, posts[len(posts)-1].LikeCount)
+		tu.AssertEqual(lastPost.Author.DisplayName, posts[len(posts)-1].Author.DisplayName)
+		tu.AssertEqual(lastPost.Author.Username, posts[len(posts)-1].Author.Username)
+		tu.AssertEqual(lastPost.Author.Avatar, posts[len(posts)-1].Author.Avatar)
+		tu.AssertEqual(lastPost.CreatedAt, posts[len(posts)-1].CreatedAt)
+		tu.AssertEqual(lastPost.UpdatedAt, posts[len(posts)-1].UpdatedAt)
+	})
+}
+
+func TestTimelineForYouRanked(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, _ time.Time) {
+		tu := testutil.NewTestUtil(t)
+		popularID := testhelpers.CreatePost(dtypes.PostInput{UserID: 4, Content: "sycamore"}, db)
+		db.Exec("UPDATE Post SET like_count = 500 WHERE id = $1;", popularID)
+
+		rankedAt := time.Now().UTC().Truncate(time.Second)
+		timeline := NewTimelineController(db)
+		timeline.Set(7, FOR_YOU)
+		timeline.weights = ranking.Weights{Likes: 1, Engagement: 1, SeenPenalty: 100, CandidatePool: 1000}
+		timeline.now = func() time.Time { return rankedAt }
+
+		posts, next, err := timeline.GetPosts(5, nil)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(5, len(posts))
+		tu.AssertEqual(popularID, posts[0].ID)
+		tu.AssertEqual(rankedAt.Format(constants.TIME_LAYOUT), next.RankedAt)
+
+		seen := map[string]bool{}
+		for _, post := range posts {
+			seen[fmt.Sprintf("%s:%d", post.Type, post.ID)] = true
+		}
+
+		// a fresh ranking pushes what was already served down
+		timeline.now = func() time.Time { return rankedAt.Add(time.Hour) }
+		fresh, _, err := timeline.GetPosts(5, nil)
+		tu.AssertErrorNil(err)
+		tu.AssertFalse(seen[fmt.Sprintf("%s:%d", fresh[0].Type, fresh[0].ID)])
+
+		// later pages keep the first page's ranking, views since then don't
+		// reorder it
+		for next != nil {
+			posts, next, err = timeline.GetPosts(5, next)
+			tu.AssertErrorNil(err)
+			for _, post := range posts {
+				key := fmt.Sprintf("%s:%d", post.Type, post.ID)
+				tu.AssertFalse(seen[key])
+				seen[key] = true
+			}
+		}
+		tu.AssertTrue(len(seen) > 5)
+		tu.AssertTrue(seen[fmt.Sprintf("%s:%d", fresh[0].Type, fresh[0].ID)])
+
+		_, _, err = timeline.GetPosts(0, nil)
+		tu.AssertErrorNotNil(err)
 	})
 }
@@ -3,29 +3,71 @@ package controller
 import (
 	"database/sql"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/marcusprice/twitter-clone/internal/dtypes"
+	"github.com/marcusprice/twitter-clone/internal/logger"
+	"github.com/marcusprice/twitter-clone/internal/mailer"
 	"github.com/marcusprice/twitter-clone/internal/model"
 	"github.com/marcusprice/twitter-clone/internal/permissions"
 	"github.com/marcusprice/twitter-clone/internal/util"
 	"golang.org/x/crypto/bcrypt"
 )
 
+type IncorrectPasswordError struct{}
+
+func (i IncorrectPasswordError) Error() string {
+	return "Current password is incorrect"
+}
+
+type RateLimitedError struct{}
+
+func (r RateLimitedError) Error() string {
+	return "Too many requests, try again later"
+}
+
+type BlockedError struct{}
+
+func (b BlockedError) Error() string {
+	return "User has been blocked"
+}
+
+type PrivateAccountError struct{}
+
+func (p PrivateAccountError) Error() string {
+	return "Account is private"
+}
+
+// at most PASSWORD_RESET_LIMIT reset emails per account per window
+const PASSWORD_RESET_LIMIT = 3
+const PASSWORD_RESET_WINDOW = "-1 hours"
+
+// the sign up email counts towards ACTIVATION_LIMIT
+const ACTIVATION_LIMIT = 3
+const ACTIVATION_WINDOW = "-1 hours"
+
 type User struct {
-	model       *model.UserModel
-	id          *int // TODO: change this to a regular in, use 0 value as null check
-	Email       string
-	Username    string
-	FirstName   string
-	LastName    string
-	DisplayName string
-	Avatar      string
-	IsActive    bool
-	Role        permissions.Role
-	LastLogin   time.Time
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	model         *model.UserModel
+	session       *model.SessionModel
+	mailer        mailer.Mailer
+	id            *int // TODO: change this to a regular in, use 0 value as null check
+	Email         string
+	Username      string
+	FirstName     string
+	LastName      string
+	DisplayName   string
+	Bio           string
+	Avatar        string
+	IsActive      bool
+	IsPrivate     bool
+	DMMutualsOnly bool
+	Role          permissions.Role
+	// JWTs are only valid for the current version, see model.UpdatePassword
+	TokenVersion int
+	LastLogin    time.Time
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
 }
 
 func (u User) ID() int {
@@ -42,11 +84,17 @@ func (u *User) Set(userID *int, userInput dtypes.UserInput) {
 }
 
 func (u *User) SetID(id int) *User {
-	newUser := &User{model: u.model}
+	newUser := u.Fresh()
 	newUser.id = &id
 	return newUser
 }
 
+// Fresh returns an empty user sharing u's models and mailer, handlers load
+// into their own copy since the controller they're given is shared
+func (u *User) Fresh() *User {
+	return &User{model: u.model, session: u.session, mailer: u.mailer}
+}
+
 func (u *User) setFromModel(userData dtypes.UserData) {
 	u.id = &userData.ID
 	u.Email = userData.Email
@@ -54,9 +102,13 @@ func (u *User) setFromModel(userData dtypes.UserData) {
 	u.FirstName = userData.FirstName
 	u.LastName = userData.LastName
 	u.DisplayName = userData.DisplayName
+	u.Bio = userData.Bio
 	u.Avatar = userData.Avatar
 	u.IsActive = userData.IsActive != 0
+	u.IsPrivate = userData.IsPrivate != 0
+	u.DMMutualsOnly = userData.DMMutualsOnly != 0
 	u.Role = userData.Role
+	u.TokenVersion = userData.TokenVersion
 	u.LastLogin = util.ParseTime(userData.LastLogin)
 	u.CreatedAt = util.ParseTime(userData.CreatedAt)
 	u.UpdatedAt = util.ParseTime(userData.UpdatedAt)
@@ -110,23 +162,100 @@ func (u *User) Create(password string) error {
 	}
 
 	u.setFromModel(userData)
+	u.sendActivationEmail(userData.ActivationToken)
 	return nil
 }
 
-func (u *User) Follow(followeeUsername string) error {
-	followeeData, err := u.model.GetByIdentifier("", followeeUsername)
+// SetMailer swaps the mailer used for account emails
+func (u *User) SetMailer(m mailer.Mailer) *User {
+	u.mailer = m
+	return u
+}
+
+// sendActivationEmail doesn't fail user creation, a failed send is logged
+func (u *User) sendActivationEmail(token string) {
+	if u.mailer == nil {
+		u.mailer = mailer.NewMailer()
+	}
+
+	err := u.mailer.Send(mailer.Message{
+		To:      u.Email,
+		Subject: "Activate your account",
+		Body: fmt.Sprintf(
+			"Hi %s, use the token below to activate your account:\n\n%s",
+			u.DisplayName, token),
+	})
+
+	if err != nil {
+		logger.LogError("User.sendActivationEmail() error sending email: " + err.Error())
+	}
+}
+
+// ResendActivation emails a new activation token to the inactive account with
+// the given email. Returns model.UserNotFoundError or RateLimitedError, callers
+// facing the public shouldn't reveal either. Active accounts get nothing.
+func (u *User) ResendActivation(email string) error {
+	userData, err := u.model.GetByIdentifier(email, "")
 	if err != nil {
 		return err
 	}
 
-	err = u.model.Follow(u.ID(), followeeData.ID)
+	if userData.IsActive != 0 {
+		return nil
+	}
+
+	recentCount, err := u.model.RecentTokenCount(
+		userData.ID, model.ACTIVATION_TOKEN, ACTIVATION_WINDOW)
+	if err != nil {
+		return err
+	}
+
+	if recentCount >= ACTIVATION_LIMIT {
+		logger.LogWarn(fmt.Sprintf("User.ResendActivation(): rate limit hit for user %d", userData.ID))
+		return RateLimitedError{}
+	}
+
+	token, err := u.model.CreateToken(
+		userData.ID, model.ACTIVATION_TOKEN, model.ACTIVATION_TOKEN_TTL)
 	if err != nil {
 		return err
 	}
 
+	u.setFromModel(userData)
+	u.sendActivationEmail(token)
 	return nil
 }
 
+func (u *User) Activate(token string) error {
+	userID, err := u.model.Activate(token)
+	if err != nil {
+		return err
+	}
+
+	return u.ByID(userID)
+}
+
+// Follow fails with BlockedError when either user has blocked the other,
+// requested is true when the followee is private and has to accept first
+func (u *User) Follow(followeeUsername string) (requested bool, err error) {
+	followeeData, err := u.model.GetByIdentifier("", followeeUsername)
+	if err != nil {
+		return false, err
+	}
+
+	err = checkBlocked(u.model, followeeData.ID, u.ID())
+	if err != nil {
+		return false, err
+	}
+
+	err = checkBlocked(u.model, u.ID(), followeeData.ID)
+	if err != nil {
+		return false, err
+	}
+
+	return u.model.Follow(u.ID(), followeeData.ID)
+}
+
 func (u *User) UnFollow(followeeUsername string) error {
 	if u.Username == followeeUsername {
 		return errors.New("cannot unfollow yourself")
@@ -146,6 +275,89 @@ func (u *User) UnFollow(followeeUsername string) error {
 
 }
 
+// FollowRequests lists the users waiting on u to accept their follow
+func (u *User) FollowRequests(limit, offset int) (users []dtypes.Author, usersRemaining int, err error) {
+	users, err = u.model.GetFollowRequests(u.ID(), limit, offset)
+	if err != nil {
+		return []dtypes.Author{}, -1, err
+	}
+
+	totalRequests, err := u.model.GetFollowRequestCount(u.ID())
+	if err != nil {
+		return []dtypes.Author{}, -1, err
+	}
+
+	return users, totalRequests - (limit + offset), nil
+}
+
+func (u *User) AcceptFollowRequest(username string) error {
+	return u.withOtherUser(username, u.model.AcceptFollowRequest)
+}
+
+func (u *User) RejectFollowRequest(username string) error {
+	return u.withOtherUser(username, u.model.RejectFollowRequest)
+}
+
+func (u *User) Block(username string) error {
+	return u.withOtherUser(username, u.model.Block)
+}
+
+func (u *User) UnBlock(username string) error {
+	return u.withOtherUser(username, u.model.UnBlock)
+}
+
+func (u *User) Mute(username string) error {
+	return u.withOtherUser(username, u.model.Mute)
+}
+
+func (u *User) UnMute(username string) error {
+	return u.withOtherUser(username, u.model.UnMute)
+}
+
+// withOtherUser resolves username and runs action with u as the acting user,
+// acting on yourself is rejected with UnauthorizedActionError
+func (u *User) withOtherUser(username string, action func(userID, otherUserID int) error) error {
+	otherUserData, err := u.model.GetByIdentifier("", username)
+	if err != nil {
+		return err
+	}
+
+	if otherUserData.ID == u.ID() {
+		return UnauthorizedActionError{}
+	}
+
+	return action(u.ID(), otherUserData.ID)
+}
+
+// checkBlocked returns BlockedError when blockerID has blocked userID
+func checkBlocked(userModel *model.UserModel, blockerID, userID int) error {
+	blocked, err := userModel.HasBlocked(blockerID, userID)
+	if err != nil {
+		return err
+	}
+
+	if blocked {
+		return BlockedError{}
+	}
+
+	return nil
+}
+
+// checkCanView fails with PrivateAccountError when ownerID is a private
+// account viewerID doesn't follow
+func checkCanView(userModel *model.UserModel, ownerID, viewerID int) error {
+	visible, err := userModel.CanView(ownerID, viewerID)
+	if err != nil {
+		return err
+	}
+
+	if !visible {
+		return PrivateAccountError{}
+	}
+
+	return nil
+}
+
 func (u *User) AuthenticateAndSet(pwd string) (authenticated bool, err error) {
 	model := u.model
 	userData, err := model.GetByIdentifier(u.Email, u.Username)
@@ -180,6 +392,125 @@ func (user *User) Login() error {
 	return err
 }
 
+func (u *User) ChangePassword(currentPassword, newPassword string) error {
+	userData, err := u.model.GetByID(u.ID())
+	if err != nil {
+		return err
+	}
+
+	valid := bcrypt.CompareHashAndPassword(
+		[]byte(userData.Password), []byte(currentPassword)) == nil
+	if !valid {
+		return IncorrectPasswordError{}
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	tokenVersion, err := u.model.UpdatePassword(u.ID(), string(hashedPassword))
+	if err != nil {
+		return err
+	}
+
+	u.TokenVersion = tokenVersion
+	return nil
+}
+
+// RequestPasswordReset emails a reset token to the account with the given
+// email. Returns model.UserNotFoundError or RateLimitedError, callers facing
+// the public shouldn't reveal either.
+func (u *User) RequestPasswordReset(email string) error {
+	userData, err := u.model.GetByIdentifier(email, "")
+	if err != nil {
+		return err
+	}
+
+	recentCount, err := u.model.RecentTokenCount(
+		userData.ID, model.PASSWORD_RESET_TOKEN, PASSWORD_RESET_WINDOW)
+	if err != nil {
+		return err
+	}
+
+	if recentCount >= PASSWORD_RESET_LIMIT {
+		logger.LogWarn(fmt.Sprintf("User.RequestPasswordReset(): rate limit hit for user %d", userData.ID))
+		return RateLimitedError{}
+	}
+
+	token, err := u.model.CreateToken(
+		userData.ID, model.PASSWORD_RESET_TOKEN, model.PASSWORD_RESET_TOKEN_TTL)
+	if err != nil {
+		return err
+	}
+
+	if u.mailer == nil {
+		u.mailer = mailer.NewMailer()
+	}
+
+	return u.mailer.Send(mailer.Message{
+		To:      userData.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Hi %s, use the token below to reset your password. It expires in an hour:\n\n%s",
+			userData.DisplayName, token),
+	})
+}
+
+func (u *User) ResetPassword(token, newPassword string) error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	userID, err := u.model.ResetPassword(token, string(hashedPassword))
+	if err != nil {
+		return err
+	}
+
+	return u.ByID(userID)
+}
+
+// StartSession issues a refresh token for the user, the caller signs the
+// matching access token
+func (u *User) StartSession() (refreshToken string, err error) {
+	return u.session.CreateRefreshToken(u.ID())
+}
+
+// RefreshSession trades a refresh token for a new one and loads its owner,
+// userID is the owner of the rotated token.
+// Returns model.InvalidTokenError for unknown, expired or reused tokens.
+func (u *User) RefreshSession(refreshToken string) (userID int, newRefreshToken string, err error) {
+	userID, newRefreshToken, err = u.session.RotateRefreshToken(refreshToken)
+	if err != nil {
+		return 0, "", err
+	}
+
+	err = u.ByID(userID)
+	if err != nil {
+		return 0, "", err
+	}
+
+	return userID, newRefreshToken, nil
+}
+
+// EndSession revokes the access token identified by jti and, when given, the
+// refresh token it was paired with
+func (u *User) EndSession(jti string, expiresAt time.Time, refreshToken string) error {
+	if refreshToken != "" {
+		err := u.session.RevokeRefreshToken(u.ID(), refreshToken)
+		if err != nil {
+			return err
+		}
+	}
+
+	return u.session.RevokeAccessToken(jti, expiresAt)
+}
+
+func (u *User) TokenRevoked(jti string) (bool, error) {
+	return u.session.IsAccessTokenRevoked(jti)
+}
+
 func (u *User) ByPostID(postID int) (dtypes.Author, error) {
 	author, err := u.model.GetByPostID(postID, u.ID())
 	if err != nil {
@@ -199,18 +530,32 @@ func (u *User) ByID(userID int) error {
 	return nil
 }
 
-func (user *User) GetBookmarks(limit, offset int) (bookmarkData []dtypes.BookmarkData, postsRemaining int, err error) {
-	bookmarks, err := user.model.GetBookmarks(user.ID(), limit, offset)
+func (u *User) UpdateProfile(profileInput dtypes.ProfileInput) error {
+	err := u.model.UpdateProfile(u.ID(), profileInput)
+	if err != nil {
+		return err
+	}
+
+	return u.ByID(u.ID())
+}
+
+func (u *User) UpdateAvatar(avatar string) (previousAvatar string, err error) {
+	previousAvatar, err = u.model.UpdateAvatar(u.ID(), avatar)
 	if err != nil {
-		return []dtypes.BookmarkData{}, -1, err
+		return "", err
 	}
 
-	bookmarkCount, err := user.model.GetBookmarkCount(user.ID())
+	u.Avatar = avatar
+	return previousAvatar, nil
+}
+
+func (user *User) GetBookmarks(limit int, cursor *dtypes.Cursor) (bookmarkData []dtypes.BookmarkData, next *dtypes.Cursor, err error) {
+	bookmarks, next, err := user.model.GetBookmarks(user.ID(), limit, cursor)
 	if err != nil {
-		return []dtypes.BookmarkData{}, -1, err
+		return []dtypes.BookmarkData{}, nil, err
 	}
 
-	return bookmarks, bookmarkCount - (limit + offset), nil
+	return bookmarks, next, nil
 }
 
 func NewUserController(dbConn *sql.DB) *User {
@@ -218,8 +563,9 @@ func NewUserController(dbConn *sql.DB) *User {
 		panic("db conn cannot be nil")
 	}
 
-	model := model.NewUserModel(dbConn)
 	return &User{
-		model: model,
+		model:   model.NewUserModel(dbConn),
+		session: model.NewSessionModel(dbConn),
+		mailer:  mailer.NewMailer(),
 	}
 }
@@ -192,31 +192,33 @@ func TestUserFollow(t *testing.T) {
 		user1.ByID(1)
 		user2.ByID(2)
 		user3.ByID(3)
+		// the seed data's follows
+		seeded := testhelpers.QueryUserFollowTableCount(db)
 
-		err := user1.Follow(user2.Username)
+		_, err := user1.Follow(user2.Username)
 		tu.AssertErrorNil(err)
 
-		err = user2.Follow(user1.Username)
+		_, err = user2.Follow(user1.Username)
 		tu.AssertErrorNil(err)
 
-		err = user1.Follow("idontexist")
+		_, err = user1.Follow("idontexist")
 		tu.AssertErrorNotNil(err)
 		tu.AssertTrue(errors.Is(err, model.UserNotFoundError{}))
 
-		err = user1.Follow(user1.Username)
+		_, err = user1.Follow(user1.Username)
 		var constraintError dbutils.ConstraintError
 		tu.AssertErrorNotNil(err)
 		tu.AssertTrue(errors.As(err, &constraintError))
 		tu.AssertEqual(dbutils.CHECK_ERROR, constraintError.Constraint)
 
 		userFollowNumRows := testhelpers.QueryUserFollowTableCount(db)
-		tu.AssertEqual(2, userFollowNumRows)
+		tu.AssertEqual(seeded+2, userFollowNumRows)
 
-		err = user3.Follow(user2.Username)
+		_, err = user3.Follow(user2.Username)
 		tu.AssertErrorNil(err)
 
 		userFollowNumRows = testhelpers.QueryUserFollowTableCount(db)
-		tu.AssertEqual(3, userFollowNumRows)
+		tu.AssertEqual(seeded+3, userFollowNumRows)
 	})
 }
 
@@ -231,31 +233,32 @@ func TestUserUnFollow(t *testing.T) {
 		user2.ByID(2)
 		user3.ByID(3)
 		user4.ByID(4)
+		seeded := testhelpers.QueryUserFollowTableCount(db)
 
 		user1.Follow(user2.Username)
 		user1.Follow(user3.Username)
 		user1.Follow(user4.Username)
-		tu.AssertEqual(3, testhelpers.QueryUserFollowTableCount(db))
+		tu.AssertEqual(seeded+3, testhelpers.QueryUserFollowTableCount(db))
 
 		err := user1.UnFollow(user4.Username)
 		tu.AssertErrorNil(err)
-		tu.AssertEqual(2, testhelpers.QueryUserFollowTableCount(db))
+		tu.AssertEqual(seeded+2, testhelpers.QueryUserFollowTableCount(db))
 
 		err = user1.UnFollow(user3.Username)
 		tu.AssertErrorNil(err)
-		tu.AssertEqual(1, testhelpers.QueryUserFollowTableCount(db))
+		tu.AssertEqual(seeded+1, testhelpers.QueryUserFollowTableCount(db))
 
 		err = user1.UnFollow(user3.Username)
 		tu.AssertErrorNil(err)
-		tu.AssertEqual(1, testhelpers.QueryUserFollowTableCount(db))
+		tu.AssertEqual(seeded+1, testhelpers.QueryUserFollowTableCount(db))
 
 		err = user1.UnFollow(user2.Username)
 		tu.AssertErrorNil(err)
-		tu.AssertEqual(0, testhelpers.QueryUserFollowTableCount(db))
+		tu.AssertEqual(seeded, testhelpers.QueryUserFollowTableCount(db))
 
 		err = user1.UnFollow(user2.Username)
 		tu.AssertErrorNil(err)
-		tu.AssertEqual(0, testhelpers.QueryUserFollowTableCount(db))
+		tu.AssertEqual(seeded, testhelpers.QueryUserFollowTableCount(db))
 
 		err = user1.UnFollow(user1.Username)
 		tu.AssertErrorNotNil(err)
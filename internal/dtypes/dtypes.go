@@ -16,9 +16,10 @@ type UserInput struct {
 }
 
 type PostInput struct {
-	UserID  int
-	Content string
-	Image   string
+	UserID   int
+	Content  string
+	Image    string
+	ImageAlt string
 }
 
 type CommentInput struct {
@@ -27,22 +28,101 @@ type CommentInput struct {
 	ParentCommentID int
 	Content         string
 	Image           string
+	// reply-guy placeholder, content is streamed in later
+	Generating bool
+}
+
+// ProfileInput fields are pointers so a PATCH can tell an omitted field
+// apart from one being cleared
+type ProfileInput struct {
+	FirstName   *string `json:"firstName"`
+	LastName    *string `json:"lastName"`
+	DisplayName *string `json:"displayName"`
+	Bio         *string `json:"bio"`
+	IsPrivate   *bool   `json:"isPrivate"`
+	// only accept direct messages from mutual followers
+	DMMutualsOnly *bool `json:"dmMutualsOnly"`
+}
+
+type TokenInput struct {
+	Token string `json:"token"`
+}
+
+type ResendActivationInput struct {
+	Email string `json:"email"`
+}
+
+type PasswordChangeInput struct {
+	CurrentPassword string `json:"currentPassword"`
+	NewPassword     string `json:"newPassword"`
+}
+
+type ForgotPasswordInput struct {
+	Email string `json:"email"`
+}
+
+type PasswordResetInput struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"newPassword"`
+}
+
+type RefreshInput struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+type RoleInput struct {
+	Role permissions.Role `json:"role"`
+}
+
+type ReportInput struct {
+	Reason  string `json:"reason"`
+	Details string `json:"details"`
+}
+
+type ResolveReportInput struct {
+	Action string `json:"action"`
+}
+
+type EditInput struct {
+	Content string `json:"content"`
+}
+
+// GenerationInput is the content generated so far for a reply-guy comment,
+// done marks it finished
+type GenerationInput struct {
+	Content string `json:"content"`
+	Done    bool   `json:"done"`
+}
+
+type MessageInput struct {
+	Content string `json:"content"`
+}
+
+type NotificationReadInput struct {
+	NotificationIDs []int `json:"notificationIDs"`
 }
 
 type UserData struct {
-	ID          int
-	Email       string
-	Username    string
-	FirstName   string
-	LastName    string
-	DisplayName string
-	Avatar      string
-	Password    string
-	LastLogin   string
-	IsActive    int
-	Role        permissions.Role
-	CreatedAt   string
-	UpdatedAt   string
+	ID            int
+	Email         string
+	Username      string
+	FirstName     string
+	LastName      string
+	DisplayName   string
+	Bio           string
+	Avatar        string
+	Password      string
+	LastLogin     string
+	IsActive      int
+	IsPrivate     int
+	DMMutualsOnly int
+	Role          permissions.Role
+	TokenVersion  int
+	CreatedAt     string
+	UpdatedAt     string
+
+	// only set when the user is first created
+	ActivationToken string
 }
 
 type Author struct {
@@ -54,6 +134,20 @@ type Author struct {
 	FollowingCount  int
 	ViewerFollowing bool
 	MutalFollowers  []*Author
+	// only set on profiles, ViewerRequested means the viewer is waiting on a
+	// follow request
+	IsPrivate       bool
+	ViewerRequested bool
+}
+
+// UserSuggestion is an account suggested to follow, MutualCount is how many
+// accounts the viewer follows follow it and LikedCount is how many of its posts
+// the viewer liked
+type UserSuggestion struct {
+	UserID      int
+	User        Author
+	MutualCount int
+	LikedCount  int
 }
 
 type PostData struct {
@@ -68,11 +162,14 @@ type PostData struct {
 	BookmarkCount int
 	Impressions   int
 	Image         string
+	ImageAlt      string
 	CreatedAt     string
 	UpdatedAt     string
 	Liked         int
 	Retweeted     int
 	Bookmarked    int
+	// users the content mentions that exist, see model.MentionModel
+	MentionedUsernames []string
 }
 
 type CommentData struct {
@@ -89,8 +186,11 @@ type CommentData struct {
 	BookmarkCount   int
 	Impressions     int
 	Image           string
+	Generating      bool
 	CreatedAt       string
 	UpdatedAt       string
+	// users the content mentions that exist, see model.MentionModel
+	MentionedUsernames []string
 }
 
 type Retweeter struct {
@@ -120,9 +220,45 @@ type TimelinePostData struct {
 	ParentPostAuthorUsername    string
 	ParentCommentID             int
 	ParentCommentAuthorUsername string
+	// SortTime is when the row entered the timeline, the retweet or quote
+	// time for retweets and quotes
+	SortTime string
+
+	Author     Author
+	Retweeter  Retweeter
+	QuotedPost QuotedPost
+}
+
+// Cursor is the sort key of the last row of a page, the next page starts
+// right after it
+type Cursor struct {
+	SortTime string
+	Type     string
+	ID       int
 
+	// set on ranked timelines, later pages reuse the first page's ranking
+	// time and continue after the last row's score
+	RankedAt string
+	Score    float64
+}
+
+// ForYouCandidate is a For You timeline row along with the viewer specific
+// signals it gets ranked on
+type ForYouCandidate struct {
+	Post          TimelinePostData
+	SortID        int
+	FollowsAuthor bool
+	AuthorLikes   int
+	Seen          bool
+}
+
+type QuotedPost struct {
+	ID        int
+	Content   string
+	Image     string
+	CreatedAt string
+	UpdatedAt string
 	Author    Author
-	Retweeter Retweeter
 }
 
 type BookmarkData struct {
@@ -140,23 +276,104 @@ type BookmarkData struct {
 	Type              string
 }
 
+type NotificationData struct {
+	ID        int
+	Type      string
+	IsRead    int
+	PostID    int
+	CommentID int
+	Content   string
+	Initiator Author
+	CreatedAt string
+	UpdatedAt string
+}
+
+type MessageData struct {
+	ID             int
+	ConversationID int
+	// Sender is the sender's username
+	Sender    string
+	Content   string
+	ReadAt    string
+	CreatedAt string
+}
+
+// ConversationData is one of the user's conversations, User is the other
+// participant
+type ConversationData struct {
+	ID          int
+	User        Author
+	LastMessage MessageData
+	UnreadCount int
+}
+
+type AuditLogData struct {
+	ID         int
+	Action     string
+	TargetType string
+	TargetID   int
+	Details    string
+	Actor      Author
+	CreatedAt  string
+}
+
+type MentionEntity struct {
+	Username string
+	Start    int
+	End      int
+}
+
+type HashtagData struct {
+	Tag  string
+	Uses int
+}
+
+// Snippet is html escaped with matches wrapped in <mark>
+type SearchPostData struct {
+	Post    TimelinePostData
+	Snippet string
+}
+
+type SearchUserData struct {
+	User    Author
+	Snippet string
+}
+
+type ReportData struct {
+	ID         int
+	Reason     string
+	Details    string
+	Status     string
+	PostID     int
+	CommentID  int
+	Content    string
+	Image      string
+	Reporter   Author
+	Author     Author
+	AuthorID   int
+	CreatedAt  string
+	ResolvedAt string
+}
+
 type IdentifierAlreadyExistsError struct{}
 
 func (_ IdentifierAlreadyExistsError) Error() string {
 	return "Username or email already exists"
 }
 
+// ModelResponse is ollama's /api/chat response
 type ModelResponse struct {
-	Model              string    `json:"model"`
-	CreatedAt          time.Time `json:"created_at"`
-	Response           string    `json:"response"`
-	Done               bool      `json:"done"`
-	DoneReason         string    `json:"done_reason"`
-	Context            []int     `json:"context"`
-	TotalDuration      int64     `json:"total_duration"`
-	LoadDuration       int       `json:"load_duration"`
-	PromptEvalCount    int       `json:"prompt_eval_count"`
-	PromptEvalDuration int64     `json:"prompt_eval_duration"`
-	EvalCount          int       `json:"eval_count"`
-	EvalDuration       int       `json:"eval_duration"`
+	Model              string        `json:"model"`
+	CreatedAt          time.Time     `json:"created_at"`
+	Message            OllamaMessage `json:"message"`
+	Done               bool          `json:"done"`
+	DoneReason         string        `json:"done_reason"`
+	TotalDuration      int64         `json:"total_duration"`
+	LoadDuration       int           `json:"load_duration"`
+	PromptEvalCount    int           `json:"prompt_eval_count"`
+	PromptEvalDuration int64         `json:"prompt_eval_duration"`
+	EvalCount          int           `json:"eval_count"`
+	EvalDuration       int           `json:"eval_duration"`
+	// set instead of a message when a stream fails part way
+	Error string `json:"error"`
 }
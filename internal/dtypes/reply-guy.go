@@ -16,11 +16,52 @@ type ReplyGuyRequest struct {
 	Comment       ReplyGuyComment `json:"comment"`
 	ParentComment ReplyGuyComment `json:"parentComment"`
 	ParentPost    ReplyGuyPost    `json:"parentPost"`
-	Model         string          `json:"model"`
+	// Persona is the mentioned account, Model is filled in by reply-guy from
+	// the persona registry
+	Persona string `json:"persona"`
+	Model   string `json:"model"`
+	// PersonaTokenVersion is the persona account's token version when the
+	// request was sent, reply-guy signs its requests to the core service with it
+	PersonaTokenVersion int `json:"personaTokenVersion"`
 }
 
-type OllamaRequest struct {
-	Stream bool   `json:"stream"`
-	Model  string `json:"model"`
-	Prompt string `json:"prompt"`
+// ReplyGuyThread is the post a reply-guy request is about, decoded from the
+// core service's post endpoint
+type ReplyGuyThread struct {
+	ID       int                     `json:"postID"`
+	Content  string                  `json:"content"`
+	Image    string                  `json:"image"`
+	ImageAlt string                  `json:"imageAlt"`
+	Author   ReplyGuyThreadAuthor    `json:"author"`
+	Comments []ReplyGuyThreadComment `json:"comments"`
+}
+
+type ReplyGuyThreadComment struct {
+	ID              int                     `json:"commentID"`
+	ParentCommentID int                     `json:"parentCommentID"`
+	Content         string                  `json:"content"`
+	Image           string                  `json:"image"`
+	Author          ReplyGuyThreadAuthor    `json:"author"`
+	Replies         []ReplyGuyThreadComment `json:"replies"`
+}
+
+type ReplyGuyThreadAuthor struct {
+	Username string `json:"username"`
+}
+
+const (
+	OLLAMA_SYSTEM_ROLE    = "system"
+	OLLAMA_USER_ROLE      = "user"
+	OLLAMA_ASSISTANT_ROLE = "assistant"
+)
+
+type OllamaMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type OllamaChatRequest struct {
+	Stream   bool            `json:"stream"`
+	Model    string          `json:"model"`
+	Messages []OllamaMessage `json:"messages"`
 }
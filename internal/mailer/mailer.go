@@ -0,0 +1,81 @@
+package mailer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/marcusprice/twitter-clone/internal/logger"
+)
+
+const (
+	LOG_MAILER  = "log"
+	FILE_MAILER = "file"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// LogMailer writes messages to the app log, for local dev where nothing
+// should actually be delivered
+type LogMailer struct{}
+
+func (_ LogMailer) Send(msg Message) error {
+	logger.LogInfo(fmt.Sprintf(
+		"LogMailer.Send() to: %s subject: %s\n%s", msg.To, msg.Subject, msg.Body))
+	return nil
+}
+
+// FileMailer writes each message to its own file in Dir so they can be opened
+// like an inbox
+type FileMailer struct {
+	Dir string
+}
+
+func (fm FileMailer) Send(msg Message) error {
+	err := os.MkdirAll(fm.Dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	filename := fmt.Sprintf(
+		"%d-%s.eml", time.Now().UnixNano(), sanitizeAddress(msg.To))
+	contents := fmt.Sprintf(
+		"To: %s\nSubject: %s\nDate: %s\n\n%s\n",
+		msg.To, msg.Subject, time.Now().UTC().Format(time.RFC1123Z), msg.Body)
+
+	return os.WriteFile(filepath.Join(fm.Dir, filename), []byte(contents), 0644)
+}
+
+// NewMailer picks an implementation from MAILER, defaulting to LogMailer
+func NewMailer() Mailer {
+	switch os.Getenv("MAILER") {
+	case FILE_MAILER:
+		dir := os.Getenv("MAIL_DIR")
+		if dir == "" {
+			dir = filepath.Join(os.TempDir(), "twitter-clone-mail")
+		}
+		return FileMailer{Dir: dir}
+	default:
+		return LogMailer{}
+	}
+}
+
+func sanitizeAddress(address string) string {
+	return strings.Map(func(char rune) rune {
+		if char == '/' || char == '\\' || char == ' ' {
+			return '_'
+		}
+
+		return char
+	}, address)
+}
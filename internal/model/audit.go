@@ -0,0 +1,122 @@
+package model
+
+import (
+	"database/sql"
+	_ "embed"
+
+	"github.com/marcusprice/twitter-clone/internal/dtypes"
+	"github.com/marcusprice/twitter-clone/internal/logger"
+)
+
+// AuditModel records admin actions, see controller.Admin
+type AuditModel struct {
+	db *sql.DB
+}
+
+// AuditEntry is handed to the models making a moderation change, which
+// record it in the same transaction as the change
+type AuditEntry struct {
+	ActorID    int
+	Action     AuditAction
+	TargetType AuditTarget
+	TargetID   int
+	Details    string
+}
+
+//go:embed queries/create-audit-log.sql
+var createAuditLogQuery string
+
+func (am *AuditModel) Record(actorID int, action AuditAction, targetType AuditTarget, targetID int, details string) error {
+	return recordAudit(am.db, AuditEntry{
+		ActorID:    actorID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Details:    details,
+	})
+}
+
+func recordAudit(db execer, entries ...AuditEntry) error {
+	for _, entry := range entries {
+		_, err := db.Exec(
+			createAuditLogQuery, entry.ActorID, entry.Action, entry.TargetType,
+			entry.TargetID, entry.Details)
+		if err != nil {
+			logger.LogError("recordAudit() error: " + err.Error())
+			return err
+		}
+	}
+
+	return nil
+}
+
+//go:embed queries/select-audit-log.sql
+var selectAuditLogQuery string
+
+func (am *AuditModel) GetRecent(limit, offset int) ([]dtypes.AuditLogData, error) {
+	result, err := am.db.Query(selectAuditLogQuery, limit, offset)
+	if err != nil {
+		logger.LogError("AuditModel.GetRecent() query error: " + err.Error())
+		return []dtypes.AuditLogData{}, err
+	}
+	defer result.Close()
+
+	entries := []dtypes.AuditLogData{}
+	for result.Next() {
+		var id int
+		var action string
+		var target_type string
+		var target_id int
+		var details string
+		var actor_user_name sql.NullString
+		var actor_display_name sql.NullString
+		var actor_avatar sql.NullString
+		var created_at string
+
+		err := result.Scan(
+			&id, &action, &target_type, &target_id, &details, &actor_user_name,
+			&actor_display_name, &actor_avatar, &created_at)
+
+		if err != nil {
+			logger.LogError("AuditModel.GetRecent() error scanning row: " + err.Error())
+			return []dtypes.AuditLogData{}, err
+		}
+
+		// the actor is null once their account is deleted
+		actor := dtypes.Author{
+			Username:    actor_user_name.String,
+			DisplayName: actor_display_name.String,
+			Avatar:      actor_avatar.String,
+		}
+
+		entries = append(entries, dtypes.AuditLogData{
+			ID:         id,
+			Action:     action,
+			TargetType: target_type,
+			TargetID:   target_id,
+			Details:    details,
+			Actor:      actor,
+			CreatedAt:  created_at,
+		})
+	}
+
+	return entries, nil
+}
+
+//go:embed queries/select-audit-log-count.sql
+var selectAuditLogCountQuery string
+
+func (am *AuditModel) GetCount() (int, error) {
+	var count int
+	err := am.db.QueryRow(selectAuditLogCountQuery).Scan(&count)
+	if err != nil {
+		logger.LogError("AuditModel.GetCount() error: " + err.Error())
+		return -1, err
+	}
+
+	return count, nil
+}
+
+func NewAuditModel(db *sql.DB) *AuditModel {
+	return &AuditModel{db}
+}
@@ -0,0 +1,37 @@
+package model
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/marcusprice/twitter-clone/internal/testutil"
+)
+
+func TestAuditRecordAndGetRecent(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, timestamp time.Time) {
+		tu := testutil.NewTestUtil(t)
+		auditModel := NewAuditModel(db)
+
+		err := auditModel.Record(2, DEACTIVATE_USER_ACTION, USER_AUDIT_TARGET, 4, "")
+		tu.AssertErrorNil(err)
+		err = auditModel.Record(2, DELETE_POST_ACTION, POST_AUDIT_TARGET, 42069, "author 4")
+		tu.AssertErrorNil(err)
+		err = auditModel.Record(2, "not_an_action", POST_AUDIT_TARGET, 1, "")
+		tu.AssertErrorNotNil(err)
+
+		entries, err := auditModel.GetRecent(10, 0)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(2, len(entries))
+		tu.AssertEqual(string(DELETE_POST_ACTION), entries[0].Action)
+		tu.AssertEqual(string(POST_AUDIT_TARGET), entries[0].TargetType)
+		tu.AssertEqual(42069, entries[0].TargetID)
+		tu.AssertEqual("author 4", entries[0].Details)
+		tu.AssertEqual("wallphace", entries[0].Actor.Username)
+		tu.AssertEqual(string(DEACTIVATE_USER_ACTION), entries[1].Action)
+
+		count, err := auditModel.GetCount()
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(2, count)
+	})
+}
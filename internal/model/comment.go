@@ -17,8 +17,19 @@ type CommentModel struct {
 //go:embed queries/select-comment-by-id.sql
 var selectCommentByIDQuery string
 
-func (commentModel *CommentModel) GetByID(postID int) (dtypes.CommentData, error) {
-	row := commentModel.db.QueryRow(selectCommentByIDQuery, postID)
+// GetByID returns CommentNotFoundError for hidden comments and comments on
+// hidden posts
+func (commentModel *CommentModel) GetByID(id int) (dtypes.CommentData, error) {
+	return commentModel.getByID(id, false)
+}
+
+// GetByIDIncludingHidden also loads comments hidden by moderation, for admins
+func (commentModel *CommentModel) GetByIDIncludingHidden(id int) (dtypes.CommentData, error) {
+	return commentModel.getByID(id, true)
+}
+
+func (commentModel *CommentModel) getByID(id int, includeHidden bool) (dtypes.CommentData, error) {
+	row := commentModel.db.QueryRow(selectCommentByIDQuery, id, includeHidden)
 	commentData, err := parseCommentQueryRow(row)
 
 	if err != nil {
@@ -35,8 +46,10 @@ func (commentModel *CommentModel) GetByID(postID int) (dtypes.CommentData, error
 //go:embed queries/select-comment-by-post-id.sql
 var selectCommentByPostIDQuery string
 
-func (commentModel *CommentModel) GetByPostID(postID int) ([]dtypes.CommentData, error) {
-	result, err := commentModel.db.Query(selectCommentByPostIDQuery, postID)
+// GetByPostID leaves out comments by users viewerID has muted or has a block
+// with
+func (commentModel *CommentModel) GetByPostID(postID, viewerID int) ([]dtypes.CommentData, error) {
+	result, err := commentModel.db.Query(selectCommentByPostIDQuery, viewerID, postID)
 	if err != nil && err != sql.ErrNoRows {
 		return []dtypes.CommentData{}, err
 	}
@@ -60,7 +73,7 @@ func (commentModel *CommentModel) NewPostComment(commentInput dtypes.CommentInpu
 	err = commentModel.db.
 		QueryRow(
 			creatPostCommentQuery, commentInput.UserID, commentInput.PostID,
-			commentInput.Content, commentInput.Image).
+			commentInput.Content, commentInput.Image, commentInput.Generating).
 		Scan(&rowID)
 
 	if err != nil {
@@ -72,6 +85,12 @@ func (commentModel *CommentModel) NewPostComment(commentInput dtypes.CommentInpu
 		return -1, err
 	}
 
+	notificationModel := NewNotificationModel(commentModel.db)
+	notificationModel.NewCommentNotification(POST_COMMENT_NOTIFICATION, commentInput.UserID, rowID)
+	notificationModel.NewMentionNotifications(commentInput.UserID, 0, rowID, commentInput.Content)
+	NewHashtagModel(commentModel.db).Index(0, rowID, commentInput.Content)
+	NewMentionModel(commentModel.db).Index(0, rowID, commentInput.Content)
+
 	return rowID, nil
 }
 
@@ -83,7 +102,7 @@ func (commentModel *CommentModel) NewCommentReply(commentInput dtypes.CommentInp
 		QueryRow(
 			creatCommentReplyQuery, commentInput.UserID, commentInput.PostID,
 			commentInput.ParentCommentID, commentInput.Content,
-			commentInput.Image).
+			commentInput.Image, commentInput.Generating).
 		Scan(&rowID)
 
 	if err != nil {
@@ -95,8 +114,150 @@ func (commentModel *CommentModel) NewCommentReply(commentInput dtypes.CommentInp
 		return -1, err
 	}
 
+	notificationModel := NewNotificationModel(commentModel.db)
+	notificationModel.NewCommentNotification(COMMENT_REPLY_NOTIFICATION, commentInput.UserID, rowID)
+	notificationModel.NewMentionNotifications(commentInput.UserID, 0, rowID, commentInput.Content)
+	NewHashtagModel(commentModel.db).Index(0, rowID, commentInput.Content)
+	NewMentionModel(commentModel.db).Index(0, rowID, commentInput.Content)
+
 	return rowID, nil
+}
+
+//go:embed queries/update-comment-content.sql
+var updateCommentContentQuery string
+
+func (commentModel *CommentModel) UpdateContent(commentID int, content string) error {
+	result, err := commentModel.db.Exec(updateCommentContentQuery, content, commentID)
+	if err != nil {
+		logger.LogError("CommentModel.UpdateContent() error: " + err.Error())
+		if dbutils.ConstraintFailed(err) {
+			return dbutils.WrapConstraintError(err)
+		}
+
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return CommentNotFoundError{}
+	}
+
+	NewHashtagModel(commentModel.db).Reindex(0, commentID, content)
+	NewMentionModel(commentModel.db).Reindex(0, commentID, content)
+
+	return nil
+}
+
+//go:embed queries/update-comment-generation.sql
+var updateCommentGenerationQuery string
+
+// UpdateGeneration replaces the content of a comment that's still
+// generating, done finishes it and notifies and indexes the final content.
+// Finished comments fail with CommentNotFoundError.
+func (commentModel *CommentModel) UpdateGeneration(commentID, userID int, content string, done bool) error {
+	result, err := commentModel.db.Exec(updateCommentGenerationQuery, content, !done, commentID)
+	if err != nil {
+		logger.LogError("CommentModel.UpdateGeneration() error: " + err.Error())
+		if dbutils.ConstraintFailed(err) {
+			return dbutils.WrapConstraintError(err)
+		}
+
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return CommentNotFoundError{}
+	}
+
+	// partial content would index half typed hashtags and mentions
+	if done {
+		NewNotificationModel(commentModel.db).NewMentionNotifications(userID, 0, commentID, content)
+		NewHashtagModel(commentModel.db).Reindex(0, commentID, content)
+		NewMentionModel(commentModel.db).Reindex(0, commentID, content)
+	}
+
+	return nil
+}
+
+//go:embed queries/select-stale-generating-comments.sql
+var selectStaleGeneratingCommentsQuery string
+
+// GetStaleGenerating lists the comments still generating that haven't been
+// updated within window, a sqlite datetime() modifier such as "-5 minutes"
+func (commentModel *CommentModel) GetStaleGenerating(window string) ([]int, error) {
+	result, err := commentModel.db.Query(selectStaleGeneratingCommentsQuery, window)
+	if err != nil {
+		logger.LogError("CommentModel.GetStaleGenerating() query error: " + err.Error())
+		return []int{}, err
+	}
+	defer result.Close()
+
+	commentIDs := []int{}
+	for result.Next() {
+		var commentID int
+		err := result.Scan(&commentID)
+		if err != nil {
+			logger.LogError("CommentModel.GetStaleGenerating() error scanning row: " + err.Error())
+			return []int{}, err
+		}
 
+		commentIDs = append(commentIDs, commentID)
+	}
+
+	return commentIDs, nil
+}
+
+//go:embed queries/select-comment-images.sql
+var selectCommentImagesQuery string
+
+//go:embed queries/delete-comment.sql
+var deleteCommentQuery string
+
+// Delete removes the comment along with its replies, returning the filenames
+// of any images that were attached to them. Moderators pass an audit entry.
+func (commentModel *CommentModel) Delete(commentID int, audit ...AuditEntry) (images []string, err error) {
+	images, err = queryImages(commentModel.db, selectCommentImagesQuery, commentID)
+	if err != nil {
+		logger.LogError("CommentModel.Delete() error querying images: " + err.Error())
+		return []string{}, err
+	}
+
+	tx, err := commentModel.db.Begin()
+	if err != nil {
+		return []string{}, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(deleteCommentQuery, commentID)
+	if err != nil {
+		logger.LogError("CommentModel.Delete() error: " + err.Error())
+		return []string{}, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return []string{}, err
+	}
+
+	if rowsAffected == 0 {
+		return []string{}, CommentNotFoundError{}
+	}
+
+	err = recordAudit(tx, audit...)
+	if err != nil {
+		return []string{}, err
+	}
+
+	return images, tx.Commit()
 }
 
 func parseCommentQueryRow(rowScanner dbutils.RowScanner) (dtypes.CommentData, error) {
@@ -111,17 +272,19 @@ func parseCommentQueryRow(rowScanner dbutils.RowScanner) (dtypes.CommentData, er
 	var retweet_count int
 	var bookmark_count int
 	var impressions int
+	var generating bool
 	var created_at string
 	var updated_at string
 	var author_username string
 	var author_display_name string
 	var author_avatar string
+	var mentioned_usernames sql.NullString
 
 	err := rowScanner.Scan(
 		&id, &post_id, &user_id, &depth, &parent_comment_id, &content,
 		&image, &like_count, &retweet_count, &bookmark_count, &impressions,
-		&created_at, &updated_at, &author_username, &author_display_name,
-		&author_avatar)
+		&generating, &created_at, &updated_at, &author_username, &author_display_name,
+		&author_avatar, &mentioned_usernames)
 
 	if err != nil {
 		return dtypes.CommentData{}, err
@@ -145,9 +308,12 @@ func parseCommentQueryRow(rowScanner dbutils.RowScanner) (dtypes.CommentData, er
 		RetweetCount:    retweet_count,
 		BookmarkCount:   bookmark_count,
 		Impressions:     impressions,
+		Generating:      generating,
 		CreatedAt:       created_at,
 		UpdatedAt:       updated_at,
 		Author:          author,
+
+		MentionedUsernames: parseMentionedUsernames(mentioned_usernames),
 	}
 
 	return commentData, nil
@@ -0,0 +1,149 @@
+package model
+
+import (
+	"database/sql"
+	_ "embed"
+
+	"github.com/marcusprice/twitter-clone/internal/dbutils"
+)
+
+type CommentAction struct {
+	db *sql.DB
+}
+
+//go:embed queries/create-comment-like.sql
+var createCommentLikeQuery string
+
+func (ca *CommentAction) Like(commentID, userID int) error {
+	result, err := ca.db.Exec(createCommentLikeQuery, commentID, userID)
+	if err != nil {
+		if dbutils.IsUniqueConstraintError(err) {
+			// user already likes this comment, likely a duplicate request
+			return nil
+		}
+
+		if dbutils.ConstraintFailed(err) {
+			return dbutils.WrapConstraintError(err)
+		}
+
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil || int(rowsAffected) == 0 {
+		return err
+	}
+
+	NewNotificationModel(ca.db).NewCommentNotification(COMMENT_LIKE_NOTIFICATION, userID, commentID)
+
+	return nil
+}
+
+//go:embed queries/delete-comment-like.sql
+var deleteCommentLikeQuery string
+
+func (ca *CommentAction) Unlike(commentID, userID int) error {
+	result, err := ca.db.Exec(deleteCommentLikeQuery, commentID, userID)
+	if err != nil {
+		return err
+	}
+
+	_, err = result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+//go:embed queries/create-comment-retweet.sql
+var createCommentRetweetQuery string
+
+func (ca *CommentAction) Retweet(commentID, userID int) error {
+	result, err := ca.db.Exec(createCommentRetweetQuery, commentID, userID)
+	if err != nil {
+		if dbutils.IsUniqueConstraintError(err) {
+			// user already retweeted this comment, likely a duplicate request
+			return nil
+		}
+
+		if dbutils.ConstraintFailed(err) {
+			return dbutils.WrapConstraintError(err)
+		}
+
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil || int(rowsAffected) == 0 {
+		return err
+	}
+
+	NewNotificationModel(ca.db).NewCommentNotification(COMMENT_RETWEET_NOTIFICATION, userID, commentID)
+
+	return nil
+}
+
+//go:embed queries/delete-comment-retweet.sql
+var deleteCommentRetweetQuery string
+
+func (ca *CommentAction) UnRetweet(commentID, userID int) error {
+	result, err := ca.db.Exec(deleteCommentRetweetQuery, commentID, userID)
+	if err != nil {
+		return err
+	}
+
+	_, err = result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+//go:embed queries/create-comment-bookmark.sql
+var createCommentBookmarkQuery string
+
+func (ca *CommentAction) Bookmark(commentID, userID int) error {
+	result, err := ca.db.Exec(createCommentBookmarkQuery, commentID, userID)
+	if err != nil {
+		if dbutils.IsUniqueConstraintError(err) {
+			// user already bookmarked this comment, likely a duplicate request
+			return nil
+		}
+
+		if dbutils.ConstraintFailed(err) {
+			return dbutils.WrapConstraintError(err)
+		}
+
+		return err
+	}
+
+	_, err = result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+//go:embed queries/delete-comment-bookmark.sql
+var deleteCommentBookmarkQuery string
+
+func (ca *CommentAction) UnBookmark(commentID, userID int) error {
+	result, err := ca.db.Exec(deleteCommentBookmarkQuery, commentID, userID)
+	if err != nil {
+		return err
+	}
+
+	_, err = result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func NewCommentActionModel(db *sql.DB) *CommentAction {
+	return &CommentAction{db}
+}
@@ -0,0 +1,142 @@
+package model
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/marcusprice/twitter-clone/internal/dbutils"
+	"github.com/marcusprice/twitter-clone/internal/dtypes"
+	"github.com/marcusprice/twitter-clone/internal/testutil"
+)
+
+func TestCommentActionLike(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, timestamp time.Time) {
+		tu := testutil.NewTestUtil(t)
+		commentAction := NewCommentActionModel(db)
+		commentModel := NewCommentModel(db)
+		commentID := insertTestComment(1, 1, db, t)
+
+		err := commentAction.Like(commentID, 2)
+		tu.AssertErrorNil(err)
+		err = commentAction.Like(commentID, 3)
+		tu.AssertErrorNil(err)
+
+		// duplicate like is a no-op
+		err = commentAction.Like(commentID, 3)
+		tu.AssertErrorNil(err)
+
+		comment, err := commentModel.GetByID(commentID)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(2, comment.LikeCount)
+
+		err = commentAction.Unlike(commentID, 3)
+		tu.AssertErrorNil(err)
+		err = commentAction.Unlike(commentID, 3)
+		tu.AssertErrorNil(err)
+
+		comment, err = commentModel.GetByID(commentID)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(1, comment.LikeCount)
+
+		notifications, err := NewNotificationModel(db).GetByReceiverID(1, 10, 0, false)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(2, len(notifications))
+		tu.AssertEqual(string(COMMENT_LIKE_NOTIFICATION), notifications[0].Type)
+		tu.AssertEqual(commentID, notifications[0].CommentID)
+	})
+}
+
+func TestCommentActionRetweet(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, timestamp time.Time) {
+		tu := testutil.NewTestUtil(t)
+		commentAction := NewCommentActionModel(db)
+		commentModel := NewCommentModel(db)
+		commentID := insertTestComment(1, 1, db, t)
+
+		err := commentAction.Retweet(commentID, 2)
+		tu.AssertErrorNil(err)
+		err = commentAction.Retweet(commentID, 2)
+		tu.AssertErrorNil(err)
+
+		comment, err := commentModel.GetByID(commentID)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(1, comment.RetweetCount)
+
+		err = commentAction.UnRetweet(commentID, 2)
+		tu.AssertErrorNil(err)
+
+		comment, err = commentModel.GetByID(commentID)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(0, comment.RetweetCount)
+	})
+}
+
+func TestCommentActionBookmark(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, timestamp time.Time) {
+		tu := testutil.NewTestUtil(t)
+		commentAction := NewCommentActionModel(db)
+		commentModel := NewCommentModel(db)
+		commentID := insertTestComment(1, 1, db, t)
+
+		err := commentAction.Bookmark(commentID, 1)
+		tu.AssertErrorNil(err)
+		err = commentAction.Bookmark(commentID, 1)
+		tu.AssertErrorNil(err)
+
+		comment, err := commentModel.GetByID(commentID)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(1, comment.BookmarkCount)
+
+		err = commentAction.UnBookmark(commentID, 1)
+		tu.AssertErrorNil(err)
+
+		comment, err = commentModel.GetByID(commentID)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(0, comment.BookmarkCount)
+
+		// bookmarks don't notify the author
+		count, err := NewNotificationModel(db).GetCount(1, false)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(0, count)
+	})
+}
+
+func TestCommentActionConstraintError(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, timestamp time.Time) {
+		tu := testutil.NewTestUtil(t)
+		commentAction := &CommentAction{db}
+		var constraintError dbutils.ConstraintError
+		commentID := insertTestComment(1, 1, db, t)
+
+		err := commentAction.Like(commentID, 42069)
+		tu.AssertErrorNotNil(err)
+		tu.AssertTrue(errors.As(err, &constraintError))
+		tu.AssertEqual(dbutils.FOREIGN_KEY_ERROR, constraintError.Constraint)
+
+		err = commentAction.Retweet(42069, 1)
+		tu.AssertErrorNotNil(err)
+		tu.AssertTrue(errors.As(err, &constraintError))
+		tu.AssertEqual(dbutils.FOREIGN_KEY_ERROR, constraintError.Constraint)
+
+		err = commentAction.Bookmark(42069, 42069)
+		tu.AssertErrorNotNil(err)
+		tu.AssertTrue(errors.As(err, &constraintError))
+		tu.AssertEqual(dbutils.FOREIGN_KEY_ERROR, constraintError.Constraint)
+	})
+}
+
+func insertTestComment(postID, userID int, db *sql.DB, t *testing.T) int {
+	commentID, err := NewCommentModel(db).NewPostComment(dtypes.CommentInput{
+		PostID:  postID,
+		UserID:  userID,
+		Content: "test comment",
+	})
+
+	if err != nil {
+		t.Fatal("Error inserting Comment row", err.Error())
+	}
+
+	return commentID
+}
@@ -46,6 +46,27 @@ func TestCommentGetByID(t *testing.T) {
 	})
 }
 
+func TestCommentGetByIDHidden(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, _ time.Time) {
+		tu := testutil.NewTestUtil(t)
+		commentModel := &CommentModel{db: db}
+		testhelpers.CreateComment(dtypes.CommentInput{PostID: 1, UserID: 1, Content: "hidden"}, db)
+		testhelpers.CreateComment(dtypes.CommentInput{PostID: 2, UserID: 1, Content: "on a hidden post"}, db)
+		db.Exec("UPDATE Comment SET is_hidden = 1 WHERE id = 1;")
+		db.Exec("UPDATE Post SET is_hidden = 1 WHERE id = 2;")
+
+		// both are only loaded for moderation
+		for _, commentID := range []int{1, 2} {
+			_, err := commentModel.GetByID(commentID)
+			tu.AssertTrue(errors.Is(err, CommentNotFoundError{}))
+
+			commentData, err := commentModel.GetByIDIncludingHidden(commentID)
+			tu.AssertErrorNil(err)
+			tu.AssertEqual(commentID, commentData.ID)
+		}
+	})
+}
+
 func TestNewPostComment(t *testing.T) {
 	testutil.WithTestData(t, func(db *sql.DB, _ time.Time) {
 		tu := testutil.NewTestUtil(t)
@@ -224,3 +245,106 @@ func TestNewCommentReply(t *testing.T) {
 		tu.AssertEqual(dbutils.CHECK_ERROR, constraintError.Constraint)
 	})
 }
+
+func TestCommentUpdateContent(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, _ time.Time) {
+		tu := testutil.NewTestUtil(t)
+		commentModel := NewCommentModel(db)
+		commentID := insertTestComment(1, 1, db, t)
+
+		err := commentModel.UpdateContent(commentID, "edited")
+		tu.AssertErrorNil(err)
+
+		comment, err := commentModel.GetByID(commentID)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual("edited", comment.Content)
+
+		var previousContent string
+		db.QueryRow("SELECT content FROM CommentEdit WHERE comment_id = $1;", commentID).
+			Scan(&previousContent)
+		tu.AssertEqual("test comment", previousContent)
+
+		err = commentModel.UpdateContent(42069, "edited")
+		tu.AssertTrue(errors.Is(err, CommentNotFoundError{}))
+	})
+}
+
+func TestCommentUpdateGeneration(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, _ time.Time) {
+		tu := testutil.NewTestUtil(t)
+		commentModel := NewCommentModel(db)
+		commentID, err := commentModel.NewPostComment(dtypes.CommentInput{
+			PostID:     1,
+			UserID:     3,
+			Generating: true,
+		})
+		tu.AssertErrorNil(err)
+
+		comment, err := commentModel.GetByID(commentID)
+		tu.AssertErrorNil(err)
+		tu.AssertTrue(comment.Generating)
+		tu.AssertEqual("", comment.Content)
+
+		err = commentModel.UpdateGeneration(commentID, 3, "the owls are", false)
+		tu.AssertErrorNil(err)
+		comment, _ = commentModel.GetByID(commentID)
+		tu.AssertTrue(comment.Generating)
+		tu.AssertEqual("the owls are", comment.Content)
+
+		// finishing with nothing generated breaks the content check
+		err = commentModel.UpdateGeneration(commentID, 3, "", true)
+		tu.AssertTrue(dbutils.IsConstraintError(err))
+
+		err = commentModel.UpdateGeneration(commentID, 3, "the owls are not what they seem #twinpeaks", true)
+		tu.AssertErrorNil(err)
+		comment, _ = commentModel.GetByID(commentID)
+		tu.AssertFalse(comment.Generating)
+		tu.AssertEqual("the owls are not what they seem #twinpeaks", comment.Content)
+
+		// streamed content isn't an edit
+		var edits int
+		db.QueryRow("SELECT COUNT(*) FROM CommentEdit WHERE comment_id = $1;", commentID).Scan(&edits)
+		tu.AssertEqual(0, edits)
+
+		var hashtags int
+		db.QueryRow("SELECT COUNT(*) FROM PostHashtag WHERE comment_id = $1;", commentID).Scan(&hashtags)
+		tu.AssertEqual(1, hashtags)
+
+		err = commentModel.UpdateGeneration(commentID, 3, "edited", false)
+		tu.AssertTrue(errors.Is(err, CommentNotFoundError{}))
+
+		// regular comments can't be generated into
+		err = commentModel.UpdateGeneration(insertTestComment(1, 1, db, t), 1, "edited", false)
+		tu.AssertTrue(errors.Is(err, CommentNotFoundError{}))
+	})
+}
+
+func TestCommentDelete(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, _ time.Time) {
+		tu := testutil.NewTestUtil(t)
+		commentModel := NewCommentModel(db)
+		commentID := insertTestComment(1, 1, db, t)
+		replyID, err := commentModel.NewCommentReply(dtypes.CommentInput{
+			PostID:          1,
+			ParentCommentID: commentID,
+			UserID:          2,
+			Image:           "reply.png",
+		})
+		tu.AssertErrorNil(err)
+		post, _ := NewPostModel(db).GetByID(1)
+		tu.AssertEqual(2, post.CommentCount)
+
+		images, err := commentModel.Delete(commentID)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(1, len(images))
+		tu.AssertEqual("reply.png", images[0])
+		post, _ = NewPostModel(db).GetByID(1)
+		tu.AssertEqual(0, post.CommentCount)
+
+		_, err = commentModel.GetByID(replyID)
+		tu.AssertTrue(errors.Is(err, CommentNotFoundError{}))
+
+		_, err = commentModel.Delete(commentID)
+		tu.AssertTrue(errors.Is(err, CommentNotFoundError{}))
+	})
+}
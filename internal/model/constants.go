@@ -1 +1,73 @@
 package model
+
+type NotificationType string
+
+const (
+	POST_LIKE_NOTIFICATION       NotificationType = "post_like"
+	POST_COMMENT_NOTIFICATION    NotificationType = "post_comment"
+	POST_RETWEET_NOTIFICATION    NotificationType = "post_retweet"
+	COMMENT_LIKE_NOTIFICATION    NotificationType = "comment_like"
+	COMMENT_REPLY_NOTIFICATION   NotificationType = "comment_reply"
+	COMMENT_RETWEET_NOTIFICATION NotificationType = "comment_retweet"
+	MENTION_NOTIFICATION         NotificationType = "mention"
+	FOLLOW_NOTIFICATION          NotificationType = "follow"
+)
+
+type TokenPurpose string
+
+const (
+	ACTIVATION_TOKEN     TokenPurpose = "activation"
+	PASSWORD_RESET_TOKEN TokenPurpose = "password_reset"
+)
+
+// passed to sqlite's datetime() as modifiers
+const (
+	ACTIVATION_TOKEN_TTL     = "+48 hours"
+	PASSWORD_RESET_TOKEN_TTL = "+1 hours"
+)
+
+type AuditAction string
+
+const (
+	DEACTIVATE_USER_ACTION AuditAction = "deactivate_user"
+	REACTIVATE_USER_ACTION AuditAction = "reactivate_user"
+	CHANGE_ROLE_ACTION     AuditAction = "change_role"
+	DELETE_POST_ACTION     AuditAction = "delete_post"
+	DELETE_COMMENT_ACTION  AuditAction = "delete_comment"
+	RESOLVE_REPORT_ACTION  AuditAction = "resolve_report"
+)
+
+type AuditTarget string
+
+const (
+	USER_AUDIT_TARGET    AuditTarget = "user"
+	POST_AUDIT_TARGET    AuditTarget = "post"
+	COMMENT_AUDIT_TARGET AuditTarget = "comment"
+	REPORT_AUDIT_TARGET  AuditTarget = "report"
+)
+
+type ReportReason string
+
+const (
+	SPAM_REPORT           ReportReason = "spam"
+	HARASSMENT_REPORT     ReportReason = "harassment"
+	HATE_REPORT           ReportReason = "hate"
+	VIOLENCE_REPORT       ReportReason = "violence"
+	NUDITY_REPORT         ReportReason = "nudity"
+	MISINFORMATION_REPORT ReportReason = "misinformation"
+	OTHER_REPORT          ReportReason = "other"
+)
+
+var REPORT_REASONS = []ReportReason{
+	SPAM_REPORT, HARASSMENT_REPORT, HATE_REPORT, VIOLENCE_REPORT,
+	NUDITY_REPORT, MISINFORMATION_REPORT, OTHER_REPORT,
+}
+
+type ReportStatus string
+
+const (
+	OPEN_REPORT               ReportStatus = "open"
+	DISMISSED_REPORT          ReportStatus = "dismissed"
+	CONTENT_HIDDEN_REPORT     ReportStatus = "content_hidden"
+	AUTHOR_DEACTIVATED_REPORT ReportStatus = "author_deactivated"
+)
@@ -23,3 +23,27 @@ type CommentNotFoundError struct{}
 func (_ CommentNotFoundError) Error() string {
 	return "Comment not found"
 }
+
+type InvalidTokenError struct{}
+
+func (_ InvalidTokenError) Error() string {
+	return "Token is invalid, expired or already used"
+}
+
+type ReportNotFoundError struct{}
+
+func (_ ReportNotFoundError) Error() string {
+	return "Report not found"
+}
+
+type FollowRequestNotFoundError struct{}
+
+func (_ FollowRequestNotFoundError) Error() string {
+	return "Follow request not found"
+}
+
+type ConversationNotFoundError struct{}
+
+func (_ ConversationNotFoundError) Error() string {
+	return "Conversation not found"
+}
@@ -0,0 +1,204 @@
+package model
+
+import (
+	"database/sql"
+	_ "embed"
+	"slices"
+
+	"github.com/marcusprice/twitter-clone/internal/dtypes"
+	"github.com/marcusprice/twitter-clone/internal/logger"
+	"github.com/marcusprice/twitter-clone/internal/util"
+)
+
+// HashtagModel indexes the #hashtags used in posts and comments and serves the
+// hashtag feeds and trending tags built from them
+type HashtagModel struct {
+	db *sql.DB
+}
+
+//go:embed queries/create-hashtag.sql
+var createHashtagQuery string
+
+//go:embed queries/create-post-hashtag.sql
+var createPostHashtagQuery string
+
+// Index records the hashtags used in content. Exactly one of postID or
+// commentID should be set, the other left as 0.
+func (hm *HashtagModel) Index(postID, commentID int, content string) error {
+	tags := util.ParseHashtags(content)
+	if len(tags) == 0 {
+		return nil
+	}
+
+	tx, err := hm.db.Begin()
+	if err != nil {
+		logger.LogError("HashtagModel.Index() error starting transaction: " + err.Error())
+		return err
+	}
+	defer tx.Rollback()
+
+	err = indexHashtags(tx, postID, commentID, tags)
+	if err != nil {
+		logger.LogError("HashtagModel.Index() error: " + err.Error())
+		return err
+	}
+
+	return tx.Commit()
+}
+
+//go:embed queries/select-post-hashtags.sql
+var selectPostHashtagsQuery string
+
+//go:embed queries/delete-post-hashtag.sql
+var deletePostHashtagQuery string
+
+// Reindex updates the hashtags recorded for an edited post or comment. Tags
+// kept through the edit keep their created_at so trending still counts them
+// from when they were first used.
+func (hm *HashtagModel) Reindex(postID, commentID int, content string) error {
+	tx, err := hm.db.Begin()
+	if err != nil {
+		logger.LogError("HashtagModel.Reindex() error starting transaction: " + err.Error())
+		return err
+	}
+	defer tx.Rollback()
+
+	existing, err := postHashtags(tx, postID, commentID)
+	if err != nil {
+		logger.LogError("HashtagModel.Reindex() error selecting hashtags: " + err.Error())
+		return err
+	}
+
+	tags := util.ParseHashtags(content)
+	for _, tag := range existing {
+		if slices.Contains(tags, tag) {
+			continue
+		}
+
+		_, err = tx.Exec(deletePostHashtagQuery, nullableID(postID), nullableID(commentID), tag)
+		if err != nil {
+			logger.LogError("HashtagModel.Reindex() error clearing hashtag: " + err.Error())
+			return err
+		}
+	}
+
+	added := slices.DeleteFunc(tags, func(tag string) bool {
+		return slices.Contains(existing, tag)
+	})
+	err = indexHashtags(tx, postID, commentID, added)
+	if err != nil {
+		logger.LogError("HashtagModel.Reindex() error: " + err.Error())
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func postHashtags(tx *sql.Tx, postID, commentID int) ([]string, error) {
+	result, err := tx.Query(selectPostHashtagsQuery, nullableID(postID), nullableID(commentID))
+	if err != nil {
+		return nil, err
+	}
+	defer result.Close()
+
+	tags := []string{}
+	for result.Next() {
+		var tag string
+		err := result.Scan(&tag)
+		if err != nil {
+			return nil, err
+		}
+
+		tags = append(tags, tag)
+	}
+
+	return tags, result.Err()
+}
+
+func indexHashtags(db execer, postID, commentID int, tags []string) error {
+	for _, tag := range tags {
+		_, err := db.Exec(createHashtagQuery, tag)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(createPostHashtagQuery, nullableID(postID), nullableID(commentID), tag)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//go:embed queries/select-hashtag-feed.sql
+var selectHashtagFeedQuery string
+
+// GetFeed lists the posts and comments tagged with tag, newest first, leaving
+// out users viewerID has muted or has a block with
+func (hm *HashtagModel) GetFeed(tag string, viewerID, limit, offset int) ([]dtypes.TimelinePostData, error) {
+	result, err := hm.db.Query(selectHashtagFeedQuery, viewerID, tag, limit, offset)
+	if err != nil {
+		logger.LogError("HashtagModel.GetFeed() query error: " + err.Error())
+		return []dtypes.TimelinePostData{}, err
+	}
+	defer result.Close()
+
+	postRows := []dtypes.TimelinePostData{}
+	for result.Next() {
+		postData, _, err := parseTimelineRow(result)
+		if err != nil {
+			return []dtypes.TimelinePostData{}, err
+		}
+
+		postRows = append(postRows, postData)
+	}
+
+	return postRows, nil
+}
+
+//go:embed queries/select-hashtag-feed-count.sql
+var selectHashtagFeedCountQuery string
+
+func (hm *HashtagModel) GetFeedCount(tag string, viewerID int) (int, error) {
+	var count int
+	err := hm.db.QueryRow(selectHashtagFeedCountQuery, viewerID, tag).Scan(&count)
+	if err != nil {
+		logger.LogError("HashtagModel.GetFeedCount() error scanning row: " + err.Error())
+		return -1, err
+	}
+
+	return count, nil
+}
+
+//go:embed queries/select-trending-hashtags.sql
+var selectTrendingHashtagsQuery string
+
+// GetTrending ranks tags by how many posts and comments used them within
+// window, a sqlite datetime() modifier such as "-24 hours"
+func (hm *HashtagModel) GetTrending(window string, limit int) ([]dtypes.HashtagData, error) {
+	result, err := hm.db.Query(selectTrendingHashtagsQuery, window, limit)
+	if err != nil {
+		logger.LogError("HashtagModel.GetTrending() query error: " + err.Error())
+		return []dtypes.HashtagData{}, err
+	}
+	defer result.Close()
+
+	hashtags := []dtypes.HashtagData{}
+	for result.Next() {
+		var hashtag dtypes.HashtagData
+		err := result.Scan(&hashtag.Tag, &hashtag.Uses)
+		if err != nil {
+			logger.LogError("HashtagModel.GetTrending() error scanning row: " + err.Error())
+			return []dtypes.HashtagData{}, err
+		}
+
+		hashtags = append(hashtags, hashtag)
+	}
+
+	return hashtags, nil
+}
+
+func NewHashtagModel(db *sql.DB) *HashtagModel {
+	return &HashtagModel{db}
+}
@@ -0,0 +1,105 @@
+package model
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/marcusprice/twitter-clone/internal/dtypes"
+	"github.com/marcusprice/twitter-clone/internal/testutil"
+)
+
+func TestHashtagIndexAndFeed(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, timestamp time.Time) {
+		tu := testutil.NewTestUtil(t)
+		postModel := NewPostModel(db)
+		commentModel := NewCommentModel(db)
+		hashtagModel := NewHashtagModel(db)
+
+		postID, err := postModel.New(dtypes.PostInput{UserID: 1, Content: "#Cats rule, #cats #dogs drool #2024"})
+		tu.AssertErrorNil(err)
+		commentID, err := commentModel.NewPostComment(dtypes.CommentInput{
+			PostID: postID, UserID: 4, Content: "agreed #cats",
+		})
+		tu.AssertErrorNil(err)
+		postModel.New(dtypes.PostInput{UserID: 2, Content: "no tags here, email#notatag"})
+
+		var tags int
+		db.QueryRow("SELECT COUNT(*) FROM Hashtag;").Scan(&tags)
+		tu.AssertEqual(2, tags)
+
+		feed, err := hashtagModel.GetFeed("cats", 7, 10, 0)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(2, len(feed))
+		count, err := hashtagModel.GetFeedCount("cats", 7)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(2, count)
+
+		types := []string{feed[0].Type, feed[1].Type}
+		tu.AssertTrue(types[0] != types[1])
+		for _, item := range feed {
+			if item.Type == "comment" {
+				tu.AssertEqual(commentID, item.ID)
+				tu.AssertEqual(postID, item.ParentPostID)
+				tu.AssertEqual("estecat", item.ParentPostAuthorUsername)
+			} else {
+				tu.AssertEqual(postID, item.ID)
+			}
+		}
+
+		// editing re-indexes, muting hides the comment
+		err = postModel.UpdateContent(postID, "#dogs only now")
+		tu.AssertErrorNil(err)
+		NewUserModel(db).Mute(7, 4)
+		count, _ = hashtagModel.GetFeedCount("cats", 7)
+		tu.AssertEqual(0, count)
+		feed, _ = hashtagModel.GetFeed("dogs", 7, 10, 0)
+		tu.AssertEqual(1, len(feed))
+
+		// tags kept through an edit keep when they were first used
+		db.Exec(
+			"UPDATE PostHashtag SET created_at = datetime(current_timestamp, '-3 days') WHERE post_id = $1;",
+			postID)
+		err = postModel.UpdateContent(postID, "#dogs and #birds")
+		tu.AssertErrorNil(err)
+		var staleTags int
+		db.QueryRow(
+			"SELECT COUNT(*) FROM PostHashtag WHERE post_id = $1 AND created_at < datetime(current_timestamp, '-1 days');",
+			postID).Scan(&staleTags)
+		tu.AssertEqual(1, staleTags)
+		count, _ = hashtagModel.GetFeedCount("birds", 7)
+		tu.AssertEqual(1, count)
+	})
+}
+
+func TestHashtagTrending(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, timestamp time.Time) {
+		tu := testutil.NewTestUtil(t)
+		postModel := NewPostModel(db)
+		hashtagModel := NewHashtagModel(db)
+
+		postModel.New(dtypes.PostInput{UserID: 1, Content: "#cats"})
+		postModel.New(dtypes.PostInput{UserID: 2, Content: "#cats #synths"})
+		postModel.New(dtypes.PostInput{UserID: 3, Content: "#coffee #cats #synths"})
+		oldPostID, _ := postModel.New(dtypes.PostInput{UserID: 4, Content: "#coffee"})
+		db.Exec(
+			"UPDATE PostHashtag SET created_at = datetime(current_timestamp, '-3 days') WHERE post_id = $1;",
+			oldPostID)
+
+		trending, err := hashtagModel.GetTrending("-24 hours", 10)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(3, len(trending))
+		tu.AssertEqual(dtypes.HashtagData{Tag: "cats", Uses: 3}, trending[0])
+		tu.AssertEqual(dtypes.HashtagData{Tag: "synths", Uses: 2}, trending[1])
+		tu.AssertEqual(dtypes.HashtagData{Tag: "coffee", Uses: 1}, trending[2])
+
+		trending, _ = hashtagModel.GetTrending("-7 days", 1)
+		tu.AssertEqual(1, len(trending))
+		tu.AssertEqual("cats", trending[0].Tag)
+
+		// ties go to the most recently used, then alphabetically
+		trending, _ = hashtagModel.GetTrending("-7 days", 10)
+		tu.AssertEqual(dtypes.HashtagData{Tag: "coffee", Uses: 2}, trending[1])
+		tu.AssertEqual(dtypes.HashtagData{Tag: "synths", Uses: 2}, trending[2])
+	})
+}
@@ -0,0 +1,136 @@
+package model
+
+import (
+	"database/sql"
+	_ "embed"
+	"strings"
+
+	"github.com/marcusprice/twitter-clone/internal/dtypes"
+	"github.com/marcusprice/twitter-clone/internal/logger"
+	"github.com/marcusprice/twitter-clone/internal/util"
+)
+
+// MentionModel indexes the users @mentioned in posts and comments and serves
+// each user's mentions feed
+type MentionModel struct {
+	db *sql.DB
+}
+
+//go:embed queries/create-mention.sql
+var createMentionQuery string
+
+// Index records the users @mentioned in content. Exactly one of postID or
+// commentID should be set, the other left as 0.
+func (mm *MentionModel) Index(postID, commentID int, content string) error {
+	usernames := util.ParseMentions(content)
+	if len(usernames) == 0 {
+		return nil
+	}
+
+	tx, err := mm.db.Begin()
+	if err != nil {
+		logger.LogError("MentionModel.Index() error starting transaction: " + err.Error())
+		return err
+	}
+	defer tx.Rollback()
+
+	err = indexMentions(tx, postID, commentID, usernames)
+	if err != nil {
+		logger.LogError("MentionModel.Index() error: " + err.Error())
+		return err
+	}
+
+	return tx.Commit()
+}
+
+//go:embed queries/delete-mentions.sql
+var deleteMentionsQuery string
+
+// Reindex replaces the mentions recorded for an edited post or comment
+func (mm *MentionModel) Reindex(postID, commentID int, content string) error {
+	tx, err := mm.db.Begin()
+	if err != nil {
+		logger.LogError("MentionModel.Reindex() error starting transaction: " + err.Error())
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(deleteMentionsQuery, nullableID(postID), nullableID(commentID))
+	if err != nil {
+		logger.LogError("MentionModel.Reindex() error clearing mentions: " + err.Error())
+		return err
+	}
+
+	err = indexMentions(tx, postID, commentID, util.ParseMentions(content))
+	if err != nil {
+		logger.LogError("MentionModel.Reindex() error: " + err.Error())
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func indexMentions(db execer, postID, commentID int, usernames []string) error {
+	for _, username := range usernames {
+		_, err := db.Exec(createMentionQuery, nullableID(postID), nullableID(commentID), username)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//go:embed queries/select-mention-feed.sql
+var selectMentionFeedQuery string
+
+// GetFeed lists the posts and comments mentioning userID, newest first,
+// leaving out users userID has muted or has a block with
+func (mm *MentionModel) GetFeed(userID, limit, offset int) ([]dtypes.TimelinePostData, error) {
+	result, err := mm.db.Query(selectMentionFeedQuery, userID, limit, offset)
+	if err != nil {
+		logger.LogError("MentionModel.GetFeed() query error: " + err.Error())
+		return []dtypes.TimelinePostData{}, err
+	}
+	defer result.Close()
+
+	postRows := []dtypes.TimelinePostData{}
+	for result.Next() {
+		postData, _, err := parseTimelineRow(result)
+		if err != nil {
+			return []dtypes.TimelinePostData{}, err
+		}
+
+		postRows = append(postRows, postData)
+	}
+
+	return postRows, nil
+}
+
+//go:embed queries/select-mention-feed-count.sql
+var selectMentionFeedCountQuery string
+
+func (mm *MentionModel) GetFeedCount(userID int) (int, error) {
+	var count int
+	err := mm.db.QueryRow(selectMentionFeedCountQuery, userID).Scan(&count)
+	if err != nil {
+		logger.LogError("MentionModel.GetFeedCount() error scanning row: " + err.Error())
+		return -1, err
+	}
+
+	return count, nil
+}
+
+// parseMentionedUsernames splits the space separated usernames the post and
+// comment queries select as mentioned_usernames, nil when there are none
+func parseMentionedUsernames(usernames sql.NullString) []string {
+	if !usernames.Valid || usernames.String == "" {
+		return nil
+	}
+
+	return strings.Fields(usernames.String)
+}
+
+func NewMentionModel(db *sql.DB) *MentionModel {
+	return &MentionModel{db}
+}
@@ -0,0 +1,90 @@
+package model
+
+import (
+	"database/sql"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/marcusprice/twitter-clone/internal/dtypes"
+	"github.com/marcusprice/twitter-clone/internal/testutil"
+)
+
+func TestMentionIndex(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, timestamp time.Time) {
+		tu := testutil.NewTestUtil(t)
+		postModel := NewPostModel(db)
+		commentModel := NewCommentModel(db)
+
+		postID, err := postModel.New(dtypes.PostInput{
+			UserID: 1, Content: "@audrey @dalecooperfan @dalecooper and @audrey again, bob@bobbybriggs.com",
+		})
+		tu.AssertErrorNil(err)
+
+		postData, err := postModel.GetByID(postID)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(2, len(postData.MentionedUsernames))
+		tu.AssertTrue(slices.Contains(postData.MentionedUsernames, "audrey"))
+		tu.AssertTrue(slices.Contains(postData.MentionedUsernames, "dalecooper"))
+
+		var mentions int
+		db.QueryRow("SELECT COUNT(*) FROM Mention WHERE post_id = $1;", postID).Scan(&mentions)
+		tu.AssertEqual(2, mentions)
+
+		tu.AssertErrorNil(postModel.UpdateContent(postID, "never mind @donnahayward"))
+		postData, _ = postModel.GetByID(postID)
+		tu.AssertTrue(slices.Equal([]string{"donnahayward"}, postData.MentionedUsernames))
+
+		commentID, err := commentModel.NewPostComment(dtypes.CommentInput{
+			PostID: postID, UserID: 6, Content: "@estecat what?",
+		})
+		tu.AssertErrorNil(err)
+		commentData, err := commentModel.GetByID(commentID)
+		tu.AssertErrorNil(err)
+		tu.AssertTrue(slices.Equal([]string{"estecat"}, commentData.MentionedUsernames))
+
+		comments, err := commentModel.GetByPostID(postID, 7)
+		tu.AssertErrorNil(err)
+		tu.AssertTrue(slices.Equal([]string{"estecat"}, comments[0].MentionedUsernames))
+	})
+}
+
+func TestMentionFeed(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, timestamp time.Time) {
+		tu := testutil.NewTestUtil(t)
+		postModel := NewPostModel(db)
+		commentModel := NewCommentModel(db)
+		mentionModel := NewMentionModel(db)
+
+		postID, _ := postModel.New(dtypes.PostInput{UserID: 1, Content: "hey @endlesshappiness"})
+		postModel.New(dtypes.PostInput{UserID: 5, Content: "@endlesshappiness you owe me"})
+		commentModel.NewPostComment(dtypes.CommentInput{
+			PostID: postID, UserID: 4, Content: "@endlesshappiness over here",
+		})
+
+		feed, err := mentionModel.GetFeed(7, 10, 0)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(3, len(feed))
+		count, err := mentionModel.GetFeedCount(7)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(3, count)
+
+		types := map[string]int{}
+		for _, row := range feed {
+			types[row.Type]++
+		}
+		tu.AssertEqual(2, types["post"])
+		tu.AssertEqual(1, types["comment"])
+
+		tu.AssertErrorNil(NewUserModel(db).Mute(7, 5))
+		feed, _ = mentionModel.GetFeed(7, 10, 0)
+		tu.AssertEqual(2, len(feed))
+		count, _ = mentionModel.GetFeedCount(7)
+		tu.AssertEqual(2, count)
+
+		_, err = db.Exec("UPDATE Post SET is_hidden = 1 WHERE id = $1;", postID)
+		tu.AssertErrorNil(err)
+		count, _ = mentionModel.GetFeedCount(7)
+		tu.AssertEqual(0, count)
+	})
+}
@@ -0,0 +1,215 @@
+package model
+
+import (
+	"database/sql"
+	_ "embed"
+	"errors"
+
+	"github.com/marcusprice/twitter-clone/internal/dbutils"
+	"github.com/marcusprice/twitter-clone/internal/dtypes"
+	"github.com/marcusprice/twitter-clone/internal/logger"
+)
+
+// messages only sort by time and id, the cursor type is fixed
+const MESSAGE_CURSOR_TYPE = "message"
+
+type MessageModel struct {
+	db *sql.DB
+}
+
+//go:embed queries/create-conversation.sql
+var createConversationQuery string
+
+//go:embed queries/select-conversation-id.sql
+var selectConversationIDQuery string
+
+//go:embed queries/create-message.sql
+var createMessageQuery string
+
+// Send adds a message to the conversation between the two users, starting
+// one if they don't have one yet
+func (mm *MessageModel) Send(senderID, recipientID int, content string) (dtypes.MessageData, error) {
+	tx, err := mm.db.Begin()
+	if err != nil {
+		return dtypes.MessageData{}, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(createConversationQuery, senderID, recipientID)
+	if err != nil {
+		if dbutils.ConstraintFailed(err) {
+			return dtypes.MessageData{}, dbutils.WrapConstraintError(err)
+		}
+
+		logger.LogError("MessageModel.Send() - error creating conversation: " + err.Error())
+		return dtypes.MessageData{}, err
+	}
+
+	message := dtypes.MessageData{Content: content}
+	err = tx.QueryRow(selectConversationIDQuery, senderID, recipientID).Scan(&message.ConversationID)
+	if err != nil {
+		logger.LogError("MessageModel.Send() - error scanning conversation id: " + err.Error())
+		return dtypes.MessageData{}, err
+	}
+
+	err = tx.QueryRow(createMessageQuery, message.ConversationID, senderID, content).
+		Scan(&message.ID, &message.Sender, &message.CreatedAt)
+	if err != nil {
+		if dbutils.ConstraintFailed(err) {
+			return dtypes.MessageData{}, dbutils.WrapConstraintError(err)
+		}
+
+		logger.LogError("MessageModel.Send() - error creating message: " + err.Error())
+		return dtypes.MessageData{}, err
+	}
+
+	return message, tx.Commit()
+}
+
+//go:embed queries/select-conversation-other-user.sql
+var selectConversationOtherUserQuery string
+
+// GetOtherUserID returns the id of the other participant in conversationID,
+// ConversationNotFoundError when userID isn't part of it
+func (mm *MessageModel) GetOtherUserID(conversationID, userID int) (int, error) {
+	var otherUserID int
+	err := mm.db.QueryRow(selectConversationOtherUserQuery, userID, conversationID).Scan(&otherUserID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ConversationNotFoundError{}
+		}
+
+		logger.LogError("MessageModel.GetOtherUserID() - error scanning row: " + err.Error())
+		return 0, err
+	}
+
+	return otherUserID, nil
+}
+
+//go:embed queries/select-conversations.sql
+var selectConversationsQuery string
+
+// GetConversations lists userID's conversations by most recent message,
+// conversations with blocked users are left out
+func (mm *MessageModel) GetConversations(userID, limit, offset int) ([]dtypes.ConversationData, error) {
+	result, err := mm.db.Query(selectConversationsQuery, userID, limit, offset)
+	if err != nil {
+		logger.LogError("MessageModel.GetConversations() - query error: " + err.Error())
+		return []dtypes.ConversationData{}, err
+	}
+	defer result.Close()
+
+	conversations := []dtypes.ConversationData{}
+	for result.Next() {
+		var conversation dtypes.ConversationData
+		var readAt sql.NullString
+		lastMessage := &conversation.LastMessage
+
+		err := result.Scan(
+			&conversation.ID,
+			&conversation.User.Username,
+			&conversation.User.DisplayName,
+			&conversation.User.Avatar,
+			&lastMessage.ID,
+			&lastMessage.Sender,
+			&lastMessage.Content,
+			&readAt,
+			&lastMessage.CreatedAt,
+			&conversation.UnreadCount,
+		)
+		if err != nil {
+			logger.LogError("MessageModel.GetConversations() - error scanning row: " + err.Error())
+			return []dtypes.ConversationData{}, err
+		}
+
+		lastMessage.ConversationID = conversation.ID
+		lastMessage.ReadAt = readAt.String
+		conversations = append(conversations, conversation)
+	}
+
+	return conversations, nil
+}
+
+//go:embed queries/select-conversation-count.sql
+var selectConversationCountQuery string
+
+func (mm *MessageModel) GetConversationCount(userID int) (int, error) {
+	var count int
+	err := mm.db.QueryRow(selectConversationCountQuery, userID).Scan(&count)
+	if err != nil {
+		logger.LogError("MessageModel.GetConversationCount() - error scanning row: " + err.Error())
+		return 0, err
+	}
+
+	return count, nil
+}
+
+//go:embed queries/select-messages.sql
+var selectMessagesQuery string
+
+// GetMessages returns a page of the conversation newest first, next is only
+// set when there is another page
+func (mm *MessageModel) GetMessages(conversationID, limit int, cursor *dtypes.Cursor) (messages []dtypes.MessageData, next *dtypes.Cursor, err error) {
+	if limit <= 0 {
+		return []dtypes.MessageData{}, nil, errors.New("Positive limit value required")
+	}
+
+	var cursorTime, cursorID any
+	if cursor != nil {
+		cursorTime, cursorID = cursor.SortTime, cursor.ID
+	}
+
+	result, err := mm.db.Query(selectMessagesQuery, conversationID, cursorTime, cursorID, limit+1)
+	if err != nil {
+		logger.LogError("MessageModel.GetMessages() - query error: " + err.Error())
+		return []dtypes.MessageData{}, nil, err
+	}
+	defer result.Close()
+
+	messages = []dtypes.MessageData{}
+	for result.Next() {
+		if len(messages) == limit {
+			last := messages[limit-1]
+			next = &dtypes.Cursor{SortTime: last.CreatedAt, Type: MESSAGE_CURSOR_TYPE, ID: last.ID}
+			break
+		}
+
+		var message dtypes.MessageData
+		var readAt sql.NullString
+
+		err := result.Scan(
+			&message.ID,
+			&message.ConversationID,
+			&message.Sender,
+			&message.Content,
+			&readAt,
+			&message.CreatedAt,
+		)
+		if err != nil {
+			logger.LogError("MessageModel.GetMessages() - error scanning row: " + err.Error())
+			return []dtypes.MessageData{}, nil, err
+		}
+
+		message.ReadAt = readAt.String
+		messages = append(messages, message)
+	}
+
+	return messages, next, nil
+}
+
+//go:embed queries/update-messages-read.sql
+var updateMessagesReadQuery string
+
+// MarkRead marks the messages userID received in conversationID as read
+func (mm *MessageModel) MarkRead(conversationID, userID int) error {
+	_, err := mm.db.Exec(updateMessagesReadQuery, conversationID, userID)
+	if err != nil {
+		logger.LogError("MessageModel.MarkRead() - error: " + err.Error())
+	}
+
+	return err
+}
+
+func NewMessageModel(db *sql.DB) *MessageModel {
+	return &MessageModel{db}
+}
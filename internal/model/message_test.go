@@ -0,0 +1,138 @@
+package model
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/marcusprice/twitter-clone/internal/dbutils"
+	"github.com/marcusprice/twitter-clone/internal/dtypes"
+	"github.com/marcusprice/twitter-clone/internal/testutil"
+)
+
+func TestMessageSend(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, timestamp time.Time) {
+		tu := testutil.NewTestUtil(t)
+		messageModel := NewMessageModel(db)
+
+		first, err := messageModel.Send(3, 1, "damn fine coffee")
+		tu.AssertErrorNil(err)
+		tu.AssertEqual("damn fine coffee", first.Content)
+		tu.AssertEqual("dalecooper", first.Sender)
+		tu.AssertTrue(first.CreatedAt != "")
+
+		// either user sending lands in the same conversation
+		reply, err := messageModel.Send(1, 3, "meow")
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(first.ConversationID, reply.ConversationID)
+		tu.AssertTrue(reply.ID > first.ID)
+
+		other, err := messageModel.Send(1, 4, "hello audrey")
+		tu.AssertErrorNil(err)
+		tu.AssertTrue(other.ConversationID != first.ConversationID)
+
+		_, err = messageModel.Send(1, 1, "talking to myself")
+		var constraintError dbutils.ConstraintError
+		tu.AssertTrue(errors.As(err, &constraintError))
+		_, err = messageModel.Send(1, 3, "   ")
+		tu.AssertTrue(errors.As(err, &constraintError))
+
+		otherUserID, err := messageModel.GetOtherUserID(first.ConversationID, 1)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(3, otherUserID)
+		otherUserID, _ = messageModel.GetOtherUserID(first.ConversationID, 3)
+		tu.AssertEqual(1, otherUserID)
+		_, err = messageModel.GetOtherUserID(first.ConversationID, 4)
+		tu.AssertTrue(errors.Is(err, ConversationNotFoundError{}))
+	})
+}
+
+func TestMessageConversations(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, timestamp time.Time) {
+		tu := testutil.NewTestUtil(t)
+		messageModel := NewMessageModel(db)
+		userModel := NewUserModel(db)
+
+		messageModel.Send(3, 1, "damn fine coffee")
+		messageModel.Send(3, 1, "and cherry pie")
+		messageModel.Send(4, 1, "hello")
+		messageModel.Send(1, 4, "hi audrey")
+		db.Exec("UPDATE Message SET created_at = '2030-01-01 00:00:00' WHERE content = 'hi audrey';")
+
+		conversations, err := messageModel.GetConversations(1, 10, 0)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(2, len(conversations))
+		tu.AssertEqual("audrey", conversations[0].User.Username)
+		tu.AssertEqual("hi audrey", conversations[0].LastMessage.Content)
+		tu.AssertEqual("estecat", conversations[0].LastMessage.Sender)
+		tu.AssertEqual(1, conversations[0].UnreadCount)
+		tu.AssertEqual("dalecooper", conversations[1].User.Username)
+		tu.AssertEqual("and cherry pie", conversations[1].LastMessage.Content)
+		tu.AssertEqual(2, conversations[1].UnreadCount)
+
+		err = messageModel.MarkRead(conversations[1].ID, 1)
+		tu.AssertErrorNil(err)
+		conversations, _ = messageModel.GetConversations(1, 1, 1)
+		tu.AssertEqual(1, len(conversations))
+		tu.AssertEqual(0, conversations[0].UnreadCount)
+		tu.AssertTrue(conversations[0].LastMessage.ReadAt != "")
+
+		// the sender's own messages never count as unread
+		conversations, _ = messageModel.GetConversations(3, 10, 0)
+		tu.AssertEqual(0, conversations[0].UnreadCount)
+
+		count, err := messageModel.GetConversationCount(1)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(2, count)
+
+		userModel.Block(4, 1)
+		conversations, _ = messageModel.GetConversations(1, 10, 0)
+		tu.AssertEqual(1, len(conversations))
+		count, _ = messageModel.GetConversationCount(1)
+		tu.AssertEqual(1, count)
+	})
+}
+
+func TestMessageGetMessages(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, timestamp time.Time) {
+		tu := testutil.NewTestUtil(t)
+		messageModel := NewMessageModel(db)
+
+		var conversationID int
+		for i := 0; i < 5; i++ {
+			message, err := messageModel.Send(1+i%2, 2-i%2, "sycamore")
+			tu.AssertErrorNil(err)
+			conversationID = message.ConversationID
+		}
+		// messages sent in the same second are kept apart by id
+		db.Exec("UPDATE Message SET created_at = '2030-01-01 00:00:00';")
+
+		ids := []int{}
+		var cursor *dtypes.Cursor
+		pages := 0
+		for {
+			messages, next, err := messageModel.GetMessages(conversationID, 2, cursor)
+			tu.AssertErrorNil(err)
+			pages++
+			for _, message := range messages {
+				ids = append(ids, message.ID)
+			}
+
+			if next == nil {
+				break
+			}
+			tu.AssertEqual(MESSAGE_CURSOR_TYPE, next.Type)
+			cursor = next
+		}
+
+		tu.AssertEqual(3, pages)
+		tu.AssertEqual(5, len(ids))
+		for index := 1; index < len(ids); index++ {
+			tu.AssertTrue(ids[index] < ids[index-1])
+		}
+
+		_, _, err := messageModel.GetMessages(conversationID, 0, nil)
+		tu.AssertErrorNotNil(err)
+	})
+}
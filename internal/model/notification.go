@@ -0,0 +1,230 @@
+package model
+
+import (
+	"database/sql"
+	_ "embed"
+	"fmt"
+	"strconv"
+
+	"github.com/marcusprice/twitter-clone/internal/dtypes"
+	"github.com/marcusprice/twitter-clone/internal/logger"
+	"github.com/marcusprice/twitter-clone/internal/util"
+)
+
+// Notifications are best effort. The New* methods log their own errors, so
+// models emitting them as a side effect of a like, comment, follow etc. don't
+// fail the original action when a notification can't be written. The same
+// goes for HashtagModel and MentionModel indexing, a post or comment isn't
+// lost because its tags or mentions couldn't be saved.
+type NotificationModel struct {
+	db *sql.DB
+}
+
+//go:embed queries/create-post-notification.sql
+var createPostNotificationQuery string
+
+// NewPostNotification notifies the author of postID, no-op when the initiator
+// is the author
+func (nm *NotificationModel) NewPostNotification(notificationType NotificationType, initiatorID, postID int) error {
+	_, err := nm.db.Exec(createPostNotificationQuery, initiatorID, notificationType, postID)
+	if err != nil {
+		logger.LogError("NotificationModel.NewPostNotification() error: " + err.Error())
+		return err
+	}
+
+	return nil
+}
+
+//go:embed queries/create-comment-notification.sql
+var createCommentNotificationQuery string
+
+//go:embed queries/create-post-comment-notification.sql
+var createPostCommentNotificationQuery string
+
+//go:embed queries/create-comment-reply-notification.sql
+var createCommentReplyNotificationQuery string
+
+// NewCommentNotification notifies the relevant user about commentID. For
+// post_comment and comment_reply the receiver is the author of the parent
+// post/comment, otherwise it is the author of commentID itself.
+func (nm *NotificationModel) NewCommentNotification(notificationType NotificationType, initiatorID, commentID int) error {
+	var err error
+	switch notificationType {
+	case POST_COMMENT_NOTIFICATION:
+		_, err = nm.db.Exec(createPostCommentNotificationQuery, initiatorID, commentID)
+	case COMMENT_REPLY_NOTIFICATION:
+		_, err = nm.db.Exec(createCommentReplyNotificationQuery, initiatorID, commentID)
+	default:
+		_, err = nm.db.Exec(createCommentNotificationQuery, initiatorID, notificationType, commentID)
+	}
+
+	if err != nil {
+		logger.LogError("NotificationModel.NewCommentNotification() error: " + err.Error())
+		return err
+	}
+
+	return nil
+}
+
+//go:embed queries/create-mention-notification.sql
+var createMentionNotificationQuery string
+
+// NewMentionNotifications notifies every user @mentioned in content. Exactly
+// one of postID or commentID should be set, the other left as 0.
+func (nm *NotificationModel) NewMentionNotifications(initiatorID, postID, commentID int, content string) error {
+	var postIDArg any
+	var commentIDArg any
+	if postID != 0 {
+		postIDArg = postID
+	}
+
+	if commentID != 0 {
+		commentIDArg = commentID
+	}
+
+	for _, username := range util.ParseMentions(content) {
+		_, err := nm.db.Exec(
+			createMentionNotificationQuery, initiatorID, postIDArg,
+			commentIDArg, username)
+
+		if err != nil {
+			logger.LogError("NotificationModel.NewMentionNotifications() error: " + err.Error())
+			return err
+		}
+	}
+
+	return nil
+}
+
+//go:embed queries/create-follow-notification.sql
+var createFollowNotificationQuery string
+
+func (nm *NotificationModel) NewFollowNotification(followerID, followeeID int) error {
+	_, err := nm.db.Exec(createFollowNotificationQuery, followerID, followeeID)
+	if err != nil {
+		logger.LogError("NotificationModel.NewFollowNotification() error: " + err.Error())
+		return err
+	}
+
+	return nil
+}
+
+//go:embed queries/select-notifications-by-receiver-id.sql
+var selectNotificationsByReceiverIDQuery string
+
+func (nm *NotificationModel) GetByReceiverID(receiverID, limit, offset int, unreadOnly bool) ([]dtypes.NotificationData, error) {
+	result, err := nm.db.Query(
+		selectNotificationsByReceiverIDQuery, receiverID, unreadOnly, limit,
+		offset)
+
+	if err != nil {
+		logger.LogError("NotificationModel.GetByReceiverID() query error: " + err.Error())
+		return []dtypes.NotificationData{}, err
+	}
+	defer result.Close()
+
+	notifications := []dtypes.NotificationData{}
+	for result.Next() {
+		var id int
+		var notification_type string
+		var is_read int
+		var post_id sql.NullInt64
+		var comment_id sql.NullInt64
+		var content string
+		var initiator_user_name sql.NullString
+		var initiator_display_name sql.NullString
+		var initiator_avatar sql.NullString
+		var created_at string
+		var updated_at string
+
+		err := result.Scan(
+			&id, &notification_type, &is_read, &post_id, &comment_id, &content,
+			&initiator_user_name, &initiator_display_name, &initiator_avatar,
+			&created_at, &updated_at)
+
+		if err != nil {
+			logger.LogError("NotificationModel.GetByReceiverID() error scanning row: " + err.Error())
+			return []dtypes.NotificationData{}, err
+		}
+
+		initiator := dtypes.Author{
+			Username:    initiator_user_name.String,
+			DisplayName: initiator_display_name.String,
+			Avatar:      initiator_avatar.String,
+		}
+
+		notifications = append(notifications, dtypes.NotificationData{
+			ID:        id,
+			Type:      notification_type,
+			IsRead:    is_read,
+			PostID:    int(post_id.Int64),
+			CommentID: int(comment_id.Int64),
+			Content:   content,
+			Initiator: initiator,
+			CreatedAt: created_at,
+			UpdatedAt: updated_at,
+		})
+	}
+
+	return notifications, nil
+}
+
+//go:embed queries/select-notification-count.sql
+var selectNotificationCountQuery string
+
+func (nm *NotificationModel) GetCount(receiverID int, unreadOnly bool) (int, error) {
+	var count int
+	err := nm.db.
+		QueryRow(selectNotificationCountQuery, receiverID, unreadOnly).
+		Scan(&count)
+
+	if err != nil {
+		logger.LogError("NotificationModel.GetCount() error: " + err.Error())
+		return -1, err
+	}
+
+	return count, nil
+}
+
+//go:embed queries/update-notifications-read.sql
+var updateNotificationsReadQuery string
+
+//go:embed queries/update-notifications-read-by-id.sql
+var updateNotificationsReadByIDQuery string
+
+// MarkRead marks the receiver's notifications as read, limited to
+// notificationIDs when any are given
+func (nm *NotificationModel) MarkRead(receiverID int, notificationIDs []int) (rowsAffected int, err error) {
+	var result sql.Result
+	if len(notificationIDs) == 0 {
+		result, err = nm.db.Exec(updateNotificationsReadQuery, receiverID)
+	} else {
+		var idStrings string
+		for index, id := range notificationIDs {
+			if index == 0 {
+				idStrings += strconv.Itoa(id)
+			} else {
+				idStrings += fmt.Sprintf(", %d", id)
+			}
+		}
+
+		query := fmt.Sprintf(updateNotificationsReadByIDQuery, idStrings)
+		result, err = nm.db.Exec(query, receiverID)
+	}
+
+	if err != nil {
+		logger.LogError("NotificationModel.MarkRead() error: " + err.Error())
+		return -1, err
+	}
+
+	ra, err := result.RowsAffected()
+	if err != nil {
+		return -1, err
+	}
+
+	return int(ra), nil
+}
+
+func NewNotificationModel(db *sql.DB) *NotificationModel {
+	return &NotificationModel{db}
+}
@@ -0,0 +1,176 @@
+package model
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/marcusprice/twitter-clone/internal/dtypes"
+	"github.com/marcusprice/twitter-clone/internal/testutil"
+)
+
+func TestNotificationPostLike(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, _ time.Time) {
+		tu := testutil.NewTestUtil(t)
+		postAction := NewPostActionModel(db)
+		notificationModel := NewNotificationModel(db)
+		post := queryPost(1, db)
+		likerID := 4
+
+		err := postAction.Like(post.ID, likerID)
+		tu.AssertErrorNil(err)
+
+		notifications, err := notificationModel.GetByReceiverID(post.UserID, 10, 0, false)
+		liker := queryUser(likerID, db)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(1, len(notifications))
+		tu.AssertEqual(string(POST_LIKE_NOTIFICATION), notifications[0].Type)
+		tu.AssertEqual(post.ID, notifications[0].PostID)
+		tu.AssertEqual(0, notifications[0].CommentID)
+		tu.AssertEqual(post.Content, notifications[0].Content)
+		tu.AssertEqual(0, notifications[0].IsRead)
+		tu.AssertEqual(liker.Username, notifications[0].Initiator.Username)
+
+		// duplicate like doesn't create a second notification
+		err = postAction.Like(post.ID, likerID)
+		tu.AssertErrorNil(err)
+		count, err := notificationModel.GetCount(post.UserID, false)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(1, count)
+
+		// authors aren't notified about their own actions
+		err = postAction.Like(post.ID, post.UserID)
+		tu.AssertErrorNil(err)
+		count, err = notificationModel.GetCount(post.UserID, false)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(1, count)
+
+		err = postAction.Retweet(post.ID, likerID)
+		tu.AssertErrorNil(err)
+		notifications, err = notificationModel.GetByReceiverID(post.UserID, 10, 0, false)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(2, len(notifications))
+		tu.AssertEqual(string(POST_RETWEET_NOTIFICATION), notifications[0].Type)
+
+		// notifications about hidden posts are hidden along with them
+		db.Exec("UPDATE Post SET is_hidden = 1 WHERE id = $1;", post.ID)
+		notifications, err = notificationModel.GetByReceiverID(post.UserID, 10, 0, false)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(0, len(notifications))
+		count, err = notificationModel.GetCount(post.UserID, false)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(0, count)
+	})
+}
+
+func TestNotificationComments(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, _ time.Time) {
+		tu := testutil.NewTestUtil(t)
+		commentModel := NewCommentModel(db)
+		notificationModel := NewNotificationModel(db)
+		post := queryPost(1, db)
+
+		commentID, err := commentModel.NewPostComment(dtypes.CommentInput{
+			PostID:  post.ID,
+			UserID:  1,
+			Content: "meow",
+		})
+		tu.AssertErrorNil(err)
+
+		notifications, err := notificationModel.GetByReceiverID(post.UserID, 10, 0, false)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(1, len(notifications))
+		tu.AssertEqual(string(POST_COMMENT_NOTIFICATION), notifications[0].Type)
+		tu.AssertEqual(post.ID, notifications[0].PostID)
+		tu.AssertEqual(commentID, notifications[0].CommentID)
+		tu.AssertEqual("meow", notifications[0].Content)
+
+		replyID, err := commentModel.NewCommentReply(dtypes.CommentInput{
+			PostID:          post.ID,
+			ParentCommentID: commentID,
+			UserID:          2,
+			Content:         "who let the cat on the computer",
+		})
+		tu.AssertErrorNil(err)
+
+		notifications, err = notificationModel.GetByReceiverID(1, 10, 0, false)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(1, len(notifications))
+		tu.AssertEqual(string(COMMENT_REPLY_NOTIFICATION), notifications[0].Type)
+		tu.AssertEqual(post.ID, notifications[0].PostID)
+		tu.AssertEqual(replyID, notifications[0].CommentID)
+	})
+}
+
+func TestNotificationMentions(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, _ time.Time) {
+		tu := testutil.NewTestUtil(t)
+		postModel := NewPostModel(db)
+		notificationModel := NewNotificationModel(db)
+		audrey := queryUser(4, db)
+		bobby := queryUser(5, db)
+
+		postID, err := postModel.New(dtypes.PostInput{
+			UserID:  audrey.ID,
+			Content: "@bobbybriggs @bobbybriggs @audrey @bobbybriggsfan bobby@bobbybriggs.com",
+		})
+		tu.AssertErrorNil(err)
+
+		notifications, err := notificationModel.GetByReceiverID(bobby.ID, 10, 0, false)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(1, len(notifications))
+		tu.AssertEqual(string(MENTION_NOTIFICATION), notifications[0].Type)
+		tu.AssertEqual(postID, notifications[0].PostID)
+		tu.AssertEqual(audrey.Username, notifications[0].Initiator.Username)
+
+		count, err := notificationModel.GetCount(audrey.ID, false)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(0, count)
+	})
+}
+
+func TestNotificationFollowAndMarkRead(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, _ time.Time) {
+		tu := testutil.NewTestUtil(t)
+		userModel := NewUserModel(db)
+		notificationModel := NewNotificationModel(db)
+
+		for _, followerID := range []int{1, 3, 4} {
+			_, err := userModel.Follow(followerID, 2)
+			tu.AssertErrorNil(err)
+		}
+
+		notifications, err := notificationModel.GetByReceiverID(2, 10, 0, true)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(3, len(notifications))
+		tu.AssertEqual(string(FOLLOW_NOTIFICATION), notifications[0].Type)
+		tu.AssertEqual(0, notifications[0].PostID)
+		tu.AssertEqual(0, notifications[0].CommentID)
+
+		rowsAffected, err := notificationModel.MarkRead(2, []int{notifications[0].ID})
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(1, rowsAffected)
+
+		unreadCount, err := notificationModel.GetCount(2, true)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(2, unreadCount)
+
+		// other users can't mark someone else's notifications as read
+		rowsAffected, err = notificationModel.MarkRead(1, []int{notifications[1].ID})
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(0, rowsAffected)
+
+		rowsAffected, err = notificationModel.MarkRead(2, []int{})
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(2, rowsAffected)
+
+		notifications, err = notificationModel.GetByReceiverID(2, 10, 0, true)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(0, len(notifications))
+
+		notifications, err = notificationModel.GetByReceiverID(2, 10, 0, false)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(3, len(notifications))
+		tu.AssertEqual(1, notifications[2].IsRead)
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/marcusprice/twitter-clone/internal/dbutils"
 	"github.com/marcusprice/twitter-clone/internal/dtypes"
@@ -24,7 +25,7 @@ func (pm PostModel) New(postInput dtypes.PostInput) (int, error) {
 	var postID int
 	err := pm.db.QueryRow(
 		createPostQuery, postInput.UserID,
-		postInput.Content, postInput.Image).Scan(&postID)
+		postInput.Content, postInput.Image, postInput.ImageAlt).Scan(&postID)
 
 	if err != nil {
 		if dbutils.ConstraintFailed(err) {
@@ -38,13 +39,27 @@ func (pm PostModel) New(postInput dtypes.PostInput) (int, error) {
 		return -1, err
 	}
 
+	NewNotificationModel(pm.db).NewMentionNotifications(postInput.UserID, postID, 0, postInput.Content)
+	NewHashtagModel(pm.db).Index(postID, 0, postInput.Content)
+	NewMentionModel(pm.db).Index(postID, 0, postInput.Content)
+
 	return postID, nil
 }
 
 //go:embed queries/select-post-by-id.sql
 var selectPostByIdQuery string
 
+// GetByID returns PostNotFoundError for hidden posts
 func (pm PostModel) GetByID(id int) (dtypes.PostData, error) {
+	return pm.getByID(id, false)
+}
+
+// GetByIDIncludingHidden also loads posts hidden by moderation, for admins
+func (pm PostModel) GetByIDIncludingHidden(id int) (dtypes.PostData, error) {
+	return pm.getByID(id, true)
+}
+
+func (pm PostModel) getByID(id int, includeHidden bool) (dtypes.PostData, error) {
 	var username string
 	var displayName string
 	var avatar string
@@ -57,15 +72,17 @@ func (pm PostModel) GetByID(id int) (dtypes.PostData, error) {
 	var bookmarkCount int
 	var impressions int
 	var image string
+	var imageAlt string
 	var createdAt string
 	var updatedAt string
+	var mentionedUsernames sql.NullString
 
 	err := pm.db.
-		QueryRow(selectPostByIdQuery, id).
+		QueryRow(selectPostByIdQuery, id, includeHidden).
 		Scan(
 			&username, &displayName, &avatar, &postID, &userID, &content,
 			&comment_count, &likeCount, &retweetCount, &bookmarkCount,
-			&impressions, &image, &createdAt, &updatedAt)
+			&impressions, &image, &imageAlt, &createdAt, &updatedAt, &mentionedUsernames)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -95,8 +112,11 @@ func (pm PostModel) GetByID(id int) (dtypes.PostData, error) {
 		BookmarkCount: bookmarkCount,
 		Impressions:   impressions,
 		Image:         image,
+		ImageAlt:      imageAlt,
 		CreatedAt:     createdAt,
 		UpdatedAt:     updatedAt,
+
+		MentionedUsernames: parseMentionedUsernames(mentionedUsernames),
 	}
 
 	return postData, nil
@@ -118,16 +138,19 @@ func (pm PostModel) GetByIDUserContext(userID, postID int) (dtypes.PostData, err
 	var bookmarkCount int
 	var impressions int
 	var image string
+	var imageAlt string
 	var createdAt string
 	var updatedAt string
 	var liked int
+	var mentionedUsernames sql.NullString
 
 	err := pm.db.
 		QueryRow(selectByIDUserContextQuery, userID, postID).
 		Scan(
 			&username, &displayName, &avatar, &id, &authorID, &content,
 			&comment_count, &likeCount, &retweetCount, &bookmarkCount,
-			&impressions, &image, &createdAt, &updatedAt, &liked)
+			&impressions, &image, &imageAlt, &createdAt, &updatedAt, &liked,
+			&mentionedUsernames)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -157,9 +180,12 @@ func (pm PostModel) GetByIDUserContext(userID, postID int) (dtypes.PostData, err
 		BookmarkCount: bookmarkCount,
 		Impressions:   impressions,
 		Image:         image,
+		ImageAlt:      imageAlt,
 		CreatedAt:     createdAt,
 		UpdatedAt:     updatedAt,
 		Liked:         liked,
+
+		MentionedUsernames: parseMentionedUsernames(mentionedUsernames),
 	}
 
 	return postData, nil
@@ -168,84 +194,128 @@ func (pm PostModel) GetByIDUserContext(userID, postID int) (dtypes.PostData, err
 //go:embed queries/user-timeline-query.sql
 var userTimelineQuery string
 
-func (post *PostModel) QueryUserFollowingTimeline(userID, limit, offset int) (postRows []dtypes.TimelinePostData, postIDs []int, err error) {
+func (pm *PostModel) QueryUserFollowingTimeline(userID, limit int, cursor *dtypes.Cursor) (postRows []dtypes.TimelinePostData, postIDs []int, next *dtypes.Cursor, err error) {
+	return pm.queryTimelinePage(userTimelineQuery, userID, limit, cursor)
+}
+
+// queryTimelinePage runs a keyset paginated timeline query, one row past the
+// limit is fetched so next is only set when there is another page
+func (pm *PostModel) queryTimelinePage(query string, userID, limit int, cursor *dtypes.Cursor) (postRows []dtypes.TimelinePostData, postIDs []int, next *dtypes.Cursor, err error) {
 	if limit <= 0 {
-		logger.LogError("PostModel.TimelineRemainingPostsCount(): postitive limit value required")
-		return []dtypes.TimelinePostData{}, []int{}, errors.New("Positive limit value required")
+		logger.LogError("PostModel.queryTimelinePage(): postitive limit value required")
+		return []dtypes.TimelinePostData{}, []int{}, nil, errors.New("Positive limit value required")
 	}
 
-	result, err := post.db.Query(userTimelineQuery, userID, limit, offset)
+	args := append(append([]any{userID}, cursorArgs(cursor)...), limit+1)
+	result, err := pm.db.Query(query, args...)
 	if err != nil {
-		logger.LogError("PostModel.QueryUserTimeline(): query error: " + err.Error())
-		return []dtypes.TimelinePostData{}, []int{}, err
+		logger.LogError("PostModel.queryTimelinePage(): query error: " + err.Error())
+		return []dtypes.TimelinePostData{}, []int{}, nil, err
 	}
+	defer result.Close()
 
+	var lastSortID int
 	for result.Next() {
-		postData, postID, err := parseTimelineRow(result)
+		if len(postRows) == limit {
+			last := postRows[limit-1]
+			next = &dtypes.Cursor{SortTime: last.SortTime, Type: last.Type, ID: lastSortID}
+			break
+		}
 
+		var sortID int
+		postData, postID, err := parseTimelineRow(result, &sortID)
 		if err != nil {
-			return []dtypes.TimelinePostData{}, []int{}, err
+			return []dtypes.TimelinePostData{}, []int{}, nil, err
 		}
 
-		if postData.Type != "comment-retweet" {
+		switch postData.Type {
+		case "comment-retweet":
+			// comment impressions aren't tracked by AddImpressionBulk
+		case "post-quote":
+			postIDs = append(postIDs, postData.QuotedPost.ID)
+		default:
 			postIDs = append(postIDs, postID)
 		}
+
 		postRows = append(postRows, postData)
+		lastSortID = sortID
 	}
 
-	return postRows, postIDs, nil
+	return postRows, postIDs, next, nil
 }
 
-//go:embed queries/user-timeline-query-all-posts.sql
-var selectAllPostsQuery string
+//go:embed queries/select-for-you-candidates.sql
+var selectForYouCandidatesQuery string
 
-func (pm *PostModel) GetAllIncludingRetweets(userID, limit, offset int) (postRows []dtypes.TimelinePostData, postIDs []int, err error) {
-	result, err := pm.db.Query(selectAllPostsQuery, userID, limit, offset)
+// GetForYouCandidates returns the newest poolSize For You rows up to rankedAt
+// along with the viewer signals they get ranked on
+func (pm *PostModel) GetForYouCandidates(userID int, rankedAt string, poolSize int) ([]dtypes.ForYouCandidate, error) {
+	result, err := pm.db.Query(selectForYouCandidatesQuery, userID, rankedAt, poolSize)
 	if err != nil {
-		// TODO handle error
-		logger.LogError("PostModel.GetAll() - error querying posts: " + err.Error())
+		logger.LogError("PostModel.GetForYouCandidates(): query error: " + err.Error())
+		return []dtypes.ForYouCandidate{}, err
 	}
+	defer result.Close()
 
+	candidates := []dtypes.ForYouCandidate{}
 	for result.Next() {
-		postData, postID, err := parseTimelineRow(result)
-
+		var candidate dtypes.ForYouCandidate
+		candidate.Post, _, err = parseTimelineRow(
+			result, &candidate.SortID, &candidate.FollowsAuthor,
+			&candidate.AuthorLikes, &candidate.Seen,
+		)
 		if err != nil {
-			return []dtypes.TimelinePostData{}, []int{}, err
+			return []dtypes.ForYouCandidate{}, err
 		}
 
-		if postData.Type != "comment-retweet" {
-			postIDs = append(postIDs, postID)
-		}
+		candidates = append(candidates, candidate)
+	}
 
-		postRows = append(postRows, postData)
+	return candidates, nil
+}
+
+// cursorArgs binds a page cursor to the keyset parameters of a paginated
+// query, a nil cursor starts at the first page
+func cursorArgs(cursor *dtypes.Cursor) []any {
+	if cursor == nil {
+		return []any{nil, nil, nil}
 	}
 
-	return postRows, postIDs, nil
+	return []any{cursor.SortTime, cursor.Type, cursor.ID}
 }
 
-//go:embed queries/user-timeline-all-posts-count.sql
-var userTimelineAllPostsCount string
+//go:embed queries/select-user-posts.sql
+var selectUserPostsQuery string
 
-func (pm *PostModel) AllIncludingRetweetCount() (int, error) {
-	var count int
-	err := pm.db.QueryRow(userTimelineAllPostsCount).Scan(&count)
+func (pm *PostModel) GetByUserID(viewerID, userID, limit, offset int) ([]dtypes.TimelinePostData, error) {
+	result, err := pm.db.Query(selectUserPostsQuery, viewerID, userID, limit, offset)
 	if err != nil {
-		logger.LogError("PostModel.GetAllCount() - error scanning row: " + err.Error())
-		return -1, err
+		logger.LogError("PostModel.GetByUserID() - query error: " + err.Error())
+		return []dtypes.TimelinePostData{}, err
 	}
+	defer result.Close()
 
-	return count, nil
+	var postRows []dtypes.TimelinePostData
+	for result.Next() {
+		postData, _, err := parseTimelineRow(result)
+		if err != nil {
+			return []dtypes.TimelinePostData{}, err
+		}
+
+		postRows = append(postRows, postData)
+	}
+
+	return postRows, nil
 }
 
-//go:embed queries/user-timeline-following-count.sql
-var timelineOffsetCountQuery string
+//go:embed queries/select-user-posts-count.sql
+var selectUserPostsCountQuery string
 
-func (pm *PostModel) UserFollowingTimelineCount(userID int) (int, error) {
+func (pm *PostModel) UserPostCount(userID int) (int, error) {
 	var count int
-
-	err := pm.db.QueryRow(timelineOffsetCountQuery, userID).Scan(&count)
+	err := pm.db.QueryRow(selectUserPostsCountQuery, userID).Scan(&count)
 	if err != nil {
-		logger.LogError("PostModel.OffsetCount() - error: " + err.Error())
+		logger.LogError("PostModel.UserPostCount() - error scanning row: " + err.Error())
 		return -1, err
 	}
 
@@ -287,16 +357,7 @@ func (postModel *PostModel) AddImpression(postID int) error {
 var addImpressionBulkQuery string
 
 func (postModel *PostModel) AddImpressionBulk(postIDs []int) (rowsAffected int, err error) {
-	var idStrings string
-	for index, id := range postIDs {
-		if index == 0 {
-			idStrings += strconv.Itoa(id)
-		} else {
-			idStrings += fmt.Sprintf(", %d", id)
-		}
-	}
-
-	query := fmt.Sprintf(addImpressionBulkQuery, idStrings)
+	query := fmt.Sprintf(addImpressionBulkQuery, joinIDs(postIDs))
 
 	result, err := postModel.db.Exec(query)
 	if err != nil {
@@ -316,7 +377,129 @@ func (postModel *PostModel) AddImpressionBulk(postIDs []int) (rowsAffected int,
 	return int(ra), nil
 }
 
-func parseTimelineRow(result dbutils.RowScanner) (postData dtypes.TimelinePostData, postID int, err error) {
+//go:embed queries/create-post-views.sql
+var createPostViewsQuery string
+
+// AddViews records that the user was served the posts, a post viewed again
+// keeps the time it was first seen
+func (postModel *PostModel) AddViews(userID int, postIDs []int) error {
+	if len(postIDs) == 0 {
+		return nil
+	}
+
+	_, err := postModel.db.Exec(fmt.Sprintf(createPostViewsQuery, joinIDs(postIDs)), userID)
+	if err != nil {
+		logger.LogError("PostModel.AddViews(): error recording views: " + err.Error())
+	}
+
+	return err
+}
+
+func joinIDs(ids []int) string {
+	idStrings := make([]string, len(ids))
+	for index, id := range ids {
+		idStrings[index] = strconv.Itoa(id)
+	}
+
+	return strings.Join(idStrings, ", ")
+}
+
+//go:embed queries/update-post-content.sql
+var updatePostContentQuery string
+
+func (pm *PostModel) UpdateContent(postID int, content string) error {
+	result, err := pm.db.Exec(updatePostContentQuery, content, postID)
+	if err != nil {
+		logger.LogError("PostModel.UpdateContent() error: " + err.Error())
+		if dbutils.ConstraintFailed(err) {
+			return dbutils.WrapConstraintError(err)
+		}
+
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return PostNotFoundError{}
+	}
+
+	NewHashtagModel(pm.db).Reindex(postID, 0, content)
+	NewMentionModel(pm.db).Reindex(postID, 0, content)
+
+	return nil
+}
+
+//go:embed queries/select-post-images.sql
+var selectPostImagesQuery string
+
+//go:embed queries/delete-post.sql
+var deletePostQuery string
+
+// Delete removes the post along with its comments, returning the filenames of
+// any images that were attached to them. Moderators pass an audit entry.
+func (pm *PostModel) Delete(postID int, audit ...AuditEntry) (images []string, err error) {
+	images, err = queryImages(pm.db, selectPostImagesQuery, postID)
+	if err != nil {
+		logger.LogError("PostModel.Delete() error querying images: " + err.Error())
+		return []string{}, err
+	}
+
+	tx, err := pm.db.Begin()
+	if err != nil {
+		return []string{}, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(deletePostQuery, postID)
+	if err != nil {
+		logger.LogError("PostModel.Delete() error: " + err.Error())
+		return []string{}, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return []string{}, err
+	}
+
+	if rowsAffected == 0 {
+		return []string{}, PostNotFoundError{}
+	}
+
+	err = recordAudit(tx, audit...)
+	if err != nil {
+		return []string{}, err
+	}
+
+	return images, tx.Commit()
+}
+
+func queryImages(db *sql.DB, query string, id int) ([]string, error) {
+	result, err := db.Query(query, id)
+	if err != nil {
+		return []string{}, err
+	}
+	defer result.Close()
+
+	images := []string{}
+	for result.Next() {
+		var image string
+		if err := result.Scan(&image); err != nil {
+			return []string{}, err
+		}
+
+		images = append(images, image)
+	}
+
+	return images, nil
+}
+
+// parseTimelineRow scans the columns shared by the timeline queries, extra
+// receives any columns a query selects after sort_time
+func parseTimelineRow(result dbutils.RowScanner, extra ...any) (postData dtypes.TimelinePostData, postID int, err error) {
 	var content_type string
 	var id int
 	var user_id int
@@ -341,16 +524,30 @@ func parseTimelineRow(result dbutils.RowScanner) (postData dtypes.TimelinePostDa
 	var liked int
 	var retweeted int
 	var bookmarked int
-	var sort_throwaway string
-
-	err = result.Scan(
+	var quoted_post_id sql.NullInt64
+	var quoted_post_content sql.NullString
+	var quoted_post_image sql.NullString
+	var quoted_post_created_at sql.NullString
+	var quoted_post_updated_at sql.NullString
+	var quoted_post_author_user_name sql.NullString
+	var quoted_post_author_display_name sql.NullString
+	var quoted_post_author_avatar sql.NullString
+	var sort_time string
+
+	dest := []any{
 		&content_type, &id, &user_id, &content, &comment_count, &like_count,
 		&retweet_count, &bookmark_count, &impressions, &image, &created_at,
 		&updated_at, &author_user_name, &author_display_name, &author_avatar,
 		&retweeter_user_name_ns, &retweeter_display_name_ns, &parent_post_id,
 		&parent_post_author_username, &parent_comment_id,
 		&parent_comment_author_username, &liked, &retweeted, &bookmarked,
-		&sort_throwaway)
+		&quoted_post_id, &quoted_post_content, &quoted_post_image,
+		&quoted_post_created_at, &quoted_post_updated_at,
+		&quoted_post_author_user_name, &quoted_post_author_display_name,
+		&quoted_post_author_avatar, &sort_time,
+	}
+
+	err = result.Scan(append(dest, extra...)...)
 
 	if err != nil {
 		logger.LogError("PostModel.parseTimelineRow(): error scanning timeline post: " + err.Error())
@@ -368,6 +565,19 @@ func parseTimelineRow(result dbutils.RowScanner) (postData dtypes.TimelinePostDa
 		Avatar:      author_avatar,
 	}
 
+	quotedPost := dtypes.QuotedPost{
+		ID:        int(quoted_post_id.Int64),
+		Content:   quoted_post_content.String,
+		Image:     quoted_post_image.String,
+		CreatedAt: quoted_post_created_at.String,
+		UpdatedAt: quoted_post_updated_at.String,
+		Author: dtypes.Author{
+			Username:    quoted_post_author_user_name.String,
+			DisplayName: quoted_post_author_display_name.String,
+			Avatar:      quoted_post_author_avatar.String,
+		},
+	}
+
 	postData = dtypes.TimelinePostData{
 		Type:                        content_type,
 		ID:                          id,
@@ -388,9 +598,11 @@ func parseTimelineRow(result dbutils.RowScanner) (postData dtypes.TimelinePostDa
 		ParentPostAuthorUsername:    parent_post_author_username.String,
 		ParentCommentID:             int(parent_comment_id.Int64),
 		ParentCommentAuthorUsername: parent_comment_author_username.String,
+		SortTime:                    sort_time,
 
-		Author:    postAuthor,
-		Retweeter: postRetweeter,
+		Author:     postAuthor,
+		Retweeter:  postRetweeter,
+		QuotedPost: quotedPost,
 	}
 
 	return postData, id, nil
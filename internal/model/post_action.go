@@ -3,6 +3,7 @@ package model
 import (
 	"database/sql"
 	_ "embed"
+	"errors"
 
 	"github.com/marcusprice/twitter-clone/internal/dbutils"
 )
@@ -34,6 +35,8 @@ func (pa *PostAction) Like(postID, userID int) error {
 		return err
 	}
 
+	NewNotificationModel(pa.db).NewPostNotification(POST_LIKE_NOTIFICATION, userID, postID)
+
 	return nil
 }
 
@@ -77,26 +80,60 @@ func (pa *PostAction) Retweet(postID, userID int) error {
 		return err
 	}
 
+	NewNotificationModel(pa.db).NewPostNotification(POST_RETWEET_NOTIFICATION, userID, postID)
+
 	return nil
 }
 
-//go:embed queries/delete-post-retweet.sql
-var deletePostRetweetQuery string
+//go:embed queries/create-post-quote.sql
+var createPostQuoteQuery string
 
-func (pa *PostAction) UnRetweet(postID, userID int) error {
-	result, err := pa.db.Exec(deletePostRetweetQuery, postID, userID)
+// QuoteRetweet retweets the post with commentary and/or an image. Unlike
+// Retweet, an existing retweet of the post is reported as a constraint error
+// rather than ignored so the commentary isn't silently dropped.
+func (pa *PostAction) QuoteRetweet(postID, userID int, content, image string) error {
+	result, err := pa.db.Exec(createPostQuoteQuery, postID, userID, content, image)
 	if err != nil {
+		if dbutils.ConstraintFailed(err) {
+			return dbutils.WrapConstraintError(err)
+		}
+
 		return err
 	}
 
-	_, err = result.RowsAffected()
-	if err != nil {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil || int(rowsAffected) == 0 {
 		return err
 	}
 
+	NewNotificationModel(pa.db).NewPostNotification(POST_RETWEET_NOTIFICATION, userID, postID)
+
 	return nil
 }
 
+//go:embed queries/delete-post-retweet.sql
+var deletePostRetweetQuery string
+
+// UnRetweet removes userID's retweet of postID, returning the filename of the
+// quote's image if it had one
+func (pa *PostAction) UnRetweet(postID, userID int) (images []string, err error) {
+	var image string
+	err = pa.db.QueryRow(deletePostRetweetQuery, postID, userID).Scan(&image)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return []string{}, nil
+		}
+
+		return []string{}, err
+	}
+
+	if image == "" {
+		return []string{}, nil
+	}
+
+	return []string{image}, nil
+}
+
 //go:embed queries/create-post-bookmark.sql
 var createPostBookmarkQuery string
 
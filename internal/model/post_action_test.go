@@ -117,32 +117,32 @@ func TestPostActionUnRetweet(t *testing.T) {
 		insertPostRetweetRow(1, 3, db, t)
 		insertPostRetweetRow(1, 4, db, t)
 
-		err := postAction.UnRetweet(1, 4)
+		_, err := postAction.UnRetweet(1, 4)
 		tu.AssertErrorNil(err)
 		postData := queryPost(1, db)
 		tu.AssertEqual(3, postData.RetweetCount)
 
-		err = postAction.UnRetweet(1, 3)
+		_, err = postAction.UnRetweet(1, 3)
 		tu.AssertErrorNil(err)
 		postData = queryPost(1, db)
 		tu.AssertEqual(2, postData.RetweetCount)
 
-		err = postAction.UnRetweet(1, 2)
+		_, err = postAction.UnRetweet(1, 2)
 		tu.AssertErrorNil(err)
 		postData = queryPost(1, db)
 		tu.AssertEqual(1, postData.RetweetCount)
 
-		err = postAction.UnRetweet(1, 2)
+		_, err = postAction.UnRetweet(1, 2)
 		tu.AssertErrorNil(err)
 		postData = queryPost(1, db)
 		tu.AssertEqual(1, postData.RetweetCount)
 
-		err = postAction.UnRetweet(1, 1)
+		_, err = postAction.UnRetweet(1, 1)
 		tu.AssertErrorNil(err)
 		postData = queryPost(1, db)
 		tu.AssertEqual(0, postData.RetweetCount)
 
-		err = postAction.UnRetweet(1, 1)
+		_, err = postAction.UnRetweet(1, 1)
 		tu.AssertErrorNil(err)
 		postData = queryPost(1, db)
 		tu.AssertEqual(0, postData.RetweetCount)
@@ -256,6 +256,77 @@ func TestPostActionBookmarkConstraintError(t *testing.T) {
 	})
 }
 
+func TestPostActionQuoteRetweet(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, timestamp time.Time) {
+		tu := testutil.NewTestUtil(t)
+		postAction := NewPostActionModel(db)
+		postModel := NewPostModel(db)
+		quoted := queryPost(1, db)
+		quoterID := 4
+
+		err := postAction.QuoteRetweet(quoted.ID, quoterID, "coffee > chocolate", "")
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(1, queryPost(quoted.ID, db).RetweetCount)
+
+		// a quote counts as the user's retweet of the post
+		var constraintError dbutils.ConstraintError
+		err = postAction.QuoteRetweet(quoted.ID, quoterID, "again", "")
+		tu.AssertTrue(errors.As(err, &constraintError))
+		tu.AssertEqual(dbutils.UNIQUE_ERROR, constraintError.Constraint)
+
+		err = postAction.Retweet(2, quoterID)
+		tu.AssertErrorNil(err)
+
+		quoteCount := 0
+		retweetCount := 0
+		for _, post := range forYouPosts(postModel, 1, 40) {
+			switch post.Type {
+			case "post-quote":
+				quoteCount++
+				tu.AssertEqual("coffee > chocolate", post.Content)
+				tu.AssertEqual("audrey", post.Author.Username)
+				tu.AssertEqual(quoted.ID, post.QuotedPost.ID)
+				tu.AssertEqual(quoted.Content, post.QuotedPost.Content)
+				tu.AssertEqual(quoted.Image, post.QuotedPost.Image)
+				tu.AssertEqual("dalecooper", post.QuotedPost.Author.Username)
+			case "post-retweet":
+				retweetCount++
+				tu.AssertEqual(2, post.ID)
+				tu.AssertEqual(0, post.QuotedPost.ID)
+			}
+		}
+
+		tu.AssertEqual(1, quoteCount)
+		tu.AssertEqual(1, retweetCount)
+
+		// user 7 follows audrey
+		posts, _, _, err := postModel.QueryUserFollowingTimeline(7, 40, nil)
+		tu.AssertErrorNil(err)
+
+		quoteCount = 0
+		for _, post := range posts {
+			if post.Type == "post-quote" {
+				quoteCount++
+				tu.AssertEqual(quoted.ID, post.QuotedPost.ID)
+			}
+		}
+
+		tu.AssertEqual(1, quoteCount)
+
+		// unretweeting a quote hands back its image
+		err = postAction.QuoteRetweet(2, 5, "", "owl.png")
+		tu.AssertErrorNil(err)
+		images, err := postAction.UnRetweet(2, 5)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(1, len(images))
+		tu.AssertEqual("owl.png", images[0])
+
+		images, err = postAction.UnRetweet(quoted.ID, quoterID)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(0, len(images))
+	})
+}
+
 func queryPostLikeRowByID(id int, db *sql.DB) (int, int) {
 	var postID int
 	var userID int
@@ -55,6 +55,18 @@ func TestPostNew(t *testing.T) {
 		tu.AssertTrue(createdAt.Before(afterAction))
 		tu.AssertTrue(updatedAt.After(beforeAction))
 		tu.AssertTrue(updatedAt.Before(afterAction))
+
+		postInput.ImageAlt = "two cats asleep on a keyboard"
+		postID, err = postModel.New(postInput)
+		tu.AssertErrorNil(err)
+
+		postData, err = postModel.GetByID(postID)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual("two cats asleep on a keyboard", postData.ImageAlt)
+
+		postData, err = postModel.GetByIDUserContext(userID, postID)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual("two cats asleep on a keyboard", postData.ImageAlt)
 	})
 }
 
@@ -127,6 +139,65 @@ func TestPostGetByID(t *testing.T) {
 	})
 }
 
+func TestPostUpdateContent(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, _ time.Time) {
+		tu := testutil.NewTestUtil(t)
+		postModel := NewPostModel(db)
+		originalPost := queryPost(2, db)
+
+		err := postModel.UpdateContent(2, "edited")
+		tu.AssertErrorNil(err)
+
+		post := queryPost(2, db)
+		tu.AssertEqual("edited", post.Content)
+		tu.AssertTrue(post.UpdatedAt != originalPost.UpdatedAt)
+
+		var editCount int
+		var previousContent string
+		db.QueryRow("SELECT COUNT(*), content FROM PostEdit WHERE post_id = 2;").
+			Scan(&editCount, &previousContent)
+		tu.AssertEqual(1, editCount)
+		tu.AssertEqual(originalPost.Content, previousContent)
+
+		err = postModel.UpdateContent(42069, "edited")
+		tu.AssertTrue(errors.Is(err, PostNotFoundError{}))
+	})
+}
+
+func TestPostDelete(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, _ time.Time) {
+		tu := testutil.NewTestUtil(t)
+		postModel := NewPostModel(db)
+		commentModel := NewCommentModel(db)
+		post := queryPost(1, db)
+
+		commentID, err := commentModel.NewPostComment(dtypes.CommentInput{
+			PostID:  post.ID,
+			UserID:  1,
+			Content: "meow",
+			Image:   "cat.png",
+		})
+		tu.AssertErrorNil(err)
+		err = NewPostActionModel(db).QuoteRetweet(post.ID, 4, "", "owl.png")
+		tu.AssertErrorNil(err)
+
+		images, err := postModel.Delete(post.ID)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(3, len(images))
+		tu.AssertEqual(post.Image, images[0])
+		tu.AssertEqual("cat.png", images[1])
+		tu.AssertEqual("owl.png", images[2])
+
+		_, err = postModel.GetByID(post.ID)
+		tu.AssertTrue(errors.Is(err, PostNotFoundError{}))
+		_, err = commentModel.GetByID(commentID)
+		tu.AssertTrue(errors.Is(err, CommentNotFoundError{}))
+
+		_, err = postModel.Delete(post.ID)
+		tu.AssertTrue(errors.Is(err, PostNotFoundError{}))
+	})
+}
+
 func queryPost(id int, db *sql.DB) dtypes.PostData {
 	query := `
 		SELECT
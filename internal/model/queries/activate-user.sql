@@ -0,0 +1 @@
+UPDATE User SET is_active = 1 WHERE id = $1;
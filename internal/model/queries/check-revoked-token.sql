@@ -0,0 +1 @@
+SELECT COUNT(*) FROM RevokedToken WHERE jti = $1;
@@ -0,0 +1 @@
+SELECT COUNT(*) FROM UserBlock WHERE blocker_id = $1 AND blocked_id = $2;
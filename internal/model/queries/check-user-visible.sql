@@ -0,0 +1,13 @@
+-- an account's content is visible to the viewer when it's public, or the
+-- viewer owns or follows it
+SELECT EXISTS (
+    SELECT 1
+    FROM User
+    WHERE User.id = $1 AND (
+        User.is_private = 0
+        OR User.id = $2
+        OR EXISTS (
+            SELECT 1 FROM UserFollows WHERE followee_id = $1 AND follower_id = $2
+        )
+    )
+);
@@ -0,0 +1,8 @@
+UPDATE UserToken
+SET used_at = current_timestamp
+WHERE
+    token_hash = $1
+    AND purpose = $2
+    AND used_at IS NULL
+    AND expires_at > current_timestamp
+RETURNING user_id;
@@ -0,0 +1,5 @@
+INSERT INTO UserFollows
+    (follower_id, followee_id)
+VALUES
+    ($1, $2)
+ON CONFLICT (follower_id, followee_id) DO NOTHING;
@@ -0,0 +1,2 @@
+INSERT INTO AuditLog (actor_id, action, target_type, target_id, details)
+VALUES ($1, $2, $3, $4, $5);
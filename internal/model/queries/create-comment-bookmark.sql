@@ -0,0 +1,2 @@
+INSERT INTO CommentBookmark (comment_id, user_id)
+VALUES ($1, $2);
@@ -0,0 +1,2 @@
+INSERT INTO CommentLike (comment_id, user_id)
+VALUES ($1, $2);
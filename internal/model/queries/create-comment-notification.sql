@@ -0,0 +1,4 @@
+INSERT INTO Notification (initiator_id, type, receiver_id, comment_id)
+SELECT $1, $2, Comment.user_id, Comment.id
+FROM Comment
+WHERE Comment.id = $3 AND Comment.user_id != $1;
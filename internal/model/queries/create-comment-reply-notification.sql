@@ -0,0 +1,6 @@
+INSERT INTO Notification (initiator_id, receiver_id, comment_id, type)
+SELECT $1, ParentComment.user_id, Comment.id, 'comment_reply'
+FROM
+    Comment
+    INNER JOIN Comment ParentComment ON ParentComment.id = Comment.parent_comment_id
+WHERE Comment.id = $2 AND ParentComment.user_id != $1;
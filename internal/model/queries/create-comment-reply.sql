@@ -1,5 +1,5 @@
 INSERT INTO Comment 
-    (user_id, post_id, parent_comment_id, content, image, depth)
+    (user_id, post_id, parent_comment_id, content, image, generating, depth)
 VALUES
-    ($1, $2, $3, $4, $5, 1)
+    ($1, $2, $3, $4, $5, $6, 1)
 RETURNING id;
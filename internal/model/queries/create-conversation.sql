@@ -0,0 +1,5 @@
+INSERT INTO Conversation
+    (user_one_id, user_two_id)
+VALUES
+    (min($1, $2), max($1, $2))
+ON CONFLICT (user_one_id, user_two_id) DO NOTHING;
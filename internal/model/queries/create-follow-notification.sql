@@ -0,0 +1,2 @@
+INSERT INTO Notification (initiator_id, receiver_id, type)
+VALUES ($1, $2, 'follow');
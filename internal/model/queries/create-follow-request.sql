@@ -0,0 +1,7 @@
+-- no request is needed when the requester already follows the target
+INSERT INTO FollowRequest
+    (requester_id, target_id)
+SELECT $1, $2
+WHERE NOT EXISTS (
+    SELECT 1 FROM UserFollows WHERE follower_id = $1 AND followee_id = $2
+);
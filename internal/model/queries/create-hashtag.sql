@@ -0,0 +1,2 @@
+INSERT INTO Hashtag (tag) VALUES ($1)
+ON CONFLICT (tag) DO NOTHING;
@@ -0,0 +1,7 @@
+INSERT INTO Notification (initiator_id, receiver_id, post_id, comment_id, type)
+SELECT $1, User.id, $2, $3, 'mention'
+FROM User
+WHERE User.user_name = $4 AND User.id != $1
+    AND NOT EXISTS (
+        SELECT 1 FROM UserBlock WHERE UserBlock.blocker_id = User.id AND UserBlock.blocked_id = $1
+    );
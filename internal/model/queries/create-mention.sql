@@ -0,0 +1,5 @@
+INSERT INTO Mention (user_id, post_id, comment_id)
+SELECT User.id, $1, $2
+FROM User
+WHERE User.user_name = $3
+ON CONFLICT DO NOTHING;
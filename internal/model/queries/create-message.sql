@@ -0,0 +1,8 @@
+INSERT INTO Message
+    (conversation_id, sender_id, content)
+VALUES
+    ($1, $2, $3)
+RETURNING
+    id,
+    (SELECT user_name FROM User WHERE User.id = sender_id) AS sender,
+    created_at;
@@ -0,0 +1,6 @@
+INSERT INTO Notification (initiator_id, receiver_id, comment_id, type)
+SELECT $1, Post.user_id, Comment.id, 'post_comment'
+FROM
+    Comment
+    INNER JOIN Post ON Post.id = Comment.post_id
+WHERE Comment.id = $2 AND Post.user_id != $1;
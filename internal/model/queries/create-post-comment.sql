@@ -1,5 +1,5 @@
 INSERT INTO Comment 
-    (user_id, post_id, content, image, depth)
+    (user_id, post_id, content, image, generating, depth)
 VALUES
-    ($1, $2, $3, $4, 0)
+    ($1, $2, $3, $4, $5, 0)
 RETURNING id;
@@ -0,0 +1,5 @@
+INSERT INTO PostHashtag (hashtag_id, post_id, comment_id)
+SELECT Hashtag.id, $1, $2
+FROM Hashtag
+WHERE Hashtag.tag = $3
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,4 @@
+INSERT INTO Notification (initiator_id, type, receiver_id, post_id)
+SELECT $1, $2, Post.user_id, Post.id
+FROM Post
+WHERE Post.id = $3 AND Post.user_id != $1;
@@ -0,0 +1,2 @@
+INSERT INTO PostRetweet (post_id, user_id, content, image_url)
+VALUES ($1, $2, $3, $4);
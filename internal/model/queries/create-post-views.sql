@@ -0,0 +1,3 @@
+INSERT INTO PostView (post_id, user_id)
+SELECT id, $1 FROM Post WHERE id IN (%s)
+ON CONFLICT (user_id, post_id) DO NOTHING;
@@ -1,3 +1,3 @@
-INSERT INTO Post (user_id, content, image)
-VALUES ($1, $2, $3)
+INSERT INTO Post (user_id, content, image, image_alt)
+VALUES ($1, $2, $3, $4)
 RETURNING id;
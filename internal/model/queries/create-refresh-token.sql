@@ -0,0 +1,2 @@
+INSERT INTO RefreshToken (user_id, token_hash, expires_at)
+VALUES ($1, $2, datetime(current_timestamp, $3));
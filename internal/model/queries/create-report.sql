@@ -0,0 +1,2 @@
+INSERT INTO Report (reporter_id, post_id, comment_id, reason, details)
+VALUES ($1, $2, $3, $4, $5);
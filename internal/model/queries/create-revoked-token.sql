@@ -0,0 +1 @@
+INSERT OR IGNORE INTO RevokedToken (jti, expires_at) VALUES ($1, $2);
@@ -0,0 +1,4 @@
+INSERT INTO UserBlock
+    (blocker_id, blocked_id)
+VALUES
+    ($1, $2);
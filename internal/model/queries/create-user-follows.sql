@@ -1,4 +1,5 @@
+-- private accounts are followed through a FollowRequest instead
 INSERT INTO UserFollows
     (follower_id, followee_id)
-VALUES
-    ($1, $2);
+SELECT $1, $2
+WHERE NOT EXISTS (SELECT 1 FROM User WHERE id = $2 AND is_private = 1);
@@ -0,0 +1,4 @@
+INSERT INTO UserMute
+    (muter_id, muted_id)
+VALUES
+    ($1, $2);
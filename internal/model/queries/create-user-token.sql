@@ -0,0 +1,2 @@
+INSERT INTO UserToken (user_id, token_hash, purpose, expires_at)
+VALUES ($1, $2, $3, datetime(current_timestamp, $4));
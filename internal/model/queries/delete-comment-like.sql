@@ -0,0 +1 @@
+DELETE FROM CommentLike WHERE comment_id = $1 AND user_id = $2;
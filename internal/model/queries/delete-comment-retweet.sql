@@ -0,0 +1 @@
+DELETE FROM CommentRetweet WHERE comment_id = $1 AND user_id = $2;
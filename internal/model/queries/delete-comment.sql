@@ -0,0 +1 @@
+DELETE FROM Comment WHERE id = $1;
@@ -0,0 +1 @@
+DELETE FROM RevokedToken WHERE expires_at <= current_timestamp;
@@ -0,0 +1,2 @@
+DELETE FROM Mention
+WHERE post_id IS $1 AND comment_id IS $2;
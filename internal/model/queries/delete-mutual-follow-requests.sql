@@ -0,0 +1,2 @@
+DELETE FROM FollowRequest
+WHERE (requester_id = $1 AND target_id = $2) OR (requester_id = $2 AND target_id = $1);
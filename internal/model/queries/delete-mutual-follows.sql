@@ -0,0 +1,2 @@
+DELETE FROM UserFollows
+WHERE (follower_id = $1 AND followee_id = $2) OR (follower_id = $2 AND followee_id = $1);
@@ -0,0 +1,3 @@
+DELETE FROM PostHashtag
+WHERE post_id IS $1 AND comment_id IS $2
+    AND hashtag_id = (SELECT id FROM Hashtag WHERE tag = $3);
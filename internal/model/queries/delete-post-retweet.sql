@@ -1 +1 @@
-DELETE FROM PostRetweet WHERE post_id = $1 AND user_id = $2;
+DELETE FROM PostRetweet WHERE post_id = $1 AND user_id = $2 RETURNING COALESCE(image_url, '');
@@ -0,0 +1 @@
+DELETE FROM Post WHERE id = $1;
@@ -0,0 +1 @@
+DELETE FROM UserMute WHERE muter_id = $1 AND muted_id = $2;
@@ -0,0 +1,3 @@
+UPDATE UserToken
+SET used_at = current_timestamp
+WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL;
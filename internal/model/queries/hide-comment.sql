@@ -0,0 +1 @@
+UPDATE Comment SET is_hidden = 1 WHERE id = $1;
@@ -0,0 +1 @@
+UPDATE Post SET is_hidden = 1 WHERE id = $1;
@@ -0,0 +1,3 @@
+UPDATE Report
+SET status = $1, resolved_by = $2, resolved_at = current_timestamp
+WHERE status = 'open' AND (post_id = $3 OR comment_id = $4);
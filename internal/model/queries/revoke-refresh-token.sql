@@ -0,0 +1,3 @@
+UPDATE RefreshToken
+SET revoked_at = current_timestamp
+WHERE token_hash = $1 AND user_id = $2 AND revoked_at IS NULL;
@@ -0,0 +1,14 @@
+SELECT
+    AuditLog.id,
+    AuditLog.action,
+    AuditLog.target_type,
+    AuditLog.target_id,
+    AuditLog.details,
+    User.user_name,
+    User.display_name,
+    User.avatar,
+    AuditLog.created_at
+FROM AuditLog
+LEFT JOIN User ON User.id = AuditLog.actor_id
+ORDER BY AuditLog.created_at DESC, AuditLog.id DESC
+LIMIT $1 OFFSET $2;
@@ -3,8 +3,8 @@ SELECT
     Author.display_name AS display_name,
     Author.avatar AS avatar,
     Author.bio AS bio,
-    COUNT(Followers.id) AS follower_count,
-    COUNT(Following.id) AS following_count,
+    COUNT(DISTINCT Followers.id) AS follower_count,
+    COUNT(DISTINCT Following.id) AS following_count,
     CASE WHEN ViewerFollowing.id IS NOT NULL THEN 1 ELSE 0 END AS viewer_following
 FROM
     Post
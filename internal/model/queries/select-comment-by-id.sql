@@ -10,12 +10,22 @@ SELECT
     Comment.retweet_count,
     Comment.bookmark_count,
     Comment.impressions,
+    Comment.generating,
     Comment.created_at,
     Comment.updated_at,
     Author.user_name,
     Author.display_name,
-    Author.avatar
+    Author.avatar,
+    (
+        SELECT group_concat(MentionedUser.user_name, ' ')
+        FROM Mention
+            INNER JOIN User MentionedUser ON MentionedUser.id = Mention.user_id
+        WHERE Mention.comment_id = Comment.id
+    ) AS mentioned_usernames
 FROM 
     Comment
     INNER JOIN User Author ON Author.id = Comment.user_id
-WHERE Comment.id = $1;
+    INNER JOIN Post ON Post.id = Comment.post_id
+-- hidden comments, and comments on hidden posts, are only loaded for
+-- moderation, $2 = 1
+WHERE Comment.id = $1 AND ($2 = 1 OR (Comment.is_hidden = 0 AND Post.is_hidden = 0));
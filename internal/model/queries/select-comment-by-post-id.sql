@@ -1,3 +1,6 @@
+WITH HiddenUser AS (
+    SELECT id FROM HiddenUserByViewer WHERE viewer_id = $1
+)
 SELECT
     Comment.id,
     Comment.post_id,
@@ -10,13 +13,20 @@ SELECT
     Comment.retweet_count,
     Comment.bookmark_count,
     Comment.impressions,
+    Comment.generating,
     Comment.created_at,
     Comment.updated_at,
     Author.user_name,
     Author.display_name,
-    Author.avatar
+    Author.avatar,
+    (
+        SELECT group_concat(MentionedUser.user_name, ' ')
+        FROM Mention
+            INNER JOIN User MentionedUser ON MentionedUser.id = Mention.user_id
+        WHERE Mention.comment_id = Comment.id
+    ) AS mentioned_usernames
 FROM 
     Comment
     INNER JOIN User Author ON Author.id = Comment.user_id
-WHERE Comment.post_id = $1
+WHERE Comment.post_id = $2 AND Comment.is_hidden = 0 AND Comment.user_id NOT IN (SELECT id FROM HiddenUser)
 ORDER BY Comment.created_at DESC;
@@ -0,0 +1,3 @@
+SELECT image
+FROM Comment
+WHERE (id = $1 OR parent_comment_id = $1) AND image != '';
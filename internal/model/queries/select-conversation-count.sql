@@ -0,0 +1,9 @@
+SELECT COUNT(*)
+FROM Conversation
+WHERE (user_one_id = $1 OR user_two_id = $1)
+    AND EXISTS (SELECT 1 FROM Message WHERE Message.conversation_id = Conversation.id)
+    AND NOT EXISTS (
+        SELECT 1 FROM UserBlock
+        WHERE (blocker_id = Conversation.user_one_id AND blocked_id = Conversation.user_two_id)
+            OR (blocker_id = Conversation.user_two_id AND blocked_id = Conversation.user_one_id)
+    );
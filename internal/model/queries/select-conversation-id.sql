@@ -0,0 +1,2 @@
+SELECT id FROM Conversation
+WHERE user_one_id = min($1, $2) AND user_two_id = max($1, $2);
@@ -0,0 +1,6 @@
+-- the user on the other end of the conversation, no rows when $1 isn't part
+-- of it
+SELECT
+    CASE WHEN user_one_id = $1 THEN user_two_id ELSE user_one_id END AS other_user_id
+FROM Conversation
+WHERE id = $2 AND (user_one_id = $1 OR user_two_id = $1);
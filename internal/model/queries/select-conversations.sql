@@ -0,0 +1,43 @@
+WITH UserConversation AS (
+    SELECT
+        id,
+        CASE WHEN user_one_id = $1 THEN user_two_id ELSE user_one_id END AS other_user_id
+    FROM Conversation
+    WHERE user_one_id = $1 OR user_two_id = $1
+),
+BlockedUser AS (
+    SELECT blocked_id AS id FROM UserBlock WHERE blocker_id = $1
+    UNION
+    SELECT blocker_id FROM UserBlock WHERE blocked_id = $1
+)
+SELECT
+    UserConversation.id,
+    OtherUser.user_name,
+    OtherUser.display_name,
+    OtherUser.avatar,
+    LastMessage.id,
+    Sender.user_name,
+    LastMessage.content,
+    LastMessage.read_at,
+    LastMessage.created_at,
+    (
+        SELECT COUNT(*) FROM Message
+        WHERE Message.conversation_id = UserConversation.id
+            AND Message.sender_id != $1 AND Message.read_at IS NULL
+    ) AS unread_count
+FROM
+    UserConversation
+    INNER JOIN User OtherUser
+        ON OtherUser.id = UserConversation.other_user_id
+    INNER JOIN Message LastMessage
+        ON LastMessage.id = (
+            SELECT id FROM Message
+            WHERE Message.conversation_id = UserConversation.id
+            ORDER BY created_at DESC, id DESC
+            LIMIT 1
+        )
+    INNER JOIN User Sender
+        ON Sender.id = LastMessage.sender_id
+WHERE UserConversation.other_user_id NOT IN (SELECT id FROM BlockedUser)
+ORDER BY LastMessage.created_at DESC, LastMessage.id DESC
+LIMIT $2 OFFSET $3;
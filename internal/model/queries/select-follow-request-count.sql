@@ -0,0 +1 @@
+SELECT COUNT(*) FROM FollowRequest WHERE target_id = $1;
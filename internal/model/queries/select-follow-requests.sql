@@ -0,0 +1,16 @@
+-- pending requests to follow $2, the viewer $1 is always $2 itself
+SELECT
+    Requester.user_name AS username,
+    Requester.display_name AS display_name,
+    Requester.avatar AS avatar,
+    Requester.bio AS bio,
+    CASE WHEN ViewerFollowing.id IS NOT NULL THEN 1 ELSE 0 END AS viewer_following
+FROM
+    FollowRequest
+    INNER JOIN User Requester
+        ON Requester.id = FollowRequest.requester_id
+    LEFT JOIN UserFollows ViewerFollowing
+        ON ViewerFollowing.followee_id = Requester.id AND ViewerFollowing.follower_id = $1
+WHERE FollowRequest.target_id = $2
+ORDER BY FollowRequest.created_at DESC, FollowRequest.id DESC
+LIMIT $3 OFFSET $4;
@@ -0,0 +1,236 @@
+WITH HiddenUser AS (
+    SELECT id FROM HiddenUserByViewer WHERE viewer_id = $1
+),
+TimelineRow AS (
+SELECT
+	'post' AS type,
+    Post.id,
+    Post.user_id,
+    Post.content,
+    Post.comment_count,
+    Post.like_count,
+    Post.retweet_count,
+    Post.bookmark_count,
+    Post.impressions,
+    Post.image,
+    Post.created_at,
+    Post.updated_at,
+    Author.user_name,
+    Author.display_name,
+    Author.avatar,
+    '' AS retweeter_user_name,
+    '' AS retweeter_display_name,
+    NULL AS parent_post_id,
+    NULL AS parent_post_author_username,
+    NULL AS parent_comment_id,
+    NULL AS parent_comment_author_username,
+    CASE WHEN PostLike.post_id IS NOT NULL THEN 1 ELSE 0 END AS liked,
+    CASE WHEN ViewerRetweet.post_id IS NOT NULL THEN 1 ELSE 0 END AS retweeted,
+    CASE WHEN PostBookmark.post_id IS NOT NULL THEN 1 ELSE 0 END AS bookmarked,
+    NULL AS quoted_post_id,
+    NULL AS quoted_post_content,
+    NULL AS quoted_post_image,
+    NULL AS quoted_post_created_at,
+    NULL AS quoted_post_updated_at,
+    NULL AS quoted_post_author_user_name,
+    NULL AS quoted_post_author_display_name,
+    NULL AS quoted_post_author_avatar,
+    Post.created_at AS sort_time,
+    Post.id AS sort_id
+FROM 
+    Post
+    INNER JOIN User Author
+        ON Author.id = Post.user_id
+	-- viewer specific data
+    LEFT JOIN PostLike
+        ON PostLike.post_id = Post.id AND PostLike.user_id = $1
+    LEFT JOIN PostRetweet ViewerRetweet
+        ON ViewerRetweet.post_id = Post.id AND ViewerRetweet.user_id = $1
+    LEFT JOIN PostBookmark
+        ON PostBookmark.post_id = Post.id AND PostBookmark.user_id = $1
+WHERE Post.is_hidden = 0 AND Post.user_id NOT IN (SELECT id FROM HiddenUser)
+UNION ALL
+SELECT
+	'post-retweet' AS type,
+    Post.id AS post_id,
+    Post.user_id,
+    Post.content,
+    Post.comment_count,
+    Post.like_count,
+    Post.retweet_count,
+    Post.bookmark_count,
+    Post.impressions,
+    Post.image,
+    Post.created_at,
+    Post.updated_at,
+    Author.user_name,
+    Author.display_name,
+    Author.avatar,
+    Retweeter.user_name AS retweeter_user_name,
+    Retweeter.display_name AS retweeter_display_name,
+    NULL AS parent_post_id,
+    NULL AS parent_post_author_username,
+    NULL AS parent_comment_id,
+    NULL AS parent_comment_author_username,
+    CASE WHEN PostLike.post_id IS NOT NULL THEN 1 ELSE 0 END AS liked,
+    CASE WHEN ViewerRetweet.post_id IS NOT NULL THEN 1 ELSE 0 END AS retweeted,
+    CASE WHEN PostBookmark.post_id IS NOT NULL THEN 1 ELSE 0 END AS bookmarked,
+    NULL AS quoted_post_id,
+    NULL AS quoted_post_content,
+    NULL AS quoted_post_image,
+    NULL AS quoted_post_created_at,
+    NULL AS quoted_post_updated_at,
+    NULL AS quoted_post_author_user_name,
+    NULL AS quoted_post_author_display_name,
+    NULL AS quoted_post_author_avatar,
+    PostRetweet.created_at AS sort_time,
+    PostRetweet.id AS sort_id
+FROM 
+    PostRetweet
+    INNER JOIN Post
+        ON Post.id = PostRetweet.post_id
+    INNER JOIN User Author
+        ON Author.id = Post.user_id
+    INNER JOIN User Retweeter
+        ON Retweeter.id = PostRetweet.user_id
+	-- viewer specific data
+	LEFT JOIN PostLike
+        ON PostLike.post_id = Post.id AND PostLike.user_id = $1
+	LEFT JOIN PostRetweet ViewerRetweet
+        ON ViewerRetweet.post_id = Post.id AND ViewerRetweet.user_id = $1
+	LEFT JOIN PostBookmark
+        ON PostBookmark.post_id = Post.id AND PostBookmark.user_id = $1
+WHERE COALESCE(PostRetweet.content, '') = '' AND COALESCE(PostRetweet.image_url, '') = '' AND Post.is_hidden = 0
+    AND PostRetweet.user_id NOT IN (SELECT id FROM HiddenUser) AND Post.user_id NOT IN (SELECT id FROM HiddenUser)
+UNION ALL
+SELECT
+    'post-quote' AS type,
+    PostRetweet.id,
+    PostRetweet.user_id,
+    COALESCE(PostRetweet.content, '') AS content,
+    0 AS comment_count,
+    0 AS like_count,
+    0 AS retweet_count,
+    0 AS bookmark_count,
+    0 AS impressions,
+    COALESCE(PostRetweet.image_url, '') AS image,
+    PostRetweet.created_at,
+    PostRetweet.created_at AS updated_at,
+    Quoter.user_name,
+    Quoter.display_name,
+    Quoter.avatar,
+    '' AS retweeter_user_name,
+    '' AS retweeter_display_name,
+    NULL AS parent_post_id,
+    NULL AS parent_post_author_username,
+    NULL AS parent_comment_id,
+    NULL AS parent_comment_author_username,
+    0 AS liked,
+    0 AS retweeted,
+    0 AS bookmarked,
+    Post.id AS quoted_post_id,
+    Post.content AS quoted_post_content,
+    Post.image AS quoted_post_image,
+    Post.created_at AS quoted_post_created_at,
+    Post.updated_at AS quoted_post_updated_at,
+    Author.user_name AS quoted_post_author_user_name,
+    Author.display_name AS quoted_post_author_display_name,
+    Author.avatar AS quoted_post_author_avatar,
+    PostRetweet.created_at AS sort_time,
+    PostRetweet.id AS sort_id
+FROM
+    PostRetweet
+    INNER JOIN Post
+        ON Post.id = PostRetweet.post_id
+    INNER JOIN User Quoter
+        ON Quoter.id = PostRetweet.user_id
+    INNER JOIN User Author
+        ON Author.id = Post.user_id
+WHERE (COALESCE(PostRetweet.content, '') != '' OR COALESCE(PostRetweet.image_url, '') != '') AND Post.is_hidden = 0
+    AND PostRetweet.user_id NOT IN (SELECT id FROM HiddenUser) AND Post.user_id NOT IN (SELECT id FROM HiddenUser)
+UNION ALL
+SELECT
+	'comment-retweet' AS type,
+    Comment.id,
+    Comment.user_id,
+    Comment.content,
+    0 AS comment_count,
+    Comment.like_count,
+    Comment.retweet_count,
+    Comment.bookmark_count,
+    Comment.impressions,
+    Comment.image,
+    Comment.created_at,
+    Comment.updated_at,
+    Author.user_name,
+    Author.display_name,
+    Author.avatar,
+    Retweeter.user_name AS retweeter_user_name,
+    Retweeter.display_name AS retweeter_display_name,
+    ParentPost.id AS parent_post_id,
+    ParentPostAuthor.user_name AS parent_post_author_username,
+    ParentComment.id AS parent_comment_id,
+    ParentCommentAuthor.user_name AS parent_comment_author_username,
+    CASE WHEN CommentLike.comment_id IS NOT NULL THEN 1 ELSE 0 END AS liked,
+    CASE WHEN ViewerRetweet.comment_id IS NOT NULL THEN 1 ELSE 0 END AS retweeted,
+    CASE WHEN CommentBookmark.comment_id IS NOT NULL THEN 1 ELSE 0 END AS bookmarked,
+    NULL AS quoted_post_id,
+    NULL AS quoted_post_content,
+    NULL AS quoted_post_image,
+    NULL AS quoted_post_created_at,
+    NULL AS quoted_post_updated_at,
+    NULL AS quoted_post_author_user_name,
+    NULL AS quoted_post_author_display_name,
+    NULL AS quoted_post_author_avatar,
+    CommentRetweet.created_at AS sort_time,
+    CommentRetweet.id AS sort_id
+FROM
+	CommentRetweet
+	INNER JOIN Comment
+        ON Comment.id = CommentRetweet.comment_id
+	INNER JOIN User Retweeter
+        ON Retweeter.id = CommentRetweet.user_id
+	INNER JOIN User Author
+        ON Author.id = Comment.user_id
+	-- comment context (parent post, parent comment info)
+    INNER JOIN Post ParentPost
+        ON ParentPost.id = Comment.post_id
+    INNER JOIN User ParentPostAuthor
+        ON ParentPostAuthor.id = ParentPost.user_id
+    LEFT JOIN Comment ParentComment
+        ON ParentComment.id = Comment.parent_comment_id
+    LEFT JOIN User ParentCommentAuthor
+        ON ParentCommentAuthor.id = ParentComment.user_id
+	-- viewer specific data
+	LEFT JOIN CommentLike
+        ON CommentLike.comment_id = Comment.id AND CommentLike.user_id = $1
+	LEFT JOIN CommentRetweet ViewerRetweet
+        ON ViewerRetweet.comment_id = Comment.id AND ViewerRetweet.user_id = $1
+	LEFT JOIN CommentBookmark
+        ON CommentBookmark.comment_id = Comment.id AND CommentBookmark.user_id = $1
+WHERE Comment.is_hidden = 0
+    AND CommentRetweet.user_id NOT IN (SELECT id FROM HiddenUser) AND Comment.user_id NOT IN (SELECT id FROM HiddenUser)
+)
+SELECT
+    TimelineRow.*,
+    EXISTS (
+        SELECT 1 FROM UserFollows
+        WHERE follower_id = $1 AND followee_id = TimelineRow.user_id
+    ) AS follows_author,
+    (
+        SELECT COUNT(*) FROM PostLike
+        INNER JOIN Post LikedPost ON LikedPost.id = PostLike.post_id
+        WHERE PostLike.user_id = $1 AND LikedPost.user_id = TimelineRow.user_id
+    ) AS author_likes,
+    -- only views from before this ranking, pages of the same ranking don't
+    -- penalize what earlier pages served
+    EXISTS (
+        SELECT 1 FROM PostView
+        WHERE PostView.user_id = $1 AND PostView.created_at < $2
+            AND PostView.post_id = CASE TimelineRow.type WHEN 'post-quote' THEN TimelineRow.quoted_post_id ELSE TimelineRow.id END
+            AND TimelineRow.type != 'comment-retweet'
+    ) AS seen
+FROM TimelineRow
+WHERE sort_time <= $2
+ORDER BY sort_time DESC, type DESC, sort_id DESC
+LIMIT $3;
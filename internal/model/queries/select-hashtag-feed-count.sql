@@ -0,0 +1,19 @@
+WITH HiddenUser AS (
+    SELECT id FROM HiddenUserByViewer WHERE viewer_id = $1
+)
+SELECT COUNT(*)
+FROM
+    PostHashtag
+    INNER JOIN Hashtag
+        ON Hashtag.id = PostHashtag.hashtag_id
+    LEFT JOIN Post
+        ON Post.id = PostHashtag.post_id
+    LEFT JOIN Comment
+        ON Comment.id = PostHashtag.comment_id
+    LEFT JOIN Post ParentPost
+        ON ParentPost.id = Comment.post_id
+WHERE Hashtag.tag = $2
+    AND (
+        (Post.is_hidden = 0 AND Post.user_id NOT IN (SELECT id FROM HiddenUser)) OR
+        (Comment.is_hidden = 0 AND ParentPost.is_hidden = 0 AND Comment.user_id NOT IN (SELECT id FROM HiddenUser))
+    );
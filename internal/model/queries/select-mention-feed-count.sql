@@ -0,0 +1,17 @@
+WITH HiddenUser AS (
+    SELECT id FROM HiddenUserByViewer WHERE viewer_id = $1
+)
+SELECT COUNT(*)
+FROM
+    Mention
+    LEFT JOIN Post
+        ON Post.id = Mention.post_id
+    LEFT JOIN Comment
+        ON Comment.id = Mention.comment_id
+    LEFT JOIN Post ParentPost
+        ON ParentPost.id = Comment.post_id
+WHERE Mention.user_id = $1
+    AND (
+        (Post.is_hidden = 0 AND Post.user_id NOT IN (SELECT id FROM HiddenUser)) OR
+        (Comment.is_hidden = 0 AND ParentPost.is_hidden = 0 AND Comment.user_id NOT IN (SELECT id FROM HiddenUser))
+    );
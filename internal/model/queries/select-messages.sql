@@ -0,0 +1,17 @@
+SELECT
+    Message.id,
+    Message.conversation_id,
+    Sender.user_name,
+    Message.content,
+    Message.read_at,
+    Message.created_at
+FROM
+    Message
+    INNER JOIN User Sender
+        ON Sender.id = Message.sender_id
+WHERE Message.conversation_id = $1
+    -- keyset pagination, $2 and $3 hold the created_at and id of the last
+    -- message already seen
+    AND ($2 IS NULL OR (Message.created_at, Message.id) < ($2, $3))
+ORDER BY Message.created_at DESC, Message.id DESC
+LIMIT $4;
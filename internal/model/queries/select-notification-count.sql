@@ -0,0 +1,10 @@
+SELECT COUNT(*)
+FROM
+    Notification
+    LEFT JOIN Post ON Post.id = Notification.post_id
+    LEFT JOIN Comment ON Comment.id = Notification.comment_id
+WHERE
+    Notification.receiver_id = $1
+    AND ($2 = 0 OR Notification.is_read = 0)
+    AND COALESCE(Post.is_hidden, 0) = 0
+    AND COALESCE(Comment.is_hidden, 0) = 0;
@@ -0,0 +1,27 @@
+SELECT
+    Notification.id,
+    Notification.type,
+    Notification.is_read,
+    COALESCE(Notification.post_id, Comment.post_id) AS post_id,
+    Notification.comment_id,
+    COALESCE(Comment.content, Post.content, '') AS content,
+    Initiator.user_name,
+    Initiator.display_name,
+    Initiator.avatar,
+    Notification.created_at,
+    Notification.updated_at
+FROM
+    Notification
+    LEFT JOIN User Initiator ON Initiator.id = Notification.initiator_id
+    LEFT JOIN Post ON Post.id = Notification.post_id
+    LEFT JOIN Comment ON Comment.id = Notification.comment_id
+WHERE
+    Notification.receiver_id = $1
+    AND ($2 = 0 OR Notification.is_read = 0)
+    -- notifications about content hidden by moderation go with it
+    AND COALESCE(Post.is_hidden, 0) = 0
+    AND COALESCE(Comment.is_hidden, 0) = 0
+ORDER BY
+    Notification.created_at DESC,
+    Notification.id DESC
+LIMIT $3 OFFSET $4;
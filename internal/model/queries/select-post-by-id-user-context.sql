@@ -11,14 +11,21 @@ SELECT
     Post.bookmark_count,
     Post.impressions,
     Post.image,
+    Post.image_alt,
     Post.created_at,
     Post.updated_at,
     CASE
         WHEN PostLike.post_id IS NOT NULL THEN 1
         ELSE 0
-    END AS liked
+    END AS liked,
+    (
+        SELECT group_concat(MentionedUser.user_name, ' ')
+        FROM Mention
+            INNER JOIN User MentionedUser ON MentionedUser.id = Mention.user_id
+        WHERE Mention.post_id = Post.id
+    ) AS mentioned_usernames
 FROM
     Post
     INNER JOIN User ON User.id = Post.user_id
     LEFT JOIN PostLike ON PostLike.post_id = Post.id AND PostLike.user_id = $1
-WHERE Post.id = $2;
+WHERE Post.id = $2 AND Post.is_hidden = 0;
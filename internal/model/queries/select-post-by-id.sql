@@ -11,9 +11,17 @@ SELECT
     Post.bookmark_count,
     Post.impressions,
     Post.image,
+    Post.image_alt,
     Post.created_at,
-    Post.updated_at
+    Post.updated_at,
+    (
+        SELECT group_concat(MentionedUser.user_name, ' ')
+        FROM Mention
+            INNER JOIN User MentionedUser ON MentionedUser.id = Mention.user_id
+        WHERE Mention.post_id = Post.id
+    ) AS mentioned_usernames
 FROM
     Post
     INNER JOIN User ON User.id = Post.user_id
-WHERE Post.id = $1;
+-- hidden posts are only loaded for moderation, $2 = 1
+WHERE Post.id = $1 AND ($2 = 1 OR Post.is_hidden = 0);
@@ -0,0 +1,4 @@
+SELECT Hashtag.tag
+FROM PostHashtag
+INNER JOIN Hashtag ON Hashtag.id = PostHashtag.hashtag_id
+WHERE PostHashtag.post_id IS $1 AND PostHashtag.comment_id IS $2;
@@ -0,0 +1,5 @@
+SELECT image FROM Post WHERE id = $1 AND image != ''
+UNION ALL
+SELECT image FROM Comment WHERE post_id = $1 AND image != ''
+UNION ALL
+SELECT image_url FROM PostRetweet WHERE post_id = $1 AND image_url != '';
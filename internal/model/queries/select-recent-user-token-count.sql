@@ -0,0 +1,6 @@
+SELECT COUNT(*)
+FROM UserToken
+WHERE
+    user_id = $1
+    AND purpose = $2
+    AND created_at > datetime(current_timestamp, $3);
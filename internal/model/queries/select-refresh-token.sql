@@ -0,0 +1,7 @@
+SELECT
+    id,
+    user_id,
+    CASE WHEN revoked_at IS NOT NULL THEN 1 ELSE 0 END AS revoked,
+    CASE WHEN expires_at <= current_timestamp THEN 1 ELSE 0 END AS expired
+FROM RefreshToken
+WHERE token_hash = $1;
@@ -0,0 +1,24 @@
+SELECT
+    Report.id,
+    Report.reason,
+    Report.details,
+    Report.status,
+    COALESCE(Report.post_id, 0) AS post_id,
+    COALESCE(Report.comment_id, 0) AS comment_id,
+    COALESCE(Post.content, Comment.content, '') AS content,
+    COALESCE(Post.image, Comment.image, '') AS image,
+    Reporter.user_name,
+    Reporter.display_name,
+    Reporter.avatar,
+    ContentAuthor.id,
+    ContentAuthor.user_name,
+    ContentAuthor.display_name,
+    ContentAuthor.avatar,
+    Report.created_at,
+    Report.resolved_at
+FROM
+    Report
+    INNER JOIN User Reporter ON Reporter.id = Report.reporter_id
+    LEFT JOIN Post ON Post.id = Report.post_id
+    LEFT JOIN Comment ON Comment.id = Report.comment_id
+    INNER JOIN User ContentAuthor ON ContentAuthor.id = COALESCE(Post.user_id, Comment.user_id)
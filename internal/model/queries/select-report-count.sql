@@ -0,0 +1 @@
+SELECT COUNT(*) FROM Report WHERE status = $1;
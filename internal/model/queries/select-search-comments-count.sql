@@ -0,0 +1,12 @@
+WITH HiddenUser AS (
+    SELECT id FROM HiddenUserByViewer WHERE viewer_id = $1
+)
+SELECT COUNT(*)
+FROM
+    CommentSearch
+    INNER JOIN Comment
+        ON Comment.id = CommentSearch.rowid
+    INNER JOIN Post ParentPost
+        ON ParentPost.id = Comment.post_id
+WHERE CommentSearch MATCH $2 AND Comment.is_hidden = 0 AND ParentPost.is_hidden = 0
+    AND Comment.user_id NOT IN (SELECT id FROM HiddenUser);
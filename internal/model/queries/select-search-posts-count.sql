@@ -0,0 +1,10 @@
+WITH HiddenUser AS (
+    SELECT id FROM HiddenUserByViewer WHERE viewer_id = $1
+)
+SELECT COUNT(*)
+FROM
+    PostSearch
+    INNER JOIN Post
+        ON Post.id = PostSearch.rowid
+WHERE PostSearch MATCH $2 AND Post.is_hidden = 0
+    AND Post.user_id NOT IN (SELECT id FROM HiddenUser);
@@ -0,0 +1,56 @@
+WITH HiddenUser AS (
+    SELECT id FROM HiddenUserByViewer WHERE viewer_id = $1
+)
+SELECT
+    'post' AS type,
+    Post.id,
+    Post.user_id,
+    Post.content,
+    Post.comment_count,
+    Post.like_count,
+    Post.retweet_count,
+    Post.bookmark_count,
+    Post.impressions,
+    Post.image,
+    Post.created_at,
+    Post.updated_at,
+    Author.user_name,
+    Author.display_name,
+    Author.avatar,
+    '' AS retweeter_user_name,
+    '' AS retweeter_display_name,
+    NULL AS parent_post_id,
+    NULL AS parent_post_author_username,
+    NULL AS parent_comment_id,
+    NULL AS parent_comment_author_username,
+    CASE WHEN PostLike.post_id IS NOT NULL THEN 1 ELSE 0 END AS liked,
+    CASE WHEN ViewerRetweet.post_id IS NOT NULL THEN 1 ELSE 0 END AS retweeted,
+    CASE WHEN PostBookmark.post_id IS NOT NULL THEN 1 ELSE 0 END AS bookmarked,
+    NULL AS quoted_post_id,
+    NULL AS quoted_post_content,
+    NULL AS quoted_post_image,
+    NULL AS quoted_post_created_at,
+    NULL AS quoted_post_updated_at,
+    NULL AS quoted_post_author_user_name,
+    NULL AS quoted_post_author_display_name,
+    NULL AS quoted_post_author_avatar,
+    Post.created_at AS sort_time,
+    -- matches are wrapped in \x02 and \x03, see markSnippet
+    snippet(PostSearch, 0, char(2), char(3), '…', 16) AS snippet
+FROM
+    PostSearch
+    INNER JOIN Post
+        ON Post.id = PostSearch.rowid
+    INNER JOIN User Author
+        ON Author.id = Post.user_id
+    -- viewer specific data
+    LEFT JOIN PostLike
+        ON PostLike.post_id = Post.id AND PostLike.user_id = $1
+    LEFT JOIN PostRetweet ViewerRetweet
+        ON ViewerRetweet.post_id = Post.id AND ViewerRetweet.user_id = $1
+    LEFT JOIN PostBookmark
+        ON PostBookmark.post_id = Post.id AND PostBookmark.user_id = $1
+WHERE PostSearch MATCH $2 AND Post.is_hidden = 0
+    AND Post.user_id NOT IN (SELECT id FROM HiddenUser)
+ORDER BY PostSearch.rank, Post.created_at DESC
+LIMIT $3 OFFSET $4;
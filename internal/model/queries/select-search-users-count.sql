@@ -0,0 +1,13 @@
+WITH BlockedUser AS (
+    -- users the viewer blocked or was blocked by, muted users are still found
+    SELECT blocked_id AS id FROM UserBlock WHERE blocker_id = $1
+    UNION
+    SELECT blocker_id FROM UserBlock WHERE blocked_id = $1
+)
+SELECT COUNT(*)
+FROM
+    UserSearch
+    INNER JOIN User
+        ON User.id = UserSearch.rowid
+WHERE UserSearch MATCH $2 AND User.is_active = 1
+    AND User.id NOT IN (SELECT id FROM BlockedUser);
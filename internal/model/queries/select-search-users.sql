@@ -0,0 +1,25 @@
+WITH BlockedUser AS (
+    -- users the viewer blocked or was blocked by, muted users are still found
+    SELECT blocked_id AS id FROM UserBlock WHERE blocker_id = $1
+    UNION
+    SELECT blocker_id FROM UserBlock WHERE blocked_id = $1
+)
+SELECT
+    User.user_name,
+    User.display_name,
+    User.avatar,
+    User.bio,
+    CASE WHEN ViewerFollowing.id IS NOT NULL THEN 1 ELSE 0 END AS viewer_following,
+    -- matches are wrapped in \x02 and \x03, see markSnippet
+    snippet(UserSearch, -1, char(2), char(3), '…', 16) AS snippet
+FROM
+    UserSearch
+    INNER JOIN User
+        ON User.id = UserSearch.rowid
+    LEFT JOIN UserFollows ViewerFollowing
+        ON ViewerFollowing.followee_id = User.id AND ViewerFollowing.follower_id = $1
+-- names count for more than bios
+WHERE UserSearch MATCH $2 AND User.is_active = 1
+    AND User.id NOT IN (SELECT id FROM BlockedUser)
+ORDER BY bm25(UserSearch, 10.0, 5.0, 1.0), User.id ASC
+LIMIT $3 OFFSET $4;
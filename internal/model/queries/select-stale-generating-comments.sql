@@ -0,0 +1,5 @@
+-- generating comments without an update within $1, a sqlite datetime()
+-- modifier such as "-5 minutes"
+SELECT id
+FROM Comment
+WHERE generating = 1 AND updated_at < datetime(current_timestamp, $1);
@@ -0,0 +1,16 @@
+SELECT
+    Hashtag.tag,
+    COUNT(*) AS uses
+FROM
+    PostHashtag
+    INNER JOIN Hashtag
+        ON Hashtag.id = PostHashtag.hashtag_id
+    LEFT JOIN Post
+        ON Post.id = PostHashtag.post_id
+    LEFT JOIN Comment
+        ON Comment.id = PostHashtag.comment_id
+WHERE PostHashtag.created_at >= datetime(current_timestamp, $1)
+    AND COALESCE(Post.is_hidden, Comment.is_hidden) = 0
+GROUP BY Hashtag.id
+ORDER BY uses DESC, MAX(PostHashtag.created_at) DESC, Hashtag.tag ASC
+LIMIT $2;
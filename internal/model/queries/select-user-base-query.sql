@@ -6,10 +6,14 @@ SELECT
     first_name,
     last_name,
     display_name,
+    bio,
     avatar,
     last_login,
     role,
     is_active,
+    is_private,
+    dm_mutuals_only,
+    token_version,
     created_at,
     updated_at
 FROM User 
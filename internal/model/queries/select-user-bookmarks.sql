@@ -1,3 +1,11 @@
+WITH PrivateUser AS (
+    -- private accounts the viewer doesn't follow (anymore), bookmarks of their
+    -- content are kept but not shown
+    SELECT id FROM User
+    WHERE is_private = 1 AND id != $1
+        AND id NOT IN (SELECT followee_id FROM UserFollows WHERE follower_id = $1)
+),
+BookmarkRow AS (
 SELECT
     PostBookmark.created_at AS bookmark_created_at,
     Post.id AS id,
@@ -12,13 +20,16 @@ SELECT
     PostAuthor.user_name as author_user_name,
     PostAuthor.display_name as author_display_name,
     PostAuthor.avatar as author_avatar,
-    'post' AS type
+    'post' AS type,
+    PostBookmark.id AS bookmark_id
 FROM
     PostBookmark
     INNER JOIN Post ON Post.id = PostBookmark.post_id
     INNER JOIN User PostAuthor ON PostAuthor.id = Post.user_id
 
 WHERE PostBookmark.user_id = $1
+    AND Post.is_hidden = 0
+    AND Post.user_id NOT IN (SELECT id FROM PrivateUser)
 
 UNION
 
@@ -36,14 +47,21 @@ SELECT
     CommentAuthor.user_name as author_user_name,
     CommentAuthor.display_name as author_display_name,
     CommentAuthor.avatar as author_avatar,
-    'comment' AS type
+    'comment' AS type,
+    CommentBookmark.id AS bookmark_id
 FROM
     CommentBookmark
     INNER JOIN Comment ON Comment.id = CommentBookmark.comment_id
     INNER JOIN User CommentAuthor ON CommentAuthor.id = Comment.user_id
 
 WHERE CommentBookmark.user_id = $1
+    AND Comment.is_hidden = 0
+    AND Comment.user_id NOT IN (SELECT id FROM PrivateUser)
 
+)
+SELECT * FROM BookmarkRow
+-- keyset pagination, $2-$4 hold the sort key of the last row already seen
+WHERE $2 IS NULL OR (bookmark_created_at, type, bookmark_id) < ($2, $3, $4)
 ORDER BY
-    bookmark_created_at DESC
-LIMIT $2 OFFSET $3;
+    bookmark_created_at DESC, type DESC, bookmark_id DESC
+LIMIT $5;
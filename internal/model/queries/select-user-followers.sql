@@ -0,0 +1,15 @@
+SELECT
+    Follower.user_name AS username,
+    Follower.display_name AS display_name,
+    Follower.avatar AS avatar,
+    Follower.bio AS bio,
+    CASE WHEN ViewerFollowing.id IS NOT NULL THEN 1 ELSE 0 END AS viewer_following
+FROM
+    UserFollows
+    INNER JOIN User Follower
+        ON Follower.id = UserFollows.follower_id
+    LEFT JOIN UserFollows ViewerFollowing
+        ON ViewerFollowing.followee_id = Follower.id AND ViewerFollowing.follower_id = $1
+WHERE UserFollows.followee_id = $2
+ORDER BY UserFollows.created_at DESC, UserFollows.id DESC
+LIMIT $3 OFFSET $4;
@@ -0,0 +1,15 @@
+SELECT
+    Followee.user_name AS username,
+    Followee.display_name AS display_name,
+    Followee.avatar AS avatar,
+    Followee.bio AS bio,
+    CASE WHEN ViewerFollowing.id IS NOT NULL THEN 1 ELSE 0 END AS viewer_following
+FROM
+    UserFollows
+    INNER JOIN User Followee
+        ON Followee.id = UserFollows.followee_id
+    LEFT JOIN UserFollows ViewerFollowing
+        ON ViewerFollowing.followee_id = Followee.id AND ViewerFollowing.follower_id = $1
+WHERE UserFollows.follower_id = $2
+ORDER BY UserFollows.created_at DESC, UserFollows.id DESC
+LIMIT $3 OFFSET $4;
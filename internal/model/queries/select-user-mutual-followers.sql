@@ -0,0 +1,14 @@
+-- users the viewer follows who also follow the profile user
+SELECT
+    Mutual.user_name AS username,
+    Mutual.display_name AS display_name,
+    Mutual.avatar AS avatar
+FROM
+    UserFollows ViewerFollows
+    INNER JOIN UserFollows MutualFollows
+        ON MutualFollows.follower_id = ViewerFollows.followee_id
+    INNER JOIN User Mutual
+        ON Mutual.id = MutualFollows.follower_id
+WHERE ViewerFollows.follower_id = $1 AND MutualFollows.followee_id = $2
+ORDER BY MutualFollows.created_at DESC, MutualFollows.id DESC
+LIMIT $3;
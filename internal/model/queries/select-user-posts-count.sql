@@ -0,0 +1,16 @@
+SELECT (
+    SELECT COUNT(*) FROM Post WHERE Post.user_id = $1 AND Post.is_hidden = 0
+)
++
+(
+    SELECT COUNT(*)
+    FROM PostRetweet INNER JOIN Post ON Post.id = PostRetweet.post_id
+    WHERE PostRetweet.user_id = $1 AND Post.is_hidden = 0
+)
++
+(
+    SELECT COUNT(*)
+    FROM CommentRetweet INNER JOIN Comment ON Comment.id = CommentRetweet.comment_id
+    WHERE CommentRetweet.user_id = $1 AND Comment.is_hidden = 0
+)
+AS total_count;
@@ -23,6 +23,14 @@ SELECT
     CASE WHEN PostLike.post_id IS NOT NULL THEN 1 ELSE 0 END AS liked,
     CASE WHEN ViewerRetweet.post_id IS NOT NULL THEN 1 ELSE 0 END AS retweeted,
     CASE WHEN PostBookmark.post_id IS NOT NULL THEN 1 ELSE 0 END AS bookmarked,
+    NULL AS quoted_post_id,
+    NULL AS quoted_post_content,
+    NULL AS quoted_post_image,
+    NULL AS quoted_post_created_at,
+    NULL AS quoted_post_updated_at,
+    NULL AS quoted_post_author_user_name,
+    NULL AS quoted_post_author_display_name,
+    NULL AS quoted_post_author_avatar,
     Post.created_at AS sort_time
 FROM 
     Post
@@ -35,6 +43,7 @@ FROM
         ON ViewerRetweet.post_id = Post.id AND ViewerRetweet.user_id = $1
     LEFT JOIN PostBookmark
         ON PostBookmark.post_id = Post.id AND PostBookmark.user_id = $1
+WHERE Post.user_id = $2 AND Post.is_hidden = 0
 UNION ALL
 SELECT
 	'post-retweet' AS type,
@@ -61,6 +70,14 @@ SELECT
     CASE WHEN PostLike.post_id IS NOT NULL THEN 1 ELSE 0 END AS liked,
     CASE WHEN ViewerRetweet.post_id IS NOT NULL THEN 1 ELSE 0 END AS retweeted,
     CASE WHEN PostBookmark.post_id IS NOT NULL THEN 1 ELSE 0 END AS bookmarked,
+    NULL AS quoted_post_id,
+    NULL AS quoted_post_content,
+    NULL AS quoted_post_image,
+    NULL AS quoted_post_created_at,
+    NULL AS quoted_post_updated_at,
+    NULL AS quoted_post_author_user_name,
+    NULL AS quoted_post_author_display_name,
+    NULL AS quoted_post_author_avatar,
     PostRetweet.created_at AS sort_time
 FROM 
     PostRetweet
@@ -77,6 +94,70 @@ FROM
         ON ViewerRetweet.post_id = Post.id AND ViewerRetweet.user_id = $1
 	LEFT JOIN PostBookmark
         ON PostBookmark.post_id = Post.id AND PostBookmark.user_id = $1
+WHERE
+    PostRetweet.user_id = $2
+    AND COALESCE(PostRetweet.content, '') = ''
+    AND COALESCE(PostRetweet.image_url, '') = ''
+    AND Post.is_hidden = 0
+    -- a private author's posts stay with their followers when retweeted
+    AND (
+        Author.is_private = 0
+        OR Author.id = $1
+        OR EXISTS (SELECT 1 FROM UserFollows WHERE followee_id = Author.id AND follower_id = $1)
+    )
+UNION ALL
+SELECT
+    'post-quote' AS type,
+    PostRetweet.id,
+    PostRetweet.user_id,
+    COALESCE(PostRetweet.content, '') AS content,
+    0 AS comment_count,
+    0 AS like_count,
+    0 AS retweet_count,
+    0 AS bookmark_count,
+    0 AS impressions,
+    COALESCE(PostRetweet.image_url, '') AS image,
+    PostRetweet.created_at,
+    PostRetweet.created_at AS updated_at,
+    Quoter.user_name,
+    Quoter.display_name,
+    Quoter.avatar,
+    '' AS retweeter_user_name,
+    '' AS retweeter_display_name,
+    NULL AS parent_post_id,
+    NULL AS parent_post_author_username,
+    NULL AS parent_comment_id,
+    NULL AS parent_comment_author_username,
+    0 AS liked,
+    0 AS retweeted,
+    0 AS bookmarked,
+    Post.id AS quoted_post_id,
+    Post.content AS quoted_post_content,
+    Post.image AS quoted_post_image,
+    Post.created_at AS quoted_post_created_at,
+    Post.updated_at AS quoted_post_updated_at,
+    Author.user_name AS quoted_post_author_user_name,
+    Author.display_name AS quoted_post_author_display_name,
+    Author.avatar AS quoted_post_author_avatar,
+    PostRetweet.created_at AS sort_time
+FROM
+    PostRetweet
+    INNER JOIN Post
+        ON Post.id = PostRetweet.post_id
+    INNER JOIN User Quoter
+        ON Quoter.id = PostRetweet.user_id
+    INNER JOIN User Author
+        ON Author.id = Post.user_id
+WHERE
+    PostRetweet.user_id = $2
+    AND (COALESCE(PostRetweet.content, '') != '' OR COALESCE(PostRetweet.image_url, '') != '')
+    AND Post.is_hidden = 0
+    -- a private author's posts stay with their followers when retweeted
+    AND (
+        Author.is_private = 0
+        OR Author.id = $1
+        OR EXISTS (SELECT 1 FROM UserFollows WHERE followee_id = Author.id AND follower_id = $1)
+    )
 UNION ALL
 SELECT
 	'comment-retweet' AS type,
@@ -103,6 +184,14 @@ SELECT
     CASE WHEN CommentLike.comment_id IS NOT NULL THEN 1 ELSE 0 END AS liked,
     CASE WHEN ViewerRetweet.comment_id IS NOT NULL THEN 1 ELSE 0 END AS retweeted,
     CASE WHEN CommentBookmark.comment_id IS NOT NULL THEN 1 ELSE 0 END AS bookmarked,
+    NULL AS quoted_post_id,
+    NULL AS quoted_post_content,
+    NULL AS quoted_post_image,
+    NULL AS quoted_post_created_at,
+    NULL AS quoted_post_updated_at,
+    NULL AS quoted_post_author_user_name,
+    NULL AS quoted_post_author_display_name,
+    NULL AS quoted_post_author_avatar,
     CommentRetweet.created_at AS sort_time
 FROM
 	CommentRetweet
@@ -128,5 +217,6 @@ FROM
         ON ViewerRetweet.comment_id = Comment.id AND ViewerRetweet.user_id = $1
 	LEFT JOIN CommentBookmark
         ON CommentBookmark.comment_id = Comment.id AND CommentBookmark.user_id = $1
+WHERE CommentRetweet.user_id = $2 AND Comment.is_hidden = 0
 ORDER BY sort_time DESC
-LIMIT $2 OFFSET $3;
+LIMIT $3 OFFSET $4;
@@ -0,0 +1,21 @@
+SELECT
+    User.user_name AS username,
+    User.display_name AS display_name,
+    User.avatar AS avatar,
+    User.bio AS bio,
+    (
+        SELECT COUNT(*) FROM UserFollows WHERE UserFollows.followee_id = User.id
+    ) AS follower_count,
+    (
+        SELECT COUNT(*) FROM UserFollows WHERE UserFollows.follower_id = User.id
+    ) AS following_count,
+    CASE WHEN ViewerFollowing.id IS NOT NULL THEN 1 ELSE 0 END AS viewer_following,
+    User.is_private AS is_private,
+    CASE WHEN ViewerRequest.id IS NOT NULL THEN 1 ELSE 0 END AS viewer_requested
+FROM
+    User
+    LEFT JOIN UserFollows ViewerFollowing
+        ON ViewerFollowing.followee_id = User.id AND ViewerFollowing.follower_id = $1
+    LEFT JOIN FollowRequest ViewerRequest
+        ON ViewerRequest.target_id = User.id AND ViewerRequest.requester_id = $1
+WHERE User.id = $2;
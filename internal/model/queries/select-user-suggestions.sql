@@ -0,0 +1,56 @@
+-- accounts suggested for the viewer to follow, friends of friends and authors
+-- of posts the viewer liked rank first, popular accounts fill the rest
+WITH Excluded AS (
+    -- the viewer, accounts they follow or muted, and blocks either way
+    SELECT $1 AS id
+    UNION
+    SELECT followee_id FROM UserFollows WHERE follower_id = $1
+    UNION
+    SELECT muted_id FROM UserMute WHERE muter_id = $1
+    UNION
+    SELECT blocked_id FROM UserBlock WHERE blocker_id = $1
+    UNION
+    SELECT blocker_id FROM UserBlock WHERE blocked_id = $1
+),
+FriendOfFriend AS (
+    SELECT FriendFollows.followee_id AS user_id, COUNT(*) AS mutual_count
+    FROM
+        UserFollows ViewerFollows
+        INNER JOIN UserFollows FriendFollows
+            ON FriendFollows.follower_id = ViewerFollows.followee_id
+    WHERE ViewerFollows.follower_id = $1
+    GROUP BY FriendFollows.followee_id
+),
+LikedAuthor AS (
+    SELECT Post.user_id AS user_id, COUNT(*) AS liked_count
+    FROM
+        PostLike
+        INNER JOIN Post ON Post.id = PostLike.post_id
+    WHERE PostLike.user_id = $1
+    GROUP BY Post.user_id
+)
+SELECT
+    User.id AS id,
+    User.user_name AS username,
+    User.display_name AS display_name,
+    User.avatar AS avatar,
+    User.bio AS bio,
+    (
+        SELECT COUNT(*) FROM UserFollows WHERE UserFollows.followee_id = User.id
+    ) AS follower_count,
+    (
+        SELECT COUNT(*) FROM UserFollows WHERE UserFollows.follower_id = User.id
+    ) AS following_count,
+    COALESCE(FriendOfFriend.mutual_count, 0) AS mutual_count,
+    COALESCE(LikedAuthor.liked_count, 0) AS liked_count
+FROM
+    User
+    LEFT JOIN FriendOfFriend ON FriendOfFriend.user_id = User.id
+    LEFT JOIN LikedAuthor ON LikedAuthor.user_id = User.id
+WHERE User.is_active = 1 AND User.id NOT IN (SELECT id FROM Excluded)
+-- a shared connection counts twice as much as a like
+ORDER BY
+    mutual_count * 2 + liked_count DESC,
+    follower_count DESC,
+    User.id ASC
+LIMIT $2;
@@ -0,0 +1 @@
+UPDATE Comment SET content = $1 WHERE id = $2;
@@ -0,0 +1,6 @@
+UPDATE Comment
+SET
+    content = $1,
+    generating = $2,
+    updated_at = current_timestamp
+WHERE id = $3 AND generating = 1;
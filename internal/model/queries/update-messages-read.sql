@@ -0,0 +1,3 @@
+UPDATE Message
+SET read_at = current_timestamp
+WHERE conversation_id = $1 AND sender_id != $2 AND read_at IS NULL;
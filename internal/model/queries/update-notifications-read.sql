@@ -0,0 +1,2 @@
+UPDATE Notification SET is_read = 1
+WHERE receiver_id = $1 AND is_read = 0;
@@ -0,0 +1 @@
+UPDATE Post SET content = $1 WHERE id = $2;
@@ -0,0 +1,6 @@
+-- deactivating bumps token_version so the user's JWTs stop working
+UPDATE User
+SET
+    is_active = $1,
+    token_version = token_version + CASE WHEN $1 = 0 THEN 1 ELSE 0 END
+WHERE id = $2;
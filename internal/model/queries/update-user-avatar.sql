@@ -0,0 +1 @@
+UPDATE User SET avatar = $1 WHERE id = $2;
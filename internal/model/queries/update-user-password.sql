@@ -0,0 +1,6 @@
+UPDATE User
+SET
+    password = $1,
+    token_version = token_version + 1
+WHERE id = $2
+RETURNING token_version;
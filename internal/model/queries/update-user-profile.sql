@@ -0,0 +1,9 @@
+UPDATE User
+SET
+    first_name = COALESCE($1, first_name),
+    last_name = COALESCE($2, last_name),
+    display_name = COALESCE($3, display_name),
+    bio = COALESCE($4, bio),
+    is_private = COALESCE($5, is_private),
+    dm_mutuals_only = COALESCE($6, dm_mutuals_only)
+WHERE id = $7;
@@ -0,0 +1 @@
+UPDATE User SET role = $1 WHERE id = $2;
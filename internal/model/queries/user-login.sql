@@ -1,7 +1,6 @@
 UPDATE User
 SET 
-    last_login = CURRENT_TIMESTAMP,
-    is_active = 1
+    last_login = CURRENT_TIMESTAMP
 WHERE id = $1
 RETURNING
     last_login, is_active;
@@ -1,3 +1,7 @@
+WITH HiddenUser AS (
+    SELECT id FROM HiddenUserByViewer WHERE viewer_id = $1
+),
+TimelineRow AS (
 SELECT
     'post' AS type,
     Post.id,
@@ -23,7 +27,16 @@ SELECT
     CASE WHEN PostLike.post_id IS NOT NULL THEN 1 ELSE 0 END AS liked,
     CASE WHEN ViewerRetweet.post_id IS NOT NULL THEN 1 ELSE 0 END AS retweeted,
     CASE WHEN PostBookmark.post_id IS NOT NULL THEN 1 ELSE 0 END AS bookmarked,
-    Post.created_at AS sort_time
+    NULL AS quoted_post_id,
+    NULL AS quoted_post_content,
+    NULL AS quoted_post_image,
+    NULL AS quoted_post_created_at,
+    NULL AS quoted_post_updated_at,
+    NULL AS quoted_post_author_user_name,
+    NULL AS quoted_post_author_display_name,
+    NULL AS quoted_post_author_avatar,
+    Post.created_at AS sort_time,
+    Post.id AS sort_id
 FROM
 	Post
 	INNER JOIN User Author
@@ -36,7 +49,7 @@ FROM
 		ON ViewerRetweet.post_id = Post.id AND ViewerRetweet.user_id = $1
 	LEFT JOIN PostBookmark
 		ON PostBookmark.post_id = Post.id AND PostBookmark.user_id = $1
-WHERE UserFollows.follower_id IS NOT NULL
+WHERE UserFollows.follower_id IS NOT NULL AND Post.is_hidden = 0 AND Post.user_id NOT IN (SELECT id FROM HiddenUser)
 UNION ALL
 SELECT
     'post-retweet' AS type,
@@ -63,7 +76,16 @@ SELECT
     CASE WHEN PostLike.post_id IS NOT NULL THEN 1 ELSE 0 END AS liked,
     CASE WHEN ViewerRetweet.post_id IS NOT NULL THEN 1 ELSE 0 END AS retweeted,
     CASE WHEN PostBookmark.post_id IS NOT NULL THEN 1 ELSE 0 END AS bookmarked,
-    PostRetweet.created_at AS sort_time
+    NULL AS quoted_post_id,
+    NULL AS quoted_post_content,
+    NULL AS quoted_post_image,
+    NULL AS quoted_post_created_at,
+    NULL AS quoted_post_updated_at,
+    NULL AS quoted_post_author_user_name,
+    NULL AS quoted_post_author_display_name,
+    NULL AS quoted_post_author_avatar,
+    PostRetweet.created_at AS sort_time,
+    PostRetweet.id AS sort_id
 FROM
 	PostRetweet
 	INNER JOIN Post
@@ -80,7 +102,56 @@ FROM
 		ON ViewerRetweet.post_id = Post.id AND ViewerRetweet.user_id = $1
 	LEFT JOIN PostBookmark 
 		ON PostBookmark.post_id = Post.id AND PostBookmark.user_id = $1
-WHERE UserFollows.follower_id = $1
+WHERE UserFollows.follower_id = $1 AND COALESCE(PostRetweet.content, '') = '' AND COALESCE(PostRetweet.image_url, '') = '' AND Post.is_hidden = 0
+	AND PostRetweet.user_id NOT IN (SELECT id FROM HiddenUser) AND Post.user_id NOT IN (SELECT id FROM HiddenUser)
+UNION ALL
+SELECT
+    'post-quote' AS type,
+    PostRetweet.id,
+    PostRetweet.user_id,
+    COALESCE(PostRetweet.content, '') AS content,
+    0 AS comment_count,
+    0 AS like_count,
+    0 AS retweet_count,
+    0 AS bookmark_count,
+    0 AS impressions,
+    COALESCE(PostRetweet.image_url, '') AS image,
+    PostRetweet.created_at,
+    PostRetweet.created_at AS updated_at,
+    Quoter.user_name,
+    Quoter.display_name,
+    Quoter.avatar,
+    '' AS retweeter_user_name,
+    '' AS retweeter_display_name,
+    NULL AS parent_post_id,
+    NULL AS parent_post_author_username,
+    NULL AS parent_comment_id,
+    NULL AS parent_comment_author_username,
+    0 AS liked,
+    0 AS retweeted,
+    0 AS bookmarked,
+    Post.id AS quoted_post_id,
+    Post.content AS quoted_post_content,
+    Post.image AS quoted_post_image,
+    Post.created_at AS quoted_post_created_at,
+    Post.updated_at AS quoted_post_updated_at,
+    Author.user_name AS quoted_post_author_user_name,
+    Author.display_name AS quoted_post_author_display_name,
+    Author.avatar AS quoted_post_author_avatar,
+    PostRetweet.created_at AS sort_time,
+    PostRetweet.id AS sort_id
+FROM
+	PostRetweet
+	INNER JOIN Post
+		ON Post.id = PostRetweet.post_id
+	INNER JOIN User Quoter
+		ON Quoter.id = PostRetweet.user_id
+	INNER JOIN User Author
+		ON Author.id = Post.user_id
+	LEFT JOIN UserFollows
+		ON UserFollows.followee_id = PostRetweet.user_id
+WHERE UserFollows.follower_id = $1 AND (COALESCE(PostRetweet.content, '') != '' OR COALESCE(PostRetweet.image_url, '') != '') AND Post.is_hidden = 0
+	AND PostRetweet.user_id NOT IN (SELECT id FROM HiddenUser) AND Post.user_id NOT IN (SELECT id FROM HiddenUser)
 UNION ALL
 SELECT
     'comment-retweet' AS type,
@@ -107,7 +178,16 @@ SELECT
     CASE WHEN CommentLike.comment_id IS NOT NULL THEN 1 ELSE 0 END AS liked,
     CASE WHEN ViewerRetweet.comment_id IS NOT NULL THEN 1 ELSE 0 END AS retweeted,
     CASE WHEN CommentBookmark.comment_id IS NOT NULL THEN 1 ELSE 0 END AS bookmarked,
-    CommentRetweet.created_at AS sort_time
+    NULL AS quoted_post_id,
+    NULL AS quoted_post_content,
+    NULL AS quoted_post_image,
+    NULL AS quoted_post_created_at,
+    NULL AS quoted_post_updated_at,
+    NULL AS quoted_post_author_user_name,
+    NULL AS quoted_post_author_display_name,
+    NULL AS quoted_post_author_avatar,
+    CommentRetweet.created_at AS sort_time,
+    CommentRetweet.id AS sort_id
 FROM
 	CommentRetweet
 	INNER JOIN Comment 
@@ -134,6 +214,11 @@ FROM
 		ON ViewerRetweet.comment_id = Comment.id AND ViewerRetweet.user_id = $1
 	LEFT JOIN CommentBookmark 
 		ON CommentBookmark.comment_id = Comment.id AND CommentBookmark.user_id = $1
-WHERE UserFollows.follower_id = $1
-ORDER BY sort_time DESC
-LIMIT $2 OFFSET $3;
+WHERE UserFollows.follower_id = $1 AND Comment.is_hidden = 0
+	AND CommentRetweet.user_id NOT IN (SELECT id FROM HiddenUser) AND Comment.user_id NOT IN (SELECT id FROM HiddenUser)
+)
+SELECT * FROM TimelineRow
+-- keyset pagination, $2-$4 hold the sort key of the last row already seen
+WHERE $2 IS NULL OR (sort_time, type, sort_id) < ($2, $3, $4)
+ORDER BY sort_time DESC, type DESC, sort_id DESC
+LIMIT $5;
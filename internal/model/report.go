@@ -0,0 +1,224 @@
+package model
+
+import (
+	"database/sql"
+	_ "embed"
+	"errors"
+
+	"github.com/marcusprice/twitter-clone/internal/dbutils"
+	"github.com/marcusprice/twitter-clone/internal/dtypes"
+	"github.com/marcusprice/twitter-clone/internal/logger"
+)
+
+type ReportModel struct {
+	db *sql.DB
+}
+
+//go:embed queries/create-report.sql
+var createReportQuery string
+
+// New files a report against exactly one of postID or commentID, the other
+// should be left as 0. A repeat report of the same content by the same user
+// fails the unique constraint.
+func (rm *ReportModel) New(reporterID, postID, commentID int, reason ReportReason, details string) error {
+	_, err := rm.db.Exec(
+		createReportQuery, reporterID, nullableID(postID), nullableID(commentID),
+		reason, details)
+
+	if err != nil {
+		if dbutils.ConstraintFailed(err) {
+			return dbutils.WrapConstraintError(err)
+		}
+
+		logger.LogError("ReportModel.New() error: " + err.Error())
+		return err
+	}
+
+	return nil
+}
+
+//go:embed queries/select-report-base-query.sql
+var selectReportBaseQuery string
+
+func (rm *ReportModel) GetByID(reportID int) (dtypes.ReportData, error) {
+	row := rm.db.QueryRow(selectReportBaseQuery+"WHERE Report.id = $1;", reportID)
+	reportData, err := parseReportRow(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dtypes.ReportData{}, ReportNotFoundError{}
+		}
+
+		logger.LogError("ReportModel.GetByID() error scanning row: " + err.Error())
+		return dtypes.ReportData{}, err
+	}
+
+	return reportData, nil
+}
+
+// GetByStatus lists reports oldest first, so the queue is worked in order
+func (rm *ReportModel) GetByStatus(status ReportStatus, limit, offset int) ([]dtypes.ReportData, error) {
+	query := selectReportBaseQuery +
+		"WHERE Report.status = $1 ORDER BY Report.created_at ASC, Report.id ASC LIMIT $2 OFFSET $3;"
+
+	result, err := rm.db.Query(query, status, limit, offset)
+	if err != nil {
+		logger.LogError("ReportModel.GetByStatus() query error: " + err.Error())
+		return []dtypes.ReportData{}, err
+	}
+	defer result.Close()
+
+	reports := []dtypes.ReportData{}
+	for result.Next() {
+		reportData, err := parseReportRow(result)
+		if err != nil {
+			logger.LogError("ReportModel.GetByStatus() error scanning row: " + err.Error())
+			return []dtypes.ReportData{}, err
+		}
+
+		reports = append(reports, reportData)
+	}
+
+	return reports, nil
+}
+
+//go:embed queries/select-report-count.sql
+var selectReportCountQuery string
+
+func (rm *ReportModel) GetCount(status ReportStatus) (int, error) {
+	var count int
+	err := rm.db.QueryRow(selectReportCountQuery, status).Scan(&count)
+	if err != nil {
+		logger.LogError("ReportModel.GetCount() error: " + err.Error())
+		return -1, err
+	}
+
+	return count, nil
+}
+
+//go:embed queries/resolve-reports.sql
+var resolveReportsQuery string
+
+//go:embed queries/hide-post.sql
+var hidePostQuery string
+
+//go:embed queries/hide-comment.sql
+var hideCommentQuery string
+
+// Resolve closes every open report against the reported content with status,
+// any status other than dismissed also hides the content and
+// AUTHOR_DEACTIVATED_REPORT deactivates its author. audit is recorded in the
+// same transaction.
+func (rm *ReportModel) Resolve(report dtypes.ReportData, resolverID int, status ReportStatus, audit ...AuditEntry) error {
+	tx, err := rm.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(
+		resolveReportsQuery, status, resolverID, nullableID(report.PostID),
+		nullableID(report.CommentID))
+
+	if err != nil {
+		if dbutils.ConstraintFailed(err) {
+			return dbutils.WrapConstraintError(err)
+		}
+
+		logger.LogError("ReportModel.Resolve() error resolving reports: " + err.Error())
+		return err
+	}
+
+	if status != DISMISSED_REPORT {
+		if report.PostID != 0 {
+			_, err = tx.Exec(hidePostQuery, report.PostID)
+		} else {
+			_, err = tx.Exec(hideCommentQuery, report.CommentID)
+		}
+
+		if err != nil {
+			logger.LogError("ReportModel.Resolve() error hiding content: " + err.Error())
+			return err
+		}
+	}
+
+	if status == AUTHOR_DEACTIVATED_REPORT {
+		err = setUserActive(tx, report.AuthorID, false)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = recordAudit(tx, audit...)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func parseReportRow(row dbutils.RowScanner) (dtypes.ReportData, error) {
+	var id int
+	var reason string
+	var details string
+	var status string
+	var post_id int
+	var comment_id int
+	var content string
+	var image string
+	var reporter_user_name string
+	var reporter_display_name string
+	var reporter_avatar string
+	var author_id int
+	var author_user_name string
+	var author_display_name string
+	var author_avatar string
+	var created_at string
+	var resolved_at sql.NullString
+
+	err := row.Scan(
+		&id, &reason, &details, &status, &post_id, &comment_id, &content,
+		&image, &reporter_user_name, &reporter_display_name, &reporter_avatar,
+		&author_id, &author_user_name, &author_display_name, &author_avatar, &created_at,
+		&resolved_at)
+
+	if err != nil {
+		return dtypes.ReportData{}, err
+	}
+
+	return dtypes.ReportData{
+		ID:        id,
+		Reason:    reason,
+		Details:   details,
+		Status:    status,
+		PostID:    post_id,
+		CommentID: comment_id,
+		Content:   content,
+		Image:     image,
+		Reporter: dtypes.Author{
+			Username:    reporter_user_name,
+			DisplayName: reporter_display_name,
+			Avatar:      reporter_avatar,
+		},
+		Author: dtypes.Author{
+			Username:    author_user_name,
+			DisplayName: author_display_name,
+			Avatar:      author_avatar,
+		},
+		AuthorID:   author_id,
+		CreatedAt:  created_at,
+		ResolvedAt: resolved_at.String,
+	}, nil
+}
+
+// nullableID maps the 0 "not set" id to NULL for nullable foreign keys
+func nullableID(id int) any {
+	if id == 0 {
+		return nil
+	}
+
+	return id
+}
+
+func NewReportModel(db *sql.DB) *ReportModel {
+	return &ReportModel{db}
+}
@@ -0,0 +1,96 @@
+package model
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/marcusprice/twitter-clone/internal/dbutils"
+	"github.com/marcusprice/twitter-clone/internal/dtypes"
+	"github.com/marcusprice/twitter-clone/internal/testutil"
+)
+
+func TestReportNewAndGetByStatus(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, timestamp time.Time) {
+		tu := testutil.NewTestUtil(t)
+		reportModel := NewReportModel(db)
+		postID := insertPost(dtypes.PostInput{UserID: 4, Content: "buy my stuff"}, db)
+		commentID := insertTestComment(postID, 5, db, t)
+
+		err := reportModel.New(1, postID, 0, SPAM_REPORT, "")
+		tu.AssertErrorNil(err)
+		err = reportModel.New(6, 0, commentID, HARASSMENT_REPORT, "rude")
+		tu.AssertErrorNil(err)
+
+		err = reportModel.New(1, postID, 0, OTHER_REPORT, "")
+		tu.AssertTrue(dbutils.IsUniqueConstraintError(err))
+		err = reportModel.New(1, 0, 0, SPAM_REPORT, "")
+		tu.AssertTrue(dbutils.IsConstraintError(err))
+		err = reportModel.New(1, postID, 0, "boring", "")
+		tu.AssertTrue(dbutils.IsConstraintError(err))
+
+		reports, err := reportModel.GetByStatus(OPEN_REPORT, 10, 0)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(2, len(reports))
+		tu.AssertEqual(postID, reports[0].PostID)
+		tu.AssertEqual(0, reports[0].CommentID)
+		tu.AssertEqual(string(SPAM_REPORT), reports[0].Reason)
+		tu.AssertEqual("estecat", reports[0].Reporter.Username)
+		tu.AssertEqual("audrey", reports[0].Author.Username)
+		tu.AssertEqual("buy my stuff", reports[0].Content)
+		tu.AssertEqual(commentID, reports[1].CommentID)
+		tu.AssertEqual("bobbybriggs", reports[1].Author.Username)
+		tu.AssertEqual("rude", reports[1].Details)
+
+		count, err := reportModel.GetCount(OPEN_REPORT)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(2, count)
+
+		_, err = reportModel.GetByID(42069)
+		tu.AssertTrue(errors.Is(err, ReportNotFoundError{}))
+	})
+}
+
+func TestReportResolve(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, timestamp time.Time) {
+		tu := testutil.NewTestUtil(t)
+		reportModel := NewReportModel(db)
+		postID := insertPost(dtypes.PostInput{UserID: 4, Content: "buy my stuff"}, db)
+		commentID := insertTestComment(postID, 5, db, t)
+
+		reportModel.New(1, postID, 0, SPAM_REPORT, "")
+		reportModel.New(6, postID, 0, SPAM_REPORT, "")
+		reportModel.New(1, 0, commentID, HARASSMENT_REPORT, "")
+
+		reports, _ := reportModel.GetByStatus(OPEN_REPORT, 10, 0)
+		err := reportModel.Resolve(reports[0], 2, CONTENT_HIDDEN_REPORT)
+		tu.AssertErrorNil(err)
+
+		// both reports against the post are closed, the comment's stays open
+		count, _ := reportModel.GetCount(OPEN_REPORT)
+		tu.AssertEqual(1, count)
+		count, _ = reportModel.GetCount(CONTENT_HIDDEN_REPORT)
+		tu.AssertEqual(2, count)
+
+		report, err := reportModel.GetByID(reports[0].ID)
+		tu.AssertErrorNil(err)
+		tu.AssertTrue(report.ResolvedAt != "")
+
+		var isHidden int
+		db.QueryRow("SELECT is_hidden FROM Post WHERE id = $1;", postID).Scan(&isHidden)
+		tu.AssertEqual(1, isHidden)
+
+		// hidden posts are only loaded for moderation
+		postModel := NewPostModel(db)
+		_, err = postModel.GetByID(postID)
+		tu.AssertTrue(errors.Is(err, PostNotFoundError{}))
+		_, err = postModel.GetByIDIncludingHidden(postID)
+		tu.AssertErrorNil(err)
+
+		err = reportModel.Resolve(reports[2], 2, DISMISSED_REPORT)
+		tu.AssertErrorNil(err)
+		db.QueryRow("SELECT is_hidden FROM Comment WHERE id = $1;", commentID).Scan(&isHidden)
+		tu.AssertEqual(0, isHidden)
+	})
+}
@@ -0,0 +1,153 @@
+package model
+
+import (
+	"database/sql"
+	_ "embed"
+	"html"
+	"strings"
+
+	"github.com/marcusprice/twitter-clone/internal/dtypes"
+	"github.com/marcusprice/twitter-clone/internal/logger"
+)
+
+// SearchModel queries the fts5 tables in schema.sql, which triggers keep in
+// sync with Post, Comment and User. Results are ranked by bm25, leaving out
+// hidden content and users viewerID has a block with (and for posts and
+// comments, users viewerID muted).
+type SearchModel struct {
+	db *sql.DB
+}
+
+//go:embed queries/select-search-posts.sql
+var selectSearchPostsQuery string
+
+//go:embed queries/select-search-comments.sql
+var selectSearchCommentsQuery string
+
+func (sm *SearchModel) Posts(query string, viewerID, limit, offset int) ([]dtypes.SearchPostData, error) {
+	return sm.searchTimelineRows("Posts", selectSearchPostsQuery, query, viewerID, limit, offset)
+}
+
+func (sm *SearchModel) Comments(query string, viewerID, limit, offset int) ([]dtypes.SearchPostData, error) {
+	return sm.searchTimelineRows("Comments", selectSearchCommentsQuery, query, viewerID, limit, offset)
+}
+
+func (sm *SearchModel) searchTimelineRows(
+	method, searchQuery, query string,
+	viewerID, limit, offset int,
+) ([]dtypes.SearchPostData, error) {
+	result, err := sm.db.Query(searchQuery, viewerID, matchExpression(query), limit, offset)
+	if err != nil {
+		logger.LogError("SearchModel." + method + "() query error: " + err.Error())
+		return []dtypes.SearchPostData{}, err
+	}
+	defer result.Close()
+
+	results := []dtypes.SearchPostData{}
+	for result.Next() {
+		var snippet string
+		postData, _, err := parseTimelineRow(result, &snippet)
+		if err != nil {
+			return []dtypes.SearchPostData{}, err
+		}
+
+		results = append(results, dtypes.SearchPostData{
+			Post:    postData,
+			Snippet: markSnippet(snippet),
+		})
+	}
+
+	return results, nil
+}
+
+//go:embed queries/select-search-users.sql
+var selectSearchUsersQuery string
+
+func (sm *SearchModel) Users(query string, viewerID, limit, offset int) ([]dtypes.SearchUserData, error) {
+	result, err := sm.db.Query(selectSearchUsersQuery, viewerID, matchExpression(query), limit, offset)
+	if err != nil {
+		logger.LogError("SearchModel.Users() query error: " + err.Error())
+		return []dtypes.SearchUserData{}, err
+	}
+	defer result.Close()
+
+	results := []dtypes.SearchUserData{}
+	for result.Next() {
+		var user dtypes.Author
+		var snippet string
+		err := result.Scan(
+			&user.Username, &user.DisplayName, &user.Avatar, &user.Bio,
+			&user.ViewerFollowing, &snippet)
+
+		if err != nil {
+			logger.LogError("SearchModel.Users() error scanning row: " + err.Error())
+			return []dtypes.SearchUserData{}, err
+		}
+
+		results = append(results, dtypes.SearchUserData{
+			User:    user,
+			Snippet: markSnippet(snippet),
+		})
+	}
+
+	return results, nil
+}
+
+//go:embed queries/select-search-posts-count.sql
+var selectSearchPostsCountQuery string
+
+//go:embed queries/select-search-comments-count.sql
+var selectSearchCommentsCountQuery string
+
+//go:embed queries/select-search-users-count.sql
+var selectSearchUsersCountQuery string
+
+func (sm *SearchModel) PostsCount(query string, viewerID int) (int, error) {
+	return sm.count("PostsCount", selectSearchPostsCountQuery, query, viewerID)
+}
+
+func (sm *SearchModel) CommentsCount(query string, viewerID int) (int, error) {
+	return sm.count("CommentsCount", selectSearchCommentsCountQuery, query, viewerID)
+}
+
+func (sm *SearchModel) UsersCount(query string, viewerID int) (int, error) {
+	return sm.count("UsersCount", selectSearchUsersCountQuery, query, viewerID)
+}
+
+func (sm *SearchModel) count(method, countQuery, query string, viewerID int) (int, error) {
+	var count int
+	err := sm.db.QueryRow(countQuery, viewerID, matchExpression(query)).Scan(&count)
+	if err != nil {
+		logger.LogError("SearchModel." + method + "() error scanning row: " + err.Error())
+		return -1, err
+	}
+
+	return count, nil
+}
+
+// matchExpression turns user input into an fts5 query, each word is quoted
+// so operators and stray punctuation can't cause syntax errors, and the last
+// word is a prefix so results show up while typing. Words are ANDed.
+func matchExpression(query string) string {
+	words := strings.Fields(query)
+	for i, word := range words {
+		words[i] = `"` + strings.ReplaceAll(word, `"`, `""`) + `"`
+	}
+
+	if len(words) > 0 {
+		words[len(words)-1] += "*"
+	}
+
+	return strings.Join(words, " ")
+}
+
+// markSnippet escapes a snippet() result and swaps the \x02 and \x03 markers
+// the search queries put around matches for <mark> tags
+func markSnippet(snippet string) string {
+	snippet = html.EscapeString(snippet)
+	return strings.NewReplacer("\x02", "<mark>", "\x03", "</mark>").Replace(snippet)
+}
+
+func NewSearchModel(db *sql.DB) *SearchModel {
+	return &SearchModel{db}
+}
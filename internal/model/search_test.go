@@ -0,0 +1,150 @@
+package model
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marcusprice/twitter-clone/internal/dtypes"
+	"github.com/marcusprice/twitter-clone/internal/testutil"
+)
+
+func TestSearchPosts(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, timestamp time.Time) {
+		tu := testutil.NewTestUtil(t)
+		postModel := NewPostModel(db)
+		searchModel := NewSearchModel(db)
+
+		postID, err := postModel.New(dtypes.PostInput{UserID: 1, Content: "Knocked the <b>log lady's</b> log off the shelf"})
+		tu.AssertErrorNil(err)
+		insertPostLikeRow(postID, 7, db, t)
+
+		results, err := searchModel.Posts("LOG lad", 7, 10, 0)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(1, len(results))
+		tu.AssertEqual(postID, results[0].Post.ID)
+		tu.AssertEqual("post", results[0].Post.Type)
+		tu.AssertEqual(1, results[0].Post.ViewerLiked)
+		tu.AssertEqual(0, results[0].Post.ViewerBookmarked)
+		tu.AssertEqual(
+			"Knocked the &lt;b&gt;<mark>log</mark> <mark>lady</mark>&#39;s&lt;/b&gt; <mark>log</mark> off the shelf",
+			results[0].Snippet)
+
+		count, err := searchModel.PostsCount("log lad", 7)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(1, count)
+
+		// fts syntax in the query is searched for literally rather than erroring
+		_, err = searchModel.Posts(`log" OR (NEAR`, 7, 10, 0)
+		tu.AssertErrorNil(err)
+
+		// edits and deletes are picked up by the triggers
+		tu.AssertErrorNil(postModel.UpdateContent(postID, "knocked over the sycamore trees"))
+		results, _ = searchModel.Posts("log", 7, 10, 0)
+		tu.AssertEqual(0, len(results))
+		results, _ = searchModel.Posts("sycamore", 7, 10, 0)
+		tu.AssertEqual(1, len(results))
+
+		_, err = db.Exec("UPDATE Post SET is_hidden = 1 WHERE id = $1;", postID)
+		tu.AssertErrorNil(err)
+		results, _ = searchModel.Posts("sycamore", 7, 10, 0)
+		tu.AssertEqual(0, len(results))
+
+		_, err = postModel.Delete(postID)
+		tu.AssertErrorNil(err)
+		var indexed int
+		db.QueryRow("SELECT COUNT(*) FROM PostSearch WHERE PostSearch MATCH 'sycamore';").Scan(&indexed)
+		tu.AssertEqual(0, indexed)
+	})
+}
+
+func TestSearchPostsRanking(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, timestamp time.Time) {
+		tu := testutil.NewTestUtil(t)
+		postModel := NewPostModel(db)
+		searchModel := NewSearchModel(db)
+
+		passing, _ := postModel.New(dtypes.PostInput{UserID: 4, Content: "the sycamores are not what they seem, nor is the diner, nor the mill, nor the lodge"})
+		focused, _ := postModel.New(dtypes.PostInput{UserID: 5, Content: "sycamores sycamores sycamores"})
+
+		results, err := searchModel.Posts("sycamores", 7, 10, 0)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(2, len(results))
+		tu.AssertEqual(focused, results[0].Post.ID)
+		tu.AssertEqual(passing, results[1].Post.ID)
+
+		// muted authors are left out
+		tu.AssertErrorNil(NewUserModel(db).Mute(7, 5))
+		results, _ = searchModel.Posts("sycamores", 7, 10, 0)
+		tu.AssertEqual(1, len(results))
+		tu.AssertEqual(passing, results[0].Post.ID)
+		count, _ := searchModel.PostsCount("sycamores", 7)
+		tu.AssertEqual(1, count)
+	})
+}
+
+func TestSearchComments(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, timestamp time.Time) {
+		tu := testutil.NewTestUtil(t)
+		commentModel := NewCommentModel(db)
+		searchModel := NewSearchModel(db)
+
+		commentID, err := commentModel.NewPostComment(dtypes.CommentInput{
+			PostID: 1, UserID: 4, Content: "the gum you like is going to come back in style",
+		})
+		tu.AssertErrorNil(err)
+
+		results, err := searchModel.Comments("gum", 7, 10, 0)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(1, len(results))
+		tu.AssertEqual(commentID, results[0].Post.ID)
+		tu.AssertEqual("comment", results[0].Post.Type)
+		tu.AssertEqual(1, results[0].Post.ParentPostID)
+		tu.AssertTrue(strings.Contains(results[0].Snippet, "<mark>gum</mark>"))
+
+		// posts and comments are searched separately
+		posts, _ := searchModel.Posts("gum", 7, 10, 0)
+		tu.AssertEqual(0, len(posts))
+
+		tu.AssertErrorNil(commentModel.UpdateContent(commentID, "that gum is back"))
+		results, _ = searchModel.Comments("style", 7, 10, 0)
+		tu.AssertEqual(0, len(results))
+		count, _ := searchModel.CommentsCount("gum", 7)
+		tu.AssertEqual(1, count)
+	})
+}
+
+func TestSearchUsers(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, timestamp time.Time) {
+		tu := testutil.NewTestUtil(t)
+		userModel := NewUserModel(db)
+		searchModel := NewSearchModel(db)
+
+		results, err := searchModel.Users("dale", 7, 10, 0)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(1, len(results))
+		tu.AssertEqual("dalecooper", results[0].User.Username)
+		tu.AssertTrue(results[0].User.ViewerFollowing)
+		tu.AssertEqual("<mark>dalecooper</mark>", results[0].Snippet)
+
+		// names rank above bios, dalecooper only mentions coffee in his bio
+		_, err = db.Exec("UPDATE User SET display_name = 'Coffee Drinker' WHERE id = 5;")
+		tu.AssertErrorNil(err)
+		results, _ = searchModel.Users("coffee", 7, 10, 0)
+		tu.AssertEqual(2, len(results))
+		tu.AssertEqual("bobbybriggs", results[0].User.Username)
+		tu.AssertEqual("dalecooper", results[1].User.Username)
+
+		// blocks hide users both ways, deactivated users are hidden from everyone
+		tu.AssertErrorNil(userModel.Block(3, 7))
+		results, _ = searchModel.Users("coffee", 7, 10, 0)
+		tu.AssertEqual(1, len(results))
+		tu.AssertEqual("bobbybriggs", results[0].User.Username)
+
+		tu.AssertErrorNil(userModel.SetActive(5, false))
+		count, err := searchModel.UsersCount("coffee", 7)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(0, count)
+	})
+}
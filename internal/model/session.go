@@ -0,0 +1,170 @@
+package model
+
+import (
+	"database/sql"
+	_ "embed"
+	"errors"
+	"time"
+
+	"github.com/marcusprice/twitter-clone/internal/constants"
+	"github.com/marcusprice/twitter-clone/internal/logger"
+	"github.com/marcusprice/twitter-clone/internal/util"
+)
+
+// passed to sqlite's datetime() as a modifier
+const REFRESH_TOKEN_TTL = "+30 days"
+
+type SessionModel struct {
+	db *sql.DB
+}
+
+//go:embed queries/create-refresh-token.sql
+var createRefreshTokenQuery string
+
+func (sm *SessionModel) CreateRefreshToken(userID int) (string, error) {
+	token, err := insertRefreshToken(sm.db, userID)
+	if err != nil {
+		logger.LogError("SessionModel.CreateRefreshToken() - error creating token: " + err.Error())
+		return "", err
+	}
+
+	return token, nil
+}
+
+//go:embed queries/select-refresh-token.sql
+var selectRefreshTokenQuery string
+
+//go:embed queries/revoke-refresh-token.sql
+var revokeRefreshTokenQuery string
+
+//go:embed queries/revoke-user-refresh-tokens.sql
+var revokeUserRefreshTokensQuery string
+
+// RotateRefreshToken revokes token and issues its replacement. Presenting a
+// token that was already rotated means it leaked, so every refresh token the
+// user holds is revoked.
+func (sm *SessionModel) RotateRefreshToken(token string) (userID int, newToken string, err error) {
+	tx, err := sm.db.Begin()
+	if err != nil {
+		return 0, "", err
+	}
+	defer tx.Rollback()
+
+	var id int
+	var revoked int
+	var expired int
+	tokenHash := util.HashToken(token)
+	err = tx.QueryRow(selectRefreshTokenQuery, tokenHash).Scan(&id, &userID, &revoked, &expired)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, "", InvalidTokenError{}
+		}
+
+		logger.LogError("SessionModel.RotateRefreshToken() - error scanning row: " + err.Error())
+		return 0, "", err
+	}
+
+	if revoked == 1 {
+		logger.LogWarn("SessionModel.RotateRefreshToken() - revoked refresh token reused, revoking all sessions")
+		_, err = tx.Exec(revokeUserRefreshTokensQuery, userID)
+		if err != nil {
+			return 0, "", err
+		}
+
+		err = tx.Commit()
+		if err != nil {
+			return 0, "", err
+		}
+
+		return 0, "", InvalidTokenError{}
+	}
+
+	if expired == 1 {
+		return 0, "", InvalidTokenError{}
+	}
+
+	_, err = tx.Exec(revokeRefreshTokenQuery, tokenHash, userID)
+	if err != nil {
+		return 0, "", err
+	}
+
+	newToken, err = insertRefreshToken(tx, userID)
+	if err != nil {
+		logger.LogError("SessionModel.RotateRefreshToken() - error creating token: " + err.Error())
+		return 0, "", err
+	}
+
+	return userID, newToken, tx.Commit()
+}
+
+// RevokeRefreshToken is scoped to userID so one user can't end another's
+// session, revoking an unknown or already revoked token is a no-op
+func (sm *SessionModel) RevokeRefreshToken(userID int, token string) error {
+	_, err := sm.db.Exec(revokeRefreshTokenQuery, util.HashToken(token), userID)
+	if err != nil {
+		logger.LogError("SessionModel.RevokeRefreshToken() - error revoking token: " + err.Error())
+	}
+
+	return err
+}
+
+//go:embed queries/create-revoked-token.sql
+var createRevokedTokenQuery string
+
+//go:embed queries/delete-expired-revoked-tokens.sql
+var deleteExpiredRevokedTokensQuery string
+
+// RevokeAccessToken blocks an access token by its jti until it would have
+// expired anyway
+func (sm *SessionModel) RevokeAccessToken(jti string, expiresAt time.Time) error {
+	_, err := sm.db.Exec(
+		createRevokedTokenQuery, jti, expiresAt.UTC().Format(constants.TIME_LAYOUT))
+	if err != nil {
+		logger.LogError("SessionModel.RevokeAccessToken() - error revoking token: " + err.Error())
+		return err
+	}
+
+	// piggyback cleanup on revocations rather than running a job for it
+	_, err = sm.db.Exec(deleteExpiredRevokedTokensQuery)
+	if err != nil {
+		logger.LogWarn("SessionModel.RevokeAccessToken() - error clearing expired tokens: " + err.Error())
+	}
+
+	return nil
+}
+
+//go:embed queries/check-revoked-token.sql
+var checkRevokedTokenQuery string
+
+func (sm *SessionModel) IsAccessTokenRevoked(jti string) (bool, error) {
+	var count int
+	err := sm.db.QueryRow(checkRevokedTokenQuery, jti).Scan(&count)
+	if err != nil {
+		logger.LogError("SessionModel.IsAccessTokenRevoked() - error scanning row: " + err.Error())
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func insertRefreshToken(db execer, userID int) (string, error) {
+	token, err := util.GenerateToken()
+	if err != nil {
+		return "", err
+	}
+
+	_, err = db.Exec(createRefreshTokenQuery, userID, util.HashToken(token), REFRESH_TOKEN_TTL)
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+func NewSessionModel(db *sql.DB) *SessionModel {
+	if db == nil {
+		panic("db conn cannot be nil")
+	}
+
+	return &SessionModel{db: db}
+}
@@ -0,0 +1,92 @@
+package model
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/marcusprice/twitter-clone/internal/testutil"
+)
+
+func TestSessionRotateRefreshToken(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, timestamp time.Time) {
+		tu := testutil.NewTestUtil(t)
+		sessionModel := NewSessionModel(db)
+		userModel := NewUserModel(db)
+
+		token, err := sessionModel.CreateRefreshToken(1)
+		tu.AssertErrorNil(err)
+
+		userID, rotatedToken, err := sessionModel.RotateRefreshToken(token)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(1, userID)
+		tu.AssertTrue(rotatedToken != token)
+
+		_, _, err = sessionModel.RotateRefreshToken("not a token")
+		tu.AssertTrue(errors.Is(err, InvalidTokenError{}))
+
+		// reusing a rotated token revokes the whole family
+		_, _, err = sessionModel.RotateRefreshToken(token)
+		tu.AssertTrue(errors.Is(err, InvalidTokenError{}))
+		_, _, err = sessionModel.RotateRefreshToken(rotatedToken)
+		tu.AssertTrue(errors.Is(err, InvalidTokenError{}))
+
+		// users can only revoke their own tokens
+		token, err = sessionModel.CreateRefreshToken(1)
+		tu.AssertErrorNil(err)
+		tu.AssertErrorNil(sessionModel.RevokeRefreshToken(2, token))
+		_, token, err = sessionModel.RotateRefreshToken(token)
+		tu.AssertErrorNil(err)
+		tu.AssertErrorNil(sessionModel.RevokeRefreshToken(1, token))
+		_, _, err = sessionModel.RotateRefreshToken(token)
+		tu.AssertTrue(errors.Is(err, InvalidTokenError{}))
+
+		// changing the password ends every session
+		token, err = sessionModel.CreateRefreshToken(1)
+		tu.AssertErrorNil(err)
+		_, err = userModel.UpdatePassword(1, "hashed")
+		tu.AssertErrorNil(err)
+		_, _, err = sessionModel.RotateRefreshToken(token)
+		tu.AssertTrue(errors.Is(err, InvalidTokenError{}))
+
+		token, err = sessionModel.CreateRefreshToken(1)
+		tu.AssertErrorNil(err)
+		_, err = db.Exec(
+			"UPDATE RefreshToken SET expires_at = datetime(current_timestamp, '-1 minutes');")
+		tu.AssertErrorNil(err)
+		_, _, err = sessionModel.RotateRefreshToken(token)
+		tu.AssertTrue(errors.Is(err, InvalidTokenError{}))
+	})
+}
+
+func TestSessionRevokeAccessToken(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, timestamp time.Time) {
+		tu := testutil.NewTestUtil(t)
+		sessionModel := NewSessionModel(db)
+
+		revoked, err := sessionModel.IsAccessTokenRevoked("jti-1")
+		tu.AssertErrorNil(err)
+		tu.AssertFalse(revoked)
+
+		err = sessionModel.RevokeAccessToken("jti-1", time.Now().Add(time.Hour))
+		tu.AssertErrorNil(err)
+		revoked, err = sessionModel.IsAccessTokenRevoked("jti-1")
+		tu.AssertErrorNil(err)
+		tu.AssertTrue(revoked)
+
+		// revoking twice is fine
+		err = sessionModel.RevokeAccessToken("jti-1", time.Now().Add(time.Hour))
+		tu.AssertErrorNil(err)
+
+		// already expired entries are cleared on the next revocation
+		err = sessionModel.RevokeAccessToken("jti-2", time.Now().Add(-time.Hour))
+		tu.AssertErrorNil(err)
+		err = sessionModel.RevokeAccessToken("jti-3", time.Now().Add(time.Hour))
+		tu.AssertErrorNil(err)
+
+		var count int
+		db.QueryRow("SELECT COUNT(*) FROM RevokedToken;").Scan(&count)
+		tu.AssertEqual(2, count)
+	})
+}
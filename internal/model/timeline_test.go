@@ -12,116 +12,190 @@ import (
 	"github.com/marcusprice/twitter-clone/internal/util"
 )
 
-func TestTimelineOffsetCount(t *testing.T) {
+func TestQueryUserTimeline(t *testing.T) {
 	testutil.WithTestData(t, func(db *sql.DB, _ time.Time) {
 		tu := testutil.NewTestUtil(t)
-		PostModel := &PostModel{db}
+		postModel := PostModel{db}
 		user1 := queryUser(1, db)
 		insertUserFollow(user1.ID, 2, db)
 		insertUserFollow(user1.ID, 3, db)
 		insertUserFollow(user1.ID, 4, db)
 
-		count, err := PostModel.TimelineRemainingPostsCount(user1.ID, 0, 0)
+		posts, postIDs, next, err := postModel.QueryUserFollowingTimeline(user1.ID, 0, nil)
 		tu.AssertErrorNotNil(err)
 		tu.AssertEqual("Positive limit value required", err.Error())
-		tu.AssertEqual(-1, count)
+		tu.AssertEqual(0, len(posts))
+		tu.AssertEqual(0, len(postIDs))
+		tu.AssertNil(next)
 
-		count, err = PostModel.TimelineRemainingPostsCount(user1.ID, -42069, 0)
+		posts, postIDs, next, err = postModel.QueryUserFollowingTimeline(user1.ID, -42069, nil)
 		tu.AssertErrorNotNil(err)
 		tu.AssertEqual("Positive limit value required", err.Error())
-		tu.AssertEqual(-1, count)
+		tu.AssertEqual(0, len(posts))
+		tu.AssertEqual(0, len(postIDs))
+		tu.AssertNil(next)
+
+		posts, postIDs, next, err = postModel.QueryUserFollowingTimeline(user1.ID, 10, nil)
+		post1CreatedAt := util.ParseTime(posts[0].CreatedAt)
+		post10CreatedAt := util.ParseTime(posts[9].CreatedAt)
+		post1 := posts[0]
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(10, len(posts))
+		tu.AssertEqual(10, len(postIDs))
+		tu.AssertTrue(post1CreatedAt.After(post10CreatedAt))
+		tu.AssertEqual(46, post1.ID)
+		tu.AssertEqual(46, postIDs[0])
+		tu.AssertEqual(2, post1.UserID)
+		tu.AssertEqual("waveform-cave.jpg", post1.Image)
+		tu.AssertEqual("", post1.Content)
+		tu.AssertEqual(0, post1.LikeCount)
+		tu.AssertEqual(0, post1.RetweetCount)
+		tu.AssertEqual(0, post1.BookmarkCount)
+		tu.AssertEqual(0, post1.Impressions)
+		tu.AssertEqual(posts[9].SortTime, next.SortTime)
+		tu.AssertEqual("post", next.Type)
+		tu.AssertEqual(posts[9].ID, next.ID)
+
+		posts, _, _, err = postModel.QueryUserFollowingTimeline(user1.ID, 10, next)
+		post11CreatedAt := util.ParseTime(posts[0].CreatedAt)
+		post20CreatedAt := util.ParseTime(posts[9].CreatedAt)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(10, len(posts))
+		tu.AssertTrue(post10CreatedAt.After(post11CreatedAt))
+		tu.AssertTrue(post11CreatedAt.After(post20CreatedAt))
+	})
+}
+
+func TestTimelineCursorPagination(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, _ time.Time) {
+		tu := testutil.NewTestUtil(t)
+		postModel := NewPostModel(db)
+		user1 := queryUser(1, db)
+		insertUserFollow(user1.ID, 2, db)
+		insertUserFollow(user1.ID, 3, db)
+		insertUserFollow(user1.ID, 4, db)
 
 		// verify num of posts in case test db seed data changes
 		numOfPosts := getNumOfPosts(db, 2, 3, 4)
-		tu.AssertEqual(56, numOfPosts)
+		tu.AssertEqual(54, numOfPosts)
 
-		count, err = PostModel.TimelineRemainingPostsCount(user1.ID, 10, 0)
+		firstPage, _, next, err := postModel.QueryUserFollowingTimeline(user1.ID, 15, nil)
 		tu.AssertErrorNil(err)
-		tu.AssertEqual(46, count)
+		tu.AssertEqual(15, len(firstPage))
+		tu.AssertNotNil(next)
 
-		count, err = PostModel.TimelineRemainingPostsCount(user1.ID, 10, 10)
-		tu.AssertErrorNil(err)
-		tu.AssertEqual(36, count)
+		// posts arriving between pages don't shift the pages after the cursor
+		insertPost(dtypes.PostInput{UserID: 2, Content: "sycamore"}, db)
+		insertPost(dtypes.PostInput{UserID: 3, Content: "sycamore"}, db)
 
-		count, err = PostModel.TimelineRemainingPostsCount(user1.ID, 10, 20)
-		tu.AssertErrorNil(err)
-		tu.AssertEqual(26, count)
+		seen := map[int]bool{}
+		for _, post := range firstPage {
+			seen[post.ID] = true
+		}
 
-		count, err = PostModel.TimelineRemainingPostsCount(user1.ID, 10, 30)
-		tu.AssertErrorNil(err)
-		tu.AssertEqual(16, count)
+		pages := 1
+		for next != nil {
+			var posts []dtypes.TimelinePostData
+			posts, _, next, err = postModel.QueryUserFollowingTimeline(user1.ID, 15, next)
+			tu.AssertErrorNil(err)
+			tu.AssertTrue(len(posts) > 0)
+			for _, post := range posts {
+				tu.AssertFalse(seen[post.ID])
+				seen[post.ID] = true
+			}
+			pages++
+		}
 
-		count, err = PostModel.TimelineRemainingPostsCount(user1.ID, 10, 40)
-		tu.AssertErrorNil(err)
-		tu.AssertEqual(6, count)
+		tu.AssertEqual(4, pages)
+		tu.AssertEqual(numOfPosts, len(seen))
+		tu.AssertEqual(numOfPosts+2, timelineLength(postModel.QueryUserFollowingTimeline, user1.ID))
 
-		count, err = PostModel.TimelineRemainingPostsCount(user1.ID, 10, 50)
+		// a full last page doesn't report another page
+		posts, _, next, err := postModel.QueryUserFollowingTimeline(user1.ID, numOfPosts+2, nil)
 		tu.AssertErrorNil(err)
-		tu.AssertEqual(0, count)
+		tu.AssertEqual(numOfPosts+2, len(posts))
+		tu.AssertNil(next)
+	})
+}
 
-		count, err = PostModel.TimelineRemainingPostsCount(user1.ID, 15, 0)
+func TestTimelineCursorRetweetTies(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, _ time.Time) {
+		tu := testutil.NewTestUtil(t)
+		postModel := NewPostModel(db)
+		postID := insertPost(dtypes.PostInput{UserID: 1, Content: "sycamore"}, db)
+
+		// two retweets of the same post in the same second share sort_time,
+		// type and post id, the retweet id keeps them apart
+		_, err := db.Exec(`
+			INSERT INTO PostRetweet (post_id, user_id, created_at)
+			VALUES ($1, 2, '2030-01-01 00:00:00'), ($1, 3, '2030-01-01 00:00:00');
+		`, postID)
 		tu.AssertErrorNil(err)
-		tu.AssertEqual(41, count)
 
-		count, err = PostModel.TimelineRemainingPostsCount(user1.ID, 15, 15)
+		first, _, next, err := postModel.QueryUserFollowingTimeline(7, 1, nil)
 		tu.AssertErrorNil(err)
-		tu.AssertEqual(26, count)
-
-		count, err = PostModel.TimelineRemainingPostsCount(user1.ID, 15, 30)
+		second, _, _, err := postModel.QueryUserFollowingTimeline(7, 1, next)
 		tu.AssertErrorNil(err)
-		tu.AssertEqual(11, count)
 
-		count, err = PostModel.TimelineRemainingPostsCount(user1.ID, 15, 45)
-		tu.AssertErrorNil(err)
-		tu.AssertEqual(0, count)
+		tu.AssertEqual("post-retweet", first[0].Type)
+		tu.AssertEqual("post-retweet", second[0].Type)
+		tu.AssertEqual(postID, first[0].ID)
+		tu.AssertEqual(postID, second[0].ID)
+		tu.AssertEqual("dalecooper", first[0].Retweeter.Username)
+		tu.AssertEqual("wallphace", second[0].Retweeter.Username)
 	})
 }
 
-func TestQueryUserTimeline(t *testing.T) {
+func TestForYouCandidates(t *testing.T) {
 	testutil.WithTestData(t, func(db *sql.DB, _ time.Time) {
 		tu := testutil.NewTestUtil(t)
-		postModel := PostModel{db}
+		postModel := NewPostModel(db)
 		user1 := queryUser(1, db)
 		insertUserFollow(user1.ID, 2, db)
-		insertUserFollow(user1.ID, 3, db)
-		insertUserFollow(user1.ID, 4, db)
+		sycamoreID := insertPost(dtypes.PostInput{UserID: 4, Content: "sycamore"}, db)
+		likedID := insertPost(dtypes.PostInput{UserID: 4, Content: "gum"}, db)
+		insertPostLikeRow(likedID, user1.ID, db, t)
 
-		posts, postIDs, err := postModel.QueryUserTimeline(user1.ID, 0, 0)
-		tu.AssertErrorNotNil(err)
-		tu.AssertEqual("Positive limit value required", err.Error())
-		tu.AssertEqual(0, len(posts))
-		tu.AssertEqual(0, len(postIDs))
+		err := postModel.AddViews(user1.ID, []int{sycamoreID})
+		tu.AssertErrorNil(err)
+		// viewing again keeps the first view
+		err = postModel.AddViews(user1.ID, []int{sycamoreID, likedID})
+		tu.AssertErrorNil(err)
+		var views int
+		db.QueryRow("SELECT COUNT(*) FROM PostView WHERE user_id = $1;", user1.ID).Scan(&views)
+		tu.AssertEqual(2, views)
+		db.Exec("UPDATE PostView SET created_at = '9999-12-31 23:59:59' WHERE post_id = $1;", likedID)
 
-		posts, postIDs, err = postModel.QueryUserTimeline(user1.ID, -42069, 0)
-		tu.AssertErrorNotNil(err)
-		tu.AssertEqual("Positive limit value required", err.Error())
-		tu.AssertEqual(0, len(posts))
-		tu.AssertEqual(0, len(postIDs))
+		candidates, err := postModel.GetForYouCandidates(user1.ID, "9999-12-31 23:59:59", 1000)
+		tu.AssertErrorNil(err)
 
-		// TODO: Add tests for postIDs
-		posts, _, err = postModel.QueryUserTimeline(user1.ID, 10, 0)
-		post1CreatedAt := util.ParseTime(posts[0].CreatedAt)
-		post10CreatedAt := util.ParseTime(posts[9].CreatedAt)
-		post1 := posts[0]
+		byID := map[int]dtypes.ForYouCandidate{}
+		for _, candidate := range candidates {
+			if candidate.Post.Type == "post" {
+				byID[candidate.Post.ID] = candidate
+			}
+		}
+
+		sycamore := byID[sycamoreID]
+		tu.AssertEqual(sycamoreID, sycamore.SortID)
+		tu.AssertFalse(sycamore.FollowsAuthor)
+		tu.AssertEqual(1, sycamore.AuthorLikes)
+		tu.AssertTrue(sycamore.Seen)
+		tu.AssertTrue(sycamore.Post.SortTime != "")
+
+		// views from the ranking time on don't count as seen yet
+		tu.AssertFalse(byID[likedID].Seen)
+		tu.AssertTrue(byID[2].FollowsAuthor)
+
+		// the pool is the newest rows up to the ranking time
+		candidates, err = postModel.GetForYouCandidates(user1.ID, "9999-12-31 23:59:59", 2)
 		tu.AssertErrorNil(err)
-		tu.AssertEqual(10, len(posts))
-		tu.AssertTrue(post1CreatedAt.After(post10CreatedAt))
-		tu.AssertEqual(44, post1.ID)
-		tu.AssertEqual(2, post1.UserID)
-		tu.AssertEqual("waveform-cave.jpg", post1.Image)
-		tu.AssertEqual("", post1.Content)
-		tu.AssertEqual(0, post1.LikeCount)
-		tu.AssertEqual(0, post1.RetweetCount)
-		tu.AssertEqual(0, post1.BookmarkCount)
-		tu.AssertEqual(0, post1.Impressions)
+		tu.AssertEqual(2, len(candidates))
+		tu.AssertEqual(likedID, candidates[0].Post.ID)
 
-		posts, _, err = postModel.QueryUserTimeline(user1.ID, 10, 10)
-		post11CreatedAt := util.ParseTime(posts[0].CreatedAt)
-		post20CreatedAt := util.ParseTime(posts[9].CreatedAt)
+		candidates, err = postModel.GetForYouCandidates(user1.ID, "2000-01-01 00:00:00", 1000)
 		tu.AssertErrorNil(err)
-		tu.AssertEqual(10, len(posts))
-		tu.AssertTrue(post10CreatedAt.After(post11CreatedAt))
-		tu.AssertTrue(post11CreatedAt.After(post20CreatedAt))
+		tu.AssertEqual(0, len(candidates))
 	})
 }
 
@@ -144,7 +218,7 @@ func TestQueryUserTimelineWithRetweet(t *testing.T) {
 		testhelpers.CreateRetweet(postID, user2.ID, db)
 		retweetedPost := queryPost(postID, db)
 
-		posts, postIDs, err := postModel.QueryUserTimeline(user1.ID, 10, 0)
+		posts, postIDs, _, err := postModel.QueryUserFollowingTimeline(user1.ID, 10, nil)
 		tu.AssertErrorNil(err)
 		tu.AssertEqual(user3.ID, posts[0].UserID)
 		tu.AssertEqual(retweetedPost.Content, posts[0].Content)
@@ -155,6 +229,41 @@ func TestQueryUserTimelineWithRetweet(t *testing.T) {
 	})
 }
 
+type timelinePageFunc func(userID, limit int, cursor *dtypes.Cursor) ([]dtypes.TimelinePostData, []int, *dtypes.Cursor, error)
+
+// timelineLength walks every page of a timeline and counts its rows
+func timelineLength(fetch timelinePageFunc, userID int) int {
+	length := 0
+	var cursor *dtypes.Cursor
+	for {
+		posts, _, next, err := fetch(userID, 7, cursor)
+		if err != nil {
+			panic(err)
+		}
+
+		length += len(posts)
+		if next == nil {
+			return length
+		}
+		cursor = next
+	}
+}
+
+// forYouPosts returns the newest poolSize For You rows for the user, unranked
+func forYouPosts(postModel *PostModel, userID, poolSize int) []dtypes.TimelinePostData {
+	candidates, err := postModel.GetForYouCandidates(userID, "9999-12-31 23:59:59", poolSize)
+	if err != nil {
+		panic(err)
+	}
+
+	posts := []dtypes.TimelinePostData{}
+	for _, candidate := range candidates {
+		posts = append(posts, candidate.Post)
+	}
+
+	return posts
+}
+
 func getNumOfPosts(db *sql.DB, userIDs ...int) int {
 	query := `
 		SELECT
@@ -19,13 +19,25 @@ type UserModel struct {
 //go:embed queries/create-user.sql
 var createUserQuery string
 
+//go:embed queries/create-user-token.sql
+var createUserTokenQuery string
+
+// New creates the user along with a single use activation token, the raw
+// token is only ever returned here (UserData.ActivationToken), the db keeps
+// its hash
 func (um *UserModel) New(userInput dtypes.UserInput) (dtypes.UserData, error) {
 	var userID int
 	var lastLogin sql.NullString
 	var createdAt string
 	var updatedAt string
 
-	err := um.db.QueryRow(
+	tx, err := um.db.Begin()
+	if err != nil {
+		return dtypes.UserData{}, err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(
 		createUserQuery,
 		userInput.Email,
 		userInput.Username,
@@ -44,52 +56,246 @@ func (um *UserModel) New(userInput dtypes.UserInput) (dtypes.UserData, error) {
 		return dtypes.UserData{}, err
 	}
 
+	activationToken, err := createUserToken(tx, userID, ACTIVATION_TOKEN, ACTIVATION_TOKEN_TTL)
+	if err != nil {
+		logger.LogError("UserModel.New() - error creating activation token: " + err.Error())
+		return dtypes.UserData{}, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return dtypes.UserData{}, err
+	}
+
 	out := dtypes.UserData{
-		ID:          userID,
-		Email:       userInput.Email,
-		Username:    userInput.Username,
-		FirstName:   userInput.FirstName,
-		LastName:    userInput.LastName,
-		DisplayName: userInput.DisplayName,
-		LastLogin:   "", // last login null in the db
-		Role:        permissions.USER_ROLE,
-		CreatedAt:   createdAt,
-		UpdatedAt:   updatedAt,
+		ID:              userID,
+		Email:           userInput.Email,
+		Username:        userInput.Username,
+		FirstName:       userInput.FirstName,
+		LastName:        userInput.LastName,
+		DisplayName:     userInput.DisplayName,
+		LastLogin:       "", // last login null in the db
+		Role:            permissions.USER_ROLE,
+		CreatedAt:       createdAt,
+		UpdatedAt:       updatedAt,
+		ActivationToken: activationToken,
 	}
 
 	return out, nil
 }
 
+// CreateToken issues a new single use token, ttl is a sqlite datetime
+// modifier e.g. "+1 hours"
+func (um *UserModel) CreateToken(userID int, purpose TokenPurpose, ttl string) (string, error) {
+	token, err := createUserToken(um.db, userID, purpose, ttl)
+	if err != nil {
+		if dbutils.ConstraintFailed(err) {
+			return "", dbutils.WrapConstraintError(err)
+		}
+
+		logger.LogError("UserModel.CreateToken() - error creating token: " + err.Error())
+		return "", err
+	}
+
+	return token, nil
+}
+
+//go:embed queries/select-recent-user-token-count.sql
+var selectRecentUserTokenCountQuery string
+
+// RecentTokenCount counts tokens issued within window, a negative sqlite
+// datetime modifier e.g. "-1 hours"
+func (um *UserModel) RecentTokenCount(userID int, purpose TokenPurpose, window string) (int, error) {
+	var count int
+	err := um.db.QueryRow(
+		selectRecentUserTokenCountQuery, userID, purpose, window).Scan(&count)
+	if err != nil {
+		logger.LogError("UserModel.RecentTokenCount() - error scanning row: " + err.Error())
+		return -1, err
+	}
+
+	return count, nil
+}
+
+//go:embed queries/update-user-password.sql
+var updateUserPasswordQuery string
+
+// UpdatePassword also bumps token_version and revokes the user's refresh
+// tokens, which signs out every session started before the change
+func (um *UserModel) UpdatePassword(userID int, hashedPassword string) (tokenVersion int, err error) {
+	tx, err := um.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(updateUserPasswordQuery, hashedPassword, userID).Scan(&tokenVersion)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, UserNotFoundError{}
+		}
+
+		logger.LogError("UserModel.UpdatePassword() - error updating password: " + err.Error())
+		return 0, err
+	}
+
+	_, err = tx.Exec(revokeUserRefreshTokensQuery, userID)
+	if err != nil {
+		logger.LogError("UserModel.UpdatePassword() - error revoking refresh tokens: " + err.Error())
+		return 0, err
+	}
+
+	return tokenVersion, tx.Commit()
+}
+
+//go:embed queries/expire-user-tokens.sql
+var expireUserTokensQuery string
+
+// ResetPassword consumes a password reset token and sets the new password,
+// any other outstanding reset tokens for the user are used up along with it
+func (um *UserModel) ResetPassword(token, hashedPassword string) (userID int, err error) {
+	tx, err := um.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(
+		consumeUserTokenQuery, util.HashToken(token), PASSWORD_RESET_TOKEN).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, InvalidTokenError{}
+		}
+
+		logger.LogError("UserModel.ResetPassword() - error consuming token: " + err.Error())
+		return 0, err
+	}
+
+	var tokenVersion int
+	err = tx.QueryRow(updateUserPasswordQuery, hashedPassword, userID).Scan(&tokenVersion)
+	if err != nil {
+		logger.LogError("UserModel.ResetPassword() - error updating password: " + err.Error())
+		return 0, err
+	}
+
+	_, err = tx.Exec(expireUserTokensQuery, userID, PASSWORD_RESET_TOKEN)
+	if err != nil {
+		logger.LogError("UserModel.ResetPassword() - error expiring tokens: " + err.Error())
+		return 0, err
+	}
+
+	_, err = tx.Exec(revokeUserRefreshTokensQuery, userID)
+	if err != nil {
+		logger.LogError("UserModel.ResetPassword() - error revoking refresh tokens: " + err.Error())
+		return 0, err
+	}
+
+	return userID, tx.Commit()
+}
+
+//go:embed queries/consume-user-token.sql
+var consumeUserTokenQuery string
+
+//go:embed queries/activate-user.sql
+var activateUserQuery string
+
+// Activate consumes an activation token and flips the owner's is_active flag
+func (um *UserModel) Activate(token string) (userID int, err error) {
+	tx, err := um.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(
+		consumeUserTokenQuery, util.HashToken(token), ACTIVATION_TOKEN).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, InvalidTokenError{}
+		}
+
+		logger.LogError("UserModel.Activate() - error consuming token: " + err.Error())
+		return 0, err
+	}
+
+	_, err = tx.Exec(activateUserQuery, userID)
+	if err != nil {
+		logger.LogError("UserModel.Activate() - error activating user: " + err.Error())
+		return 0, err
+	}
+
+	return userID, tx.Commit()
+}
+
 //go:embed queries/create-user-follows.sql
 var createUserFollowsQuery string
 
-func (um *UserModel) Follow(followerID, followeeID int) error {
+//go:embed queries/create-follow-request.sql
+var createFollowRequestQuery string
+
+// Follow follows public accounts straight away, private accounts get a
+// FollowRequest to accept instead and requested is true
+func (um *UserModel) Follow(followerID, followeeID int) (requested bool, err error) {
 	result, err := um.db.Exec(createUserFollowsQuery, followerID, followeeID)
 	if err != nil {
 		if dbutils.IsUniqueConstraintError(err) {
-			// user already likes this post, likely a duplicate request
-			return nil
+			// user already follows this user, likely a duplicate request
+			return false, nil
 		}
 
 		if dbutils.ConstraintFailed(err) {
-			return dbutils.WrapConstraintError(err)
+			return false, dbutils.WrapConstraintError(err)
 		}
 
-		return err
+		return false, err
 	}
 
-	_, err = result.RowsAffected()
+	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	return nil
+	if rowsAffected == 0 {
+		return um.requestFollow(followerID, followeeID)
+	}
+
+	NewNotificationModel(um.db).NewFollowNotification(followerID, followeeID)
+
+	return false, nil
+}
+
+func (um *UserModel) requestFollow(requesterID, targetID int) (requested bool, err error) {
+	result, err := um.db.Exec(createFollowRequestQuery, requesterID, targetID)
+	if err != nil {
+		if dbutils.IsUniqueConstraintError(err) {
+			// already requested
+			return true, nil
+		}
+
+		if dbutils.ConstraintFailed(err) {
+			return false, dbutils.WrapConstraintError(err)
+		}
+
+		logger.LogError("UserModel.requestFollow() - error creating request: " + err.Error())
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
 
+	// no rows means the requester already follows the target
+	return rowsAffected == 1, nil
 }
 
 //go:embed queries/delete-user-follows.sql
 var deleteUserFollowsQuery string
 
+//go:embed queries/delete-follow-request.sql
+var deleteFollowRequestQuery string
+
+// UnFollow also cancels a pending follow request
 func (um *UserModel) UnFollow(followerID, followeeID int) error {
 	result, err := um.db.Exec(deleteUserFollowsQuery, followerID, followeeID)
 
@@ -102,9 +308,233 @@ func (um *UserModel) UnFollow(followerID, followeeID int) error {
 		return err
 	}
 
+	_, err = um.db.Exec(deleteFollowRequestQuery, followerID, followeeID)
+	if err != nil {
+		logger.LogError("UserModel.UnFollow() - error cancelling request: " + err.Error())
+		return err
+	}
+
 	return nil
 }
 
+//go:embed queries/create-accepted-follow.sql
+var createAcceptedFollowQuery string
+
+// AcceptFollowRequest turns requesterID's pending request into a follow of
+// targetID, FollowRequestNotFoundError when there is no such request
+func (um *UserModel) AcceptFollowRequest(targetID, requesterID int) error {
+	tx, err := um.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(deleteFollowRequestQuery, requesterID, targetID)
+	if err != nil {
+		logger.LogError("UserModel.AcceptFollowRequest() - error removing request: " + err.Error())
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return FollowRequestNotFoundError{}
+	}
+
+	_, err = tx.Exec(createAcceptedFollowQuery, requesterID, targetID)
+	if err != nil {
+		logger.LogError("UserModel.AcceptFollowRequest() - error creating follow: " + err.Error())
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RejectFollowRequest drops requesterID's pending request to follow targetID,
+// FollowRequestNotFoundError when there is no such request
+func (um *UserModel) RejectFollowRequest(targetID, requesterID int) error {
+	result, err := um.db.Exec(deleteFollowRequestQuery, requesterID, targetID)
+	if err != nil {
+		logger.LogError("UserModel.RejectFollowRequest() - error: " + err.Error())
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return FollowRequestNotFoundError{}
+	}
+
+	return nil
+}
+
+//go:embed queries/select-follow-requests.sql
+var selectFollowRequestsQuery string
+
+// GetFollowRequests lists the users waiting for userID to accept their
+// follow, newest first
+func (um *UserModel) GetFollowRequests(userID, limit, offset int) ([]dtypes.Author, error) {
+	return um.queryFollowList(selectFollowRequestsQuery, userID, userID, limit, offset)
+}
+
+//go:embed queries/select-follow-request-count.sql
+var selectFollowRequestCountQuery string
+
+func (um *UserModel) GetFollowRequestCount(userID int) (int, error) {
+	var count int
+	err := um.db.QueryRow(selectFollowRequestCountQuery, userID).Scan(&count)
+	if err != nil {
+		logger.LogError("UserModel.GetFollowRequestCount() - error scanning row: " + err.Error())
+		return 0, err
+	}
+
+	return count, nil
+}
+
+//go:embed queries/check-mutual-follow.sql
+var checkMutualFollowQuery string
+
+// IsMutualFollow reports whether the two users follow each other
+func (um *UserModel) IsMutualFollow(userID, otherUserID int) (bool, error) {
+	var count int
+	err := um.db.QueryRow(checkMutualFollowQuery, userID, otherUserID).Scan(&count)
+	if err != nil {
+		logger.LogError("UserModel.IsMutualFollow() - error scanning row: " + err.Error())
+		return false, err
+	}
+
+	return count == 2, nil
+}
+
+//go:embed queries/check-user-visible.sql
+var checkUserVisibleQuery string
+
+// CanView reports whether viewerID may see ownerID's content, private
+// accounts are only visible to themselves and their followers
+func (um *UserModel) CanView(ownerID, viewerID int) (bool, error) {
+	var visible int
+	err := um.db.QueryRow(checkUserVisibleQuery, ownerID, viewerID).Scan(&visible)
+	if err != nil {
+		logger.LogError("UserModel.CanView() - error scanning row: " + err.Error())
+		return false, err
+	}
+
+	return visible == 1, nil
+}
+
+//go:embed queries/create-user-block.sql
+var createUserBlockQuery string
+
+//go:embed queries/delete-mutual-follows.sql
+var deleteMutualFollowsQuery string
+
+//go:embed queries/delete-mutual-follow-requests.sql
+var deleteMutualFollowRequestsQuery string
+
+// Block also removes any follow or follow request between the two users, in
+// either direction.
+// Blocking someone already blocked is a no-op.
+func (um *UserModel) Block(blockerID, blockedID int) error {
+	tx, err := um.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(createUserBlockQuery, blockerID, blockedID)
+	if err != nil {
+		if dbutils.IsUniqueConstraintError(err) {
+			return nil
+		}
+
+		if dbutils.ConstraintFailed(err) {
+			return dbutils.WrapConstraintError(err)
+		}
+
+		logger.LogError("UserModel.Block() - error blocking user: " + err.Error())
+		return err
+	}
+
+	_, err = tx.Exec(deleteMutualFollowsQuery, blockerID, blockedID)
+	if err != nil {
+		logger.LogError("UserModel.Block() - error removing follows: " + err.Error())
+		return err
+	}
+
+	_, err = tx.Exec(deleteMutualFollowRequestsQuery, blockerID, blockedID)
+	if err != nil {
+		logger.LogError("UserModel.Block() - error removing follow requests: " + err.Error())
+		return err
+	}
+
+	return tx.Commit()
+}
+
+//go:embed queries/delete-user-block.sql
+var deleteUserBlockQuery string
+
+func (um *UserModel) UnBlock(blockerID, blockedID int) error {
+	_, err := um.db.Exec(deleteUserBlockQuery, blockerID, blockedID)
+	if err != nil {
+		logger.LogError("UserModel.UnBlock() - error: " + err.Error())
+	}
+
+	return err
+}
+
+//go:embed queries/check-user-block.sql
+var checkUserBlockQuery string
+
+func (um *UserModel) HasBlocked(blockerID, blockedID int) (bool, error) {
+	var count int
+	err := um.db.QueryRow(checkUserBlockQuery, blockerID, blockedID).Scan(&count)
+	if err != nil {
+		logger.LogError("UserModel.HasBlocked() - error scanning row: " + err.Error())
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+//go:embed queries/create-user-mute.sql
+var createUserMuteQuery string
+
+func (um *UserModel) Mute(muterID, mutedID int) error {
+	_, err := um.db.Exec(createUserMuteQuery, muterID, mutedID)
+	if err != nil {
+		if dbutils.IsUniqueConstraintError(err) {
+			return nil
+		}
+
+		if dbutils.ConstraintFailed(err) {
+			return dbutils.WrapConstraintError(err)
+		}
+
+		logger.LogError("UserModel.Mute() - error: " + err.Error())
+		return err
+	}
+
+	return nil
+}
+
+//go:embed queries/delete-user-mute.sql
+var deleteUserMuteQuery string
+
+func (um *UserModel) UnMute(muterID, mutedID int) error {
+	_, err := um.db.Exec(deleteUserMuteQuery, muterID, mutedID)
+	if err != nil {
+		logger.LogError("UserModel.UnMute() - error: " + err.Error())
+	}
+
+	return err
+}
+
 //go:embed queries/select-user-base-query.sql
 var selectUserBaseQuery string
 
@@ -154,29 +584,193 @@ func (um *UserModel) GetByPostID(postID, userID int) (dtypes.Author, error) {
 	return ret, nil
 }
 
-//go:embed queries/select-user-bookmark-count.sql
-var selectUserBookmarkCountQuery string
+//go:embed queries/select-user-profile.sql
+var selectUserProfileQuery string
 
-func (um *UserModel) GetBookmarkCount(userID int) (int, error) {
-	var count int
-	err := um.db.QueryRow(selectUserBookmarkCountQuery, userID).Scan(&count)
+func (um *UserModel) GetProfile(userID, viewerID int) (dtypes.Author, error) {
+	var username string
+	var displayName string
+	var avatar string
+	var bio string
+	var followerCount int
+	var followingCount int
+	var viewerFollowing int
+	var isPrivate int
+	var viewerRequested int
+
+	err := um.db.
+		QueryRow(selectUserProfileQuery, viewerID, userID).
+		Scan(
+			&username, &displayName, &avatar, &bio, &followerCount,
+			&followingCount, &viewerFollowing, &isPrivate, &viewerRequested)
 	if err != nil {
-		return -1, err
+		if errors.Is(err, sql.ErrNoRows) {
+			return dtypes.Author{}, UserNotFoundError{}
+		}
+
+		logger.LogError("UserModel.GetProfile() - error scanning row: " + err.Error())
+		return dtypes.Author{}, err
 	}
-	return count, nil
+
+	return dtypes.Author{
+		Username:        username,
+		DisplayName:     displayName,
+		Avatar:          avatar,
+		Bio:             bio,
+		FollowerCount:   followerCount,
+		FollowingCount:  followingCount,
+		ViewerFollowing: viewerFollowing == 1,
+		IsPrivate:       isPrivate == 1,
+		ViewerRequested: viewerRequested == 1,
+	}, nil
+}
+
+//go:embed queries/select-user-mutual-followers.sql
+var selectUserMutualFollowersQuery string
+
+func (um *UserModel) GetMutualFollowers(userID, viewerID, limit int) ([]*dtypes.Author, error) {
+	result, err := um.db.Query(selectUserMutualFollowersQuery, viewerID, userID, limit)
+	if err != nil {
+		logger.LogError("UserModel.GetMutualFollowers() - query error: " + err.Error())
+		return []*dtypes.Author{}, err
+	}
+	defer result.Close()
+
+	mutuals := []*dtypes.Author{}
+	for result.Next() {
+		var username string
+		var displayName string
+		var avatar string
+
+		err := result.Scan(&username, &displayName, &avatar)
+		if err != nil {
+			logger.LogError("UserModel.GetMutualFollowers() - error scanning row: " + err.Error())
+			return []*dtypes.Author{}, err
+		}
+
+		mutuals = append(mutuals, &dtypes.Author{
+			Username:    username,
+			DisplayName: displayName,
+			Avatar:      avatar,
+		})
+	}
+
+	return mutuals, nil
+}
+
+//go:embed queries/select-user-followers.sql
+var selectUserFollowersQuery string
+
+func (um *UserModel) GetFollowers(userID, viewerID, limit, offset int) ([]dtypes.Author, error) {
+	return um.queryFollowList(selectUserFollowersQuery, userID, viewerID, limit, offset)
+}
+
+//go:embed queries/select-user-following.sql
+var selectUserFollowingQuery string
+
+func (um *UserModel) GetFollowing(userID, viewerID, limit, offset int) ([]dtypes.Author, error) {
+	return um.queryFollowList(selectUserFollowingQuery, userID, viewerID, limit, offset)
+}
+
+func (um *UserModel) queryFollowList(query string, userID, viewerID, limit, offset int) ([]dtypes.Author, error) {
+	result, err := um.db.Query(query, viewerID, userID, limit, offset)
+	if err != nil {
+		logger.LogError("UserModel.queryFollowList() - query error: " + err.Error())
+		return []dtypes.Author{}, err
+	}
+	defer result.Close()
+
+	users := []dtypes.Author{}
+	for result.Next() {
+		var username string
+		var displayName string
+		var avatar string
+		var bio string
+		var viewerFollowing int
+
+		err := result.Scan(&username, &displayName, &avatar, &bio, &viewerFollowing)
+		if err != nil {
+			logger.LogError("UserModel.queryFollowList() - error scanning row: " + err.Error())
+			return []dtypes.Author{}, err
+		}
+
+		users = append(users, dtypes.Author{
+			Username:        username,
+			DisplayName:     displayName,
+			Avatar:          avatar,
+			Bio:             bio,
+			ViewerFollowing: viewerFollowing == 1,
+		})
+	}
+
+	return users, nil
+}
+
+//go:embed queries/select-user-suggestions.sql
+var selectUserSuggestionsQuery string
+
+// GetSuggestions returns accounts the viewer might want to follow, accounts
+// they already follow, muted or blocked are never suggested
+func (um *UserModel) GetSuggestions(viewerID, limit int) ([]dtypes.UserSuggestion, error) {
+	result, err := um.db.Query(selectUserSuggestionsQuery, viewerID, limit)
+	if err != nil {
+		logger.LogError("UserModel.GetSuggestions() - query error: " + err.Error())
+		return []dtypes.UserSuggestion{}, err
+	}
+	defer result.Close()
+
+	suggestions := []dtypes.UserSuggestion{}
+	for result.Next() {
+		var suggestion dtypes.UserSuggestion
+		user := &suggestion.User
+
+		err := result.Scan(
+			&suggestion.UserID,
+			&user.Username,
+			&user.DisplayName,
+			&user.Avatar,
+			&user.Bio,
+			&user.FollowerCount,
+			&user.FollowingCount,
+			&suggestion.MutualCount,
+			&suggestion.LikedCount,
+		)
+		if err != nil {
+			logger.LogError("UserModel.GetSuggestions() - error scanning row: " + err.Error())
+			return []dtypes.UserSuggestion{}, err
+		}
+
+		suggestions = append(suggestions, suggestion)
+	}
+
+	return suggestions, nil
 }
 
 //go:embed queries/select-user-bookmarks.sql
 var selectUserBookmarksQuery string
 
-func (um *UserModel) GetBookmarks(userID, limit, offset int) ([]dtypes.BookmarkData, error) {
-	result, err := um.db.Query(selectUserBookmarksQuery, userID, limit, offset)
+// GetBookmarks returns a page of bookmarks newest first, next is only set when
+// there is another page
+func (um *UserModel) GetBookmarks(userID, limit int, cursor *dtypes.Cursor) (bookmarks []dtypes.BookmarkData, next *dtypes.Cursor, err error) {
+	if limit <= 0 {
+		return []dtypes.BookmarkData{}, nil, errors.New("Positive limit value required")
+	}
+
+	args := append(append([]any{userID}, cursorArgs(cursor)...), limit+1)
+	result, err := um.db.Query(selectUserBookmarksQuery, args...)
 	if err != nil {
-		return []dtypes.BookmarkData{}, err
+		return []dtypes.BookmarkData{}, nil, err
 	}
+	defer result.Close()
 
-	var bookmarks []dtypes.BookmarkData
+	var lastBookmarkID int
 	for result.Next() {
+		if len(bookmarks) == limit {
+			last := bookmarks[limit-1]
+			next = &dtypes.Cursor{SortTime: last.BookmarkCreatedAt, Type: last.Type, ID: lastBookmarkID}
+			break
+		}
+
 		var bookmark_created_at string
 		var id int
 		var content string
@@ -191,12 +785,13 @@ func (um *UserModel) GetBookmarks(userID, limit, offset int) ([]dtypes.BookmarkD
 		var author_display_name string
 		var author_avatar string
 		var content_type string
+		var bookmark_id int
 
 		err := result.Scan(
 			&bookmark_created_at, &id, &content, &image, &like_count,
 			&retweet_count, &bookmark_count, &impressions, &created_at,
 			&updated_at, &author_user_name, &author_display_name,
-			&author_avatar, &content_type,
+			&author_avatar, &content_type, &bookmark_id,
 		)
 
 		author := dtypes.Author{
@@ -222,11 +817,12 @@ func (um *UserModel) GetBookmarks(userID, limit, offset int) ([]dtypes.BookmarkD
 		bookmarks = append(bookmarks, bookmarkData)
 		if err != nil {
 			logger.LogError("UserModel.GetBookmarks() errors scanning row: " + err.Error())
-			return []dtypes.BookmarkData{}, err
+			return []dtypes.BookmarkData{}, nil, err
 		}
+		lastBookmarkID = bookmark_id
 	}
 
-	return bookmarks, nil
+	return bookmarks, next, nil
 }
 
 func (um *UserModel) GetByIdentifier(email, username string) (dtypes.UserData, error) {
@@ -248,6 +844,183 @@ func (um *UserModel) GetByIdentifier(email, username string) (dtypes.UserData, e
 	return parseUserQueryRow(row)
 }
 
+//go:embed queries/update-user-profile.sql
+var updateUserProfileQuery string
+
+func (um *UserModel) UpdateProfile(userID int, profileInput dtypes.ProfileInput) error {
+	result, err := um.db.Exec(
+		updateUserProfileQuery,
+		profileInput.FirstName,
+		profileInput.LastName,
+		profileInput.DisplayName,
+		profileInput.Bio,
+		profileInput.IsPrivate,
+		profileInput.DMMutualsOnly,
+		userID,
+	)
+	if err != nil {
+		if dbutils.ConstraintFailed(err) {
+			return dbutils.WrapConstraintError(err)
+		}
+
+		logger.LogError("UserModel.UpdateProfile() - error updating user: " + err.Error())
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return UserNotFoundError{}
+	}
+
+	return nil
+}
+
+//go:embed queries/update-user-avatar.sql
+var updateUserAvatarQuery string
+
+// UpdateAvatar returns the avatar being replaced so the caller can clean up
+// the old upload
+func (um *UserModel) UpdateAvatar(userID int, avatar string) (previousAvatar string, err error) {
+	userData, err := um.GetByID(userID)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = um.db.Exec(updateUserAvatarQuery, avatar, userID)
+	if err != nil {
+		logger.LogError("UserModel.UpdateAvatar() - error updating avatar: " + err.Error())
+		return "", err
+	}
+
+	return userData.Avatar, nil
+}
+
+//go:embed queries/update-user-active.sql
+var updateUserActiveQuery string
+
+// SetActive records audit alongside the change, deactivating also signs the
+// user out everywhere
+func (um *UserModel) SetActive(userID int, active bool, audit ...AuditEntry) error {
+	tx, err := um.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = setUserActive(tx, userID, active)
+	if err != nil {
+		return err
+	}
+
+	err = recordAudit(tx, audit...)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func setUserActive(db execer, userID int, active bool) error {
+	isActive := 0
+	if active {
+		isActive = 1
+	}
+
+	result, err := db.Exec(updateUserActiveQuery, isActive, userID)
+	if err != nil {
+		logger.LogError("setUserActive() - error updating user: " + err.Error())
+		return err
+	}
+
+	err = userRowAffected(result)
+	if err != nil || active {
+		return err
+	}
+
+	_, err = db.Exec(revokeUserRefreshTokensQuery, userID)
+	if err != nil {
+		logger.LogError("setUserActive() - error revoking refresh tokens: " + err.Error())
+		return err
+	}
+
+	return nil
+}
+
+//go:embed queries/update-user-role.sql
+var updateUserRoleQuery string
+
+func (um *UserModel) SetRole(userID int, role permissions.Role, audit ...AuditEntry) error {
+	tx, err := um.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(updateUserRoleQuery, role, userID)
+	if err != nil {
+		if dbutils.ConstraintFailed(err) {
+			return dbutils.WrapConstraintError(err)
+		}
+
+		logger.LogError("UserModel.SetRole() - error updating user: " + err.Error())
+		return err
+	}
+
+	err = userRowAffected(result)
+	if err != nil {
+		return err
+	}
+
+	err = recordAudit(tx, audit...)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetRecent lists accounts newest first
+func (um *UserModel) GetRecent(limit, offset int) ([]dtypes.UserData, error) {
+	query := selectUserBaseQuery + "ORDER BY created_at DESC, id DESC LIMIT $1 OFFSET $2;"
+	result, err := um.db.Query(query, limit, offset)
+	if err != nil {
+		logger.LogError("UserModel.GetRecent() - query error: " + err.Error())
+		return []dtypes.UserData{}, err
+	}
+	defer result.Close()
+
+	users := []dtypes.UserData{}
+	for result.Next() {
+		userData, err := parseUserQueryRow(result)
+		if err != nil {
+			logger.LogError("UserModel.GetRecent() - error scanning row")
+			return []dtypes.UserData{}, err
+		}
+
+		users = append(users, userData)
+	}
+
+	return users, nil
+}
+
+//go:embed queries/select-user-count.sql
+var selectUserCountQuery string
+
+func (um *UserModel) Count() (int, error) {
+	var count int
+	err := um.db.QueryRow(selectUserCountQuery).Scan(&count)
+	if err != nil {
+		logger.LogError("UserModel.Count() - error scanning row: " + err.Error())
+		return -1, err
+	}
+
+	return count, nil
+}
+
 //go:embed queries/user-login.sql
 var userLoginQuery string
 
@@ -311,6 +1084,24 @@ func (um *UserModel) Exists(userID int) (bool, error) {
 	return count > 0, nil
 }
 
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+func createUserToken(db execer, userID int, purpose TokenPurpose, ttl string) (string, error) {
+	token, err := util.GenerateToken()
+	if err != nil {
+		return "", err
+	}
+
+	_, err = db.Exec(createUserTokenQuery, userID, util.HashToken(token), purpose, ttl)
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
 func NewUserModel(dbConn *sql.DB) *UserModel {
 	if dbConn == nil {
 		panic("db conn cannot be nil")
@@ -319,7 +1110,20 @@ func NewUserModel(dbConn *sql.DB) *UserModel {
 	return &UserModel{db: dbConn}
 }
 
-func parseUserQueryRow(row *sql.Row) (dtypes.UserData, error) {
+func userRowAffected(result sql.Result) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return UserNotFoundError{}
+	}
+
+	return nil
+}
+
+func parseUserQueryRow(row dbutils.RowScanner) (dtypes.UserData, error) {
 	var id int
 	var email string
 	var userName string
@@ -327,16 +1131,21 @@ func parseUserQueryRow(row *sql.Row) (dtypes.UserData, error) {
 	var firstName string
 	var lastName string
 	var displayName string
+	var bio string
 	var avatar string
 	var lastLogin sql.NullString
 	var isActive int
+	var isPrivate int
+	var dmMutualsOnly int
 	var role int
+	var tokenVersion int
 	var createdAt string
 	var updatedAt string
 
 	err := row.Scan(
 		&id, &email, &userName, &password, &firstName, &lastName, &displayName,
-		&avatar, &lastLogin, &isActive, &role, &createdAt, &updatedAt)
+		&bio, &avatar, &lastLogin, &role, &isActive, &isPrivate, &dmMutualsOnly,
+		&tokenVersion, &createdAt, &updatedAt)
 
 	if err != nil {
 		return dtypes.UserData{}, UserNotFoundError{}
@@ -348,18 +1157,22 @@ func parseUserQueryRow(row *sql.Row) (dtypes.UserData, error) {
 	}
 
 	return dtypes.UserData{
-		ID:          id,
-		Email:       email,
-		Username:    userName,
-		FirstName:   firstName,
-		LastName:    lastName,
-		DisplayName: displayName,
-		Avatar:      avatar,
-		Password:    password,
-		LastLogin:   lastLoginString,
-		IsActive:    isActive,
-		Role:        permissions.Role(role),
-		CreatedAt:   createdAt,
-		UpdatedAt:   updatedAt,
+		ID:            id,
+		Email:         email,
+		Username:      userName,
+		FirstName:     firstName,
+		LastName:      lastName,
+		DisplayName:   displayName,
+		Bio:           bio,
+		Avatar:        avatar,
+		Password:      password,
+		LastLogin:     lastLoginString,
+		IsActive:      isActive,
+		IsPrivate:     isPrivate,
+		DMMutualsOnly: dmMutualsOnly,
+		Role:          permissions.Role(role),
+		TokenVersion:  tokenVersion,
+		CreatedAt:     createdAt,
+		UpdatedAt:     updatedAt,
 	}, nil
 }
@@ -3,11 +3,13 @@ package model
 import (
 	"database/sql"
 	"errors"
+	"slices"
 	"testing"
 	"time"
 
 	"github.com/marcusprice/twitter-clone/internal/dbutils"
 	"github.com/marcusprice/twitter-clone/internal/dtypes"
+	"github.com/marcusprice/twitter-clone/internal/permissions"
 	"github.com/marcusprice/twitter-clone/internal/testhelpers"
 	"github.com/marcusprice/twitter-clone/internal/testutil"
 	"github.com/marcusprice/twitter-clone/internal/util"
@@ -253,7 +255,8 @@ func TestUserLogin(t *testing.T) {
 		tu.AssertTrue(timestamp.Before(afterUpdate))
 		tu.AssertTrue(lastLogin.After(beforeUpdate))
 		tu.AssertTrue(lastLogin.Before(afterUpdate))
-		tu.AssertEqual(1, isActive)
+		// logging in no longer activates the account, see TestUserActivate
+		tu.AssertEqual(0, isActive)
 
 		var unsetID int
 		_, _, err = userModel.Login(unsetID)
@@ -273,33 +276,35 @@ func TestUserFollow(t *testing.T) {
 		user1 := queryUser(1, db)
 		user2 := queryUser(2, db)
 		user4 := queryUser(4, db)
+		// the seed data's follows come first
+		seeded := testhelpers.QueryUserFollowTableCount(db)
 
-		err := UserModel.Follow(user1.ID, user4.ID)
-		followerID, followeeID := queryUserFollowRow(1, db)
+		_, err := UserModel.Follow(user1.ID, user4.ID)
+		followerID, followeeID := queryUserFollowRow(seeded+1, db)
 		tu.AssertErrorNil(err)
 		tu.AssertEqual(user1.ID, followerID)
 		tu.AssertEqual(user4.ID, followeeID)
 
 		// mutual follow
-		err = UserModel.Follow(user4.ID, user1.ID)
-		followerID, followeeID = queryUserFollowRow(2, db)
+		_, err = UserModel.Follow(user4.ID, user1.ID)
+		followerID, followeeID = queryUserFollowRow(seeded+2, db)
 		tu.AssertErrorNil(err)
 		tu.AssertEqual(user4.ID, followerID)
 		tu.AssertEqual(user1.ID, followeeID)
 
-		err = UserModel.Follow(user4.ID, user2.ID)
-		followerID, followeeID = queryUserFollowRow(3, db)
+		_, err = UserModel.Follow(user4.ID, user2.ID)
+		followerID, followeeID = queryUserFollowRow(seeded+3, db)
 		tu.AssertErrorNil(err)
 		tu.AssertEqual(user4.ID, followerID)
 		tu.AssertEqual(user2.ID, followeeID)
 
 		// no error returned for double follow, also no rows inserted
-		err = UserModel.Follow(user4.ID, user2.ID)
+		_, err = UserModel.Follow(user4.ID, user2.ID)
 		numRows := testhelpers.QueryUserFollowTableCount(db)
 		tu.AssertErrorNil(err)
-		tu.AssertEqual(3, numRows)
+		tu.AssertEqual(seeded+3, numRows)
 
-		err = UserModel.Follow(user4.ID, user4.ID)
+		_, err = UserModel.Follow(user4.ID, user4.ID)
 		var constraintError dbutils.ConstraintError
 		tu.AssertErrorNotNil(err)
 		tu.AssertTrue(errors.As(err, &constraintError))
@@ -314,6 +319,7 @@ func TestUserUnFollow(t *testing.T) {
 		user1 := queryUser(1, db)
 		user2 := queryUser(2, db)
 		user3 := queryUser(3, db)
+		seeded := testhelpers.QueryUserFollowTableCount(db)
 		user1FollowsUser2RowID := insertUserFollow(user1.ID, user2.ID, db)
 		insertUserFollow(user2.ID, user1.ID, db)
 		insertUserFollow(user2.ID, user3.ID, db)
@@ -322,15 +328,15 @@ func TestUserUnFollow(t *testing.T) {
 		rowDeleted := verifyUserFollowsRowDeleted(user1FollowsUser2RowID, db)
 		tu.AssertErrorNil(err)
 		tu.AssertTrue(rowDeleted)
-		tu.AssertEqual(2, testhelpers.QueryUserFollowTableCount(db))
+		tu.AssertEqual(seeded+2, testhelpers.QueryUserFollowTableCount(db))
 
 		err = UserModel.UnFollow(user1.ID, user2.ID)
 		tu.AssertErrorNil(err)
-		tu.AssertEqual(2, testhelpers.QueryUserFollowTableCount(db))
+		tu.AssertEqual(seeded+2, testhelpers.QueryUserFollowTableCount(db))
 
 		err = UserModel.UnFollow(user1.ID, user2.ID)
 		tu.AssertErrorNil(err)
-		tu.AssertEqual(2, testhelpers.QueryUserFollowTableCount(db))
+		tu.AssertEqual(seeded+2, testhelpers.QueryUserFollowTableCount(db))
 	})
 }
 
@@ -472,3 +478,553 @@ func verifyUserFollowsRowDeleted(rowID int, db *sql.DB) bool {
 
 	return count == 0
 }
+
+func TestUserGetProfile(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, timestamp time.Time) {
+		tu := testutil.NewTestUtil(t)
+		userModel := NewUserModel(db)
+
+		// user 7 follows users 1-6 in the seed data
+		insertUserFollow(1, 7, db)
+		insertUserFollow(2, 1, db)
+
+		profile, err := userModel.GetProfile(2, 1)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual("wallphace", profile.Username)
+		tu.AssertEqual(1, profile.FollowerCount)
+		tu.AssertEqual(1, profile.FollowingCount)
+		tu.AssertFalse(profile.ViewerFollowing)
+
+		profile, err = userModel.GetProfile(7, 1)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(1, profile.FollowerCount)
+		tu.AssertEqual(6, profile.FollowingCount)
+		tu.AssertTrue(profile.ViewerFollowing)
+
+		mutuals, err := userModel.GetMutualFollowers(2, 1, 3)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(1, len(mutuals))
+		tu.AssertEqual("endlesshappiness", mutuals[0].Username)
+
+		_, err = userModel.GetProfile(42069, 1)
+		tu.AssertTrue(errors.Is(err, UserNotFoundError{}))
+	})
+}
+
+func TestUserGetFollowersAndFollowing(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, timestamp time.Time) {
+		tu := testutil.NewTestUtil(t)
+		userModel := NewUserModel(db)
+		insertUserFollow(1, 7, db)
+
+		following, err := userModel.GetFollowing(7, 1, 4, 0)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(4, len(following))
+
+		following, err = userModel.GetFollowing(7, 1, 4, 4)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(2, len(following))
+
+		followers, err := userModel.GetFollowers(7, 2, 10, 0)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(1, len(followers))
+		tu.AssertEqual("estecat", followers[0].Username)
+		tu.AssertFalse(followers[0].ViewerFollowing)
+
+		followers, err = userModel.GetFollowers(1, 7, 10, 0)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(1, len(followers))
+		tu.AssertEqual("endlesshappiness", followers[0].Username)
+		tu.AssertFalse(followers[0].ViewerFollowing)
+	})
+}
+
+func TestUserGetSuggestions(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, timestamp time.Time) {
+		tu := testutil.NewTestUtil(t)
+		userModel := NewUserModel(db)
+
+		// estecat follows wallphace who follows dalecooper, and likes two of
+		// audrey's posts
+		insertUserFollow(1, 2, db)
+		insertUserFollow(2, 3, db)
+		_, err := db.Exec(`
+			INSERT INTO PostLike (post_id, user_id)
+			SELECT id, 1 FROM Post WHERE user_id = 4 ORDER BY id LIMIT 2;
+		`)
+		tu.AssertErrorNil(err)
+
+		suggestions, err := userModel.GetSuggestions(1, 10)
+		tu.AssertErrorNil(err)
+		usernames := []string{}
+		for _, suggestion := range suggestions {
+			usernames = append(usernames, suggestion.User.Username)
+		}
+		// connected accounts first, then by follower count
+		tu.AssertTrue(slices.Equal(
+			[]string{"dalecooper", "audrey", "bobbybriggs", "donnahayward", "endlesshappiness"},
+			usernames,
+		))
+		tu.AssertEqual(3, suggestions[0].UserID)
+		tu.AssertEqual(1, suggestions[0].MutualCount)
+		tu.AssertEqual(2, suggestions[0].User.FollowerCount)
+		tu.AssertEqual(2, suggestions[1].LikedCount)
+		tu.AssertEqual(0, suggestions[4].User.FollowerCount)
+		tu.AssertEqual(6, suggestions[4].User.FollowingCount)
+
+		// blocked and muted accounts are never suggested, either direction
+		// for blocks
+		userModel.Block(1, 5)
+		userModel.Block(6, 1)
+		userModel.Mute(1, 7)
+		suggestions, err = userModel.GetSuggestions(1, 10)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(2, len(suggestions))
+
+		suggestions, err = userModel.GetSuggestions(1, 1)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(1, len(suggestions))
+		tu.AssertEqual("dalecooper", suggestions[0].User.Username)
+	})
+}
+
+func TestUserUpdateProfile(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, timestamp time.Time) {
+		tu := testutil.NewTestUtil(t)
+		userModel := NewUserModel(db)
+		bio := "meow"
+		displayName := "Esteban"
+
+		err := userModel.UpdateProfile(1, dtypes.ProfileInput{Bio: &bio})
+		tu.AssertErrorNil(err)
+		userData, err := userModel.GetByID(1)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual("meow", userData.Bio)
+		tu.AssertEqual("estecat", userData.Username)
+
+		err = userModel.UpdateProfile(1, dtypes.ProfileInput{DisplayName: &displayName})
+		tu.AssertErrorNil(err)
+		userData, err = userModel.GetByID(1)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual("Esteban", userData.DisplayName)
+		tu.AssertEqual("meow", userData.Bio)
+
+		empty := ""
+		err = userModel.UpdateProfile(1, dtypes.ProfileInput{DisplayName: &empty})
+		tu.AssertTrue(dbutils.IsConstraintError(err))
+
+		err = userModel.UpdateProfile(42069, dtypes.ProfileInput{Bio: &bio})
+		tu.AssertTrue(errors.Is(err, UserNotFoundError{}))
+	})
+}
+
+func TestUserUpdateAvatar(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, timestamp time.Time) {
+		tu := testutil.NewTestUtil(t)
+		userModel := NewUserModel(db)
+
+		previous, err := userModel.UpdateAvatar(1, "esteban.png")
+		tu.AssertErrorNil(err)
+		tu.AssertEqual("este-profile.jpg", previous)
+
+		previous, err = userModel.UpdateAvatar(1, "esteban-2.png")
+		tu.AssertErrorNil(err)
+		tu.AssertEqual("esteban.png", previous)
+
+		userData, err := userModel.GetByID(1)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual("esteban-2.png", userData.Avatar)
+	})
+}
+
+func TestUserActivate(t *testing.T) {
+	testutil.WithTestDB(t, func(db *sql.DB) {
+		tu := testutil.NewTestUtil(t)
+		userModel := NewUserModel(db)
+
+		userData, err := userModel.New(dtypes.UserInput{
+			Email:       "estecat42069@yahoo.com",
+			Username:    "estecat",
+			DisplayName: "Hungry Boy",
+			Password:    "password",
+		})
+		tu.AssertErrorNil(err)
+		tu.AssertTrue(userData.ActivationToken != "")
+
+		// only the hash is stored
+		var storedHash string
+		db.QueryRow("SELECT token_hash FROM UserToken WHERE user_id = $1", userData.ID).
+			Scan(&storedHash)
+		tu.AssertEqual(util.HashToken(userData.ActivationToken), storedHash)
+
+		_, err = userModel.Activate("meow")
+		tu.AssertTrue(errors.Is(err, InvalidTokenError{}))
+
+		userID, err := userModel.Activate(userData.ActivationToken)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(userData.ID, userID)
+
+		activatedUser, err := userModel.GetByID(userID)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(1, activatedUser.IsActive)
+
+		_, err = userModel.Activate(userData.ActivationToken)
+		tu.AssertTrue(errors.Is(err, InvalidTokenError{}))
+
+		// expired tokens are rejected
+		secondUser, err := userModel.New(dtypes.UserInput{
+			Email:       "wallphace@yahoo.com",
+			Username:    "wallphace",
+			DisplayName: "Wallphace",
+			Password:    "password",
+		})
+		tu.AssertErrorNil(err)
+		db.Exec(
+			"UPDATE UserToken SET expires_at = datetime(current_timestamp, '-1 minutes') WHERE user_id = $1",
+			secondUser.ID)
+		_, err = userModel.Activate(secondUser.ActivationToken)
+		tu.AssertTrue(errors.Is(err, InvalidTokenError{}))
+	})
+}
+
+func TestUserResetPassword(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, timestamp time.Time) {
+		tu := testutil.NewTestUtil(t)
+		userModel := NewUserModel(db)
+
+		firstToken, err := userModel.CreateToken(1, PASSWORD_RESET_TOKEN, PASSWORD_RESET_TOKEN_TTL)
+		tu.AssertErrorNil(err)
+		secondToken, err := userModel.CreateToken(1, PASSWORD_RESET_TOKEN, PASSWORD_RESET_TOKEN_TTL)
+		tu.AssertErrorNil(err)
+
+		count, err := userModel.RecentTokenCount(1, PASSWORD_RESET_TOKEN, "-1 hours")
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(2, count)
+		count, err = userModel.RecentTokenCount(1, ACTIVATION_TOKEN, "-1 hours")
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(0, count)
+
+		// activation tokens can't be used for resets and vice versa
+		_, err = userModel.Activate(firstToken)
+		tu.AssertTrue(errors.Is(err, InvalidTokenError{}))
+
+		userID, err := userModel.ResetPassword(firstToken, "hashed")
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(1, userID)
+
+		userData, err := userModel.GetByID(1)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual("hashed", userData.Password)
+		tu.AssertEqual(1, userData.TokenVersion)
+
+		// the reset used up every outstanding reset token
+		_, err = userModel.ResetPassword(secondToken, "hashed again")
+		tu.AssertTrue(errors.Is(err, InvalidTokenError{}))
+
+		tokenVersion, err := userModel.UpdatePassword(1, "hashed again")
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(2, tokenVersion)
+
+		_, err = userModel.UpdatePassword(42069, "hashed")
+		tu.AssertTrue(errors.Is(err, UserNotFoundError{}))
+	})
+}
+
+func TestUserModeration(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, timestamp time.Time) {
+		tu := testutil.NewTestUtil(t)
+		userModel := NewUserModel(db)
+
+		refreshToken, err := NewSessionModel(db).CreateRefreshToken(4)
+		tu.AssertErrorNil(err)
+		err = userModel.SetActive(4, false)
+		tu.AssertErrorNil(err)
+		userData, err := userModel.GetByID(4)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(0, userData.IsActive)
+		// deactivating signs the user out
+		tu.AssertEqual(1, userData.TokenVersion)
+		_, _, err = NewSessionModel(db).RotateRefreshToken(refreshToken)
+		tu.AssertTrue(errors.Is(err, InvalidTokenError{}))
+
+		// the change is rolled back when its audit entry fails
+		err = userModel.SetActive(4, true, AuditEntry{ActorID: 2, Action: "not_an_action"})
+		tu.AssertErrorNotNil(err)
+		userData, _ = userModel.GetByID(4)
+		tu.AssertEqual(0, userData.IsActive)
+
+		err = userModel.SetActive(4, true)
+		tu.AssertErrorNil(err)
+		userData, _ = userModel.GetByID(4)
+		tu.AssertEqual(1, userData.IsActive)
+
+		err = userModel.SetRole(4, permissions.ADMIN_ROLE)
+		tu.AssertErrorNil(err)
+		userData, _ = userModel.GetByID(4)
+		tu.AssertEqual(permissions.ADMIN_ROLE, userData.Role)
+
+		err = userModel.SetRole(4, permissions.Role(42))
+		tu.AssertTrue(dbutils.IsConstraintError(err))
+
+		err = userModel.SetActive(42069, false)
+		tu.AssertTrue(errors.Is(err, UserNotFoundError{}))
+		err = userModel.SetRole(42069, permissions.USER_ROLE)
+		tu.AssertTrue(errors.Is(err, UserNotFoundError{}))
+
+		count, err := userModel.Count()
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(7, count)
+
+		users, err := userModel.GetRecent(2, 0)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(2, len(users))
+		tu.AssertTrue(users[0].CreatedAt >= users[1].CreatedAt)
+	})
+}
+
+func TestUserBlockAndMute(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, timestamp time.Time) {
+		tu := testutil.NewTestUtil(t)
+		userModel := NewUserModel(db)
+		insertUserFollow(1, 7, db)
+
+		err := userModel.Block(7, 1)
+		tu.AssertErrorNil(err)
+		err = userModel.Block(7, 1)
+		tu.AssertErrorNil(err)
+		err = userModel.Block(7, 7)
+		tu.AssertTrue(dbutils.IsConstraintError(err))
+
+		blocked, err := userModel.HasBlocked(7, 1)
+		tu.AssertErrorNil(err)
+		tu.AssertTrue(blocked)
+		blocked, _ = userModel.HasBlocked(1, 7)
+		tu.AssertFalse(blocked)
+
+		// blocking drops the follow in both directions
+		var follows int
+		db.QueryRow(
+			"SELECT COUNT(*) FROM UserFollows WHERE (follower_id = 1 AND followee_id = 7) OR (follower_id = 7 AND followee_id = 1);",
+		).Scan(&follows)
+		tu.AssertEqual(0, follows)
+
+		err = userModel.UnBlock(7, 1)
+		tu.AssertErrorNil(err)
+		blocked, _ = userModel.HasBlocked(7, 1)
+		tu.AssertFalse(blocked)
+
+		err = userModel.Mute(7, 2)
+		tu.AssertErrorNil(err)
+		err = userModel.Mute(7, 2)
+		tu.AssertErrorNil(err)
+		err = userModel.UnMute(7, 2)
+		tu.AssertErrorNil(err)
+	})
+}
+
+func TestUserFollowRequests(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, timestamp time.Time) {
+		tu := testutil.NewTestUtil(t)
+		userModel := NewUserModel(db)
+		isPrivate := true
+		err := userModel.UpdateProfile(1, dtypes.ProfileInput{IsPrivate: &isPrivate})
+		tu.AssertErrorNil(err)
+		userData, _ := userModel.GetByID(1)
+		tu.AssertEqual(1, userData.IsPrivate)
+
+		requested, err := userModel.Follow(2, 1)
+		tu.AssertErrorNil(err)
+		tu.AssertTrue(requested)
+		requested, err = userModel.Follow(2, 1)
+		tu.AssertErrorNil(err)
+		tu.AssertTrue(requested)
+		// endlesshappiness followed before the account went private
+		requested, err = userModel.Follow(7, 1)
+		tu.AssertErrorNil(err)
+		tu.AssertFalse(requested)
+
+		profile, _ := userModel.GetProfile(1, 2)
+		tu.AssertTrue(profile.IsPrivate)
+		tu.AssertTrue(profile.ViewerRequested)
+		tu.AssertFalse(profile.ViewerFollowing)
+		tu.AssertEqual(1, profile.FollowerCount)
+
+		visible, err := userModel.CanView(1, 2)
+		tu.AssertErrorNil(err)
+		tu.AssertFalse(visible)
+		visible, _ = userModel.CanView(1, 1)
+		tu.AssertTrue(visible)
+		visible, _ = userModel.CanView(1, 7)
+		tu.AssertTrue(visible)
+		visible, _ = userModel.CanView(2, 1)
+		tu.AssertTrue(visible)
+
+		userModel.Follow(3, 1)
+		requests, err := userModel.GetFollowRequests(1, 10, 0)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(2, len(requests))
+		count, err := userModel.GetFollowRequestCount(1)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(2, count)
+
+		err = userModel.AcceptFollowRequest(1, 2)
+		tu.AssertErrorNil(err)
+		visible, _ = userModel.CanView(1, 2)
+		tu.AssertTrue(visible)
+		profile, _ = userModel.GetProfile(1, 2)
+		tu.AssertTrue(profile.ViewerFollowing)
+		tu.AssertFalse(profile.ViewerRequested)
+		err = userModel.AcceptFollowRequest(1, 2)
+		tu.AssertTrue(errors.Is(err, FollowRequestNotFoundError{}))
+
+		err = userModel.RejectFollowRequest(1, 3)
+		tu.AssertErrorNil(err)
+		visible, _ = userModel.CanView(1, 3)
+		tu.AssertFalse(visible)
+		err = userModel.RejectFollowRequest(1, 3)
+		tu.AssertTrue(errors.Is(err, FollowRequestNotFoundError{}))
+
+		// unfollowing cancels a pending request, blocking drops it
+		userModel.Follow(4, 1)
+		userModel.UnFollow(4, 1)
+		userModel.Follow(5, 1)
+		userModel.Block(1, 5)
+		count, _ = userModel.GetFollowRequestCount(1)
+		tu.AssertEqual(0, count)
+	})
+}
+
+func TestPrivateAccountVisibility(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, timestamp time.Time) {
+		tu := testutil.NewTestUtil(t)
+		userModel := NewUserModel(db)
+		postModel := NewPostModel(db)
+		insertPostBookmarkRow(1, 1, db, t)
+		insertPostBookmarkRow(2, 1, db, t)
+
+		wallphacePosts := func(viewerID int) int {
+			count := 0
+			for _, post := range forYouPosts(postModel, viewerID, 500) {
+				if post.Author.Username == "wallphace" {
+					count++
+				}
+			}
+			return count
+		}
+		bookmarkCount := func() int {
+			bookmarks, _, err := userModel.GetBookmarks(1, 10, nil)
+			tu.AssertErrorNil(err)
+			return len(bookmarks)
+		}
+
+		tu.AssertEqual(19, wallphacePosts(1))
+		tu.AssertEqual(2, bookmarkCount())
+
+		isPrivate := true
+		userModel.UpdateProfile(2, dtypes.ProfileInput{IsPrivate: &isPrivate})
+		tu.AssertEqual(0, wallphacePosts(1))
+		tu.AssertEqual(1, bookmarkCount())
+		// the account still sees its own posts
+		tu.AssertEqual(19, wallphacePosts(2))
+
+		userModel.Follow(1, 2)
+		userModel.AcceptFollowRequest(2, 1)
+		tu.AssertEqual(19, wallphacePosts(1))
+		tu.AssertEqual(2, bookmarkCount())
+	})
+}
+
+func TestUserGetBookmarks(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, timestamp time.Time) {
+		tu := testutil.NewTestUtil(t)
+		userModel := NewUserModel(db)
+		commentID := insertTestComment(1, 2, db, t)
+
+		// bookmarks made in the same second are kept apart by type and id
+		insertPostBookmarkRow(1, 7, db, t)
+		insertPostBookmarkRow(2, 7, db, t)
+		insertPostBookmarkRow(3, 7, db, t)
+		_, err := db.Exec("INSERT INTO CommentBookmark (comment_id, user_id) VALUES ($1, 7);", commentID)
+		tu.AssertErrorNil(err)
+		db.Exec("UPDATE PostBookmark SET created_at = '2030-01-01 00:00:00' WHERE user_id = 7;")
+		db.Exec("UPDATE CommentBookmark SET created_at = '2030-01-01 00:00:00' WHERE user_id = 7;")
+
+		bookmarks, next, err := userModel.GetBookmarks(7, 3, nil)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(3, len(bookmarks))
+		tu.AssertEqual("post", bookmarks[0].Type)
+		tu.AssertEqual(3, bookmarks[0].ID)
+		tu.AssertEqual(1, bookmarks[2].ID)
+		tu.AssertNotNil(next)
+
+		bookmarks, next, err = userModel.GetBookmarks(7, 3, next)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(1, len(bookmarks))
+		tu.AssertEqual("comment", bookmarks[0].Type)
+		tu.AssertEqual(commentID, bookmarks[0].ID)
+		tu.AssertNil(next)
+
+		// bookmarks of hidden content are kept but not shown
+		db.Exec("UPDATE Post SET is_hidden = 1 WHERE id = 3;")
+		db.Exec("UPDATE Comment SET is_hidden = 1 WHERE id = $1;", commentID)
+		bookmarks, _, err = userModel.GetBookmarks(7, 5, nil)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(2, len(bookmarks))
+		tu.AssertEqual(2, bookmarks[0].ID)
+	})
+}
+
+func TestUserMuteFiltersContent(t *testing.T) {
+	testutil.WithTestData(t, func(db *sql.DB, timestamp time.Time) {
+		tu := testutil.NewTestUtil(t)
+		userModel := NewUserModel(db)
+		postModel := NewPostModel(db)
+		commentModel := NewCommentModel(db)
+
+		followingCount := timelineLength(postModel.QueryUserFollowingTimeline, 7)
+		allCount := len(forYouPosts(postModel, 7, 1000))
+		postID := insertPost(dtypes.PostInput{UserID: 1, Content: "hello"}, db)
+		insertTestComment(postID, 2, db, t)
+		insertTestComment(postID, 4, db, t)
+
+		err := userModel.Mute(7, 2)
+		tu.AssertErrorNil(err)
+		var wallphacePosts int
+		db.QueryRow("SELECT COUNT(*) FROM Post WHERE user_id = 2;").Scan(&wallphacePosts)
+
+		count := timelineLength(postModel.QueryUserFollowingTimeline, 7)
+		tu.AssertEqual(followingCount+1-wallphacePosts, count)
+		count = len(forYouPosts(postModel, 7, 1000))
+		tu.AssertEqual(allCount+1-wallphacePosts, count)
+
+		posts, _, _, err := postModel.QueryUserFollowingTimeline(7, 40, nil)
+		tu.AssertErrorNil(err)
+		tu.AssertTrue(len(posts) > 0)
+		for _, post := range posts {
+			tu.AssertTrue(post.UserID != 2)
+		}
+
+		for _, post := range forYouPosts(postModel, 7, 40) {
+			tu.AssertTrue(post.UserID != 2)
+		}
+
+		comments, err := commentModel.GetByPostID(postID, 7)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(1, len(comments))
+		tu.AssertEqual(4, comments[0].UserID)
+		comments, _ = commentModel.GetByPostID(postID, 1)
+		tu.AssertEqual(2, len(comments))
+
+		// a block hides content from both users
+		userModel.Block(1, 4)
+		comments, _ = commentModel.GetByPostID(postID, 1)
+		tu.AssertEqual(1, len(comments))
+		tu.AssertEqual(2, comments[0].UserID)
+
+		var daleCooperPosts int
+		db.QueryRow("SELECT COUNT(*) FROM Post WHERE user_id = 3;").Scan(&daleCooperPosts)
+		userModel.Block(3, 7)
+		count = timelineLength(postModel.QueryUserFollowingTimeline, 7)
+		tu.AssertEqual(followingCount+1-wallphacePosts-daleCooperPosts, count)
+	})
+}
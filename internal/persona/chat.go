@@ -0,0 +1,161 @@
+package persona
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/marcusprice/twitter-clone/internal/dtypes"
+)
+
+const DEFAULT_CONTEXT_TOKENS = 2048
+
+// there's no tokenizer for ollama models here, four characters a token is
+// close enough for english text. Each message also costs a few tokens of
+// chat template.
+const (
+	CHARS_PER_TOKEN         = 4
+	MESSAGE_OVERHEAD_TOKENS = 4
+)
+
+// Messages builds the chat for a reply to request. The post, the comment
+// being replied under and the prompt are always included, the rest of the
+// thread is added newest first while it fits in ContextTokens.
+func (p Persona) Messages(request dtypes.ReplyGuyRequest, thread dtypes.ReplyGuyThread) ([]dtypes.OllamaMessage, error) {
+	prompt, err := p.Prompt(request, thread)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := []dtypes.OllamaMessage{}
+	if p.SystemPrompt != "" {
+		messages = append(messages, dtypes.OllamaMessage{Role: dtypes.OLLAMA_SYSTEM_ROLE, Content: p.SystemPrompt})
+	}
+	messages = append(messages, p.postMessage(thread))
+
+	parent, history := threadHistory(request, thread)
+	if parent != nil {
+		messages = append(messages, p.commentMessage(*parent))
+	}
+
+	last := dtypes.OllamaMessage{Role: dtypes.OLLAMA_USER_ROLE, Content: prompt}
+	budget := p.contextTokens() - estimateTokens(last)
+	for _, message := range messages {
+		budget -= estimateTokens(message)
+	}
+
+	// walk back from the newest comment until the budget runs out
+	start := len(history)
+	for start > 0 {
+		tokens := estimateTokens(p.commentMessage(history[start-1]))
+		if tokens > budget {
+			break
+		}
+
+		budget -= tokens
+		start--
+	}
+
+	for _, comment := range history[start:] {
+		messages = append(messages, p.commentMessage(comment))
+	}
+
+	return append(messages, last), nil
+}
+
+// threadHistory returns the comment request is replying under and the
+// comments before request.Comment in the same thread, oldest first. Replies
+// to a comment only see that comment's replies, top level comments see the
+// other top level comments.
+func threadHistory(request dtypes.ReplyGuyRequest, thread dtypes.ReplyGuyThread) (*dtypes.ReplyGuyThreadComment, []dtypes.ReplyGuyThreadComment) {
+	siblings := thread.Comments
+	var parent *dtypes.ReplyGuyThreadComment
+	if request.ParentComment.ID != 0 {
+		// the comment may have been deleted since, the request still has it
+		parent = &dtypes.ReplyGuyThreadComment{
+			ID:      request.ParentComment.ID,
+			Content: request.ParentComment.Content,
+			Author:  dtypes.ReplyGuyThreadAuthor{Username: request.ParentComment.Author.Username},
+		}
+		siblings = []dtypes.ReplyGuyThreadComment{}
+
+		for _, comment := range thread.Comments {
+			if comment.ID == request.ParentComment.ID {
+				parent = &comment
+				siblings = comment.Replies
+				break
+			}
+		}
+	}
+
+	history := []dtypes.ReplyGuyThreadComment{}
+	for _, comment := range siblings {
+		// ids only go up, anything newer than the comment came in after it
+		if comment.ID < request.Comment.ID {
+			history = append(history, comment)
+		}
+	}
+
+	slices.SortFunc(history, func(a, b dtypes.ReplyGuyThreadComment) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return parent, history
+}
+
+func (p Persona) postMessage(thread dtypes.ReplyGuyThread) dtypes.OllamaMessage {
+	content := thread.Content
+	if thread.Image != "" {
+		if thread.ImageAlt != "" {
+			content += fmt.Sprintf("\n[image: %s]", thread.ImageAlt)
+		} else {
+			content += "\n[image with no description]"
+		}
+	}
+
+	if p.isAuthor(thread.Author) {
+		return dtypes.OllamaMessage{Role: dtypes.OLLAMA_ASSISTANT_ROLE, Content: strings.TrimSpace(content)}
+	}
+
+	return dtypes.OllamaMessage{
+		Role:    dtypes.OLLAMA_USER_ROLE,
+		Content: fmt.Sprintf("@%s posted:\n\n%s", thread.Author.Username, strings.TrimSpace(content)),
+	}
+}
+
+// commentMessage uses the assistant role for the persona's own replies so
+// the model sees them as what it said earlier
+func (p Persona) commentMessage(comment dtypes.ReplyGuyThreadComment) dtypes.OllamaMessage {
+	content := comment.Content
+	if comment.Image != "" {
+		content += " [image]"
+	}
+
+	if p.isAuthor(comment.Author) {
+		return dtypes.OllamaMessage{Role: dtypes.OLLAMA_ASSISTANT_ROLE, Content: strings.TrimSpace(content)}
+	}
+
+	return dtypes.OllamaMessage{
+		Role:    dtypes.OLLAMA_USER_ROLE,
+		Content: fmt.Sprintf("@%s: %s", comment.Author.Username, strings.TrimSpace(content)),
+	}
+}
+
+func (p Persona) isAuthor(author dtypes.ReplyGuyThreadAuthor) bool {
+	return strings.EqualFold(author.Username, p.Username)
+}
+
+func (p Persona) contextTokens() int {
+	if p.ContextTokens > 0 {
+		return p.ContextTokens
+	}
+
+	return DEFAULT_CONTEXT_TOKENS
+}
+
+func estimateTokens(message dtypes.OllamaMessage) int {
+	characters := utf8.RuneCountInString(message.Content)
+	return (characters+CHARS_PER_TOKEN-1)/CHARS_PER_TOKEN + MESSAGE_OVERHEAD_TOKENS
+}
@@ -0,0 +1,78 @@
+package persona
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/marcusprice/twitter-clone/internal/dtypes"
+	"github.com/marcusprice/twitter-clone/internal/testutil"
+)
+
+func TestMessages(t *testing.T) {
+	tu := testutil.NewTestUtil(t)
+	persona, _ := DefaultRegistry().Get("dalecooper")
+	messages, err := persona.Messages(testRequest(), testThread())
+	tu.AssertErrorNil(err)
+	tu.AssertEqual(5, len(messages))
+
+	tu.AssertEqual(dtypes.OLLAMA_USER_ROLE, messages[0].Role)
+	tu.AssertEqual("@bobbybriggs posted:\n\nwho killed laura palmer\n[image: a log]", messages[0].Content)
+	tu.AssertEqual("@donnahayward: James was at the roadhouse", messages[1].Content)
+	tu.AssertEqual("@normajennings: [image]", messages[2].Content)
+	// the persona's earlier reply is its own turn
+	tu.AssertEqual(dtypes.OLLAMA_ASSISTANT_ROLE, messages[3].Role)
+	tu.AssertEqual("damn fine coffee", messages[3].Content)
+	tu.AssertEqual(dtypes.OLLAMA_USER_ROLE, messages[4].Role)
+	tu.AssertTrue(strings.HasPrefix(messages[4].Content, "@audrey: @dalecooper is this true?"))
+
+	// top level comments see the other top level comments before them
+	request := testRequest()
+	request.Comment.ID = 10
+	request.ParentComment = dtypes.ReplyGuyComment{}
+	messages, err = persona.Messages(request, testThread())
+	tu.AssertErrorNil(err)
+	tu.AssertEqual(4, len(messages))
+	tu.AssertEqual("@harrytruman: it was leland", messages[1].Content)
+	tu.AssertEqual("@donnahayward: James was at the roadhouse", messages[2].Content)
+
+	// a deleted parent comment still comes from the request
+	request = testRequest()
+	request.ParentComment.ID = 99
+	messages, err = persona.Messages(request, testThread())
+	tu.AssertErrorNil(err)
+	tu.AssertEqual(3, len(messages))
+	tu.AssertEqual("@donnahayward: James was at the roadhouse", messages[1].Content)
+}
+
+func TestMessagesBudget(t *testing.T) {
+	tu := testutil.NewTestUtil(t)
+	registry, err := NewRegistry([]Persona{{
+		Username:      "dalecooper",
+		Model:         "dalecooper",
+		UserID:        3,
+		SystemPrompt:  "you are special agent dale cooper",
+		ContextTokens: 1,
+	}})
+	tu.AssertErrorNil(err)
+	persona, _ := registry.Get("dalecooper")
+
+	// the system prompt, post, parent comment and prompt are kept over budget
+	messages, err := persona.Messages(testRequest(), testThread())
+	tu.AssertErrorNil(err)
+	tu.AssertEqual(4, len(messages))
+	tu.AssertEqual(dtypes.OLLAMA_SYSTEM_ROLE, messages[0].Role)
+	tu.AssertEqual("you are special agent dale cooper", messages[0].Content)
+	tu.AssertEqual("@donnahayward: James was at the roadhouse", messages[2].Content)
+
+	// room for one more message keeps the newest one
+	tokens := estimateTokens(dtypes.OllamaMessage{Content: "damn fine coffee"})
+	for _, message := range messages {
+		tokens += estimateTokens(message)
+	}
+	persona.ContextTokens = tokens
+	messages, err = persona.Messages(testRequest(), testThread())
+	tu.AssertErrorNil(err)
+	tu.AssertEqual(5, len(messages))
+	tu.AssertEqual("damn fine coffee", messages[3].Content)
+	tu.AssertEqual("@donnahayward: James was at the roadhouse", messages[2].Content)
+}
@@ -0,0 +1,157 @@
+// Package persona holds the AI accounts reply-guy answers for. Both services
+// load the same registry, the core service to know which mentions to forward
+// and reply-guy to know which model, account and prompt to use.
+package persona
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/marcusprice/twitter-clone/internal/constants"
+	"github.com/marcusprice/twitter-clone/internal/dtypes"
+)
+
+//go:embed templates/default-prompt.tmpl
+var defaultPromptTemplate string
+
+var usernameRegex = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+type Persona struct {
+	// Username is the persona's account, without the @
+	Username string `json:"username"`
+	// Model is the ollama model that writes the replies
+	Model string `json:"model"`
+	// UserID is the account replies are posted as
+	UserID int `json:"userID"`
+	// PromptTemplate is a text/template executed with PromptData for the
+	// last message of the chat, personas without one use the default prompt
+	PromptTemplate string `json:"promptTemplate"`
+	// SystemPrompt replaces the model's own system prompt when set
+	SystemPrompt string `json:"systemPrompt"`
+	// ContextTokens is roughly how many tokens of the thread fit in a chat,
+	// defaults to DEFAULT_CONTEXT_TOKENS
+	ContextTokens int `json:"contextTokens"`
+
+	prompt *template.Template
+}
+
+type PromptData struct {
+	Persona Persona
+	Thread  dtypes.ReplyGuyThread
+	dtypes.ReplyGuyRequest
+}
+
+// Prompt renders the persona's prompt for request
+func (p Persona) Prompt(request dtypes.ReplyGuyRequest, thread dtypes.ReplyGuyThread) (string, error) {
+	var prompt strings.Builder
+	err := p.prompt.Execute(&prompt, PromptData{Persona: p, Thread: thread, ReplyGuyRequest: request})
+	return prompt.String(), err
+}
+
+type Registry struct {
+	// in config order
+	personas []Persona
+}
+
+// Get returns the persona for username, with or without the @
+func (r *Registry) Get(username string) (Persona, bool) {
+	username = strings.TrimPrefix(username, "@")
+	for _, persona := range r.personas {
+		if strings.EqualFold(persona.Username, username) {
+			return persona, true
+		}
+	}
+
+	return Persona{}, false
+}
+
+func (r *Registry) Personas() []Persona {
+	return r.personas
+}
+
+// NewRegistry validates personas and parses their prompt templates
+func NewRegistry(personas []Persona) (*Registry, error) {
+	registry := &Registry{}
+	for _, persona := range personas {
+		if !usernameRegex.MatchString(persona.Username) {
+			return nil, fmt.Errorf("invalid persona username %q", persona.Username)
+		}
+
+		if _, exists := registry.Get(persona.Username); exists {
+			return nil, fmt.Errorf("duplicate persona %q", persona.Username)
+		}
+
+		if persona.Model == "" {
+			return nil, fmt.Errorf("persona %q has no model", persona.Username)
+		}
+
+		if persona.UserID <= 0 {
+			return nil, fmt.Errorf("persona %q has no user id", persona.Username)
+		}
+
+		if persona.ContextTokens < 0 {
+			return nil, fmt.Errorf("persona %q has negative context tokens", persona.Username)
+		}
+
+		promptTemplate := persona.PromptTemplate
+		if promptTemplate == "" {
+			promptTemplate = defaultPromptTemplate
+		}
+
+		prompt, err := template.New(persona.Username).Option("missingkey=error").Parse(promptTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("persona %q prompt template: %w", persona.Username, err)
+		}
+
+		persona.prompt = prompt
+		registry.personas = append(registry.personas, persona)
+	}
+
+	return registry, nil
+}
+
+// DefaultRegistry only has @dalecooper, used when PERSONAS_PATH isn't set
+func DefaultRegistry() *Registry {
+	registry, err := NewRegistry([]Persona{{
+		Username: "dalecooper",
+		Model:    "dalecooper",
+		UserID:   constants.DALE_COOPER_USER_ID,
+	}})
+	if err != nil {
+		panic(err)
+	}
+
+	return registry
+}
+
+// Load reads a json array of personas from path
+func Load(path string) (*Registry, error) {
+	file, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var personas []Persona
+	err = json.Unmarshal(file, &personas)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	return NewRegistry(personas)
+}
+
+// FromEnv loads the registry from PERSONAS_PATH, falling back to the default
+// registry when it isn't set
+func FromEnv() (*Registry, error) {
+	path := os.Getenv("PERSONAS_PATH")
+	if path == "" {
+		return DefaultRegistry(), nil
+	}
+
+	return Load(path)
+}
@@ -0,0 +1,163 @@
+package persona
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/marcusprice/twitter-clone/internal/constants"
+	"github.com/marcusprice/twitter-clone/internal/dtypes"
+	"github.com/marcusprice/twitter-clone/internal/testutil"
+)
+
+func testRequest() dtypes.ReplyGuyRequest {
+	return dtypes.ReplyGuyRequest{
+		Persona: "dalecooper",
+		Comment: dtypes.ReplyGuyComment{
+			ID:      14,
+			Content: "@dalecooper is this true?",
+			Author:  dtypes.Author{Username: "audrey"},
+		},
+		ParentComment: dtypes.ReplyGuyComment{
+			ID:      9,
+			Content: "James was at the roadhouse",
+			Author:  dtypes.Author{Username: "donnahayward"},
+		},
+		ParentPost: dtypes.ReplyGuyPost{
+			ID:      41,
+			Content: "who killed laura palmer",
+			Author:  dtypes.Author{Username: "bobbybriggs"},
+		},
+	}
+}
+
+func testThread() dtypes.ReplyGuyThread {
+	return dtypes.ReplyGuyThread{
+		ID:       41,
+		Content:  "who killed laura palmer",
+		Image:    "/uploads/log.png",
+		ImageAlt: "a log",
+		Author:   dtypes.ReplyGuyThreadAuthor{Username: "bobbybriggs"},
+		// newest first, like the core service returns them
+		Comments: []dtypes.ReplyGuyThreadComment{
+			{
+				ID:      16,
+				Content: "the owls are not what they seem",
+				Author:  dtypes.ReplyGuyThreadAuthor{Username: "loglady"},
+			},
+			{
+				ID:      9,
+				Content: "James was at the roadhouse",
+				Author:  dtypes.ReplyGuyThreadAuthor{Username: "donnahayward"},
+				Replies: []dtypes.ReplyGuyThreadComment{
+					{ID: 15, ParentCommentID: 9, Content: "too late", Author: dtypes.ReplyGuyThreadAuthor{Username: "shelly"}},
+					{ID: 14, ParentCommentID: 9, Content: "@dalecooper is this true?", Author: dtypes.ReplyGuyThreadAuthor{Username: "audrey"}},
+					{ID: 13, ParentCommentID: 9, Content: "damn fine coffee", Author: dtypes.ReplyGuyThreadAuthor{Username: "dalecooper"}},
+					{ID: 12, ParentCommentID: 9, Content: "", Image: "/uploads/pie.png", Author: dtypes.ReplyGuyThreadAuthor{Username: "normajennings"}},
+				},
+			},
+			{
+				ID:      6,
+				Content: "it was leland",
+				Author:  dtypes.ReplyGuyThreadAuthor{Username: "harrytruman"},
+			},
+		},
+	}
+}
+
+func TestRegistryGet(t *testing.T) {
+	tu := testutil.NewTestUtil(t)
+	registry, err := NewRegistry([]Persona{
+		{Username: "dalecooper", Model: "dalecooper", UserID: 3},
+		{Username: "LogLady", Model: "llama3", UserID: 7},
+	})
+	tu.AssertErrorNil(err)
+
+	persona, ok := registry.Get("@dalecooper")
+	tu.AssertTrue(ok)
+	tu.AssertEqual(3, persona.UserID)
+
+	persona, ok = registry.Get("loglady")
+	tu.AssertTrue(ok)
+	tu.AssertEqual("llama3", persona.Model)
+
+	_, ok = registry.Get("dalecooperfan")
+	tu.AssertFalse(ok)
+
+	tu.AssertEqual(2, len(registry.Personas()))
+	tu.AssertEqual("dalecooper", registry.Personas()[0].Username)
+
+	persona, ok = DefaultRegistry().Get("dalecooper")
+	tu.AssertTrue(ok)
+	tu.AssertEqual(constants.DALE_COOPER_USER_ID, persona.UserID)
+}
+
+func TestNewRegistryValidation(t *testing.T) {
+	tu := testutil.NewTestUtil(t)
+	for _, personas := range [][]Persona{
+		{{Username: "@dalecooper", Model: "dalecooper", UserID: 3}},
+		{{Username: "", Model: "dalecooper", UserID: 3}},
+		{{Username: "dalecooper", UserID: 3}},
+		{{Username: "dalecooper", Model: "dalecooper"}},
+		{{Username: "dalecooper", Model: "dalecooper", UserID: 3, PromptTemplate: "{{.Comment"}},
+		{{Username: "dalecooper", Model: "dalecooper", UserID: 3, ContextTokens: -1}},
+		{
+			{Username: "dalecooper", Model: "dalecooper", UserID: 3},
+			{Username: "DaleCooper", Model: "llama3", UserID: 7},
+		},
+	} {
+		_, err := NewRegistry(personas)
+		tu.AssertErrorNotNil(err)
+	}
+}
+
+func TestPrompt(t *testing.T) {
+	tu := testutil.NewTestUtil(t)
+	persona, _ := DefaultRegistry().Get("dalecooper")
+	prompt, err := persona.Prompt(testRequest(), testThread())
+	tu.AssertErrorNil(err)
+	tu.AssertTrue(strings.HasPrefix(prompt, "@audrey: @dalecooper is this true?\n\nYou are @dalecooper"))
+	// the thread is sent as earlier messages, not flattened into the prompt
+	tu.AssertFalse(strings.Contains(prompt, "who killed laura palmer"))
+	tu.AssertFalse(strings.Contains(prompt, "posted by the same user"))
+
+	registry, err := NewRegistry([]Persona{{
+		Username:       "loglady",
+		Model:          "llama3",
+		UserID:         7,
+		PromptTemplate: "@{{.Persona.Username}}, @{{.Comment.Author.Username}} asks about {{.Thread.ImageAlt}}: {{.Comment.Content}}",
+	}})
+	tu.AssertErrorNil(err)
+	persona, _ = registry.Get("loglady")
+	prompt, err = persona.Prompt(testRequest(), testThread())
+	tu.AssertErrorNil(err)
+	tu.AssertEqual("@loglady, @audrey asks about a log: @dalecooper is this true?", prompt)
+}
+
+func TestLoad(t *testing.T) {
+	tu := testutil.NewTestUtil(t)
+	path := filepath.Join(t.TempDir(), "personas.json")
+	err := os.WriteFile(path, []byte(`[
+		{"username": "dalecooper", "model": "dalecooper", "userID": 3},
+		{"username": "loglady", "model": "llama3", "userID": 7, "promptTemplate": "{{.Comment.Content}}"}
+	]`), 0644)
+	tu.AssertErrorNil(err)
+
+	t.Setenv("PERSONAS_PATH", path)
+	registry, err := FromEnv()
+	tu.AssertErrorNil(err)
+	tu.AssertEqual(2, len(registry.Personas()))
+	persona, ok := registry.Get("loglady")
+	tu.AssertTrue(ok)
+	tu.AssertEqual(7, persona.UserID)
+
+	t.Setenv("PERSONAS_PATH", filepath.Join(t.TempDir(), "missing.json"))
+	_, err = FromEnv()
+	tu.AssertErrorNotNil(err)
+
+	t.Setenv("PERSONAS_PATH", "")
+	registry, err = FromEnv()
+	tu.AssertErrorNil(err)
+	tu.AssertEqual(1, len(registry.Personas()))
+}
@@ -0,0 +1,205 @@
+// Package ranking scores and orders For You timeline candidates. A score only
+// depends on the candidate, the weights and the ranking time, so the same
+// inputs always rank the same way.
+package ranking
+
+import (
+	"cmp"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Weights struct {
+	// Recency scales a score that halves every HalfLife
+	Recency  float64
+	HalfLife time.Duration
+
+	// the weighted engagement sum is log scaled, then scaled by Engagement,
+	// so one viral post doesn't bury everything else
+	Likes       float64
+	Retweets    float64
+	Comments    float64
+	Impressions float64
+	Engagement  float64
+
+	// author affinity, AuthorLikes scales the log of the viewer's likes on
+	// the author's posts
+	Follow      float64
+	AuthorLikes float64
+
+	// SeenPenalty is subtracted from rows the viewer was already served
+	SeenPenalty float64
+
+	// CandidatePool is how many of the newest timeline rows get ranked
+	CandidatePool int
+}
+
+func DefaultWeights() Weights {
+	return Weights{
+		Recency:       3,
+		HalfLife:      12 * time.Hour,
+		Likes:         1,
+		Retweets:      2,
+		Comments:      1.5,
+		Impressions:   0.05,
+		Engagement:    1,
+		Follow:        1.5,
+		AuthorLikes:   0.5,
+		SeenPenalty:   2,
+		CandidatePool: 500,
+	}
+}
+
+// WeightsFromEnv returns the default weights with any RANK_* env vars applied,
+// unparsable values keep their default
+func WeightsFromEnv() Weights {
+	weights := DefaultWeights()
+	envFloat("RANK_RECENCY_WEIGHT", &weights.Recency)
+	envFloat("RANK_LIKE_WEIGHT", &weights.Likes)
+	envFloat("RANK_RETWEET_WEIGHT", &weights.Retweets)
+	envFloat("RANK_COMMENT_WEIGHT", &weights.Comments)
+	envFloat("RANK_IMPRESSION_WEIGHT", &weights.Impressions)
+	envFloat("RANK_ENGAGEMENT_WEIGHT", &weights.Engagement)
+	envFloat("RANK_FOLLOW_WEIGHT", &weights.Follow)
+	envFloat("RANK_AUTHOR_LIKE_WEIGHT", &weights.AuthorLikes)
+	envFloat("RANK_SEEN_PENALTY", &weights.SeenPenalty)
+
+	halfLife, err := time.ParseDuration(os.Getenv("RANK_HALF_LIFE"))
+	if err == nil && halfLife > 0 {
+		weights.HalfLife = halfLife
+	}
+
+	candidatePool, err := strconv.Atoi(os.Getenv("RANK_CANDIDATE_POOL"))
+	if err == nil && candidatePool > 0 {
+		weights.CandidatePool = candidatePool
+	}
+
+	return weights
+}
+
+func envFloat(key string, value *float64) {
+	parsed, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err == nil {
+		*value = parsed
+	}
+}
+
+type Candidate struct {
+	// Type and ID identify the timeline row, rows sharing a ContentKey show
+	// the same post or comment and only the best scoring one is kept
+	Type       string
+	ID         int
+	ContentKey string
+	SortTime   time.Time
+
+	LikeCount    int
+	RetweetCount int
+	CommentCount int
+	Impressions  int
+
+	FollowsAuthor bool
+	AuthorLikes   int
+	Seen          bool
+}
+
+type Ranked struct {
+	Candidate
+	// Index is the candidate's position in the slice passed to Rank
+	Index int
+	Score float64
+}
+
+func (weights Weights) Score(candidate Candidate, now time.Time) float64 {
+	age := max(now.Sub(candidate.SortTime), 0)
+	recency := 0.0
+	if weights.HalfLife > 0 {
+		recency = math.Exp2(-float64(age) / float64(weights.HalfLife))
+	}
+
+	engagement := weights.Likes*float64(candidate.LikeCount) +
+		weights.Retweets*float64(candidate.RetweetCount) +
+		weights.Comments*float64(candidate.CommentCount) +
+		weights.Impressions*float64(candidate.Impressions)
+
+	score := weights.Recency*recency +
+		weights.Engagement*math.Log1p(max(engagement, 0)) +
+		weights.AuthorLikes*math.Log1p(float64(candidate.AuthorLikes))
+
+	if candidate.FollowsAuthor {
+		score += weights.Follow
+	}
+
+	if candidate.Seen {
+		score -= weights.SeenPenalty
+	}
+
+	return score
+}
+
+// Rank scores the candidates as of now and orders them best first
+func Rank(candidates []Candidate, weights Weights, now time.Time) []Ranked {
+	ranked := make([]Ranked, 0, len(candidates))
+	byContent := map[string]int{}
+	for index, candidate := range candidates {
+		row := Ranked{Candidate: candidate, Index: index, Score: weights.Score(candidate, now)}
+		if candidate.ContentKey == "" {
+			ranked = append(ranked, row)
+			continue
+		}
+
+		if kept, ok := byContent[candidate.ContentKey]; ok {
+			if compare(row, ranked[kept]) < 0 {
+				ranked[kept] = row
+			}
+			continue
+		}
+
+		byContent[candidate.ContentKey] = len(ranked)
+		ranked = append(ranked, row)
+	}
+
+	slices.SortFunc(ranked, compare)
+	return ranked
+}
+
+// Page returns up to limit rows ranked after the given row, a nil after starts
+// at the top. more reports whether any rows are left past the page.
+func Page(ranked []Ranked, after *Ranked, limit int) (page []Ranked, more bool) {
+	start := 0
+	if after != nil {
+		position, found := slices.BinarySearchFunc(ranked, *after, compare)
+		start = position
+		if found {
+			start++
+		}
+	}
+
+	end := min(start+limit, len(ranked))
+	if start >= end {
+		return []Ranked{}, false
+	}
+
+	return ranked[start:end], end < len(ranked)
+}
+
+// compare orders rows by score then sort time, type and id, all descending,
+// which gives every row a unique position
+func compare(a, b Ranked) int {
+	if a.Score != b.Score {
+		return cmp.Compare(b.Score, a.Score)
+	}
+
+	if byTime := b.SortTime.Compare(a.SortTime); byTime != 0 {
+		return byTime
+	}
+
+	if byType := strings.Compare(b.Type, a.Type); byType != 0 {
+		return byType
+	}
+
+	return cmp.Compare(b.ID, a.ID)
+}
@@ -0,0 +1,203 @@
+package ranking
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/marcusprice/twitter-clone/internal/testutil"
+)
+
+// rankings are a pure function of their inputs, every test ranks as of now
+var now = time.Date(2025, time.June, 1, 12, 0, 0, 0, time.UTC)
+
+func candidateAged(id int, age time.Duration) Candidate {
+	return Candidate{Type: "post", ID: id, SortTime: now.Add(-age)}
+}
+
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestScoreRecencyDecay(t *testing.T) {
+	tu := testutil.NewTestUtil(t)
+	weights := Weights{Recency: 1, HalfLife: time.Hour}
+
+	tu.AssertTrue(approxEqual(1, weights.Score(candidateAged(1, 0), now)))
+	tu.AssertTrue(approxEqual(0.5, weights.Score(candidateAged(1, time.Hour), now)))
+	tu.AssertTrue(approxEqual(0.25, weights.Score(candidateAged(1, 2*time.Hour), now)))
+	// rows from the future (clock skew) score as brand new
+	tu.AssertTrue(approxEqual(1, weights.Score(candidateAged(1, -time.Hour), now)))
+
+	weights.HalfLife = 0
+	tu.AssertEqual(0.0, weights.Score(candidateAged(1, 0), now))
+}
+
+func TestScoreEngagement(t *testing.T) {
+	tu := testutil.NewTestUtil(t)
+	weights := Weights{Likes: 1, Retweets: 2, Comments: 3, Impressions: 0.5, Engagement: 2}
+
+	candidate := candidateAged(1, 0)
+	tu.AssertEqual(0.0, weights.Score(candidate, now))
+
+	candidate.LikeCount = 1
+	candidate.RetweetCount = 1
+	candidate.CommentCount = 1
+	candidate.Impressions = 2
+	tu.AssertTrue(approxEqual(2*math.Log1p(7), weights.Score(candidate, now)))
+
+	// negative weights can't push the log below zero
+	weights.Impressions = -100
+	tu.AssertEqual(0.0, weights.Score(candidate, now))
+}
+
+func TestScoreAffinityAndSeen(t *testing.T) {
+	tu := testutil.NewTestUtil(t)
+	weights := Weights{Follow: 1.5, AuthorLikes: 2, SeenPenalty: 4}
+
+	candidate := candidateAged(1, 0)
+	candidate.FollowsAuthor = true
+	tu.AssertEqual(1.5, weights.Score(candidate, now))
+
+	candidate.AuthorLikes = 3
+	tu.AssertTrue(approxEqual(1.5+2*math.Log1p(3), weights.Score(candidate, now)))
+
+	candidate.Seen = true
+	tu.AssertTrue(approxEqual(1.5+2*math.Log1p(3)-4, weights.Score(candidate, now)))
+}
+
+func TestRank(t *testing.T) {
+	tu := testutil.NewTestUtil(t)
+	weights := DefaultWeights()
+
+	fresh := candidateAged(1, time.Minute)
+	popular := candidateAged(2, 3*time.Hour)
+	popular.LikeCount = 40
+	popular.RetweetCount = 10
+	seen := candidateAged(3, time.Minute)
+	seen.Seen = true
+	followed := candidateAged(4, 2*time.Hour)
+	followed.FollowsAuthor = true
+	followed.AuthorLikes = 12
+
+	ranked := Rank([]Candidate{fresh, popular, seen, followed}, weights, now)
+	tu.AssertEqual(4, len(ranked))
+	tu.AssertEqual(2, ranked[0].ID)
+	tu.AssertEqual(4, ranked[1].ID)
+	tu.AssertEqual(1, ranked[2].ID)
+	tu.AssertEqual(3, ranked[3].ID)
+	tu.AssertEqual(1, ranked[0].Index)
+	tu.AssertEqual(0, ranked[2].Index)
+	tu.AssertTrue(ranked[0].Score > ranked[1].Score)
+}
+
+func TestRankTiesAndDuplicates(t *testing.T) {
+	tu := testutil.NewTestUtil(t)
+	weights := DefaultWeights()
+
+	// equal scores fall back to sort time, type then id
+	older := candidateAged(9, time.Hour)
+	retweet := candidateAged(5, 0)
+	retweet.Type = "post-retweet"
+	post := candidateAged(7, 0)
+	lowerID := candidateAged(6, 0)
+
+	ranked := Rank([]Candidate{lowerID, post, older, retweet}, Weights{}, now)
+	tu.AssertEqual(5, ranked[0].ID)
+	tu.AssertEqual(7, ranked[1].ID)
+	tu.AssertEqual(6, ranked[2].ID)
+	tu.AssertEqual(9, ranked[3].ID)
+
+	// a post and its retweets only keep the best scoring row
+	original := candidateAged(1, 10*time.Hour)
+	original.ContentKey = "post:1"
+	retweetOne := candidateAged(2, time.Hour)
+	retweetOne.Type = "post-retweet"
+	retweetOne.ContentKey = "post:1"
+	retweetTwo := candidateAged(3, 5*time.Hour)
+	retweetTwo.Type = "post-retweet"
+	retweetTwo.ContentKey = "post:1"
+	quote := candidateAged(4, 20*time.Hour)
+	other := candidateAged(5, 30*time.Hour)
+	other.ContentKey = "post:5"
+
+	ranked = Rank([]Candidate{original, retweetOne, retweetTwo, quote, other}, weights, now)
+	tu.AssertEqual(3, len(ranked))
+	tu.AssertEqual(2, ranked[0].ID)
+	tu.AssertEqual("post-retweet", ranked[0].Type)
+	tu.AssertEqual(4, ranked[1].ID)
+	tu.AssertEqual(5, ranked[2].ID)
+
+	// the same inputs always rank the same way
+	again := Rank([]Candidate{other, quote, retweetTwo, retweetOne, original}, weights, now)
+	for index := range ranked {
+		tu.AssertEqual(ranked[index].ID, again[index].ID)
+		tu.AssertEqual(ranked[index].Score, again[index].Score)
+	}
+}
+
+func TestPage(t *testing.T) {
+	tu := testutil.NewTestUtil(t)
+	candidates := []Candidate{}
+	for id := 1; id <= 10; id++ {
+		candidate := candidateAged(id, time.Duration(id)*time.Hour)
+		candidate.LikeCount = id % 4
+		candidates = append(candidates, candidate)
+	}
+	ranked := Rank(candidates, DefaultWeights(), now)
+
+	seen := map[int]bool{}
+	var after *Ranked
+	pages := 0
+	for {
+		page, more := Page(ranked, after, 4)
+		pages++
+		for _, row := range page {
+			tu.AssertFalse(seen[row.ID])
+			seen[row.ID] = true
+		}
+
+		if !more {
+			break
+		}
+		after = &page[len(page)-1]
+	}
+
+	tu.AssertEqual(3, pages)
+	tu.AssertEqual(10, len(seen))
+
+	// a cursor row that's no longer ranked still resumes at its position
+	missing := ranked[3]
+	missing.ID = 999
+	page, more := Page(ranked, &missing, 2)
+	tu.AssertTrue(more)
+	tu.AssertEqual(ranked[3].ID, page[0].ID)
+
+	page, more = Page(ranked, &ranked[9], 4)
+	tu.AssertEqual(0, len(page))
+	tu.AssertFalse(more)
+}
+
+func TestWeightsFromEnv(t *testing.T) {
+	tu := testutil.NewTestUtil(t)
+	t.Setenv("RANK_LIKE_WEIGHT", "4.5")
+	t.Setenv("RANK_SEEN_PENALTY", "0")
+	t.Setenv("RANK_HALF_LIFE", "90m")
+	t.Setenv("RANK_CANDIDATE_POOL", "50")
+	t.Setenv("RANK_FOLLOW_WEIGHT", "lots")
+
+	weights := WeightsFromEnv()
+	defaults := DefaultWeights()
+	tu.AssertEqual(4.5, weights.Likes)
+	tu.AssertEqual(0.0, weights.SeenPenalty)
+	tu.AssertEqual(90*time.Minute, weights.HalfLife)
+	tu.AssertEqual(50, weights.CandidatePool)
+	tu.AssertEqual(defaults.Follow, weights.Follow)
+	tu.AssertEqual(defaults.Recency, weights.Recency)
+
+	t.Setenv("RANK_HALF_LIFE", "-1h")
+	t.Setenv("RANK_CANDIDATE_POOL", "0")
+	weights = WeightsFromEnv()
+	tu.AssertEqual(defaults.HalfLife, weights.HalfLife)
+	tu.AssertEqual(defaults.CandidatePool, weights.CandidatePool)
+}
@@ -0,0 +1,112 @@
+package replyqueue
+
+import (
+	"sync"
+	"time"
+)
+
+// Metrics counts job outcomes and latencies since the service started, queue
+// depth comes from the store when a snapshot is taken
+type Metrics struct {
+	lock         sync.Mutex
+	completed    int
+	retried      int
+	deadLettered int
+	released     int
+
+	processingCount int
+	processingTotal time.Duration
+	processingMax   time.Duration
+
+	waitCount int
+	waitTotal time.Duration
+	waitMax   time.Duration
+}
+
+type MetricsSnapshot struct {
+	Workers         int            `json:"workers"`
+	Pending         int            `json:"pending"`
+	InFlight        int            `json:"inFlight"`
+	InFlightByModel map[string]int `json:"inFlightByModel"`
+	DeadLetters     int            `json:"deadLetters"`
+
+	Completed    int `json:"completed"`
+	Retried      int `json:"retried"`
+	DeadLettered int `json:"deadLettered"`
+	Released     int `json:"released"`
+
+	// time spent generating and posting a reply
+	AvgProcessingMs int64 `json:"avgProcessingMs"`
+	MaxProcessingMs int64 `json:"maxProcessingMs"`
+	// time a due job waited for a worker
+	AvgQueueWaitMs int64 `json:"avgQueueWaitMs"`
+	MaxQueueWaitMs int64 `json:"maxQueueWaitMs"`
+}
+
+func (m *Metrics) observeWait(wait time.Duration) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	// run_at only has second precision
+	wait = max(wait, 0)
+	m.waitCount++
+	m.waitTotal += wait
+	m.waitMax = max(m.waitMax, wait)
+}
+
+func (m *Metrics) observeProcessing(duration time.Duration) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.processingCount++
+	m.processingTotal += duration
+	m.processingMax = max(m.processingMax, duration)
+}
+
+func (m *Metrics) incrementCompleted() {
+	m.lock.Lock()
+	m.completed++
+	m.lock.Unlock()
+}
+
+func (m *Metrics) incrementRetried() {
+	m.lock.Lock()
+	m.retried++
+	m.lock.Unlock()
+}
+
+func (m *Metrics) incrementDeadLettered() {
+	m.lock.Lock()
+	m.deadLettered++
+	m.lock.Unlock()
+}
+
+func (m *Metrics) incrementReleased() {
+	m.lock.Lock()
+	m.released++
+	m.lock.Unlock()
+}
+
+// fill copies the counters into snapshot
+func (m *Metrics) fill(snapshot *MetricsSnapshot) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	snapshot.Completed = m.completed
+	snapshot.Retried = m.retried
+	snapshot.DeadLettered = m.deadLettered
+	snapshot.Released = m.released
+
+	snapshot.AvgProcessingMs = averageMs(m.processingTotal, m.processingCount)
+	snapshot.MaxProcessingMs = m.processingMax.Milliseconds()
+	snapshot.AvgQueueWaitMs = averageMs(m.waitTotal, m.waitCount)
+	snapshot.MaxQueueWaitMs = m.waitMax.Milliseconds()
+}
+
+func averageMs(total time.Duration, count int) int64 {
+	if count == 0 {
+		return 0
+	}
+
+	return total.Milliseconds() / int64(count)
+}
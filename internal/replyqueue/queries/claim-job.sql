@@ -0,0 +1,21 @@
+UPDATE ReplyJob
+SET
+    status = 'in_flight',
+    attempts = attempts + 1
+WHERE id = (
+    SELECT id
+    FROM ReplyJob
+    WHERE status = 'pending'
+        AND run_at <= $1
+        -- $2 is a json array of models already at their concurrency limit
+        AND COALESCE(json_extract(payload, '$.model'), '') NOT IN (SELECT value FROM json_each($2))
+    ORDER BY run_at ASC, id ASC
+    LIMIT 1
+)
+RETURNING
+    id,
+    payload,
+    COALESCE(json_extract(payload, '$.model'), '') AS model,
+    attempts,
+    run_at,
+    last_error;
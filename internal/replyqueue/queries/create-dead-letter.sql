@@ -0,0 +1,10 @@
+INSERT INTO ReplyJobDeadLetter
+    (error, job_id, payload, attempts, created_at)
+SELECT
+    $1,
+    id,
+    payload,
+    attempts,
+    created_at
+FROM ReplyJob
+WHERE id = $2;
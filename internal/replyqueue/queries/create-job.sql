@@ -0,0 +1,5 @@
+INSERT INTO ReplyJob
+    (payload, run_at)
+VALUES
+    ($1, $2)
+RETURNING id;
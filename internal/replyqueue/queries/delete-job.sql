@@ -0,0 +1 @@
+DELETE FROM ReplyJob WHERE id = $1;
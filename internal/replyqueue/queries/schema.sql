@@ -0,0 +1,25 @@
+-- reply-guy keeps its queue in its own database so pending replies survive a
+-- restart, tables are only created when missing
+CREATE TABLE IF NOT EXISTS ReplyJob (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    payload TEXT NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('pending', 'in_flight')) DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    -- earliest time the job can be picked up, pushed back after each failure
+    run_at TEXT NOT NULL,
+    last_error TEXT,
+    created_at TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_replyjob_status_run_at ON ReplyJob(status, run_at);
+
+-- jobs that failed permanently or ran out of attempts
+CREATE TABLE IF NOT EXISTS ReplyJobDeadLetter (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    job_id INTEGER NOT NULL,
+    payload TEXT NOT NULL,
+    attempts INTEGER NOT NULL,
+    error TEXT NOT NULL,
+    created_at TEXT NOT NULL,
+    failed_at TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,10 @@
+SELECT
+    id,
+    job_id,
+    payload,
+    attempts,
+    error,
+    created_at,
+    failed_at
+FROM ReplyJobDeadLetter
+ORDER BY id ASC;
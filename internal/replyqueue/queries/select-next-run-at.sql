@@ -0,0 +1,4 @@
+SELECT MIN(run_at)
+FROM ReplyJob
+WHERE status = 'pending'
+    AND COALESCE(json_extract(payload, '$.model'), '') NOT IN (SELECT value FROM json_each($1));
@@ -0,0 +1,5 @@
+SELECT
+    COALESCE(SUM(status = 'pending'), 0),
+    COALESCE(SUM(status = 'in_flight'), 0),
+    (SELECT COUNT(*) FROM ReplyJobDeadLetter)
+FROM ReplyJob;
@@ -0,0 +1,6 @@
+UPDATE ReplyJob
+SET
+    status = 'pending',
+    run_at = $1,
+    last_error = $2
+WHERE id = $3;
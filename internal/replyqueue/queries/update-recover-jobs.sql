@@ -0,0 +1,7 @@
+-- jobs left in flight were interrupted by a shutdown, the attempt they were
+-- on still counts
+UPDATE ReplyJob
+SET
+    status = 'pending',
+    run_at = $1
+WHERE status = 'in_flight';
@@ -0,0 +1,9 @@
+-- the job was interrupted by a shutdown rather than failing, so the attempt
+-- is handed back
+UPDATE ReplyJob
+SET
+    status = 'pending',
+    attempts = attempts - 1,
+    run_at = $1
+WHERE id = $2
+    AND status = 'in_flight';
@@ -1,95 +1,594 @@
 package replyqueue
 
 import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/marcusprice/twitter-clone/internal/api"
 	"github.com/marcusprice/twitter-clone/internal/client"
 	"github.com/marcusprice/twitter-clone/internal/constants"
 	"github.com/marcusprice/twitter-clone/internal/dtypes"
 	"github.com/marcusprice/twitter-clone/internal/logger"
+	"github.com/marcusprice/twitter-clone/internal/persona"
 )
 
-// TODO: write better tests for ReplyQueue
+const (
+	DEFAULT_WORKERS      = 1
+	DEFAULT_MAX_ATTEMPTS = 5
+	DEFAULT_RETRY_BASE   = 5 * time.Second
+	DEFAULT_RETRY_MAX    = 10 * time.Minute
+	// streamed replies update the comment at most this often
+	DEFAULT_STREAM_INTERVAL = 500 * time.Millisecond
+	// how long deleting a failed stream's comment gets, it goes out even
+	// when the job was cancelled
+	STREAM_CLEANUP_TIMEOUT = 5 * time.Second
+)
+
+// PermanentError is a failure retrying won't fix, the job goes straight to
+// the dead letter table
+type PermanentError struct {
+	msg string
+}
+
+func (e PermanentError) Error() string {
+	return e.msg
+}
+
+type UnknownPersonaError struct {
+	Persona string
+}
+
+func (e UnknownPersonaError) Error() string {
+	return fmt.Sprintf("unknown persona %q", e.Persona)
+}
+
+type Config struct {
+	Workers int
+	// ModelConcurrency caps how many jobs for a model run at once, models
+	// without an entry are only limited by Workers
+	ModelConcurrency map[string]int
+	// MaxAttempts includes the first attempt
+	MaxAttempts int
+	// the first retry waits RetryBase, each one after that waits twice as
+	// long up to RetryMax
+	RetryBase time.Duration
+	RetryMax  time.Duration
+	// Stream posts an empty comment right away and fills it in as the model
+	// generates, instead of posting the whole reply at the end
+	Stream         bool
+	StreamInterval time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Workers:          DEFAULT_WORKERS,
+		ModelConcurrency: map[string]int{},
+		MaxAttempts:      DEFAULT_MAX_ATTEMPTS,
+		RetryBase:        DEFAULT_RETRY_BASE,
+		RetryMax:         DEFAULT_RETRY_MAX,
+		StreamInterval:   DEFAULT_STREAM_INTERVAL,
+	}
+}
+
+// ConfigFromEnv returns the default config with any REPLY_GUY_* env vars
+// applied, unparsable values keep their default
+func ConfigFromEnv() Config {
+	config := DefaultConfig()
+
+	workers, err := strconv.Atoi(os.Getenv("REPLY_GUY_WORKERS"))
+	if err == nil && workers > 0 {
+		config.Workers = workers
+	}
+
+	// comma separated model=limit pairs, i.e. dalecooper=1,llama3=2
+	for _, pair := range strings.Split(os.Getenv("REPLY_GUY_MODEL_CONCURRENCY"), ",") {
+		model, value, found := strings.Cut(strings.TrimSpace(pair), "=")
+		limit, err := strconv.Atoi(value)
+		if found && err == nil && limit > 0 {
+			config.ModelConcurrency[model] = limit
+		}
+	}
+
+	maxAttempts, err := strconv.Atoi(os.Getenv("REPLY_GUY_MAX_ATTEMPTS"))
+	if err == nil && maxAttempts > 0 {
+		config.MaxAttempts = maxAttempts
+	}
+
+	retryBase, err := time.ParseDuration(os.Getenv("REPLY_GUY_RETRY_BASE"))
+	if err == nil && retryBase > 0 {
+		config.RetryBase = retryBase
+	}
+
+	retryMax, err := time.ParseDuration(os.Getenv("REPLY_GUY_RETRY_MAX"))
+	if err == nil && retryMax > 0 {
+		config.RetryMax = retryMax
+	}
+
+	stream, err := strconv.ParseBool(os.Getenv("REPLY_GUY_STREAM"))
+	if err == nil {
+		config.Stream = stream
+	}
+
+	streamInterval, err := time.ParseDuration(os.Getenv("REPLY_GUY_STREAM_INTERVAL"))
+	if err == nil && streamInterval >= 0 {
+		config.StreamInterval = streamInterval
+	}
+
+	return config
+}
 
 type ReplyQueue struct {
-	jobs         []dtypes.ReplyGuyRequest
-	lock         sync.Mutex
-	cond         *sync.Cond
-	coreClient   *client.CoreClient
-	ollamaClient *client.OllamaClient
+	store   *Store
+	config  Config
+	metrics *Metrics
+	// wakes a waiting worker when a job is added or a model frees up,
+	// buffered so senders never block
+	notify chan struct{}
+	// jobs in flight per model, claims happen under lock so the limits hold
+	lock     sync.Mutex
+	inFlight map[string]int
+	// closed by Stop, workers finish their current job and exit
+	stopping chan struct{}
+	workers  sync.WaitGroup
+	// passed to running jobs, cancelled when Stop gives up waiting on them
+	ctx      context.Context
+	cancel   context.CancelFunc
+	now      func() time.Time
+	handle   func(context.Context, dtypes.ReplyGuyRequest) error
+	personas *persona.Registry
+	// one per persona, each posts as the persona's account
+	coreClients   map[string]*client.CoreClient
+	tokenVersions *personaTokenVersions
+	ollamaClient  *client.OllamaClient
+}
+
+// Enqueue queues a reply from request.Persona, UnknownPersonaError when it
+// isn't registered
+func (rq *ReplyQueue) Enqueue(request dtypes.ReplyGuyRequest) error {
+	persona, ok := rq.personas.Get(request.Persona)
+	if !ok {
+		return UnknownPersonaError{request.Persona}
+	}
+
+	// the registry decides the model, it's stored with the job so the
+	// per-model limits apply
+	request.Persona = persona.Username
+	request.Model = persona.Model
+	_, err := rq.store.Add(request, rq.now())
+	if err != nil {
+		return err
+	}
+
+	rq.wake()
+	return nil
 }
 
-func (rq *ReplyQueue) Enqueue(request dtypes.ReplyGuyRequest) {
+func (rq *ReplyQueue) StartWorkers() {
+	for range rq.config.Workers {
+		rq.workers.Add(1)
+		go rq.work()
+	}
+}
+
+// Stop stops claiming jobs and waits for running ones to finish. Jobs still
+// running when ctx is done are cancelled and put back in the queue for the
+// next start.
+func (rq *ReplyQueue) Stop(ctx context.Context) error {
+	close(rq.stopping)
+	done := make(chan struct{})
+	go func() {
+		rq.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		rq.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+func (rq *ReplyQueue) work() {
+	defer rq.workers.Done()
+	for !rq.stopped() {
+		processed, err := rq.processNext()
+		if err != nil {
+			logger.LogError("ReplyQueue worker - queue store error: " + err.Error())
+			// don't spin on a broken store
+			rq.sleep(rq.config.RetryBase)
+			continue
+		}
+
+		if !processed {
+			rq.wait()
+		}
+	}
+}
+
+func (rq *ReplyQueue) stopped() bool {
+	select {
+	case <-rq.stopping:
+		return true
+	default:
+		return false
+	}
+}
+
+func (rq *ReplyQueue) wake() {
+	select {
+	case rq.notify <- struct{}{}:
+	default:
+	}
+}
+
+// wait blocks until a job is added, a model frees up, the next retry is due
+// or the queue is stopping
+func (rq *ReplyQueue) wait() {
 	rq.lock.Lock()
-	rq.jobs = append(rq.jobs, request)
-	rq.cond.Signal()
+	saturated := rq.saturatedModels()
 	rq.lock.Unlock()
+
+	next, ok, err := rq.store.NextRunAt(saturated)
+	if err != nil {
+		rq.sleep(rq.config.RetryBase)
+		return
+	}
+
+	var due <-chan time.Time
+	if ok {
+		timer := time.NewTimer(next.Sub(rq.now()))
+		defer timer.Stop()
+		due = timer.C
+	}
+
+	select {
+	case <-rq.notify:
+	case <-due:
+	case <-rq.stopping:
+	}
 }
 
-func (rq *ReplyQueue) StartWorker() {
-	go func() {
-		for {
-			rq.lock.Lock()
-			for len(rq.jobs) == 0 {
-				rq.cond.Wait()
-			}
-
-			job := rq.jobs[0]
-			rq.jobs = rq.jobs[1:]
-			rq.lock.Unlock()
-
-			err := rq.process(job)
-			if err != nil {
-				// TODO: determine what to do on failed job beyond log
-			}
+func (rq *ReplyQueue) sleep(duration time.Duration) {
+	select {
+	case <-time.After(duration):
+	case <-rq.stopping:
+	}
+}
+
+// saturatedModels returns the models at their concurrency limit, rq.lock must
+// be held
+func (rq *ReplyQueue) saturatedModels() []string {
+	saturated := []string{}
+	for model, count := range rq.inFlight {
+		limit, ok := rq.config.ModelConcurrency[model]
+		if ok && count >= limit {
+			saturated = append(saturated, model)
 		}
-	}()
+	}
+
+	return saturated
+}
+
+// claim takes the next due job whose model has room
+func (rq *ReplyQueue) claim() (Job, bool, error) {
+	rq.lock.Lock()
+	defer rq.lock.Unlock()
+
+	job, ok, err := rq.store.Claim(rq.now(), rq.saturatedModels())
+	if err != nil || !ok {
+		return job, ok, err
+	}
+
+	rq.inFlight[job.Model]++
+	return job, true, nil
 }
 
-func (rq *ReplyQueue) process(job dtypes.ReplyGuyRequest) error {
+func (rq *ReplyQueue) release(model string) {
+	rq.lock.Lock()
+	rq.inFlight[model]--
+	if rq.inFlight[model] == 0 {
+		delete(rq.inFlight, model)
+	}
+	rq.lock.Unlock()
+
+	rq.wake()
+}
+
+// processNext runs the next due job, false when there wasn't one. The error is
+// only set when the store fails, failed jobs are retried or dead lettered.
+func (rq *ReplyQueue) processNext() (bool, error) {
+	job, ok, err := rq.claim()
+	if err != nil || !ok {
+		return false, err
+	}
+	defer rq.release(job.Model)
+
+	// there may be more due jobs for the other workers
+	rq.wake()
+
+	runAt, err := time.Parse(constants.TIME_LAYOUT, job.RunAt)
+	if err == nil {
+		rq.metrics.observeWait(rq.now().Sub(runAt))
+	}
+
+	var request dtypes.ReplyGuyRequest
+	err = json.Unmarshal([]byte(job.Payload), &request)
+	if err != nil {
+		return true, rq.fail(job, PermanentError{"malformed payload: " + err.Error()})
+	}
+
+	start := time.Now()
+	err = rq.handle(rq.ctx, request)
+	rq.metrics.observeProcessing(time.Since(start))
+	if err != nil {
+		if rq.ctx.Err() != nil {
+			logger.LogWarn(fmt.Sprintf("ReplyQueue.processNext() job %d interrupted by shutdown, releasing", job.ID))
+			rq.metrics.incrementReleased()
+			return true, rq.store.Release(job.ID, rq.now())
+		}
+
+		return true, rq.fail(job, err)
+	}
+
+	rq.metrics.incrementCompleted()
+	return true, rq.store.Complete(job.ID)
+}
+
+// Metrics returns the current queue depth along with the counters collected
+// since the queue started
+func (rq *ReplyQueue) Metrics() (MetricsSnapshot, error) {
+	snapshot := MetricsSnapshot{Workers: rq.config.Workers}
+	var err error
+	snapshot.Pending, snapshot.InFlight, snapshot.DeadLetters, err = rq.store.Depth()
+	if err != nil {
+		return MetricsSnapshot{}, err
+	}
+
+	rq.lock.Lock()
+	snapshot.InFlightByModel = make(map[string]int, len(rq.inFlight))
+	for model, count := range rq.inFlight {
+		snapshot.InFlightByModel[model] = count
+	}
+	rq.lock.Unlock()
+
+	rq.metrics.fill(&snapshot)
+	return snapshot, nil
+}
+
+func (rq *ReplyQueue) fail(job Job, jobError error) error {
+	var permanentError PermanentError
+	if errors.As(jobError, &permanentError) || job.Attempts >= rq.config.MaxAttempts {
+		logger.LogError(
+			fmt.Sprintf(
+				"ReplyQueue.fail() job %d failed after %d attempt(s), moving to dead letters: %s",
+				job.ID, job.Attempts, jobError.Error()))
+
+		rq.metrics.incrementDeadLettered()
+		return rq.store.DeadLetter(job.ID, jobError)
+	}
+
+	delay := rq.backoff(job.Attempts)
+	logger.LogWarn(
+		fmt.Sprintf(
+			"ReplyQueue.fail() job %d attempt %d failed, retrying in %s: %s",
+			job.ID, job.Attempts, delay, jobError.Error()))
+
+	rq.metrics.incrementRetried()
+	return rq.store.Retry(job.ID, rq.now().Add(delay), jobError)
+}
+
+// backoff returns how long to wait after the given failed attempt
+func (rq *ReplyQueue) backoff(attempts int) time.Duration {
+	delay := rq.config.RetryBase
+	for i := 1; i < attempts && delay < rq.config.RetryMax; i++ {
+		delay *= 2
+	}
+
+	return min(delay, rq.config.RetryMax)
+}
+
+func (rq *ReplyQueue) process(ctx context.Context, job dtypes.ReplyGuyRequest) error {
 	logger.LogInfo(
 		fmt.Sprintf(
-			"ReplyQueue.process() new process request for commentID: %d",
-			job.Comment.ID))
+			"ReplyQueue.process() new process request from @%s for commentID: %d",
+			job.Persona, job.Comment.ID))
+
+	// jobs queued before personas only carried the model, which was named
+	// after the persona
+	if job.Persona == "" {
+		job.Persona = job.Model
+	}
+
+	persona, ok := rq.personas.Get(job.Persona)
+	if !ok {
+		return PermanentError{UnknownPersonaError{job.Persona}.Error()}
+	}
+
+	rq.tokenVersions.observe(persona.Username, job.PersonaTokenVersion)
+	coreClient := rq.coreClients[persona.Username]
+	thread, err := coreClient.GetThread(ctx, job.ParentPost.ID)
+	if err != nil {
+		return coreError("fetching thread", err)
+	}
+
+	messages, err := persona.Messages(job, thread)
+	if err != nil {
+		return PermanentError{"rendering prompt: " + err.Error()}
+	}
 
-	modelResponse, err := rq.ollamaClient.Prompt(job)
+	if rq.config.Stream {
+		return rq.streamReply(ctx, coreClient, persona.Model, job, messages)
+	}
+
+	modelResponse, err := rq.ollamaClient.Chat(ctx, persona.Model, messages)
 	if err != nil {
 		return err
 	}
 
-	resp, err := rq.coreClient.PostComment(
-		job.ParentPost.ID, job.ParentComment.ID, modelResponse.Response)
+	resp, err := coreClient.PostComment(
+		ctx, job.ParentPost.ID, job.ParentComment.ID, modelResponse.Message.Content)
 
 	if err != nil {
 		return err
 	}
+	defer resp.Body.Close()
+
+	// the core service answers 4xx for comments it will never accept (the
+	// post is gone, a block), only server errors are worth retrying
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("core service returned status %d", resp.StatusCode)
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return PermanentError{fmt.Sprintf("core service rejected comment with status %d", resp.StatusCode)}
+	}
+
+	return nil
+}
+
+// streamReply posts a placeholder comment and fills it in as the model
+// writes. The placeholder is deleted when the reply fails so a retry starts
+// over with a new one.
+func (rq *ReplyQueue) streamReply(
+	ctx context.Context,
+	coreClient *client.CoreClient,
+	model string,
+	job dtypes.ReplyGuyRequest,
+	messages []dtypes.OllamaMessage,
+) error {
+	commentID, err := coreClient.CreateGeneratingComment(ctx, job.ParentPost.ID, job.ParentComment.ID)
+	if err != nil {
+		return coreError("creating comment", err)
+	}
+
+	lastUpdate := time.Now()
+	modelResponse, err := rq.ollamaClient.ChatStream(ctx, model, messages, func(content string) error {
+		if time.Since(lastUpdate) < rq.config.StreamInterval {
+			return nil
+		}
 
-	if resp.StatusCode != 200 {
-		logger.LogWarn("ReplyQueue.process() no error, but non-200 status code")
+		lastUpdate = time.Now()
+		return coreClient.UpdateGeneratingComment(ctx, commentID, content, false)
+	})
+
+	if err == nil {
+		err = coreClient.UpdateGeneratingComment(ctx, commentID, modelResponse.Message.Content, true)
+	}
+
+	if err != nil {
+		cleanupCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), STREAM_CLEANUP_TIMEOUT)
+		defer cancel()
+
+		deleteErr := coreClient.DeleteComment(cleanupCtx, commentID)
+		if deleteErr != nil {
+			logger.LogError(fmt.Sprintf(
+				"ReplyQueue.streamReply() error deleting comment %d: %s", commentID, deleteErr.Error()))
+		}
+
+		return coreError("streaming comment", err)
 	}
 
 	return nil
 }
 
-func NewReplyQueue() *ReplyQueue {
-	// TODO: need to modularize JWT + handle expiration date
-	dalecooperJWT, err := api.GenerateJWT(constants.DALE_COOPER_USER_ID)
+// coreError makes 4xx responses from the core service permanent, it won't
+// accept the request however many times it's retried
+func coreError(action string, err error) error {
+	var statusError client.StatusError
+	if errors.As(err, &statusError) && statusError.StatusCode < http.StatusInternalServerError {
+		return PermanentError{action + ": " + err.Error()}
+	}
+
+	return err
+}
+
+// NewReplyQueue returns a queue backed by db that replies as personas. Jobs a
+// previous run left in flight are queued again.
+func NewReplyQueue(db *sql.DB, config Config, personas *persona.Registry) (*ReplyQueue, error) {
+	store, err := NewStore(db)
+	if err != nil {
+		return nil, err
+	}
+
+	replyQueue := newReplyQueue(store, config, personas)
+	recovered, err := store.Recover(replyQueue.now())
 	if err != nil {
-		panic(err)
+		return nil, err
+	}
+
+	if recovered > 0 {
+		logger.LogInfo(fmt.Sprintf("NewReplyQueue() recovered %d in flight job(s)", recovered))
+	}
+
+	for _, persona := range personas.Personas() {
+		replyQueue.coreClients[persona.Username] = client.NewCoreClient(
+			personaTokenSource(replyQueue.tokenVersions, persona.Username, persona.UserID))
+	}
+	replyQueue.ollamaClient = client.NewOllamaClient()
+	replyQueue.handle = replyQueue.process
+
+	return replyQueue, nil
+}
+
+// personaTokenSource signs tokens for userID at the newest token version the
+// core service sent for the persona. The queue has its own database, the
+// version comes in with each request so revoking the persona's sessions
+// doesn't lock reply-guy out.
+func personaTokenSource(tokenVersions *personaTokenVersions, username string, userID int) client.TokenSource {
+	return func() (string, error) {
+		return api.GenerateJWT(userID, tokenVersions.get(username))
 	}
+}
+
+// personaTokenVersions tracks the newest token version seen for each persona,
+// versions only go up so jobs queued before a bump can't roll it back
+type personaTokenVersions struct {
+	lock     sync.Mutex
+	versions map[string]int
+}
 
-	coreClient := client.NewCoreClient(dalecooperJWT)
-	ollamaClient := client.NewOllamaClient()
-	jobs := []dtypes.ReplyGuyRequest{}
+func (tv *personaTokenVersions) observe(username string, version int) {
+	tv.lock.Lock()
+	defer tv.lock.Unlock()
 
-	replyQueue := &ReplyQueue{
-		jobs:         jobs,
-		coreClient:   coreClient,
-		ollamaClient: ollamaClient,
+	if version > tv.versions[username] {
+		tv.versions[username] = version
 	}
-	replyQueue.cond = sync.NewCond(&replyQueue.lock)
+}
+
+func (tv *personaTokenVersions) get(username string) int {
+	tv.lock.Lock()
+	defer tv.lock.Unlock()
 
-	return replyQueue
+	return tv.versions[username]
+}
+
+func newReplyQueue(store *Store, config Config, personas *persona.Registry) *ReplyQueue {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &ReplyQueue{
+		store:         store,
+		config:        config,
+		metrics:       &Metrics{},
+		notify:        make(chan struct{}, 1),
+		inFlight:      map[string]int{},
+		stopping:      make(chan struct{}),
+		ctx:           ctx,
+		cancel:        cancel,
+		now:           time.Now,
+		personas:      personas,
+		coreClients:   map[string]*client.CoreClient{},
+		tokenVersions: &personaTokenVersions{versions: map[string]int{}},
+	}
 }
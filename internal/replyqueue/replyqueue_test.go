@@ -1,46 +1,467 @@
 package replyqueue
 
 import (
-	"sync"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/golang-jwt/jwt"
+	"github.com/marcusprice/twitter-clone/internal/api"
+	"github.com/marcusprice/twitter-clone/internal/client"
 	"github.com/marcusprice/twitter-clone/internal/dtypes"
+	"github.com/marcusprice/twitter-clone/internal/persona"
 	"github.com/marcusprice/twitter-clone/internal/testutil"
+	_ "github.com/mattn/go-sqlite3"
 )
 
+func withTestQueue(t *testing.T, testFunc func(db *sql.DB, rq *ReplyQueue, now *time.Time)) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal("failed to open in memory db:", err)
+	}
+	defer db.Close()
+	// every connection to :memory: is a separate database
+	db.SetMaxOpenConns(1)
+
+	store, err := NewStore(db)
+	if err != nil {
+		t.Fatal("failed to create store:", err)
+	}
+
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	personas, err := persona.NewRegistry([]persona.Persona{
+		{Username: "dalecooper", Model: "dalecooper", UserID: 3},
+		{Username: "logladyai", Model: "llama3", UserID: 7},
+	})
+	if err != nil {
+		t.Fatal("failed to create personas:", err)
+	}
+
+	rq := newReplyQueue(store, Config{MaxAttempts: 3, RetryBase: time.Second, RetryMax: 3 * time.Second}, personas)
+	rq.now = func() time.Time { return now }
+	rq.handle = func(context.Context, dtypes.ReplyGuyRequest) error { return nil }
+	testFunc(db, rq, &now)
+}
+
+func newTestRequest(content string) dtypes.ReplyGuyRequest {
+	return dtypes.ReplyGuyRequest{Persona: "dalecooper", Comment: dtypes.ReplyGuyComment{Content: content}}
+}
+
 func TestReplyQueueEnqueue(t *testing.T) {
 	tu := testutil.NewTestUtil(t)
-	jobs := []dtypes.ReplyGuyRequest{}
-	rq := &ReplyQueue{jobs: jobs}
-	rq.cond = sync.NewCond(&rq.lock)
-	comment := dtypes.ReplyGuyComment{Content: "yodel"}
-	newJob := dtypes.ReplyGuyRequest{Comment: comment}
-	rq.Enqueue(newJob)
-	tu.AssertEqual("yodel", rq.jobs[0].Comment.Content)
-
-	comment = dtypes.ReplyGuyComment{Content: "1"}
-	newJob = dtypes.ReplyGuyRequest{Comment: comment}
-	rq.Enqueue(newJob)
-
-	comment = dtypes.ReplyGuyComment{Content: "2"}
-	newJob = dtypes.ReplyGuyRequest{Comment: comment}
-	rq.Enqueue(newJob)
-
-	comment = dtypes.ReplyGuyComment{Content: "3"}
-	newJob = dtypes.ReplyGuyRequest{Comment: comment}
-	rq.Enqueue(newJob)
-
-	comment = dtypes.ReplyGuyComment{Content: "4"}
-	newJob = dtypes.ReplyGuyRequest{Comment: comment}
-	rq.Enqueue(newJob)
-
-	comment = dtypes.ReplyGuyComment{Content: "5"}
-	newJob = dtypes.ReplyGuyRequest{Comment: comment}
-	rq.Enqueue(newJob)
-
-	tu.AssertEqual("1", rq.jobs[1].Comment.Content)
-	tu.AssertEqual("2", rq.jobs[2].Comment.Content)
-	tu.AssertEqual("3", rq.jobs[3].Comment.Content)
-	tu.AssertEqual("4", rq.jobs[4].Comment.Content)
-	tu.AssertEqual("5", rq.jobs[5].Comment.Content)
+	withTestQueue(t, func(db *sql.DB, rq *ReplyQueue, now *time.Time) {
+		for _, content := range []string{"yodel", "1", "2", "3"} {
+			tu.AssertErrorNil(rq.Enqueue(newTestRequest(content)))
+		}
+
+		handled := []string{}
+		rq.handle = func(ctx context.Context, request dtypes.ReplyGuyRequest) error {
+			handled = append(handled, request.Comment.Content)
+			return nil
+		}
+
+		for {
+			processed, err := rq.processNext()
+			tu.AssertErrorNil(err)
+			if !processed {
+				break
+			}
+		}
+
+		tu.AssertEqual(4, len(handled))
+		tu.AssertEqual("yodel", handled[0])
+		tu.AssertEqual("1", handled[1])
+		tu.AssertEqual("2", handled[2])
+		tu.AssertEqual("3", handled[3])
+
+		// completed jobs are removed
+		_, ok, err := rq.store.NextRunAt(nil)
+		tu.AssertErrorNil(err)
+		tu.AssertFalse(ok)
+	})
+}
+
+func TestReplyQueueRetry(t *testing.T) {
+	tu := testutil.NewTestUtil(t)
+	withTestQueue(t, func(db *sql.DB, rq *ReplyQueue, now *time.Time) {
+		tu.AssertErrorNil(rq.Enqueue(newTestRequest("yodel")))
+
+		calls := 0
+		rq.handle = func(context.Context, dtypes.ReplyGuyRequest) error {
+			calls++
+			return errors.New("ollama is down")
+		}
+
+		processed, err := rq.processNext()
+		tu.AssertErrorNil(err)
+		tu.AssertTrue(processed)
+
+		// first retry waits RetryBase
+		next, ok, err := rq.store.NextRunAt(nil)
+		tu.AssertErrorNil(err)
+		tu.AssertTrue(ok)
+		tu.AssertTrue(next.Equal(now.Add(time.Second)))
+
+		processed, err = rq.processNext()
+		tu.AssertErrorNil(err)
+		tu.AssertFalse(processed)
+
+		*now = next
+		processed, err = rq.processNext()
+		tu.AssertErrorNil(err)
+		tu.AssertTrue(processed)
+
+		// the delay doubles
+		next, _, err = rq.store.NextRunAt(nil)
+		tu.AssertErrorNil(err)
+		tu.AssertTrue(next.Equal(now.Add(2 * time.Second)))
+
+		var attempts int
+		var lastError string
+		err = db.QueryRow("SELECT attempts, last_error FROM ReplyJob").Scan(&attempts, &lastError)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(2, attempts)
+		tu.AssertEqual("ollama is down", lastError)
+
+		// the third attempt is the last
+		*now = next
+		processed, err = rq.processNext()
+		tu.AssertErrorNil(err)
+		tu.AssertTrue(processed)
+		tu.AssertEqual(3, calls)
+
+		_, ok, err = rq.store.NextRunAt(nil)
+		tu.AssertErrorNil(err)
+		tu.AssertFalse(ok)
+
+		deadLetters, err := rq.store.DeadLetters()
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(1, len(deadLetters))
+		tu.AssertEqual(3, deadLetters[0].Attempts)
+		tu.AssertEqual("ollama is down", deadLetters[0].Error)
+		tu.AssertTrue(strings.Contains(deadLetters[0].Payload, "yodel"))
+	})
+}
+
+func TestReplyQueuePermanentError(t *testing.T) {
+	tu := testutil.NewTestUtil(t)
+	withTestQueue(t, func(db *sql.DB, rq *ReplyQueue, now *time.Time) {
+		tu.AssertErrorNil(rq.Enqueue(newTestRequest("yodel")))
+		rq.handle = func(context.Context, dtypes.ReplyGuyRequest) error {
+			return PermanentError{"core service rejected comment with status 403"}
+		}
+
+		processed, err := rq.processNext()
+		tu.AssertErrorNil(err)
+		tu.AssertTrue(processed)
+
+		deadLetters, err := rq.store.DeadLetters()
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(1, len(deadLetters))
+		tu.AssertEqual(1, deadLetters[0].Attempts)
+	})
+}
+
+func TestReplyQueueBackoff(t *testing.T) {
+	tu := testutil.NewTestUtil(t)
+	rq := newReplyQueue(nil, Config{MaxAttempts: 10, RetryBase: time.Second, RetryMax: 5 * time.Second}, nil)
+	tu.AssertEqual(time.Second, rq.backoff(1))
+	tu.AssertEqual(2*time.Second, rq.backoff(2))
+	tu.AssertEqual(4*time.Second, rq.backoff(3))
+	tu.AssertEqual(5*time.Second, rq.backoff(4))
+	tu.AssertEqual(5*time.Second, rq.backoff(40))
+}
+
+func TestReplyQueueRecover(t *testing.T) {
+	tu := testutil.NewTestUtil(t)
+	withTestQueue(t, func(db *sql.DB, rq *ReplyQueue, now *time.Time) {
+		tu.AssertErrorNil(rq.Enqueue(newTestRequest("yodel")))
+		tu.AssertErrorNil(rq.Enqueue(newTestRequest("1")))
+
+		// simulate a shutdown mid job
+		job, ok, err := rq.store.Claim(*now, nil)
+		tu.AssertErrorNil(err)
+		tu.AssertTrue(ok)
+		tu.AssertTrue(strings.Contains(job.Payload, "yodel"))
+
+		_, ok, err = rq.store.Claim(*now, nil)
+		tu.AssertErrorNil(err)
+		tu.AssertTrue(ok)
+
+		_, ok, err = rq.store.Claim(*now, nil)
+		tu.AssertErrorNil(err)
+		tu.AssertFalse(ok)
+
+		*now = now.Add(time.Minute)
+		recovered, err := rq.store.Recover(*now)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(2, recovered)
+
+		job, ok, err = rq.store.Claim(*now, nil)
+		tu.AssertErrorNil(err)
+		tu.AssertTrue(ok)
+		tu.AssertTrue(strings.Contains(job.Payload, "yodel"))
+		tu.AssertEqual(2, job.Attempts)
+	})
+}
+
+func newTestPersonaRequest(content, persona string) dtypes.ReplyGuyRequest {
+	request := newTestRequest(content)
+	request.Persona = persona
+	return request
+}
+
+func TestReplyQueueModelConcurrency(t *testing.T) {
+	tu := testutil.NewTestUtil(t)
+	withTestQueue(t, func(db *sql.DB, rq *ReplyQueue, now *time.Time) {
+		rq.config.ModelConcurrency = map[string]int{"dalecooper": 1}
+		tu.AssertErrorNil(rq.Enqueue(newTestPersonaRequest("yodel", "dalecooper")))
+		tu.AssertErrorNil(rq.Enqueue(newTestPersonaRequest("1", "dalecooper")))
+		tu.AssertErrorNil(rq.Enqueue(newTestPersonaRequest("2", "logladyai")))
+
+		first, ok, err := rq.claim()
+		tu.AssertErrorNil(err)
+		tu.AssertTrue(ok)
+		tu.AssertEqual("dalecooper", first.Model)
+
+		// dalecooper is at its limit, the llama3 job jumps ahead
+		job, ok, err := rq.claim()
+		tu.AssertErrorNil(err)
+		tu.AssertTrue(ok)
+		tu.AssertEqual("llama3", job.Model)
+
+		_, ok, err = rq.claim()
+		tu.AssertErrorNil(err)
+		tu.AssertFalse(ok)
+
+		// the waiting job isn't due for a worker until dalecooper frees up
+		_, ok, err = rq.store.NextRunAt([]string{"dalecooper"})
+		tu.AssertErrorNil(err)
+		tu.AssertFalse(ok)
+
+		tu.AssertErrorNil(rq.store.Complete(first.ID))
+		rq.release("dalecooper")
+		job, ok, err = rq.claim()
+		tu.AssertErrorNil(err)
+		tu.AssertTrue(ok)
+		tu.AssertTrue(strings.Contains(job.Payload, `"Content":"1"`))
+
+		metrics, err := rq.Metrics()
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(0, metrics.Pending)
+		tu.AssertEqual(2, metrics.InFlight)
+		tu.AssertEqual(1, metrics.InFlightByModel["dalecooper"])
+		tu.AssertEqual(1, metrics.InFlightByModel["llama3"])
+	})
+}
+
+func TestReplyQueueEnqueuePersona(t *testing.T) {
+	tu := testutil.NewTestUtil(t)
+	withTestQueue(t, func(db *sql.DB, rq *ReplyQueue, now *time.Time) {
+		err := rq.Enqueue(newTestPersonaRequest("yodel", "nobody"))
+		var unknownPersonaError UnknownPersonaError
+		tu.AssertTrue(errors.As(err, &unknownPersonaError))
+		tu.AssertEqual("nobody", unknownPersonaError.Persona)
+
+		// the model always comes from the registry
+		request := newTestPersonaRequest("yodel", "@LogLadyAI")
+		request.Model = "dalecooper"
+		tu.AssertErrorNil(rq.Enqueue(request))
+
+		job, ok, err := rq.store.Claim(*now, nil)
+		tu.AssertErrorNil(err)
+		tu.AssertTrue(ok)
+		tu.AssertEqual("llama3", job.Model)
+		tu.AssertTrue(strings.Contains(job.Payload, `"persona":"logladyai"`))
+	})
+}
+
+func TestReplyQueueStop(t *testing.T) {
+	tu := testutil.NewTestUtil(t)
+	withTestQueue(t, func(db *sql.DB, rq *ReplyQueue, now *time.Time) {
+		rq.config.Workers = 2
+		started := make(chan string, 2)
+		rq.handle = func(ctx context.Context, request dtypes.ReplyGuyRequest) error {
+			started <- request.Comment.Content
+			if request.Comment.Content == "quick" {
+				return nil
+			}
+
+			<-ctx.Done()
+			return ctx.Err()
+		}
+
+		tu.AssertErrorNil(rq.Enqueue(newTestRequest("quick")))
+		tu.AssertErrorNil(rq.Enqueue(newTestRequest("slow")))
+		rq.StartWorkers()
+		<-started
+		<-started
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		err := rq.Stop(ctx)
+		tu.AssertTrue(errors.Is(err, context.DeadlineExceeded))
+
+		// the quick job finished, the slow one was put back without using up
+		// an attempt
+		var content string
+		var attempts int
+		var status string
+		err = db.QueryRow(
+			"SELECT json_extract(payload, '$.comment.Content'), attempts, status FROM ReplyJob",
+		).Scan(&content, &attempts, &status)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual("slow", content)
+		tu.AssertEqual(0, attempts)
+		tu.AssertEqual("pending", status)
+
+		metrics, err := rq.Metrics()
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(1, metrics.Completed)
+		tu.AssertEqual(1, metrics.Released)
+		tu.AssertEqual(1, metrics.Pending)
+		tu.AssertEqual(0, len(metrics.InFlightByModel))
+	})
+}
+
+func TestReplyQueueConfigFromEnv(t *testing.T) {
+	tu := testutil.NewTestUtil(t)
+	t.Setenv("REPLY_GUY_WORKERS", "4")
+	t.Setenv("REPLY_GUY_MODEL_CONCURRENCY", "dalecooper=1, llama3=2,broken,zero=0")
+	t.Setenv("REPLY_GUY_MAX_ATTEMPTS", "nope")
+
+	config := ConfigFromEnv()
+	tu.AssertEqual(4, config.Workers)
+	tu.AssertEqual(2, len(config.ModelConcurrency))
+	tu.AssertEqual(1, config.ModelConcurrency["dalecooper"])
+	tu.AssertEqual(2, config.ModelConcurrency["llama3"])
+	tu.AssertEqual(DEFAULT_MAX_ATTEMPTS, config.MaxAttempts)
+	tu.AssertFalse(config.Stream)
+	tu.AssertEqual(DEFAULT_STREAM_INTERVAL, config.StreamInterval)
+
+	t.Setenv("REPLY_GUY_STREAM", "true")
+	t.Setenv("REPLY_GUY_STREAM_INTERVAL", "1s")
+	config = ConfigFromEnv()
+	tu.AssertTrue(config.Stream)
+	tu.AssertEqual(time.Second, config.StreamInterval)
+}
+
+func TestReplyQueueStreamReply(t *testing.T) {
+	withTestQueue(t, func(_ *sql.DB, rq *ReplyQueue, _ *time.Time) {
+		tu := testutil.NewTestUtil(t)
+		var updates []dtypes.GenerationInput
+		deleted := false
+		ollamaStatus := http.StatusOK
+		finishStatus := http.StatusOK
+
+		// stands in for both ollama and the core service
+		mux := http.NewServeMux()
+		mux.HandleFunc("POST /api/chat", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(ollamaStatus)
+			fmt.Fprintln(w, `{"message": {"role": "assistant", "content": "the owls"}, "done": false}`)
+			fmt.Fprintln(w, `{"message": {"role": "assistant", "content": " are not"}, "done": false}`)
+			fmt.Fprintln(w, `{"message": {"role": "assistant", "content": ""}, "done": true}`)
+		})
+		mux.HandleFunc("POST /api/v1/comment/create", func(w http.ResponseWriter, r *http.Request) {
+			tu.AssertEqual("true", r.FormValue("generating"))
+			fmt.Fprint(w, `{"commentID": 99}`)
+		})
+		mux.HandleFunc("PATCH /api/v1/comment/99/stream", func(w http.ResponseWriter, r *http.Request) {
+			var update dtypes.GenerationInput
+			json.NewDecoder(r.Body).Decode(&update)
+			updates = append(updates, update)
+			if update.Done {
+				w.WriteHeader(finishStatus)
+			}
+		})
+		mux.HandleFunc("DELETE /api/v1/comment/99", func(w http.ResponseWriter, r *http.Request) {
+			deleted = true
+			w.WriteHeader(http.StatusNoContent)
+		})
+		server := httptest.NewServer(mux)
+		defer server.Close()
+
+		host, port, _ := net.SplitHostPort(strings.TrimPrefix(server.URL, "http://"))
+		t.Setenv("OLLAMA_HOST", host)
+		t.Setenv("OLLAMA_PORT", port)
+		t.Setenv("HOST", host)
+		t.Setenv("PORT", port)
+		rq.ollamaClient = client.NewOllamaClient()
+		coreClient := client.NewCoreClient(func() (string, error) { return "token", nil })
+		rq.config.StreamInterval = 0
+
+		err := rq.streamReply(context.Background(), coreClient, "dalecooper", newTestRequest("@dalecooper"), nil)
+		tu.AssertErrorNil(err)
+		tu.AssertEqual(3, len(updates))
+		tu.AssertEqual("the owls", updates[0].Content)
+		tu.AssertEqual("the owls are not", updates[1].Content)
+		tu.AssertFalse(updates[1].Done)
+		tu.AssertEqual("the owls are not", updates[2].Content)
+		tu.AssertTrue(updates[2].Done)
+		tu.AssertFalse(deleted)
+
+		// failed generations are retried from a new comment
+		ollamaStatus = http.StatusInternalServerError
+		err = rq.streamReply(context.Background(), coreClient, "dalecooper", newTestRequest("@dalecooper"), nil)
+		tu.AssertErrorNotNil(err)
+		tu.AssertFalse(errors.As(err, &PermanentError{}))
+		tu.AssertTrue(deleted)
+
+		// the core service refusing the reply isn't
+		ollamaStatus = http.StatusOK
+		finishStatus = http.StatusBadRequest
+		deleted = false
+		err = rq.streamReply(context.Background(), coreClient, "dalecooper", newTestRequest("@dalecooper"), nil)
+		tu.AssertTrue(errors.As(err, &PermanentError{}))
+		tu.AssertTrue(deleted)
+	})
+}
+
+func TestPersonaTokenVersion(t *testing.T) {
+	tu := testutil.NewTestUtil(t)
+	// only the queue's tables, like REPLY_GUY_DB_PATH
+	db, err := sql.Open("sqlite3", ":memory:")
+	tu.AssertErrorNil(err)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	// the core service, it records the token version reply-guy signed with
+	tokenVersions := []float64{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/post/{postID}", func(w http.ResponseWriter, r *http.Request) {
+		token, err := api.ParseJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+		tu.AssertErrorNil(err)
+		tokenVersions = append(tokenVersions, token.Claims.(jwt.MapClaims)["ver"].(float64))
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	host, port, _ := net.SplitHostPort(strings.TrimPrefix(server.URL, "http://"))
+	t.Setenv("HOST", host)
+	t.Setenv("PORT", port)
+	t.Setenv("JWT_KEY", "test-key")
+	personas, err := persona.NewRegistry([]persona.Persona{{Username: "dalecooper", Model: "dalecooper", UserID: 3}})
+	tu.AssertErrorNil(err)
+	rq, err := NewReplyQueue(db, Config{MaxAttempts: 3}, personas)
+	tu.AssertErrorNil(err)
+
+	request := newTestRequest("@dalecooper")
+	request.PersonaTokenVersion = 2
+	tu.AssertErrorNotNil(rq.process(context.Background(), request))
+
+	// a job queued before the persona's sessions were revoked keeps the newer version
+	request.PersonaTokenVersion = 1
+	tu.AssertErrorNotNil(rq.process(context.Background(), request))
+	tu.AssertEqual(2, len(tokenVersions))
+	tu.AssertEqual(float64(2), tokenVersions[0])
+	tu.AssertEqual(float64(2), tokenVersions[1])
 }
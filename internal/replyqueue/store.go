@@ -0,0 +1,277 @@
+package replyqueue
+
+import (
+	"database/sql"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/marcusprice/twitter-clone/internal/constants"
+	"github.com/marcusprice/twitter-clone/internal/dtypes"
+	"github.com/marcusprice/twitter-clone/internal/logger"
+)
+
+// Job is a queued reply request, Attempts counts the current attempt once
+// the job has been claimed
+type Job struct {
+	ID        int
+	Payload   string
+	Model     string
+	Attempts  int
+	RunAt     string
+	LastError string
+}
+
+type DeadLetter struct {
+	ID        int
+	JobID     int
+	Payload   string
+	Attempts  int
+	Error     string
+	CreatedAt string
+	FailedAt  string
+}
+
+// Store persists queued jobs in sqlite. Jobs are pending until a worker claims
+// them, in flight while being processed, and removed once they succeed or
+// are moved to the dead letter table.
+type Store struct {
+	db *sql.DB
+}
+
+//go:embed queries/schema.sql
+var schemaQuery string
+
+//go:embed queries/create-job.sql
+var createJobQuery string
+
+// Add queues request to run at runAt
+func (s *Store) Add(request dtypes.ReplyGuyRequest, runAt time.Time) (int, error) {
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return 0, err
+	}
+
+	var jobID int
+	err = s.db.QueryRow(createJobQuery, string(payload), formatTime(runAt)).Scan(&jobID)
+	if err != nil {
+		logger.LogError("Store.Add() - error creating job: " + err.Error())
+		return 0, err
+	}
+
+	return jobID, nil
+}
+
+//go:embed queries/claim-job.sql
+var claimJobQuery string
+
+// Claim marks the oldest pending job that is due by now as in flight and
+// returns it, false when no job is due. Jobs for excludedModels are skipped.
+func (s *Store) Claim(now time.Time, excludedModels []string) (Job, bool, error) {
+	excluded, err := modelList(excludedModels)
+	if err != nil {
+		return Job{}, false, err
+	}
+
+	var job Job
+	var lastError sql.NullString
+	err = s.db.QueryRow(claimJobQuery, formatTime(now), excluded).
+		Scan(&job.ID, &job.Payload, &job.Model, &job.Attempts, &job.RunAt, &lastError)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Job{}, false, nil
+		}
+
+		logger.LogError("Store.Claim() - error claiming job: " + err.Error())
+		return Job{}, false, err
+	}
+
+	job.LastError = lastError.String
+	return job, true, nil
+}
+
+//go:embed queries/delete-job.sql
+var deleteJobQuery string
+
+// Complete removes a job that was processed successfully
+func (s *Store) Complete(jobID int) error {
+	_, err := s.db.Exec(deleteJobQuery, jobID)
+	if err != nil {
+		logger.LogError("Store.Complete() - error deleting job: " + err.Error())
+	}
+
+	return err
+}
+
+//go:embed queries/update-job-retry.sql
+var updateJobRetryQuery string
+
+// Retry puts a failed job back in the queue to run again at runAt
+func (s *Store) Retry(jobID int, runAt time.Time, jobError error) error {
+	_, err := s.db.Exec(updateJobRetryQuery, formatTime(runAt), jobError.Error(), jobID)
+	if err != nil {
+		logger.LogError("Store.Retry() - error rescheduling job: " + err.Error())
+	}
+
+	return err
+}
+
+//go:embed queries/create-dead-letter.sql
+var createDeadLetterQuery string
+
+// DeadLetter moves a job that won't be retried to the dead letter table
+func (s *Store) DeadLetter(jobID int, jobError error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(createDeadLetterQuery, jobError.Error(), jobID)
+	if err != nil {
+		logger.LogError("Store.DeadLetter() - error creating dead letter: " + err.Error())
+		return err
+	}
+
+	_, err = tx.Exec(deleteJobQuery, jobID)
+	if err != nil {
+		logger.LogError("Store.DeadLetter() - error deleting job: " + err.Error())
+		return err
+	}
+
+	return tx.Commit()
+}
+
+//go:embed queries/update-release-job.sql
+var updateReleaseJobQuery string
+
+// Release puts an in flight job back in the queue, due at now, without
+// counting the attempt
+func (s *Store) Release(jobID int, now time.Time) error {
+	_, err := s.db.Exec(updateReleaseJobQuery, formatTime(now), jobID)
+	if err != nil {
+		logger.LogError("Store.Release() - error releasing job: " + err.Error())
+	}
+
+	return err
+}
+
+//go:embed queries/update-recover-jobs.sql
+var updateRecoverJobsQuery string
+
+// Recover returns jobs left in flight by a previous run to the queue, due at
+// now. Only call it before any workers start.
+func (s *Store) Recover(now time.Time) (int, error) {
+	result, err := s.db.Exec(updateRecoverJobsQuery, formatTime(now))
+	if err != nil {
+		logger.LogError("Store.Recover() - error recovering jobs: " + err.Error())
+		return 0, err
+	}
+
+	recovered, err := result.RowsAffected()
+	return int(recovered), err
+}
+
+//go:embed queries/select-next-run-at.sql
+var selectNextRunAtQuery string
+
+// NextRunAt returns when the next pending job not for excludedModels is due,
+// false when there isn't one
+func (s *Store) NextRunAt(excludedModels []string) (time.Time, bool, error) {
+	excluded, err := modelList(excludedModels)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	var runAt sql.NullString
+	err = s.db.QueryRow(selectNextRunAtQuery, excluded).Scan(&runAt)
+	if err != nil {
+		logger.LogError("Store.NextRunAt() - error selecting next run at: " + err.Error())
+		return time.Time{}, false, err
+	}
+
+	if !runAt.Valid {
+		return time.Time{}, false, nil
+	}
+
+	next, err := time.Parse(constants.TIME_LAYOUT, runAt.String)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	return next, true, nil
+}
+
+//go:embed queries/select-queue-depth.sql
+var selectQueueDepthQuery string
+
+// Depth returns the number of pending, in flight and dead lettered jobs
+func (s *Store) Depth() (pending, inFlight, deadLetters int, err error) {
+	err = s.db.QueryRow(selectQueueDepthQuery).Scan(&pending, &inFlight, &deadLetters)
+	if err != nil {
+		logger.LogError("Store.Depth() - error selecting queue depth: " + err.Error())
+	}
+
+	return pending, inFlight, deadLetters, err
+}
+
+//go:embed queries/select-dead-letters.sql
+var selectDeadLettersQuery string
+
+func (s *Store) DeadLetters() ([]DeadLetter, error) {
+	rows, err := s.db.Query(selectDeadLettersQuery)
+	if err != nil {
+		logger.LogError("Store.DeadLetters() - error selecting dead letters: " + err.Error())
+		return []DeadLetter{}, err
+	}
+	defer rows.Close()
+
+	deadLetters := []DeadLetter{}
+	for rows.Next() {
+		var deadLetter DeadLetter
+		err := rows.Scan(
+			&deadLetter.ID,
+			&deadLetter.JobID,
+			&deadLetter.Payload,
+			&deadLetter.Attempts,
+			&deadLetter.Error,
+			&deadLetter.CreatedAt,
+			&deadLetter.FailedAt,
+		)
+		if err != nil {
+			return []DeadLetter{}, err
+		}
+
+		deadLetters = append(deadLetters, deadLetter)
+	}
+
+	return deadLetters, rows.Err()
+}
+
+// models are passed to queries as a json array, never null so NOT IN holds
+// for an empty list
+func modelList(models []string) (string, error) {
+	if models == nil {
+		models = []string{}
+	}
+
+	list, err := json.Marshal(models)
+	return string(list), err
+}
+
+// run_at is compared as text, times are stored in UTC like CURRENT_TIMESTAMP
+func formatTime(t time.Time) string {
+	return t.UTC().Format(constants.TIME_LAYOUT)
+}
+
+// NewStore creates the queue tables if they don't exist yet
+func NewStore(db *sql.DB) (*Store, error) {
+	_, err := db.Exec(schemaQuery)
+	if err != nil {
+		logger.LogError("NewStore() - error creating queue tables: " + err.Error())
+		return nil, err
+	}
+
+	return &Store{db: db}, nil
+}
@@ -129,7 +129,7 @@ func QueryUserFollowers(followeeID int, db *sql.DB) []dtypes.UserData {
 		var last_name string
 		var display_name string
 		var password string
-		var last_login string
+		var last_login sql.NullString
 		var is_active int
 		var created_at string
 		var updated_at string
@@ -149,7 +149,7 @@ func QueryUserFollowers(followeeID int, db *sql.DB) []dtypes.UserData {
 			LastName:    last_name,
 			DisplayName: display_name,
 			Password:    password,
-			LastLogin:   last_login,
+			LastLogin:   last_login.String,
 			IsActive:    is_active,
 			CreatedAt:   created_at,
 			UpdatedAt:   updated_at,
@@ -2,7 +2,10 @@ package testutil
 
 import (
 	"database/sql"
+	"io"
 	"io/fs"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -87,6 +90,27 @@ func NewTestUtil(t *testing.T) TestUtil {
 	return TestUtil{t}
 }
 
+// NewRequest builds a request carrying token as a bearer token, target is a
+// path for ServeRequest or a full url for a live test server
+func NewRequest(method, target, token string, body io.Reader) *http.Request {
+	req, err := http.NewRequest(method, target, body)
+	if err != nil {
+		panic(err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req
+}
+
+// ServeRequest runs a request with token as its bearer token through handler
+func ServeRequest(handler http.Handler, method, path, token string, body io.Reader) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, body)
+	req.Header.Set("Authorization", "Bearer "+token)
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	return res
+}
+
 func setTestEnvironment() {
 	os.Setenv("ENV", constants.TEST_ENV)
 }
@@ -0,0 +1,28 @@
+package util
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// a hashtag is a # followed by word characters, and must not be preceded by a
+// word character, & or another # (i.e. urls#fragments, &#39;, ##double)
+var hashtagRegex = regexp.MustCompile(`(?:^|[^A-Za-z0-9_&#])#([A-Za-z0-9_]+)`)
+
+var hashtagLetterRegex = regexp.MustCompile(`[A-Za-z]`)
+
+// ParseHashtags returns the unique, lowercased tags (without the #) in
+// content, in the order they first appear. Tags without a letter (#1, #2024)
+// aren't hashtags.
+func ParseHashtags(content string) []string {
+	tags := []string{}
+	for _, match := range hashtagRegex.FindAllStringSubmatch(content, -1) {
+		tag := strings.ToLower(match[1])
+		if hashtagLetterRegex.MatchString(tag) && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
@@ -0,0 +1,46 @@
+package util
+
+import (
+	"regexp"
+	"slices"
+	"unicode/utf8"
+
+	"github.com/marcusprice/twitter-clone/internal/dtypes"
+)
+
+// a mention is an @ followed by username characters, and must not be preceded
+// by a username character or another @ (i.e. emails, @@double). The match is
+// greedy so @dalecooperfan never reads as @dalecooper.
+var mentionRegex = regexp.MustCompile(`(?:^|[^A-Za-z0-9_@])@([A-Za-z0-9_]+)`)
+
+// ParseMentions returns the unique usernames (without the @) mentioned in
+// content, in the order they first appear
+func ParseMentions(content string) []string {
+	usernames := []string{}
+	for _, mention := range ParseMentionEntities(content) {
+		if !slices.Contains(usernames, mention.Username) {
+			usernames = append(usernames, mention.Username)
+		}
+	}
+
+	return usernames
+}
+
+// ParseMentionEntities returns every mention in content, repeats included.
+// Start and End are rune offsets spanning the @ and the username, End is
+// exclusive.
+func ParseMentionEntities(content string) []dtypes.MentionEntity {
+	mentions := []dtypes.MentionEntity{}
+	for _, match := range mentionRegex.FindAllStringSubmatchIndex(content, -1) {
+		// match[2:4] is the username, the @ is the byte before it
+		start := utf8.RuneCountInString(content[:match[2]-1])
+		username := content[match[2]:match[3]]
+		mentions = append(mentions, dtypes.MentionEntity{
+			Username: username,
+			Start:    start,
+			End:      start + 1 + utf8.RuneCountInString(username),
+		})
+	}
+
+	return mentions
+}
@@ -22,4 +22,4 @@ case "$pkg" in
 esac
 
 # Run delve with the extracted path and test name
-dlv test "$path" -- -test.run "^$test_name"
+dlv test --build-flags="-tags=sqlite_fts5" "$path" -- -test.run "^$test_name"
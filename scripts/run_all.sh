@@ -22,7 +22,7 @@ trap cleanup INT TERM
 
 # Start core service
 echo "Starting core service..."
-go run ./cmd/twitter 2>&1 | sed 's/^/[CORE] /' &
+go run -tags sqlite_fts5 ./cmd/twitter 2>&1 | sed 's/^/[CORE] /' &
 PIDS+=($!)
 
 # Start reply-guy
@@ -17,18 +17,52 @@ DROP TRIGGER IF EXISTS decrement_comment_retweet_count;
 DROP TRIGGER IF EXISTS increment_comment_bookmark_count;
 DROP TRIGGER IF EXISTS decrement_comment_bookmark_count;
 
+DROP TRIGGER IF EXISTS increment_post_comment_count;
+DROP TRIGGER IF EXISTS decrement_post_comment_count;
+DROP TRIGGER IF EXISTS record_post_edit;
+DROP TRIGGER IF EXISTS record_comment_edit;
+
+DROP TRIGGER IF EXISTS index_post_search;
+DROP TRIGGER IF EXISTS reindex_post_search;
+DROP TRIGGER IF EXISTS unindex_post_search;
+DROP TRIGGER IF EXISTS index_comment_search;
+DROP TRIGGER IF EXISTS reindex_comment_search;
+DROP TRIGGER IF EXISTS unindex_comment_search;
+DROP TRIGGER IF EXISTS index_user_search;
+DROP TRIGGER IF EXISTS reindex_user_search;
+DROP TRIGGER IF EXISTS unindex_user_search;
+
 DROP TABLE IF EXISTS User;
 DROP TABLE IF EXISTS UserFollows;
+DROP TABLE IF EXISTS FollowRequest;
+DROP TABLE IF EXISTS UserBlock;
+DROP TABLE IF EXISTS UserMute;
 DROP TABLE IF EXISTS Post;
 DROP TABLE IF EXISTS Comment;
 DROP TABLE IF EXISTS Notification;
+DROP TABLE IF EXISTS Conversation;
+DROP TABLE IF EXISTS Message;
 
 DROP TABLE IF EXISTS PostLike;
 DROP TABLE IF EXISTS PostRetweet;
 DROP TABLE IF EXISTS PostBookmark;
+DROP TABLE IF EXISTS PostView;
 DROP TABLE IF EXISTS CommentLike;
 DROP TABLE IF EXISTS CommentRetweet;
-DROP TABLE IF EXISTS CommentBookmarks;
+DROP TABLE IF EXISTS CommentBookmark;
+DROP TABLE IF EXISTS PostEdit;
+DROP TABLE IF EXISTS CommentEdit;
+DROP TABLE IF EXISTS UserToken;
+DROP TABLE IF EXISTS RefreshToken;
+DROP TABLE IF EXISTS RevokedToken;
+DROP TABLE IF EXISTS AuditLog;
+DROP TABLE IF EXISTS Report;
+DROP TABLE IF EXISTS Hashtag;
+DROP TABLE IF EXISTS PostHashtag;
+DROP TABLE IF EXISTS Mention;
+DROP TABLE IF EXISTS PostSearch;
+DROP TABLE IF EXISTS CommentSearch;
+DROP TABLE IF EXISTS UserSearch;
 
 CREATE TABLE User (
     id INTEGER PRIMARY KEY,
@@ -42,6 +76,12 @@ CREATE TABLE User (
     avatar TEXT DEFAULT '',
     is_active INTEGER NOT NULL CHECK (is_active IN(0, 1)) DEFAULT 0,
     role INTEGER NOT NULL CHECK (role IN(1, 2, 3)) DEFAULT 1,
+    -- only followers see a private account's content, follows need approval
+    is_private INTEGER NOT NULL CHECK (is_private IN(0, 1)) DEFAULT 0,
+    -- only accept direct messages from mutual followers
+    dm_mutuals_only INTEGER NOT NULL CHECK (dm_mutuals_only IN(0, 1)) DEFAULT 0,
+    -- bumped on password change/reset, JWTs carrying an older version are rejected
+    token_version INTEGER NOT NULL DEFAULT 0,
     last_login TEXT,
     created_at TEXT NOT NULL DEFAULT current_timestamp,
     updated_at TEXT NOT NULL DEFAULT current_timestamp
@@ -60,16 +100,78 @@ CREATE TABLE UserFollows (
     CHECK (follower_id != followee_id)
 );
 
+-- pending follows of private accounts, moved to UserFollows once accepted
+CREATE TABLE FollowRequest (
+    id INTEGER PRIMARY KEY,
+    requester_id INTEGER NOT NULL,
+    target_id INTEGER NOT NULL,
+    created_at TEXT NOT NULL DEFAULT current_timestamp,
+
+    FOREIGN KEY (requester_id) REFERENCES User (id) ON DELETE CASCADE,
+    FOREIGN KEY (target_id) REFERENCES User (id) ON DELETE CASCADE,
+
+    UNIQUE (requester_id, target_id),
+    CHECK (requester_id != target_id)
+);
+
+CREATE TABLE UserBlock (
+    id INTEGER PRIMARY KEY,
+    blocker_id INTEGER NOT NULL,
+    blocked_id INTEGER NOT NULL,
+    created_at TEXT NOT NULL DEFAULT current_timestamp,
+
+    FOREIGN KEY (blocker_id) REFERENCES User (id) ON DELETE CASCADE,
+    FOREIGN KEY (blocked_id) REFERENCES User (id) ON DELETE CASCADE,
+
+    UNIQUE (blocker_id, blocked_id),
+    CHECK (blocker_id != blocked_id)
+);
+
+CREATE TABLE UserMute (
+    id INTEGER PRIMARY KEY,
+    muter_id INTEGER NOT NULL,
+    muted_id INTEGER NOT NULL,
+    created_at TEXT NOT NULL DEFAULT current_timestamp,
+
+    FOREIGN KEY (muter_id) REFERENCES User (id) ON DELETE CASCADE,
+    FOREIGN KEY (muted_id) REFERENCES User (id) ON DELETE CASCADE,
+
+    UNIQUE (muter_id, muted_id),
+    CHECK (muter_id != muted_id)
+);
+
+-- users whose content is kept out of viewer_id's feeds: muted and blocked
+-- users, users who blocked the viewer and private accounts the viewer doesn't
+-- follow. Queries filter it down with WHERE viewer_id = ?
+CREATE VIEW HiddenUserByViewer AS
+    SELECT muter_id AS viewer_id, muted_id AS id FROM UserMute
+    UNION
+    SELECT blocker_id, blocked_id FROM UserBlock
+    UNION
+    SELECT blocked_id, blocker_id FROM UserBlock
+    UNION
+    SELECT Viewer.id, PrivateUser.id
+    FROM
+        User Viewer
+        INNER JOIN User PrivateUser
+            ON PrivateUser.is_private = 1 AND PrivateUser.id != Viewer.id
+    WHERE PrivateUser.id NOT IN (
+        SELECT followee_id FROM UserFollows WHERE follower_id = Viewer.id
+    );
+
 CREATE TABLE Post (
     id INTEGER PRIMARY KEY,
     user_id INTEGER NOT NULL,
     content TEXT NOT NULL,
     image TEXT DEFAULT '',
+    -- description of the image for screen readers (and reply-guy)
+    image_alt TEXT NOT NULL DEFAULT '',
     comment_count INTEGER DEFAULT 0,
     like_count INTEGER DEFAULT 0,
     retweet_count INTEGER DEFAULT 0,
     bookmark_count INTEGER DEFAULT 0,
     impressions INTEGER DEFAULT 0,
+    is_hidden INTEGER NOT NULL CHECK (is_hidden IN(0, 1)) DEFAULT 0,
     created_at TEXT NOT NULL DEFAULT current_timestamp,
     updated_at TEXT NOT NULL DEFAULT current_timestamp,
 
@@ -117,6 +219,18 @@ CREATE TABLE PostBookmark (
     FOREIGN KEY (user_id) REFERENCES User (id) ON DELETE CASCADE
 );
 
+-- posts the user has been served on a timeline, only the first view is kept
+CREATE TABLE PostView (
+    id INTEGER PRIMARY KEY,
+    post_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    created_at TEXT NOT NULL DEFAULT current_timestamp,
+
+    UNIQUE (user_id, post_id),
+    FOREIGN KEY (post_id) REFERENCES Post (id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES User (id) ON DELETE CASCADE
+);
+
 CREATE TABLE Comment (
     id INTEGER PRIMARY KEY,
     post_id INTEGER NOT NULL,
@@ -129,6 +243,9 @@ CREATE TABLE Comment (
     retweet_count INTEGER DEFAULT 0,
     bookmark_count INTEGER DEFAULT 0,
     impressions INTEGER DEFAULT 0,
+    is_hidden INTEGER NOT NULL CHECK (is_hidden IN(0, 1)) DEFAULT 0,
+    -- reply-guy is still streaming the content in, may be empty until done
+    generating INTEGER NOT NULL CHECK (generating IN(0, 1)) DEFAULT 0,
     created_at TEXT NOT NULL DEFAULT current_timestamp,
     updated_at TEXT NOT NULL DEFAULT current_timestamp,
 
@@ -136,7 +253,7 @@ CREATE TABLE Comment (
     FOREIGN KEY (user_id) REFERENCES User (id) ON DELETE CASCADE,
     FOREIGN KEY (parent_comment_id) REFERENCES Comment (id) ON DELETE CASCADE
 
-    CHECK (content != '' OR image != ''),
+    CHECK (content != '' OR image != '' OR generating = 1),
     CHECK (like_count >= 0),
     CHECK (retweet_count >= 0),
     CHECK (bookmark_count >= 0)
@@ -148,6 +265,7 @@ CREATE TABLE CommentLike (
     user_id INTEGER NOT NULL,
     created_at TEXT NOT NULL DEFAULT current_timestamp,
 
+    UNIQUE (comment_id, user_id),
     FOREIGN KEY (comment_id) REFERENCES Comment (id) ON DELETE CASCADE,
     FOREIGN KEY (user_id) REFERENCES User (id) ON DELETE CASCADE
 );
@@ -158,6 +276,7 @@ CREATE TABLE CommentRetweet (
     user_id INTEGER NOT NULL,
     created_at TEXT NOT NULL DEFAULT current_timestamp,
 
+    UNIQUE (comment_id, user_id),
     FOREIGN KEY (comment_id) REFERENCES Comment (id) ON DELETE CASCADE,
     FOREIGN KEY (user_id) REFERENCES User (id) ON DELETE CASCADE
 );
@@ -168,10 +287,57 @@ CREATE TABLE CommentBookmark (
     user_id INTEGER NOT NULL,
     created_at TEXT NOT NULL DEFAULT current_timestamp,
 
+    UNIQUE (comment_id, user_id),
     FOREIGN KEY (comment_id) REFERENCES Comment (id) ON DELETE CASCADE,
     FOREIGN KEY (user_id) REFERENCES User (id) ON DELETE CASCADE
 );
 
+CREATE TABLE PostEdit (
+    id INTEGER PRIMARY KEY,
+    post_id INTEGER NOT NULL,
+    content TEXT NOT NULL,
+    created_at TEXT NOT NULL DEFAULT current_timestamp,
+
+    FOREIGN KEY (post_id) REFERENCES Post (id) ON DELETE CASCADE
+);
+
+CREATE TABLE CommentEdit (
+    id INTEGER PRIMARY KEY,
+    comment_id INTEGER NOT NULL,
+    content TEXT,
+    created_at TEXT NOT NULL DEFAULT current_timestamp,
+
+    FOREIGN KEY (comment_id) REFERENCES Comment (id) ON DELETE CASCADE
+);
+
+-- direct messages between two users, the lower user id is always user_one_id
+-- so each pair has a single conversation
+CREATE TABLE Conversation (
+    id INTEGER PRIMARY KEY,
+    user_one_id INTEGER NOT NULL,
+    user_two_id INTEGER NOT NULL,
+    created_at TEXT NOT NULL DEFAULT current_timestamp,
+
+    FOREIGN KEY (user_one_id) REFERENCES User (id) ON DELETE CASCADE,
+    FOREIGN KEY (user_two_id) REFERENCES User (id) ON DELETE CASCADE,
+
+    UNIQUE (user_one_id, user_two_id),
+    CHECK (user_one_id < user_two_id)
+);
+
+CREATE TABLE Message (
+    id INTEGER PRIMARY KEY,
+    conversation_id INTEGER NOT NULL,
+    sender_id INTEGER NOT NULL,
+    content TEXT NOT NULL CHECK (length(trim(content)) > 0),
+    -- set once the other user has loaded the conversation
+    read_at TEXT,
+    created_at TEXT NOT NULL DEFAULT current_timestamp,
+
+    FOREIGN KEY (conversation_id) REFERENCES Conversation (id) ON DELETE CASCADE,
+    FOREIGN KEY (sender_id) REFERENCES User (id) ON DELETE CASCADE
+);
+
 CREATE TABLE Notification (
     id INTEGER PRIMARY KEY,
     initiator_id INTEGER,
@@ -183,7 +349,7 @@ CREATE TABLE Notification (
         'comment_like', 'comment_reply', 'comment_retweet', 
         'mention', 'follow'
     )),
-    is_read INTEGER NOT NULL CHECK (is_read IN(0, 1)),
+    is_read INTEGER NOT NULL CHECK (is_read IN(0, 1)) DEFAULT 0,
     created_at TEXT NOT NULL DEFAULT current_timestamp,
     updated_at TEXT NOT NULL DEFAULT current_timestamp,
 
@@ -193,11 +359,167 @@ CREATE TABLE Notification (
     FOREIGN KEY (comment_id) REFERENCES Comment (id) ON DELETE CASCADE,
 
     CHECK(
+        (post_id IS NOT NULL AND comment_id IS NULL) OR
+        (post_id IS NULL AND comment_id IS NOT NULL) OR
+        (type = 'follow' AND post_id IS NULL AND comment_id IS NULL)
+    ),
+    CHECK (initiator_id != receiver_id)
+);
+
+-- single use tokens emailed to users, only the sha256 of the token is stored
+CREATE TABLE UserToken (
+    id INTEGER PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    purpose TEXT NOT NULL CHECK (purpose IN('activation', 'password_reset')),
+    expires_at TEXT NOT NULL,
+    used_at TEXT,
+    created_at TEXT NOT NULL DEFAULT current_timestamp,
+
+    FOREIGN KEY (user_id) REFERENCES User (id) ON DELETE CASCADE
+);
+
+-- long lived tokens traded for new access tokens, rotated on every use
+CREATE TABLE RefreshToken (
+    id INTEGER PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    expires_at TEXT NOT NULL,
+    revoked_at TEXT,
+    created_at TEXT NOT NULL DEFAULT current_timestamp,
+
+    FOREIGN KEY (user_id) REFERENCES User (id) ON DELETE CASCADE
+);
+
+-- access tokens (by jti) revoked before they expire, rows can be dropped
+-- once expires_at passes
+CREATE TABLE RevokedToken (
+    jti TEXT PRIMARY KEY,
+    expires_at TEXT NOT NULL
+);
+
+-- admin actions, target_id isn't a foreign key so entries outlive the
+-- posts and comments they describe
+CREATE TABLE AuditLog (
+    id INTEGER PRIMARY KEY,
+    actor_id INTEGER,
+    action TEXT NOT NULL CHECK (action IN(
+        'deactivate_user', 'reactivate_user', 'change_role',
+        'delete_post', 'delete_comment', 'resolve_report'
+    )),
+    target_type TEXT NOT NULL CHECK (target_type IN('user', 'post', 'comment', 'report')),
+    target_id INTEGER NOT NULL,
+    details TEXT NOT NULL DEFAULT '',
+    created_at TEXT NOT NULL DEFAULT current_timestamp,
+
+    FOREIGN KEY (actor_id) REFERENCES User (id) ON DELETE SET NULL
+);
+
+-- a user can report a given post or comment once, resolving a report
+-- resolves every open report for the same content
+CREATE TABLE Report (
+    id INTEGER PRIMARY KEY,
+    reporter_id INTEGER NOT NULL,
+    post_id INTEGER,
+    comment_id INTEGER,
+    reason TEXT NOT NULL CHECK (reason IN(
+        'spam', 'harassment', 'hate', 'violence', 'nudity',
+        'misinformation', 'other'
+    )),
+    details TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL CHECK (status IN(
+        'open', 'dismissed', 'content_hidden', 'author_deactivated'
+    )) DEFAULT 'open',
+    resolved_by INTEGER,
+    resolved_at TEXT,
+    created_at TEXT NOT NULL DEFAULT current_timestamp,
+
+    FOREIGN KEY (reporter_id) REFERENCES User (id) ON DELETE CASCADE,
+    FOREIGN KEY (post_id) REFERENCES Post (id) ON DELETE CASCADE,
+    FOREIGN KEY (comment_id) REFERENCES Comment (id) ON DELETE CASCADE,
+    FOREIGN KEY (resolved_by) REFERENCES User (id) ON DELETE SET NULL,
+
+    UNIQUE (reporter_id, post_id),
+    UNIQUE (reporter_id, comment_id),
+    CHECK (
         (post_id IS NOT NULL AND comment_id IS NULL) OR
         (post_id IS NULL AND comment_id IS NOT NULL)
     )
 );
 
+CREATE TABLE Hashtag (
+    id INTEGER PRIMARY KEY,
+    -- stored lowercased, see util.ParseHashtags
+    tag TEXT NOT NULL UNIQUE CHECK (length(tag) > 0),
+    created_at TEXT NOT NULL DEFAULT current_timestamp
+);
+
+-- tags used by posts and comments
+CREATE TABLE PostHashtag (
+    id INTEGER PRIMARY KEY,
+    hashtag_id INTEGER NOT NULL,
+    post_id INTEGER,
+    comment_id INTEGER,
+    created_at TEXT NOT NULL DEFAULT current_timestamp,
+
+    FOREIGN KEY (hashtag_id) REFERENCES Hashtag (id) ON DELETE CASCADE,
+    FOREIGN KEY (post_id) REFERENCES Post (id) ON DELETE CASCADE,
+    FOREIGN KEY (comment_id) REFERENCES Comment (id) ON DELETE CASCADE,
+
+    UNIQUE (hashtag_id, post_id),
+    UNIQUE (hashtag_id, comment_id),
+    CHECK (
+        (post_id IS NOT NULL AND comment_id IS NULL) OR
+        (post_id IS NULL AND comment_id IS NOT NULL)
+    )
+);
+
+-- users @mentioned by posts and comments, usernames that don't belong to
+-- anyone aren't recorded
+CREATE TABLE Mention (
+    id INTEGER PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    post_id INTEGER,
+    comment_id INTEGER,
+    created_at TEXT NOT NULL DEFAULT current_timestamp,
+
+    FOREIGN KEY (user_id) REFERENCES User (id) ON DELETE CASCADE,
+    FOREIGN KEY (post_id) REFERENCES Post (id) ON DELETE CASCADE,
+    FOREIGN KEY (comment_id) REFERENCES Comment (id) ON DELETE CASCADE,
+
+    UNIQUE (user_id, post_id),
+    UNIQUE (user_id, comment_id),
+    CHECK (
+        (post_id IS NOT NULL AND comment_id IS NULL) OR
+        (post_id IS NULL AND comment_id IS NOT NULL)
+    )
+);
+
+-- full-text indexes, external content tables kept in sync by the *_search
+-- triggers. Needs sqlite built with FTS5 (go build -tags sqlite_fts5).
+CREATE VIRTUAL TABLE PostSearch USING fts5 (
+    content,
+    content = 'Post',
+    content_rowid = 'id',
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+CREATE VIRTUAL TABLE CommentSearch USING fts5 (
+    content,
+    content = 'Comment',
+    content_rowid = 'id',
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+CREATE VIRTUAL TABLE UserSearch USING fts5 (
+    user_name,
+    display_name,
+    bio,
+    content = 'User',
+    content_rowid = 'id',
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
 CREATE TRIGGER update_user_timestamp
 AFTER UPDATE ON User
 BEGIN
@@ -229,6 +551,91 @@ BEGIN
     UPDATE Post SET comment_count = comment_count + 1 WHERE id = NEW.post_id;
 END;
 
+CREATE TRIGGER decrement_post_comment_count
+AFTER DELETE ON Comment
+BEGIN
+    UPDATE Post SET comment_count = comment_count - 1 WHERE id = OLD.post_id;
+END;
+
+-- the previous content is kept on every edit
+CREATE TRIGGER record_post_edit
+AFTER UPDATE OF content ON Post
+WHEN OLD.content IS NOT NEW.content
+BEGIN
+    INSERT INTO PostEdit (post_id, content) VALUES (OLD.id, OLD.content);
+END;
+
+-- streaming a reply-guy comment in isn't an edit
+CREATE TRIGGER record_comment_edit
+AFTER UPDATE OF content ON Comment
+WHEN OLD.content IS NOT NEW.content AND OLD.generating = 0
+BEGIN
+    INSERT INTO CommentEdit (comment_id, content) VALUES (OLD.id, OLD.content);
+END;
+
+-- external content fts tables are only told about changes, deleting an
+-- entry has to pass the values that were indexed
+CREATE TRIGGER index_post_search
+AFTER INSERT ON Post
+BEGIN
+    INSERT INTO PostSearch (rowid, content) VALUES (NEW.id, NEW.content);
+END;
+
+CREATE TRIGGER reindex_post_search
+AFTER UPDATE OF content ON Post
+BEGIN
+    INSERT INTO PostSearch (PostSearch, rowid, content) VALUES ('delete', OLD.id, OLD.content);
+    INSERT INTO PostSearch (rowid, content) VALUES (NEW.id, NEW.content);
+END;
+
+CREATE TRIGGER unindex_post_search
+AFTER DELETE ON Post
+BEGIN
+    INSERT INTO PostSearch (PostSearch, rowid, content) VALUES ('delete', OLD.id, OLD.content);
+END;
+
+CREATE TRIGGER index_comment_search
+AFTER INSERT ON Comment
+BEGIN
+    INSERT INTO CommentSearch (rowid, content) VALUES (NEW.id, NEW.content);
+END;
+
+CREATE TRIGGER reindex_comment_search
+AFTER UPDATE OF content ON Comment
+BEGIN
+    INSERT INTO CommentSearch (CommentSearch, rowid, content) VALUES ('delete', OLD.id, OLD.content);
+    INSERT INTO CommentSearch (rowid, content) VALUES (NEW.id, NEW.content);
+END;
+
+CREATE TRIGGER unindex_comment_search
+AFTER DELETE ON Comment
+BEGIN
+    INSERT INTO CommentSearch (CommentSearch, rowid, content) VALUES ('delete', OLD.id, OLD.content);
+END;
+
+CREATE TRIGGER index_user_search
+AFTER INSERT ON User
+BEGIN
+    INSERT INTO UserSearch (rowid, user_name, display_name, bio)
+    VALUES (NEW.id, NEW.user_name, NEW.display_name, NEW.bio);
+END;
+
+CREATE TRIGGER reindex_user_search
+AFTER UPDATE OF user_name, display_name, bio ON User
+BEGIN
+    INSERT INTO UserSearch (UserSearch, rowid, user_name, display_name, bio)
+    VALUES ('delete', OLD.id, OLD.user_name, OLD.display_name, OLD.bio);
+    INSERT INTO UserSearch (rowid, user_name, display_name, bio)
+    VALUES (NEW.id, NEW.user_name, NEW.display_name, NEW.bio);
+END;
+
+CREATE TRIGGER unindex_user_search
+AFTER DELETE ON User
+BEGIN
+    INSERT INTO UserSearch (UserSearch, rowid, user_name, display_name, bio)
+    VALUES ('delete', OLD.id, OLD.user_name, OLD.display_name, OLD.bio);
+END;
+
 CREATE TRIGGER increment_post_like_count
 AFTER INSERT ON PostLike
 BEGIN
@@ -308,4 +715,19 @@ CREATE INDEX idx_comment_post_id ON Comment(post_id);
 CREATE INDEX idx_comment_parent_id ON Comment(parent_comment_id);
 CREATE INDEX idx_userfollows_follower ON UserFollows(follower_id);
 CREATE INDEX idx_userfollows_followee ON UserFollows(followee_id);
+CREATE INDEX idx_userblock_blocked ON UserBlock(blocked_id);
+CREATE INDEX idx_followrequest_target ON FollowRequest(target_id);
+CREATE INDEX idx_postedit_post_id ON PostEdit(post_id);
+CREATE INDEX idx_commentedit_comment_id ON CommentEdit(comment_id);
+CREATE INDEX idx_usertoken_user_id ON UserToken(user_id, purpose);
+CREATE INDEX idx_refreshtoken_user_id ON RefreshToken(user_id);
+CREATE INDEX idx_auditlog_created_at ON AuditLog(created_at DESC);
+CREATE INDEX idx_report_status ON Report(status, created_at);
+CREATE INDEX idx_posthashtag_hashtag ON PostHashtag(hashtag_id, created_at);
+CREATE INDEX idx_posthashtag_created_at ON PostHashtag(created_at);
+CREATE INDEX idx_mention_user_id ON Mention(user_id, created_at);
+CREATE INDEX idx_mention_post_id ON Mention(post_id);
+CREATE INDEX idx_mention_comment_id ON Mention(comment_id);
+CREATE INDEX idx_conversation_user_two ON Conversation(user_two_id);
+CREATE INDEX idx_message_conversation ON Message(conversation_id, created_at);
 CREATE INDEX idx_notifications_receiver ON Notification(receiver_id, is_read, created_at DESC);